@@ -129,8 +129,23 @@ func (s *repos) Add(ctx context.Context, name api.RepoName) (err error) {
 	return nil
 }
 
+// Upsert creates or updates the repository (see db.Repos.Upsert). If the repository was renamed on
+// its code host, its clone on gitserver is moved to the new name, so that it needn't be recloned.
 func (s *repos) Upsert(ctx context.Context, op api.InsertRepoOp) error {
-	return db.Repos.Upsert(ctx, op)
+	renamedFrom, err := db.Repos.UpsertRenamed(ctx, op)
+	if err != nil {
+		return err
+	}
+
+	if renamedFrom != "" {
+		log15.Info("Repository was renamed on its code host.", "from", renamedFrom, "to", op.Name)
+		if err := gitserver.DefaultClient.RenameRepo(ctx, renamedFrom, op.Name); err != nil {
+			// Not fatal: the repository is cloned again under its new name, and the clone under
+			// its old name is purged.
+			log15.Warn("Unable to rename repository clone on gitserver.", "from", renamedFrom, "to", op.Name, "error", err)
+		}
+	}
+	return nil
 }
 
 func (s *repos) List(ctx context.Context, opt db.ReposListOptions) (repos []*types.Repo, err error) {
@@ -7,6 +7,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
 	"github.com/sourcegraph/sourcegraph/pkg/repoupdater"
 	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
 )
@@ -78,12 +79,12 @@ func TestRepos_Add(t *testing.T) {
 	defer func() { repoupdater.MockRepoLookup = nil }()
 
 	calledUpsert := false
-	db.Mocks.Repos.Upsert = func(op api.InsertRepoOp) error {
+	db.Mocks.Repos.UpsertRenamed = func(op api.InsertRepoOp) (api.RepoName, error) {
 		calledUpsert = true
 		if want := (api.InsertRepoOp{Name: repoName, Description: "d"}); !reflect.DeepEqual(op, want) {
 			t.Errorf("got %+v, want %+v", op, want)
 		}
-		return nil
+		return "", nil
 	}
 
 	if err := s.Add(ctx, repoName); err != nil {
@@ -96,3 +97,30 @@ func TestRepos_Add(t *testing.T) {
 		t.Error("!calledUpsert")
 	}
 }
+
+func TestRepos_Upsert_rename(t *testing.T) {
+	var s repos
+	ctx := testContext()
+
+	db.Mocks.Repos.UpsertRenamed = func(op api.InsertRepoOp) (api.RepoName, error) {
+		return "github.com/a/old", nil
+	}
+
+	calledRenameRepo := false
+	gitserver.MockRenameRepo = func(from, to api.RepoName) error {
+		calledRenameRepo = true
+		if from != "github.com/a/old" || to != "github.com/a/new" {
+			t.Errorf("got rename from %q to %q, want from %q to %q", from, to, "github.com/a/old", "github.com/a/new")
+		}
+		return nil
+	}
+	defer func() { gitserver.MockRenameRepo = nil }()
+
+	spec := &api.ExternalRepoSpec{ID: "1", ServiceType: "github", ServiceID: "https://github.com/"}
+	if err := s.Upsert(ctx, api.InsertRepoOp{Name: "github.com/a/new", ExternalRepo: spec}); err != nil {
+		t.Fatal(err)
+	}
+	if !calledRenameRepo {
+		t.Error("!calledRenameRepo")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/errcode"
@@ -14,10 +15,20 @@ import (
 var ErrMustBeSiteAdmin = errors.New("must be site admin")
 
 // CheckCurrentUserIsSiteAdmin returns an error if the current user is NOT a site admin.
+//
+// Requests made with access tokens that are limited to read-only scopes are never permitted.
 func CheckCurrentUserIsSiteAdmin(ctx context.Context) error {
 	if hasAuthzBypass(ctx) {
 		return nil
 	}
+	// 🚨 SECURITY: Access tokens with read-only scopes don't grant site admin privileges.
+	if err := authz.CheckScope(ctx, authz.ScopeUserAll); err != nil {
+		return err
+	}
+	return checkCurrentUserIsSiteAdmin(ctx)
+}
+
+func checkCurrentUserIsSiteAdmin(ctx context.Context) error {
 	user, err := currentUser(ctx)
 	if err != nil {
 		return err
@@ -55,16 +66,31 @@ func CheckUserIsSiteAdmin(ctx context.Context, userID int32) error {
 // It is used when an action on a user can be performed by site admins and the
 // user themselves, but nobody else.
 //
+// Requests made with access tokens that are limited to read-only scopes are never permitted,
+// because the user's private data (such as their emails and access tokens) is not covered by
+// any read-only scope.
+//
 // Returns an error containing the name of the given user.
 func CheckSiteAdminOrSameUser(ctx context.Context, subjectUserID int32) error {
+	return CheckSiteAdminOrSameUserScope(ctx, subjectUserID, authz.ScopeUserAll)
+}
+
+// CheckSiteAdminOrSameUserScope is like CheckSiteAdminOrSameUser, except that it also permits
+// requests made with access tokens that are limited to read-only scopes if they include scope. It
+// is used to read the user's data that a read-only scope covers (such as the user's settings).
+func CheckSiteAdminOrSameUserScope(ctx context.Context, subjectUserID int32, scope string) error {
 	if hasAuthzBypass(ctx) {
 		return nil
 	}
+	// 🚨 SECURITY: Check that the access token's scopes (if any) cover the user's data.
+	if err := authz.CheckScope(ctx, scope); err != nil {
+		return err
+	}
 	actor := actor.FromContext(ctx)
 	if actor.IsAuthenticated() && actor.UID == subjectUserID {
 		return nil
 	}
-	isSiteAdminErr := CheckCurrentUserIsSiteAdmin(ctx)
+	isSiteAdminErr := checkCurrentUserIsSiteAdmin(ctx)
 	if isSiteAdminErr == nil {
 		return nil
 	}
@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+)
+
+// 🚨 SECURITY: This tests that access tokens with read-only scopes don't grant access to data that
+// their scopes don't cover, even for site admins.
+func TestChecks_readOnlyScopes(t *testing.T) {
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: 1, SiteAdmin: true}, nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	readOnlyCtx := authz.WithReadOnlyScopes(ctx, []string{authz.ScopeSettingsRead})
+
+	if err := CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		t.Errorf("CheckCurrentUserIsSiteAdmin: got error %v, want nil", err)
+	}
+	if err := CheckCurrentUserIsSiteAdmin(readOnlyCtx); err != authz.ErrScopeNotPermitted {
+		t.Errorf("CheckCurrentUserIsSiteAdmin with read-only scopes: got error %v, want %v", err, authz.ErrScopeNotPermitted)
+	}
+	if err := CheckSiteAdminOrSameUser(readOnlyCtx, 1); err != authz.ErrScopeNotPermitted {
+		t.Errorf("CheckSiteAdminOrSameUser with read-only scopes: got error %v, want %v", err, authz.ErrScopeNotPermitted)
+	}
+	if err := CheckSiteAdminOrSameUserScope(readOnlyCtx, 1, authz.ScopeSettingsRead); err != nil {
+		t.Errorf("CheckSiteAdminOrSameUserScope with scope: got error %v, want nil", err)
+	}
+	if err := CheckSiteAdminOrSameUserScope(readOnlyCtx, 2, authz.ScopeSettingsRead); err != nil {
+		t.Errorf("CheckSiteAdminOrSameUserScope with scope for other user: got error %v, want nil", err)
+	}
+	if err := CheckSiteAdminOrSameUserScope(readOnlyCtx, 1, authz.ScopeRepoRead); err != authz.ErrScopeNotPermitted {
+		t.Errorf("CheckSiteAdminOrSameUserScope without scope: got error %v, want %v", err, authz.ErrScopeNotPermitted)
+	}
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	dbtesting "github.com/sourcegraph/sourcegraph/cmd/frontend/db/testing"
 )
@@ -32,7 +33,7 @@ func TestAccessTokens_Create(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tid0, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID)
+	tid0, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -51,7 +52,7 @@ func TestAccessTokens_Create(t *testing.T) {
 		t.Errorf("got %q, want %q", got.Note, want)
 	}
 
-	gotSubjectUserID, err := AccessTokens.Lookup(ctx, tv0, "a")
+	gotSubjectUserID, _, err := AccessTokens.Lookup(ctx, tv0, []string{"a"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -106,11 +107,11 @@ func TestAccessTokens_List(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, _, err = AccessTokens.Create(ctx, subject1.ID, []string{"a", "b"}, "n0", subject1.ID)
+	_, _, err = AccessTokens.Create(ctx, subject1.ID, []string{"a", "b"}, "n0", subject1.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, _, err = AccessTokens.Create(ctx, subject1.ID, []string{"a", "b"}, "n1", subject1.ID)
+	_, _, err = AccessTokens.Create(ctx, subject1.ID, []string{"a", "b"}, "n1", subject1.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -184,13 +185,13 @@ func TestAccessTokens_Lookup(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	tid0, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID)
+	tid0, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a", "b"}, "n0", creator.ID, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	for _, scope := range []string{"a", "b"} {
-		gotSubjectUserID, err := AccessTokens.Lookup(ctx, tv0, scope)
+		gotSubjectUserID, _, err := AccessTokens.Lookup(ctx, tv0, []string{scope})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -199,13 +200,25 @@ func TestAccessTokens_Lookup(t *testing.T) {
 		}
 	}
 
+	// Lookup with any of several scopes and ensure it returns all of the token's scopes.
+	gotSubjectUserID, gotScopes, err := AccessTokens.Lookup(ctx, tv0, []string{"x", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := subject.ID; gotSubjectUserID != want {
+		t.Errorf("got %v, want %v", gotSubjectUserID, want)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(gotScopes, want) {
+		t.Errorf("got scopes %q, want %q", gotScopes, want)
+	}
+
 	// Lookup with a nonexistent scope and ensure it fails.
-	if _, err := AccessTokens.Lookup(ctx, tv0, "x"); err == nil {
+	if _, _, err := AccessTokens.Lookup(ctx, tv0, []string{"x"}); err == nil {
 		t.Fatal(err)
 	}
 
-	// Lookup with an empty scope and ensure it fails.
-	if _, err := AccessTokens.Lookup(ctx, tv0, ""); err == nil {
+	// Lookup with no scopes and ensure it fails.
+	if _, _, err := AccessTokens.Lookup(ctx, tv0, nil); err == nil {
 		t.Fatal(err)
 	}
 
@@ -213,12 +226,32 @@ func TestAccessTokens_Lookup(t *testing.T) {
 	if err := AccessTokens.DeleteByID(ctx, tid0, subject.ID); err != nil {
 		t.Fatal(err)
 	}
-	if _, err := AccessTokens.Lookup(ctx, tv0, "a"); err == nil {
+	if _, _, err := AccessTokens.Lookup(ctx, tv0, []string{"a"}); err == nil {
 		t.Fatal(err)
 	}
 
 	// Try to Lookup a token that was never created.
-	if _, err := AccessTokens.Lookup(ctx, "abcdefg" /* this token value was never created */, "a"); err == nil {
+	if _, _, err := AccessTokens.Lookup(ctx, "abcdefg" /* this token value was never created */, []string{"a"}); err == nil {
+		t.Fatal(err)
+	}
+
+	// Lookup an expired token and ensure it fails.
+	expiresAt := time.Now().Add(-time.Minute)
+	_, tv1, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n1", creator.ID, &expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := AccessTokens.Lookup(ctx, tv1, []string{"a"}); err == nil {
+		t.Fatal("Lookup: want error looking up expired token")
+	}
+
+	// Lookup a token that has not expired yet.
+	expiresAt = time.Now().Add(time.Hour)
+	_, tv2, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n2", creator.ID, &expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := AccessTokens.Lookup(ctx, tv2, []string{"a"}); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -251,18 +284,18 @@ func TestAccessTokens_Lookup_deletedUser(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		_, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID)
+		_, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
 		if err := Users.Delete(ctx, subject.ID); err != nil {
 			t.Fatal(err)
 		}
-		if _, err := AccessTokens.Lookup(ctx, tv0, "a"); err == nil {
+		if _, _, err := AccessTokens.Lookup(ctx, tv0, []string{"a"}); err == nil {
 			t.Fatal("Lookup: want error looking up token for deleted subject user")
 		}
 
-		if _, _, err := AccessTokens.Create(ctx, subject.ID, nil, "n0", creator.ID); err == nil {
+		if _, _, err := AccessTokens.Create(ctx, subject.ID, nil, "n0", creator.ID, nil); err == nil {
 			t.Fatal("Create: want error creating token for deleted subject user")
 		}
 	})
@@ -287,18 +320,18 @@ func TestAccessTokens_Lookup_deletedUser(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		_, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID)
+		_, tv0, err := AccessTokens.Create(ctx, subject.ID, []string{"a"}, "n0", creator.ID, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
 		if err := Users.Delete(ctx, creator.ID); err != nil {
 			t.Fatal(err)
 		}
-		if _, err := AccessTokens.Lookup(ctx, tv0, "a"); err == nil {
+		if _, _, err := AccessTokens.Lookup(ctx, tv0, []string{"a"}); err == nil {
 			t.Fatal("Lookup: want error looking up token for deleted creator user")
 		}
 
-		if _, _, err := AccessTokens.Create(ctx, subject.ID, nil, "n0", creator.ID); err == nil {
+		if _, _, err := AccessTokens.Create(ctx, subject.ID, nil, "n0", creator.ID, nil); err == nil {
 			t.Fatal("Create: want error creating token for deleted creator user")
 		}
 	})
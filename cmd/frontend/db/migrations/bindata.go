@@ -180,6 +180,16 @@
 // ../../../../migrations/1528395557_.up.sql (1.62kB)
 // ../../../../migrations/1528395558_.down.sql (110B)
 // ../../../../migrations/1528395558_.up.sql (110B)
+// ../../../../migrations/1528395559_.down.sql (96B)
+// ../../../../migrations/1528395559_.up.sql (435B)
+// ../../../../migrations/1528395560_.down.sql (39B)
+// ../../../../migrations/1528395560_.up.sql (755B)
+// ../../../../migrations/1528395561_.down.sql (39B)
+// ../../../../migrations/1528395561_.up.sql (625B)
+// ../../../../migrations/1528395562_.down.sql (50B)
+// ../../../../migrations/1528395562_.up.sql (178B)
+// ../../../../migrations/1528395563_.down.sql (59B)
+// ../../../../migrations/1528395563_.up.sql (478B)
 
 package migrations
 
@@ -3848,6 +3858,206 @@ func _1528395558_UpSql() (*asset, error) {
 	return a, nil
 }
 
+var __1528395559_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2d\xc8\x8f\x4f\xad\x28\x49\x2d\xca\x4b\xcc\x89\x07\xf3\x32\x53\x2a\xac\xb9\x1c\x7d\x42\x5c\x83\x14\x42\x1c\x9d\x7c\x5c\xc1\x6a\x14\x5c\x40\xba\x9d\xfd\x7d\x42\x7d\xfd\x90\xb4\xa7\xa4\xe6\xa4\x96\xa4\xa6\xc4\x27\x96\x58\x73\x01\x00\xac\x7d\x7c\x44\x60\x00\x00\x00")
+
+func _1528395559_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395559_DownSql,
+		"1528395559_.down.sql",
+	)
+}
+
+func _1528395559_DownSql() (*asset, error) {
+	bytes, err := _1528395559_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395559_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf7, 0x11, 0xc5, 0xd6, 0xac, 0x83, 0xb3, 0xfc, 0x9, 0xa0, 0x83, 0x32, 0x7f, 0xa4, 0x91, 0x3b, 0x7e, 0x8f, 0x85, 0x18, 0xb2, 0x23, 0x48, 0x2f, 0x76, 0x6b, 0xde, 0xde, 0x2a, 0x34, 0x11, 0xcc}}
+	return a, nil
+}
+
+var __1528395559_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x65\x90\xcd\x6e\xc2\x30\x10\x84\xef\x79\x8a\x39\x16\x89\xf0\x02\x9c\x52\xc8\xa1\x12\x05\x29\xa2\x52\x6f\x91\xb1\x97\x66\x55\x62\x5b\x5e\xf3\x93\x3e\x7d\x97\xdf\x22\xf5\xb8\xf3\xcd\xee\x8c\x5d\x96\x68\x28\x06\xe1\x1c\x12\x93\x20\x77\x26\xe3\x48\x89\xe0\x68\x47\x99\x1c\x82\x57\x91\x38\xc1\x06\x47\xe8\x82\x64\x18\xc5\xbd\x49\xdf\x4a\x8d\x3c\x8c\xec\x25\x93\xd1\x85\x2d\x36\xc4\xfe\x0b\x89\xfa\x70\x20\x37\x2e\xca\x12\x12\xae\xa7\xf5\xd4\x00\x6b\xbc\x5a\x94\x8b\xa6\xea\xe6\xcb\x91\x73\x77\x4b\x71\x2c\x76\x2f\xc2\xc1\xcb\x18\x62\x74\x1f\x42\x26\xd9\x8e\x74\xa6\x6c\x27\x23\xf0\xf6\x7a\x26\x91\x89\x51\xd9\xa4\xa8\x16\xeb\xba\xc1\xba\x7a\x5d\xd4\xaa\xc6\x80\x6a\x3e\xc7\x6c\xb5\xf8\x78\x5f\xde\xeb\xb5\xe7\x70\xee\x35\xd2\xf4\x11\xd7\x40\x1d\xf1\x13\x3c\x4d\x8b\x73\xc5\x86\xbc\xe9\x35\x2e\x3d\xff\xc7\xf9\xa9\xdb\xb0\xf7\x0e\x9b\xe1\xd6\x90\x4e\x99\x92\x37\xbb\x3f\xe3\x00\x89\x64\x27\xc5\xac\xa9\xab\x75\x8d\xb7\xe5\xbc\xfe\xbc\xd0\xf6\xee\x6d\x2f\x13\xbb\x13\x56\xcb\x0b\x79\x79\x10\xa1\x74\x60\x4b\x6d\x1e\x22\x8d\xf1\x4f\x66\xf7\x24\xb2\x1b\x4d\x8b\x5f\xdb\x2f\x47\xf5\xb3\x01\x00\x00")
+
+func _1528395559_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395559_UpSql,
+		"1528395559_.up.sql",
+	)
+}
+
+func _1528395559_UpSql() (*asset, error) {
+	bytes, err := _1528395559_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395559_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x6e, 0x94, 0xd4, 0xaf, 0x53, 0xf0, 0x83, 0x8c, 0x76, 0xff, 0xd2, 0xa0, 0x64, 0xab, 0x66, 0xd5, 0xab, 0x58, 0x37, 0xea, 0xf6, 0x2a, 0xbc, 0x2, 0x59, 0xa7, 0x25, 0xf0, 0x9c, 0xc4, 0xba, 0xe6}}
+	return a, nil
+}
+
+var __1528395560_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2d\xc8\x8f\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\xb6\xe6\x02\x00\x56\x4a\x30\xe6\x27\x00\x00\x00")
+
+func _1528395560_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395560_DownSql,
+		"1528395560_.down.sql",
+	)
+}
+
+func _1528395560_DownSql() (*asset, error) {
+	bytes, err := _1528395560_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395560_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xbd, 0x42, 0xa4, 0x2b, 0x86, 0x6e, 0x4b, 0xb9, 0x4d, 0x11, 0xad, 0x99, 0xab, 0x75, 0xea, 0x2e, 0x61, 0xf8, 0x2c, 0xf8, 0x41, 0x8c, 0xf4, 0x52, 0x50, 0x9d, 0x14, 0x53, 0x55, 0x9b, 0x15, 0xc0}}
+	return a, nil
+}
+
+var __1528395560_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8d\x51\x5b\x4f\xc2\x30\x18\x7d\xe7\x57\x9c\x37\xb7\x84\xf9\x07\x34\x26\x73\x94\x48\x98\xc3\x6c\x33\x91\xa7\xa5\x42\x65\x35\xac\x5d\xda\xcf\x20\xfc\x7a\x3f\x19\xf3\x86\xb7\xa6\x0f\x6d\x4f\xce\xe5\x3b\x8d\x22\x88\xe7\x76\xad\x17\x9a\xe0\x54\x6b\xbd\x26\xeb\xb6\x68\x95\x6b\xb4\xf7\xda\x1a\x3f\xc4\xca\x49\x43\x6a\x89\xfb\x2d\x18\x56\x90\xcb\x46\x1b\x0f\xb2\x78\xf2\xca\x79\x58\xc7\x7b\x25\x8d\xde\x49\x7a\x65\xe0\x81\x5f\xa8\x56\x83\x28\x7a\xd7\xd4\xca\x63\x53\x5b\xaf\x60\x64\xc3\xe7\x46\xd2\xa2\x86\x44\x2b\x89\x94\x33\xa7\x83\x24\x17\x71\x29\x50\xc6\x97\xa9\xd8\xd3\xaa\x0f\x21\x10\x0c\xc0\x4b\x2f\xc1\x8e\x5a\xae\x71\x93\x4f\xae\xe3\x7c\x8e\xa9\x98\x0f\xf7\xd0\x6b\x94\x8a\x71\xcd\x51\x57\xca\x21\x17\x63\x91\x8b\x2c\x11\x45\x97\x32\xd0\xcb\x10\xb3\x0c\x23\x91\x0a\xb6\x49\xe2\x22\x89\x47\xa2\xe3\x72\xfa\x1f\xa8\x8c\xfc\xca\xec\x72\x76\x23\x80\xd4\x33\x21\x9b\x95\xc8\x6e\xd3\xb4\xc3\xdf\x47\xf8\x0e\x5d\x38\x25\xb9\xd8\x4a\x12\x48\x73\x29\x24\x9b\x16\x1b\x4d\xf5\xfe\x8a\x9d\x35\xea\x8d\xc1\xf6\xe3\xf8\x36\x2d\x61\xec\x26\x08\x3b\x7e\x32\xcb\x8a\x32\x8f\x27\x59\x79\x54\x58\x55\x4b\x5f\x31\xbf\xf2\x4f\xf7\x8f\x6a\x41\x48\xae\x44\x32\x45\x10\xf4\x3d\x4d\x8a\xbd\x6c\x88\xf3\x0b\x04\x87\x02\xfa\xb7\x7f\xc8\x7f\x1c\xbc\x32\x96\x2a\xd5\xb4\xb4\xed\x5d\x3e\xd5\xc2\x06\x27\x27\xe1\x20\x3c\xeb\xbf\x78\x92\x8d\xc4\xdd\xb1\x64\x9f\x8c\xab\xfe\x8a\xf5\xa9\xff\xd4\x38\x0c\xf2\x9d\x44\x07\xb1\xc2\x0b\xd5\x90\x4e\x2f\xf3\x02\x00\x00")
+
+func _1528395560_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395560_UpSql,
+		"1528395560_.up.sql",
+	)
+}
+
+func _1528395560_UpSql() (*asset, error) {
+	bytes, err := _1528395560_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395560_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x39, 0x15, 0xd6, 0x95, 0x64, 0xf3, 0x6f, 0x22, 0x7d, 0x7, 0xe4, 0xd8, 0x4, 0xb6, 0xde, 0x3f, 0xe9, 0x84, 0x16, 0xf4, 0xe9, 0x57, 0x9b, 0x3e, 0x1, 0xc, 0xf8, 0x4e, 0xab, 0xae, 0x6e, 0x7a}}
+	return a, nil
+}
+
+var __1528395561_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\xb6\xe6\x02\x00\x8c\x60\x69\x93\x27\x00\x00\x00")
+
+func _1528395561_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395561_DownSql,
+		"1528395561_.down.sql",
+	)
+}
+
+func _1528395561_DownSql() (*asset, error) {
+	bytes, err := _1528395561_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395561_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x78, 0x12, 0x58, 0x12, 0xcc, 0xaf, 0x2f, 0x38, 0x37, 0x11, 0x24, 0xed, 0xdd, 0xc5, 0xa, 0x3d, 0xf0, 0xd2, 0x3a, 0x6b, 0xbf, 0x38, 0xc9, 0x99, 0xf0, 0xb2, 0x54, 0xfc, 0xed, 0x23, 0xc1, 0xa4}}
+	return a, nil
+}
+
+var __1528395561_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7d\x50\xcd\x6e\x83\x30\x0c\xbe\xf3\x14\x3e\x82\x04\x7d\x81\x9d\x18\xcd\xa4\x6a\x8c\x4e\x94\x1d\xaa\x69\x42\x29\x98\x12\x6d\xc4\x28\x09\xdd\xe8\xd3\x2f\x0d\x55\x4b\xab\x69\x39\x58\x71\x3e\x7f\x3f\x4e\x14\x41\xd1\x22\x28\xec\x49\x0b\x43\x6a\x84\x1e\x55\x27\xb4\x16\x24\x35\x50\x03\xc8\xab\x16\x06\x8d\x0a\x1a\x45\xdd\xd4\xf2\xc1\xb4\x47\xe8\x15\x1d\x44\x8d\x2a\xb4\x37\xac\xa8\xeb\x07\x83\x35\xec\x46\x30\x56\x70\xc7\xab\xcf\xbd\xa2\x41\xd6\x5e\x14\xdd\x68\xea\x51\x56\xa8\x16\xce\xb2\x14\xb5\x86\x96\xbe\x6c\x3d\x91\x56\x4b\x67\x69\xe6\x81\x04\x4e\x98\x8b\xd0\xf2\xa9\xb9\xea\x01\xc9\x93\x81\xcf\x3b\x92\x7b\x8b\x91\x46\x5b\xb9\x99\xc6\xce\x09\x41\x4c\x34\x4d\x83\xaa\xf0\x64\x31\x0f\xd4\x90\x0a\x80\x3b\xe7\xa1\xaf\xb9\x5d\xa2\xe4\x66\xe1\x25\x39\x8b\x0b\x06\x45\xfc\x98\x32\xe7\x5e\xce\x49\xbe\x07\xf6\xb8\x67\x51\x83\x90\x06\xf7\xd6\x27\x5b\x17\x90\xbd\xa5\x29\xe4\xec\x89\xe5\x2c\x4b\xd8\xc6\xcd\x68\x5f\xd4\x01\xac\x33\x58\xb2\x94\x59\xd1\x24\xde\x24\xf1\x92\x85\x4e\x64\xb6\x8c\xc1\x1f\x73\x11\x99\x50\xcb\x3e\x88\x0a\x4b\x33\xf6\xf8\x1f\x6e\x53\xfc\x81\x5e\x3e\xf9\x9c\xf0\xfd\xe3\x6e\xe0\xba\x31\x18\xd1\xa1\x36\xbc\xeb\xe1\x5b\x98\xd6\xb5\x70\x24\x89\x77\x8c\xd7\x7c\xf5\x12\xe7\x5b\x78\x66\x5b\xf0\xcf\x1f\x10\xce\x96\x08\x6f\x22\x87\xb3\x80\x81\x17\x3c\x78\xbf\xe2\x68\x35\x84\x71\x02\x00\x00")
+
+func _1528395561_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395561_UpSql,
+		"1528395561_.up.sql",
+	)
+}
+
+func _1528395561_UpSql() (*asset, error) {
+	bytes, err := _1528395561_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395561_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa2, 0x6b, 0xc0, 0x80, 0xb8, 0xe4, 0x35, 0x77, 0xa5, 0xd7, 0xb5, 0xe0, 0xa5, 0xb1, 0xde, 0x16, 0x52, 0x12, 0x8a, 0x99, 0x29, 0x59, 0x56, 0x4f, 0x8, 0x53, 0xa1, 0xf5, 0x99, 0xee, 0x2e, 0xd8}}
+	return a, nil
+}
+
+var __1528395562_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4c\x4e\x4e\x2d\x2e\x8e\x2f\xc9\xcf\x4e\xcd\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xad\x28\xc8\x2c\x4a\x2d\x8e\x4f\x2c\xb1\xe6\x02\x00\x36\x0b\x33\xc5\x32\x00\x00\x00")
+
+func _1528395562_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395562_DownSql,
+		"1528395562_.down.sql",
+	)
+}
+
+func _1528395562_DownSql() (*asset, error) {
+	bytes, err := _1528395562_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395562_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe8, 0xe7, 0x51, 0x42, 0xe2, 0x1a, 0x62, 0x84, 0x11, 0x4b, 0xde, 0x91, 0xfa, 0xc4, 0x8c, 0xba, 0x41, 0x68, 0x46, 0xf0, 0x28, 0x44, 0x4, 0x34, 0x6b, 0x84, 0x68, 0x2e, 0x8d, 0xfe, 0x81, 0xff}}
+	return a, nil
+}
+
+var __1528395562_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x2d\x4d\x3b\x0e\x82\x40\x10\xed\x39\xc5\x2b\xb5\xc0\x0b\x58\xad\x42\x87\x92\x18\xac\xc9\x04\x86\x30\x11\x06\xc2\x8e\xf8\x39\xbd\x9b\x95\xea\xe5\xfd\xd3\x14\xae\x69\xd8\x7b\xd8\xf4\x60\x0d\xd0\x93\xa1\xa7\x95\x41\x0a\x7e\xcf\xb2\x7c\xd0\x92\x05\xba\x30\x44\x57\x1a\xa4\x05\x75\xc6\xcb\x3f\x1a\xbd\x1d\x69\x0b\xe5\x35\x88\xb1\x12\x92\x1d\xc4\x20\x1e\xfa\x1c\x86\xfd\x21\x71\x45\x95\xdf\x50\xb9\x53\x91\x83\xe2\x61\xbd\x1d\xba\x2c\xc3\xb9\x2c\xee\x97\xeb\xd6\xf5\x75\x98\x35\x19\xd9\x1b\x8d\x33\x5e\x62\x7d\xa4\xf8\x4e\xca\xc7\xe4\x07\xd1\x6a\xbd\xd5\xb2\x00\x00\x00")
+
+func _1528395562_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395562_UpSql,
+		"1528395562_.up.sql",
+	)
+}
+
+func _1528395562_UpSql() (*asset, error) {
+	bytes, err := _1528395562_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395562_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x67, 0xbb, 0x5b, 0x2f, 0xbe, 0x81, 0xd8, 0x68, 0x72, 0xe9, 0x4c, 0x90, 0x84, 0x2, 0x35, 0x54, 0xcc, 0xd7, 0xc1, 0x8e, 0x4, 0x5, 0xc1, 0x28, 0x33, 0xaa, 0x39, 0xc6, 0xae, 0xe3, 0xd8, 0x8c}}
+	return a, nil
+}
+
+var __1528395563_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xce\x49\xcc\xcc\x4d\x4d\x89\x2f\x4a\x2d\xc8\x8f\xcf\x4c\x29\xb6\xe6\x02\x00\x77\x0c\xee\xbf\x3b\x00\x00\x00")
+
+func _1528395563_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395563_DownSql,
+		"1528395563_.down.sql",
+	)
+}
+
+func _1528395563_DownSql() (*asset, error) {
+	bytes, err := _1528395563_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395563_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xed, 0xc6, 0x3e, 0x44, 0x77, 0xf2, 0xfe, 0xaf, 0xd1, 0x3d, 0x4a, 0x18, 0x51, 0xfc, 0x63, 0x6d, 0x6, 0xd1, 0x99, 0x8e, 0xe8, 0x30, 0x9f, 0x1c, 0x5a, 0x4d, 0x41, 0x1, 0x61, 0x76, 0x6b, 0xde}}
+	return a, nil
+}
+
+var __1528395563_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x65\x51\xcd\x6e\x83\x30\x0c\xbe\xf3\x14\xbe\xed\x32\xfa\x02\x3d\xb1\xc1\xa4\x49\x14\xa4\x8a\x9e\xa6\x09\x85\xc4\x05\xab\x25\x41\x4e\xb2\x8e\x3d\xfd\x42\x36\x6d\xa5\xbd\x44\x91\x3f\x7f\x7f\x72\x9a\x82\x3c\x0b\x1a\x51\xb5\x8c\x93\x69\x49\x59\x18\xcc\x39\xbc\x6e\x40\x78\xcd\x2d\x98\x63\xfc\x2e\xa8\x25\x67\x98\x70\xc1\x84\x8b\x53\xe1\xdd\xf0\x05\x13\x9b\x0f\x52\xc8\x70\x11\x3f\x3c\x6b\x3c\x4b\x0c\xd4\x24\x4d\x61\x42\x1e\xc9\x5a\x32\xda\xc2\xd1\x30\x88\xa8\xe9\x27\x25\x5c\x70\x15\xee\x31\xac\xff\x2b\xda\x60\x81\x6a\x45\x92\x42\x43\x87\xe0\x6d\x98\x5f\xc8\x0d\xc6\xbb\x20\x72\x22\xdd\x2f\x8c\x68\xf1\x17\x60\x20\x39\xac\xb3\x92\x03\xba\x49\x75\x1b\x69\x03\x4d\x80\xf1\x93\xac\x5b\x44\xaf\x51\x65\xf4\x83\x5b\x2c\x18\xa5\x61\x15\x73\xfe\x06\xc6\x19\x04\x63\x04\xc6\xc9\x87\x2e\xd0\xcd\xd1\xa7\x13\xf2\xd4\xb3\xf1\x7a\x5d\xc3\xce\x5a\x22\x6f\x92\xbc\x28\x8b\xa6\x80\x97\x7d\xbd\x5b\x3a\x71\x7b\xb5\xb4\x4d\xb2\xb2\x29\xf6\xd0\x64\x4f\x65\x71\x87\x42\x96\xe7\xf0\x5c\x97\x87\x5d\x75\x7f\x35\xd2\x0e\x7b\xe4\xb7\x77\xa8\xea\x06\xaa\x43\x59\x6e\x93\x6f\x0f\x7a\x1a\xfb\xde\x01\x00\x00")
+
+func _1528395563_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395563_UpSql,
+		"1528395563_.up.sql",
+	)
+}
+
+func _1528395563_UpSql() (*asset, error) {
+	bytes, err := _1528395563_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395563_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x3f, 0x45, 0x17, 0x9a, 0x31, 0xd1, 0xc6, 0x18, 0x33, 0x22, 0xb8, 0xbb, 0x82, 0x3e, 0x4, 0xcd, 0xab, 0xe2, 0xf3, 0x6, 0xa1, 0x20, 0xc5, 0xd9, 0xc4, 0x30, 0xde, 0x17, 0x33, 0xc8, 0xbb, 0xbe}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -4298,6 +4508,26 @@ var _bindata = map[string]func() (*asset, error){
 	"1528395558_.down.sql": _1528395558_DownSql,
 
 	"1528395558_.up.sql": _1528395558_UpSql,
+
+	"1528395559_.down.sql": _1528395559_DownSql,
+
+	"1528395559_.up.sql": _1528395559_UpSql,
+
+	"1528395560_.down.sql": _1528395560_DownSql,
+
+	"1528395560_.up.sql": _1528395560_UpSql,
+
+	"1528395561_.down.sql": _1528395561_DownSql,
+
+	"1528395561_.up.sql": _1528395561_UpSql,
+
+	"1528395562_.down.sql": _1528395562_DownSql,
+
+	"1528395562_.up.sql": _1528395562_UpSql,
+
+	"1528395563_.down.sql": _1528395563_DownSql,
+
+	"1528395563_.up.sql": _1528395563_UpSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -4521,6 +4751,16 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1528395557_.up.sql":                                          &bintree{_1528395557_UpSql, map[string]*bintree{}},
 	"1528395558_.down.sql":                                        &bintree{_1528395558_DownSql, map[string]*bintree{}},
 	"1528395558_.up.sql":                                          &bintree{_1528395558_UpSql, map[string]*bintree{}},
+	"1528395559_.down.sql":                                        &bintree{_1528395559_DownSql, map[string]*bintree{}},
+	"1528395559_.up.sql":                                          &bintree{_1528395559_UpSql, map[string]*bintree{}},
+	"1528395560_.down.sql":                                        &bintree{_1528395560_DownSql, map[string]*bintree{}},
+	"1528395560_.up.sql":                                          &bintree{_1528395560_UpSql, map[string]*bintree{}},
+	"1528395561_.down.sql":                                        &bintree{_1528395561_DownSql, map[string]*bintree{}},
+	"1528395561_.up.sql":                                          &bintree{_1528395561_UpSql, map[string]*bintree{}},
+	"1528395562_.down.sql":                                        &bintree{_1528395562_DownSql, map[string]*bintree{}},
+	"1528395562_.up.sql":                                          &bintree{_1528395562_UpSql, map[string]*bintree{}},
+	"1528395563_.down.sql":                                        &bintree{_1528395563_DownSql, map[string]*bintree{}},
+	"1528395563_.up.sql":                                          &bintree{_1528395563_UpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
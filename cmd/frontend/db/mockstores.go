@@ -24,5 +24,8 @@ type MockStores struct {
 
 	ExternalAccounts MockExternalAccounts
 
+	RepoPermissions MockRepoPermissions
+	UserPermissions MockUserPermissions
+
 	OrgInvitations MockOrgInvitations
 }
@@ -0,0 +1,214 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz/explicit"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+// ErrRepoPermissionNotFound occurs when a database operation expects a specific repository
+// permission to exist but it does not exist.
+var ErrRepoPermissionNotFound = errors.New("repository permission not found")
+
+// repoPermissions is the store of explicit repository permissions, which site admins grant to users
+// and organizations (see package explicit).
+type repoPermissions struct{}
+
+var _ explicit.Store = (*repoPermissions)(nil)
+
+// Create grants the permission to its user or organization (exactly one of which must be set).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *repoPermissions) Create(ctx context.Context, p *types.RepoPermission) (*types.RepoPermission, error) {
+	if Mocks.RepoPermissions.Create != nil {
+		return Mocks.RepoPermissions.Create(p)
+	}
+
+	if (p.UserID == 0) == (p.OrgID == 0) {
+		return nil, errors.New("a repository permission must be granted to either a user or an organization")
+	}
+	if _, err := explicit.CompilePattern(p.RepoPattern); err != nil {
+		return nil, err
+	}
+	if authz.Perm(p.Permission) != authz.Read {
+		return nil, errors.Errorf("unsupported repository permission %q", p.Permission)
+	}
+
+	created := *p
+	if err := dbconn.Global.QueryRowContext(ctx,
+		"INSERT INTO repo_permissions(user_id, org_id, repo_pattern, permission) VALUES($1, $2, $3, $4) RETURNING id, created_at",
+		nullInt32Column(p.UserID), nullInt32Column(p.OrgID), p.RepoPattern, p.Permission,
+	).Scan(&created.ID, &created.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetByID retrieves the repository permission with the given ID.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *repoPermissions) GetByID(ctx context.Context, id int32) (*types.RepoPermission, error) {
+	if Mocks.RepoPermissions.GetByID != nil {
+		return Mocks.RepoPermissions.GetByID(id)
+	}
+
+	results, err := s.list(ctx, []*sqlf.Query{sqlf.Sprintf("id=%d", id)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, ErrRepoPermissionNotFound
+	}
+	return results[0], nil
+}
+
+// RepoPermissionsListOptions contains options for listing repository permissions.
+type RepoPermissionsListOptions struct {
+	UserID int32 // only list permissions granted directly to this user
+	OrgID  int32 // only list permissions granted to this organization
+	*LimitOffset
+}
+
+func (o RepoPermissionsListOptions) sqlConditions() []*sqlf.Query {
+	conds := []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	if o.UserID != 0 {
+		conds = append(conds, sqlf.Sprintf("user_id=%d", o.UserID))
+	}
+	if o.OrgID != 0 {
+		conds = append(conds, sqlf.Sprintf("org_id=%d", o.OrgID))
+	}
+	return conds
+}
+
+// List lists all repository permissions that satisfy the options.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *repoPermissions) List(ctx context.Context, opt RepoPermissionsListOptions) ([]*types.RepoPermission, error) {
+	if Mocks.RepoPermissions.List != nil {
+		return Mocks.RepoPermissions.List(opt)
+	}
+	return s.list(ctx, opt.sqlConditions(), opt.LimitOffset)
+}
+
+// ListForUser lists the repository permissions that are granted to the user, directly or through
+// the organizations that the user is a member of.
+func (s *repoPermissions) ListForUser(ctx context.Context, userID int32) ([]*types.RepoPermission, error) {
+	if Mocks.RepoPermissions.ListForUser != nil {
+		return Mocks.RepoPermissions.ListForUser(userID)
+	}
+	return s.list(ctx, []*sqlf.Query{sqlf.Sprintf(`
+user_id=%d OR org_id IN (
+  SELECT org_members.org_id FROM org_members
+  JOIN orgs ON orgs.id=org_members.org_id
+  WHERE org_members.user_id=%d AND orgs.deleted_at IS NULL
+)`, userID, userID)}, nil)
+}
+
+func (s *repoPermissions) list(ctx context.Context, conds []*sqlf.Query, limitOffset *LimitOffset) ([]*types.RepoPermission, error) {
+	q := sqlf.Sprintf(`
+SELECT id, user_id, org_id, repo_pattern, permission, created_at FROM repo_permissions
+WHERE (%s)
+ORDER BY id ASC
+%s`,
+		sqlf.Join(conds, ") AND ("),
+		limitOffset.SQL(),
+	)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*types.RepoPermission
+	for rows.Next() {
+		var p types.RepoPermission
+		var userID, orgID sql.NullInt64
+		if err := rows.Scan(&p.ID, &userID, &orgID, &p.RepoPattern, &p.Permission, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.UserID, p.OrgID = int32(userID.Int64), int32(orgID.Int64)
+		results = append(results, &p)
+	}
+	return results, rows.Err()
+}
+
+// Count counts all repository permissions that satisfy the options (ignoring limit and offset).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *repoPermissions) Count(ctx context.Context, opt RepoPermissionsListOptions) (int, error) {
+	q := sqlf.Sprintf("SELECT COUNT(*) FROM repo_permissions WHERE (%s)", sqlf.Join(opt.sqlConditions(), ") AND ("))
+	var count int
+	if err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Patterns returns the distinct repository patterns of all repository permissions.
+func (s *repoPermissions) Patterns(ctx context.Context) ([]string, error) {
+	if Mocks.RepoPermissions.Patterns != nil {
+		return Mocks.RepoPermissions.Patterns()
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT DISTINCT repo_pattern FROM repo_permissions")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var patterns []string
+	for rows.Next() {
+		var pattern string
+		if err := rows.Scan(&pattern); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, rows.Err()
+}
+
+// Delete revokes the repository permission with the given ID.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (s *repoPermissions) Delete(ctx context.Context, id int32) error {
+	if Mocks.RepoPermissions.Delete != nil {
+		return Mocks.RepoPermissions.Delete(id)
+	}
+
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM repo_permissions WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	nrows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if nrows == 0 {
+		return ErrRepoPermissionNotFound
+	}
+	return nil
+}
+
+// nullInt32Column returns a value that is stored as NULL if n is zero (for optional foreign keys).
+func nullInt32Column(n int32) *int32 {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
+
+type MockRepoPermissions struct {
+	Create      func(p *types.RepoPermission) (*types.RepoPermission, error)
+	GetByID     func(id int32) (*types.RepoPermission, error)
+	List        func(opt RepoPermissionsListOptions) ([]*types.RepoPermission, error)
+	ListForUser func(userID int32) ([]*types.RepoPermission, error)
+	Patterns    func() ([]string, error)
+	Delete      func(id int32) error
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	regexpsyntax "regexp/syntax"
 	"strings"
@@ -61,15 +62,16 @@ func (s *repos) Get(ctx context.Context, id api.RepoID) (*types.Repo, error) {
 }
 
 // GetByName returns the repository with the given name from the database, or an
-// error. If the repo doesn't exist in the DB, then errcode.IsNotFound will
-// return true on the error returned. It does not attempt to look up or update
-// the repository on any external service (such as its code host).
+// error. If the repo doesn't exist in the DB (or was deleted on its code host),
+// then errcode.IsNotFound will return true on the error returned. It does not
+// attempt to look up or update the repository on any external service (such as
+// its code host).
 func (s *repos) GetByName(ctx context.Context, name api.RepoName) (*types.Repo, error) {
 	if Mocks.Repos.GetByName != nil {
 		return Mocks.Repos.GetByName(ctx, name)
 	}
 
-	repos, err := s.getBySQL(ctx, sqlf.Sprintf("WHERE name=%s LIMIT 1", name))
+	repos, err := s.getBySQL(ctx, sqlf.Sprintf("WHERE name=%s AND deleted_at IS NULL LIMIT 1", name))
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +102,7 @@ func (s *repos) Count(ctx context.Context, opt ReposListOptions) (int, error) {
 }
 
 func (s *repos) getBySQL(ctx context.Context, querySuffix *sqlf.Query) ([]*types.Repo, error) {
-	q := sqlf.Sprintf("SELECT id, name, description, language, enabled, indexed_revision, created_at, updated_at, freeze_indexed_revision, external_id, external_service_type, external_service_id FROM repo %s", querySuffix)
+	q := sqlf.Sprintf("SELECT id, name, description, language, enabled, indexed_revision, created_at, updated_at, freeze_indexed_revision, external_id, external_service_type, external_service_id, deleted_at FROM repo %s", querySuffix)
 	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
 	if err != nil {
 		return nil, err
@@ -124,6 +126,7 @@ func (s *repos) getBySQL(ctx context.Context, querySuffix *sqlf.Query) ([]*types
 			&repo.UpdatedAt,
 			&freezeIndexedRevision,
 			&spec.id, &spec.serviceType, &spec.serviceID,
+			&repo.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -179,6 +182,9 @@ type ReposListOptions struct {
 	// OnlyArchived excludes non-archived repositories from the list.
 	OnlyArchived bool
 
+	// IncludeDeleted includes repositories that were deleted on their code host in the list.
+	IncludeDeleted bool
+
 	// Index when set will only include repositories which should be indexed
 	// if true. If false it will exclude repositories which should be
 	// indexed. An example use case of this is for indexed search only
@@ -260,12 +266,13 @@ func (s *repos) List(ctx context.Context, opt ReposListOptions) (results []*type
 	return rawRepos, nil
 }
 
-// ListEnabledNames returns a list of all enabled repo names. This is commonly
+// ListEnabledNames returns a list of all enabled repo names (except those of repos that were
+// deleted on their code host). This is commonly
 // requested information by other services (repo-updater and
 // indexed-search). We special case just returning enabled names so that we
 // read much less data into memory.
 func (s *repos) ListEnabledNames(ctx context.Context) ([]string, error) {
-	q := sqlf.Sprintf("SELECT name FROM repo WHERE enabled = true")
+	q := sqlf.Sprintf("SELECT name FROM repo WHERE enabled = true AND deleted_at IS NULL")
 	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
 	if err != nil {
 		return nil, err
@@ -287,6 +294,16 @@ func (s *repos) ListEnabledNames(ctx context.Context) ([]string, error) {
 	return names, nil
 }
 
+// ListByExternalService returns all repositories (enabled or not) whose external repository spec has
+// the given service type and ID. Repo-updater uses it to find the repositories of a code host
+// connection that were renamed or deleted on the code host.
+func (s *repos) ListByExternalService(ctx context.Context, serviceType, serviceID string) ([]*types.Repo, error) {
+	if Mocks.Repos.ListByExternalService != nil {
+		return Mocks.Repos.ListByExternalService(ctx, serviceType, serviceID)
+	}
+	return s.getBySQL(ctx, sqlf.Sprintf("WHERE external_service_type=%s AND external_service_id=%s ORDER BY id ASC", serviceType, serviceID))
+}
+
 func parsePattern(p string) ([]*sqlf.Query, error) {
 	exact, like, pattern, err := parseIncludePattern(p)
 	if err != nil {
@@ -384,6 +401,9 @@ func (*repos) listSQL(opt ReposListOptions) (conds []*sqlf.Query, err error) {
 	if opt.OnlyArchived {
 		conds = append(conds, sqlf.Sprintf("archived"))
 	}
+	if !opt.IncludeDeleted {
+		conds = append(conds, sqlf.Sprintf("deleted_at IS NULL"))
+	}
 
 	if opt.Index != nil {
 		// We don't currently have an index column, but when we want the
@@ -583,6 +603,19 @@ func (s *repos) Delete(ctx context.Context, repo api.RepoID) error {
 	return err
 }
 
+// MarkDeleted marks the repository as deleted on its code host. The repository row (and everything
+// that refers to it) is kept, so that the repository is restored if it reappears on the code host,
+// but it is no longer listed (and so no longer cloned, updated or indexed).
+func (s *repos) MarkDeleted(ctx context.Context, name api.RepoName) error {
+	if Mocks.Repos.MarkDeleted != nil {
+		return Mocks.Repos.MarkDeleted(ctx, name)
+	}
+
+	q := sqlf.Sprintf("UPDATE repo SET deleted_at=now() WHERE name=%s AND deleted_at IS NULL", name)
+	_, err := dbconn.Global.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	return err
+}
+
 func (s *repos) SetEnabled(ctx context.Context, id api.RepoID, enabled bool) error {
 	q := sqlf.Sprintf("UPDATE repo SET enabled=%t WHERE id=%d", enabled, id)
 	res, err := dbconn.Global.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
@@ -615,7 +648,7 @@ func (s *repos) UpdateRepositoryMetadata(ctx context.Context, name api.RepoName,
 }
 
 const upsertSQL = `WITH UPSERT AS (
-	UPDATE repo SET name=$1, description=$2, fork=$3, enabled=$4, external_id=$5, external_service_type=$6, external_service_id=$7, archived=$9 WHERE name=$1 RETURNING name
+	UPDATE repo SET name=$1, description=$2, fork=$3, enabled=$4, external_id=$5, external_service_type=$6, external_service_id=$7, archived=$9, deleted_at=NULL WHERE name=$1 RETURNING name
 )
 INSERT INTO repo(name, description, fork, language, enabled, external_id, external_service_type, external_service_id, archived) (
 	SELECT $1 AS name, $2 AS description, $3 AS fork, $8 as language, $4 AS enabled,
@@ -623,18 +656,35 @@ INSERT INTO repo(name, description, fork, language, enabled, external_id, extern
 	WHERE $1 NOT IN (SELECT name FROM upsert)
 )`
 
+const renameSQL = `UPDATE repo SET name=$1, description=$2, fork=$3, archived=$4, deleted_at=NULL WHERE id=$5`
+
+const restoreSQL = `UPDATE repo SET description=$1, fork=$2, external_id=$3, external_service_type=$4, external_service_id=$5, archived=$6, deleted_at=NULL WHERE id=$7`
+
 // Upsert updates the repository if it already exists (keyed on name) and
 // inserts it if it does not.
 //
-// If repo exists, op.Enabled is ignored.
+// If no repository has the name but one has the same external repository
+// spec (op.ExternalRepo), the repository was renamed on its code host, and
+// that repository is renamed in place (keeping its ID and everything that
+// refers to it) instead of inserting a new one.
+//
+// If repo exists, op.Enabled is ignored. Upserting a repository that was
+// marked as deleted restores it, keeping its enabled state and language.
 func (s *repos) Upsert(ctx context.Context, op api.InsertRepoOp) error {
 	if Mocks.Repos.Upsert != nil {
 		return Mocks.Repos.Upsert(op)
 	}
 
-	insert := false
-	language := ""
-	enabled := op.Enabled
+	_, err := s.UpsertRenamed(ctx, op)
+	return err
+}
+
+// UpsertRenamed is like Upsert, but it also returns the repository's previous
+// name if the repository was renamed (and "" otherwise).
+func (s *repos) UpsertRenamed(ctx context.Context, op api.InsertRepoOp) (renamedFrom api.RepoName, err error) {
+	if Mocks.Repos.UpsertRenamed != nil {
+		return Mocks.Repos.UpsertRenamed(op)
+	}
 
 	// We optimistically assume the repo is already in the table, so first
 	// check if it is. We then fallback to the upsert functionality. The
@@ -644,25 +694,62 @@ func (s *repos) Upsert(ctx context.Context, op api.InsertRepoOp) error {
 	r, err := s.GetByName(ctx, op.Name)
 	if err != nil {
 		if _, ok := err.(*repoNotFoundErr); !ok {
-			return err
+			return "", err
 		}
-		insert = true // missing
-	} else {
-		enabled = r.Enabled
-		language = r.Language
-		// Ignore Enabled for deciding to update
-		insert = ((op.Description != r.Description) ||
-			(op.Fork != r.Fork) ||
-			(!op.ExternalRepo.Equal(r.ExternalRepo)))
+		return s.upsertMissing(ctx, op)
 	}
+	// Ignore Enabled for deciding to update
+	update := ((op.Description != r.Description) ||
+		(op.Fork != r.Fork) ||
+		(!op.ExternalRepo.Equal(r.ExternalRepo)))
 
-	if !insert {
-		return nil
+	if !update {
+		return "", nil
 	}
 
 	spec := (&dbExternalRepoSpec{}).fromAPISpec(op.ExternalRepo)
-	_, err = dbconn.Global.ExecContext(ctx, upsertSQL, op.Name, op.Description, op.Fork, enabled, spec.id, spec.serviceType, spec.serviceID, language, op.Archived)
-	return err
+	_, err = dbconn.Global.ExecContext(ctx, upsertSQL, op.Name, op.Description, op.Fork, r.Enabled, spec.id, spec.serviceType, spec.serviceID, r.Language, op.Archived)
+	return "", err
+}
+
+// upsertMissing upserts a repository that has no (non-deleted) row with its
+// name. A row with its name (of a repository that was marked as deleted) is
+// restored. Otherwise, if a row has its external repository spec, the row is
+// renamed, and if not, the repository is inserted. Restored and renamed rows
+// keep their enabled state and language. It runs in a transaction that locks
+// the rows, so that concurrent upserts of a renamed repository don't insert a
+// duplicate or race with each other.
+func (s *repos) upsertMissing(ctx context.Context, op api.InsertRepoOp) (renamedFrom api.RepoName, err error) {
+	spec := (&dbExternalRepoSpec{}).fromAPISpec(op.ExternalRepo)
+	err = Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		var id api.RepoID
+		err := tx.QueryRowContext(ctx, "SELECT id FROM repo WHERE name=$1 FOR UPDATE", op.Name).Scan(&id)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, restoreSQL, op.Description, op.Fork, spec.id, spec.serviceType, spec.serviceID, op.Archived, id)
+			return err
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+		if op.ExternalRepo != nil {
+			var name api.RepoName
+			err = tx.QueryRowContext(ctx, "SELECT id, name FROM repo WHERE external_service_type=$1 AND external_service_id=$2 AND external_id=$3 ORDER BY id ASC LIMIT 1 FOR UPDATE", spec.serviceType, spec.serviceID, spec.id).Scan(&id, &name)
+			if err == nil {
+				renamedFrom = name
+				_, err = tx.ExecContext(ctx, renameSQL, op.Name, op.Description, op.Fork, op.Archived, id)
+				return err
+			}
+			if err != sql.ErrNoRows {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, upsertSQL, op.Name, op.Description, op.Fork, op.Enabled, spec.id, spec.serviceType, spec.serviceID, "", op.Archived)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return renamedFrom, nil
 }
 
 // dbExternalRepoSpec is convenience type for inserting or selecting *api.ExternalRepoSpec database data.
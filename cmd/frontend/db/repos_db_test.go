@@ -12,6 +12,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 )
 
 /*
@@ -595,6 +596,173 @@ func TestRepos_List_queryAndPatternsMutuallyExclusive(t *testing.T) {
 	})
 }
 
+func TestRepos_ListByExternalService(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := dbtesting.TestContext(t)
+	ctx = actor.WithActor(ctx, &actor.Actor{Internal: true})
+
+	for _, op := range []api.InsertRepoOp{
+		{Name: "a/r", Enabled: true, ExternalRepo: &api.ExternalRepoSpec{ID: "1", ServiceType: "github", ServiceID: "https://github.com/"}},
+		{Name: "b/r", Enabled: false, ExternalRepo: &api.ExternalRepoSpec{ID: "2", ServiceType: "github", ServiceID: "https://github.com/"}},
+		{Name: "c/r", Enabled: true, ExternalRepo: &api.ExternalRepoSpec{ID: "1", ServiceType: "github", ServiceID: "https://ghe.example.com/"}},
+		{Name: "d/r", Enabled: true},
+	} {
+		if err := Repos.Upsert(ctx, op); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repos, err := Repos.ListByExternalService(ctx, "github", "https://github.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := repoNames(repos), []api.RepoName{"a/r", "b/r"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRepos_Upsert_rename(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := dbtesting.TestContext(t)
+	ctx = actor.WithActor(ctx, &actor.Actor{Internal: true})
+
+	spec := &api.ExternalRepoSpec{ID: "1", ServiceType: "github", ServiceID: "https://github.com/"}
+	if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: "a/old", Enabled: true, ExternalRepo: spec}); err != nil {
+		t.Fatal(err)
+	}
+	old, err := Repos.GetByName(ctx, "a/old")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Upserting the repository with the same external repository spec and a new name renames it in
+	// place.
+	renamedFrom, err := Repos.UpsertRenamed(ctx, api.InsertRepoOp{Name: "a/new", Description: "d", Enabled: true, ExternalRepo: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamedFrom != "a/old" {
+		t.Errorf("got renamed from %q, want %q", renamedFrom, "a/old")
+	}
+	repo, err := Repos.GetByName(ctx, "a/new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.ID != old.ID || repo.Description != "d" {
+		t.Errorf("got renamed repo %+v, want ID %d and description %q", repo, old.ID, "d")
+	}
+	if _, err := Repos.GetByName(ctx, "a/old"); err == nil {
+		t.Error("repo with the old name still exists")
+	}
+}
+
+func TestRepos_MarkDeleted(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := dbtesting.TestContext(t)
+	ctx = actor.WithActor(ctx, &actor.Actor{Internal: true})
+
+	mustCreate(ctx, t, &types.Repo{Name: "a/r"}, &types.Repo{Name: "b/r"})
+
+	if err := Repos.MarkDeleted(ctx, "a/r"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleted repositories are neither found nor listed.
+	if _, err := Repos.GetByName(ctx, "a/r"); !errcode.IsNotFound(err) {
+		t.Errorf("got error %v getting deleted repo, want not found", err)
+	}
+	repos, err := Repos.List(ctx, ReposListOptions{Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := repoNames(repos), []api.RepoName{"b/r"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	names, err := Repos.ListEnabledNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b/r"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got enabled names %v, want %v", names, want)
+	}
+	repos, err = Repos.List(ctx, ReposListOptions{Enabled: true, IncludeDeleted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := repoNames(repos), []api.RepoName{"a/r", "b/r"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v including deleted repos, want %v", got, want)
+	}
+	if repos[0].DeletedAt == nil {
+		t.Error("got DeletedAt == nil, want the time the repo was marked as deleted")
+	}
+
+	// Upserting a deleted repository restores it.
+	createRepo(ctx, t, &types.Repo{Name: "a/r"})
+	repo, err := Repos.GetByName(ctx, "a/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.DeletedAt != nil {
+		t.Errorf("got DeletedAt %v, want nil", repo.DeletedAt)
+	}
+}
+
+func TestRepos_Upsert_restoreDeleted(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := dbtesting.TestContext(t)
+	ctx = actor.WithActor(ctx, &actor.Actor{Internal: true})
+
+	for _, op := range []api.InsertRepoOp{
+		{Name: "a/r", Enabled: true},
+		{Name: "b/r", Enabled: true, ExternalRepo: &api.ExternalRepoSpec{ID: "b", ServiceType: "github", ServiceID: "https://github.com/"}},
+	} {
+		t.Run(string(op.Name), func(t *testing.T) {
+			if err := Repos.Upsert(ctx, op); err != nil {
+				t.Fatal(err)
+			}
+			repo, err := Repos.GetByName(ctx, op.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The site admin disabled the repository before it was deleted.
+			if err := Repos.SetEnabled(ctx, repo.ID, false); err != nil {
+				t.Fatal(err)
+			}
+			if err := Repos.UpdateLanguage(ctx, repo.ID, "Go"); err != nil {
+				t.Fatal(err)
+			}
+			if err := Repos.MarkDeleted(ctx, op.Name); err != nil {
+				t.Fatal(err)
+			}
+
+			// Restoring the repository keeps its enabled state and language.
+			op.Description = "d"
+			if err := Repos.Upsert(ctx, op); err != nil {
+				t.Fatal(err)
+			}
+			restored, err := Repos.GetByName(ctx, op.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored.ID != repo.ID || restored.Enabled || restored.Language != "Go" || restored.Description != "d" || restored.DeletedAt != nil {
+				t.Errorf("got restored repo %+v, want ID %d, disabled, language Go and description d", restored, repo.ID)
+			}
+		})
+	}
+}
+
 func TestRepos_Create(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
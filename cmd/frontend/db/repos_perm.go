@@ -2,11 +2,14 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz/explicit"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
 	log15 "gopkg.in/inconshreveable/log15.v2"
 )
@@ -28,6 +31,12 @@ func authzFilter(ctx context.Context, repos []*types.Repo, p authz.Perm) ([]*typ
 		return repos, nil
 	}
 
+	// 🚨 SECURITY: Access tokens with read-only scopes can only be used to access repositories if they
+	// have the "repo:read" or "search:read" scope.
+	if authz.CheckScope(ctx, authz.ScopeRepoRead) != nil && authz.CheckScope(ctx, authz.ScopeSearchRead) != nil {
+		return []*types.Repo{}, nil
+	}
+
 	var currentUser *types.User
 	if actor.FromContext(ctx).IsAuthenticated() {
 		var err error
@@ -40,7 +49,7 @@ func authzFilter(ctx context.Context, repos []*types.Repo, p authz.Perm) ([]*typ
 		}
 	}
 
-	filteredRepoNames, err := getFilteredRepoNames(ctx, currentUser, authz.ToRepos(repos), p)
+	filteredRepoNames, err := getFilteredRepoNames(ctx, currentUser, repos, p)
 	if err != nil {
 		return nil, err
 	}
@@ -63,15 +72,28 @@ func isInternalActor(ctx context.Context) bool {
 	return actor.FromContext(ctx).Internal
 }
 
-func getFilteredRepoNames(ctx context.Context, currentUser *types.User, repos map[authz.Repo]struct{}, p authz.Perm) (accepted map[api.RepoName]struct{}, err error) {
-	var accts []*extsvc.ExternalAccount
+func getFilteredRepoNames(ctx context.Context, currentUser *types.User, repoList []*types.Repo, p authz.Perm) (accepted map[api.RepoName]struct{}, err error) {
+	repos := authz.ToRepos(repoList)
+
+	var stored map[authzProviderKey]*UserPermissionSet // permissions stored by the background permissions syncer
 	authzAllowByDefault, authzProviders := authz.GetProviders()
-	if len(authzProviders) > 0 && currentUser != nil {
-		accts, err = ExternalAccounts.List(ctx, ExternalAccountsListOptions{UserID: currentUser.ID})
-		if err != nil {
+	if len(authzProviders) > 0 && currentUser != nil && conf.PermissionsBackgroundSyncEnabled() {
+		if stored, err = storedUserPermissions(ctx, currentUser.ID, p); err != nil {
 			return nil, err
 		}
 	}
+	var reposByName map[api.RepoName]*types.Repo
+	if len(stored) > 0 {
+		reposByName = make(map[api.RepoName]*types.Repo, len(repoList))
+		for _, repo := range repoList {
+			reposByName[repo.Name] = repo
+		}
+	}
+
+	var (
+		accts       []*extsvc.ExternalAccount // the user's external accounts
+		acctsListed bool                      // whether accts were listed (only if needed)
+	)
 
 	accepted = make(map[api.RepoName]struct{})  // repositories that have been claimed and have read permissions
 	unverified := make(map[authz.Repo]struct{}) // repositories that have not been claimed by any authz provider
@@ -86,39 +108,46 @@ func getFilteredRepoNames(ctx context.Context, currentUser *types.User, repos ma
 			break
 		}
 
-		// determine external account to use
-		var providerAcct *extsvc.ExternalAccount
-		for _, acct := range accts {
-			if acct.ServiceID == authzProvider.ServiceID() && acct.ServiceType == authzProvider.ServiceType() {
-				providerAcct = acct
-				break
-			}
+		// use the stored permissions for the repos that "belonged" to this authz provider when they
+		// were computed, unless they have expired
+		set, isStored := stored[authzProviderKey{authzProvider.ServiceType(), authzProvider.ServiceID()}]
+		if isStored && time.Since(set.UpdatedAt) >= userPermissionsMaxAge(authzProvider) {
+			isStored = false
 		}
-		if providerAcct == nil && currentUser != nil { // no existing external account for authz provider
-			if pr, err := authzProvider.FetchAccount(ctx, currentUser, accts); err == nil {
-				providerAcct = pr
-				if providerAcct != nil {
-					err := ExternalAccounts.AssociateUserAndSave(ctx, currentUser.ID, providerAcct.ExternalAccountSpec, providerAcct.ExternalAccountData)
-					if err != nil {
-						return nil, err
-					}
-				}
-			} else {
-				log15.Warn("Could not fetch authz provider account for user", "username", currentUser.Username, "authzProvider", authzProvider.ServiceID(), "error", err)
+		if isStored {
+			if unverified = acceptStoredPerms(set, unverified, reposByName, accepted); len(unverified) == 0 {
+				break
 			}
+		} else if stored != nil && isSyncedAuthzProvider(authzProvider) {
+			// the user's permissions from this provider have not been computed yet or have expired
+			ScheduleUserPermissionsSync(currentUser.ID)
 		}
 
 		// determine which repos "belong" to this authz provider
 		myUnverified, nextUnverified := authzProvider.Repos(ctx, unverified)
 
-		// check the perms on those repos
-		perms, err := authzProvider.RepoPerms(ctx, providerAcct, myUnverified)
-		if err != nil {
-			return nil, err
-		}
-		for unverifiedRepo := range myUnverified {
-			if repoPerms, ok := perms[unverifiedRepo.RepoName]; ok && repoPerms[p] {
-				accepted[unverifiedRepo.RepoName] = struct{}{}
+		if !isStored || len(myUnverified) > 0 {
+			// determine external account to use
+			if !acctsListed && currentUser != nil {
+				if accts, err = ExternalAccounts.List(ctx, ExternalAccountsListOptions{UserID: currentUser.ID}); err != nil {
+					return nil, err
+				}
+				acctsListed = true
+			}
+			providerAcct, err := authzProviderAccount(ctx, currentUser, accts, authzProvider)
+			if err != nil {
+				return nil, err
+			}
+
+			// check the perms on those repos
+			perms, err := authzProvider.RepoPerms(ctx, providerAcct, myUnverified)
+			if err != nil {
+				return nil, err
+			}
+			for unverifiedRepo := range myUnverified {
+				if repoPerms, ok := perms[unverifiedRepo.RepoName]; ok && repoPerms[p] {
+					accepted[unverifiedRepo.RepoName] = struct{}{}
+				}
 			}
 		}
 		// continue checking repos that didn't belong to this authz provider
@@ -133,3 +162,33 @@ func getFilteredRepoNames(ctx context.Context, currentUser *types.User, repos ma
 
 	return accepted, nil
 }
+
+// authzProviderAccount returns the external account of the user that identifies the user to the
+// authz provider (or nil if there is none). If none of the user's existing external accounts (accts)
+// belongs to the provider, the provider is asked for the account, which is then associated with the
+// user.
+func authzProviderAccount(ctx context.Context, currentUser *types.User, accts []*extsvc.ExternalAccount, authzProvider authz.Provider) (*extsvc.ExternalAccount, error) {
+	var providerAcct *extsvc.ExternalAccount
+	for _, acct := range accts {
+		if acct.ServiceID == authzProvider.ServiceID() && acct.ServiceType == authzProvider.ServiceType() {
+			providerAcct = acct
+			break
+		}
+	}
+	if providerAcct == nil && currentUser != nil { // no existing external account for authz provider
+		if pr, err := authzProvider.FetchAccount(ctx, currentUser, accts); err == nil {
+			providerAcct = pr
+			// Accounts of the explicit permissions provider are the users themselves, so they
+			// are not stored.
+			if providerAcct != nil && providerAcct.ServiceType != explicit.ServiceType {
+				err := ExternalAccounts.AssociateUserAndSave(ctx, currentUser.ID, providerAcct.ExternalAccountSpec, providerAcct.ExternalAccountData)
+				if err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			log15.Warn("Could not fetch authz provider account for user", "username", currentUser.Username, "authzProvider", authzProvider.ServiceID(), "error", err)
+		}
+	}
+	return providerAcct, nil
+}
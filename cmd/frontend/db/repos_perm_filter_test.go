@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz/explicit"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
@@ -651,6 +652,123 @@ func Test_authzFilter_createsNewUsers(t *testing.T) {
 	}
 }
 
+// 🚨 SECURITY: This tests that access tokens with read-only scopes that cover neither repositories
+// nor search can't be used to access repositories.
+func Test_authzFilter_readOnlyScopes(t *testing.T) {
+	authz.SetProviders(true, nil)
+	Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: 1, SiteAdmin: true}, nil
+	}
+	defer func() { Mocks.Users = MockUsers{} }()
+
+	repos := []*types.Repo{{Name: "github.com/foo/bar"}}
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	for _, scope := range []string{authz.ScopeRepoRead, authz.ScopeSearchRead} {
+		filtered, err := authzFilter(authz.WithReadOnlyScopes(ctx, []string{scope}), repos, authz.Read)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(filtered, repos) {
+			t.Errorf("with scope %q: got repos %v, want %v", scope, filtered, repos)
+		}
+	}
+	filtered, err := authzFilter(authz.WithReadOnlyScopes(ctx, []string{authz.ScopeSettingsRead}), repos, authz.Read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered) != 0 {
+		t.Errorf("with scope %q: got repos %v, want none", authz.ScopeSettingsRead, filtered)
+	}
+}
+
+func Test_authzFilter_explicitPermissions(t *testing.T) {
+	defer func() { Mocks.RepoPermissions = MockRepoPermissions{} }()
+	Mocks.RepoPermissions.Patterns = func() ([]string, error) {
+		return []string{"gitolite.mine/secret/.*", "^gitolite.mine/shared$"}, nil
+	}
+	Mocks.RepoPermissions.ListForUser = func(userID int32) ([]*types.RepoPermission, error) {
+		switch userID {
+		case 1:
+			return []*types.RepoPermission{
+				{UserID: 1, RepoPattern: "gitolite.mine/secret/.*", Permission: "read"},
+				{OrgID: 1, RepoPattern: "^gitolite.mine/shared$", Permission: "read"},
+			}, nil
+		case 2:
+			return []*types.RepoPermission{{OrgID: 1, RepoPattern: "^gitolite.mine/shared$", Permission: "read"}}, nil
+		}
+		return nil, nil
+	}
+
+	var (
+		gitlabRepo   = &types.Repo{Name: "gitlab.mine/u1/r0"}
+		secretRepo   = &types.Repo{Name: "gitolite.mine/secret/r0"}
+		sharedRepo   = &types.Repo{Name: "gitolite.mine/shared"}
+		publicRepo   = &types.Repo{Name: "gitolite.mine/public"}
+		repos        = []*types.Repo{gitlabRepo, secretRepo, sharedRepo, publicRepo}
+		gitlabSecret = &types.Repo{Name: "gitlab.mine/secret/r0"} // claimed by the code host provider
+	)
+
+	authzFilter_Test{
+		description:         "explicit permissions after a code host provider",
+		authzAllowByDefault: true,
+		authzProviders: []authz.Provider{
+			&MockAuthzProvider{
+				serviceID:   "https://gitlab.mine/",
+				serviceType: "gitlab",
+				repos: map[api.RepoName]struct{}{
+					"gitlab.mine/u1/r0":     {},
+					"gitlab.mine/secret/r0": {},
+				},
+				perms: map[extsvc.ExternalAccount]map[api.RepoName]map[authz.Perm]bool{
+					extsvc.ExternalAccount{}: {"gitlab.mine/u1/r0": {authz.Read: true}},
+				},
+			},
+			explicit.NewProvider(RepoPermissions),
+		},
+		calls: []authzFilter_call{
+			{
+				description:      "user granted directly and through an organization",
+				user:             &types.User{ID: 1},
+				repos:            append(repos, gitlabSecret),
+				perm:             authz.Read,
+				expFilteredRepos: []*types.Repo{gitlabRepo, secretRepo, sharedRepo, publicRepo},
+			},
+			{
+				description:      "user granted through an organization",
+				user:             &types.User{ID: 2},
+				repos:            repos,
+				perm:             authz.Read,
+				expFilteredRepos: []*types.Repo{gitlabRepo, sharedRepo, publicRepo},
+			},
+			{
+				description:      "user without permissions",
+				user:             &types.User{ID: 3},
+				repos:            repos,
+				perm:             authz.Read,
+				expFilteredRepos: []*types.Repo{gitlabRepo, publicRepo},
+			},
+			{
+				description:      "unauthenticated",
+				repos:            repos,
+				perm:             authz.Read,
+				expFilteredRepos: []*types.Repo{gitlabRepo, publicRepo},
+			},
+		},
+	}.run(t)
+
+	// Accounts of the explicit permissions provider are not stored.
+	Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: 1}, nil
+	}
+	Mocks.ExternalAccounts.AssociateUserAndSave = func(userID int32, spec extsvc.ExternalAccountSpec, data extsvc.ExternalAccountData) error {
+		t.Errorf("unexpected call to AssociateUserAndSave for %+v", spec)
+		return nil
+	}
+	if _, err := authzFilter(actor.WithActor(context.Background(), &actor.Actor{UID: 1}), repos, authz.Read); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func acct(userID int32, serviceType, serviceID, accountID string) *extsvc.ExternalAccount {
 	return &extsvc.ExternalAccount{
 		UserID: userID,
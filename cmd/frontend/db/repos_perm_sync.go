@@ -0,0 +1,312 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz/explicit"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// When background permissions syncing is enabled (with the "permissionsBackgroundSync" experimental
+// feature), the repository permissions of each user are computed from the authz providers ahead of
+// time and stored in the database, and authzFilter looks them up instead of asking the providers
+// (which may need to call the code hosts). Only repositories that a provider was not the source of
+// permissions for when a user's permissions were computed (such as repositories that were created
+// since) are still checked with the providers. The stored permissions from a provider are only
+// used for as long as the provider would cache them (see userPermissionsMaxAge).
+//
+// The permissions are computed by SyncUserPermissions, which is called by the permissions syncer
+// (see StartPermissionsSyncer) for the users whose permissions are about to expire, and when a user
+// signs in (see ScheduleUserPermissionsSync).
+
+var (
+	// userPermissionsDefaultMaxAge is how long the stored permissions from an authz provider are
+	// valid if the provider does not specify how long it caches permissions.
+	userPermissionsDefaultMaxAge = time.Hour
+
+	// userPermissionsMinSyncInterval is the minimum interval at which the permissions syncer
+	// recomputes the permissions of each user.
+	userPermissionsMinSyncInterval = time.Minute
+
+	// userPermissionsSyncBatchSize is the maximum number of users that the permissions syncer
+	// looks up at a time.
+	userPermissionsSyncBatchSize = 100
+
+	// userPermissionsSyncTimeout is the timeout for computing the permissions of a user.
+	userPermissionsSyncTimeout = 5 * time.Minute
+)
+
+// authzProviderKey identifies an authz provider.
+type authzProviderKey struct {
+	serviceType, serviceID string
+}
+
+// userPermissionsMaxAge returns how long the stored permissions from the authz provider are valid.
+// That is the TTL of the provider's cache (see authz.CachingProvider), so that revoked permissions
+// are not kept for longer than they would be without background permissions syncing.
+func userPermissionsMaxAge(p authz.Provider) time.Duration {
+	if p, ok := p.(authz.CachingProvider); ok {
+		return p.CacheTTL()
+	}
+	return userPermissionsDefaultMaxAge
+}
+
+// isSyncedAuthzProvider reports whether the permissions of the authz provider are computed by the
+// permissions syncer. Explicit permissions are already stored in the database, and changes to them
+// take effect immediately. Providers that don't cache permissions are asked on every request.
+func isSyncedAuthzProvider(p authz.Provider) bool {
+	return p.ServiceType() != explicit.ServiceType && userPermissionsMaxAge(p) > 0
+}
+
+// userPermissionsSyncInterval returns how often the permissions syncer recomputes the permissions
+// of each user: half the shortest max age of the synced authz providers' permissions, so that they
+// are recomputed before they expire.
+func userPermissionsSyncInterval() time.Duration {
+	var interval time.Duration
+	_, authzProviders := authz.GetProviders()
+	for _, p := range authzProviders {
+		if !isSyncedAuthzProvider(p) {
+			continue
+		}
+		if d := userPermissionsMaxAge(p) / 2; interval == 0 || d < interval {
+			interval = d
+		}
+	}
+	if interval < userPermissionsMinSyncInterval {
+		interval = userPermissionsMinSyncInterval
+	}
+	return interval
+}
+
+// storedUserPermissions returns the stored permission sets of the user, by authz provider. Expired
+// permission sets are included (see userPermissionsMaxAge).
+func storedUserPermissions(ctx context.Context, userID int32, p authz.Perm) (map[authzProviderKey]*UserPermissionSet, error) {
+	sets, err := UserPermissions.ListForUser(ctx, userID, p)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[authzProviderKey]*UserPermissionSet, len(sets))
+	for _, set := range sets {
+		stored[authzProviderKey{set.ServiceType, set.ServiceID}] = set
+	}
+	return stored, nil
+}
+
+// acceptStoredPerms checks the permissions of the repositories in repos that the authz provider
+// was the source of permissions for when the stored permission set was computed, and adds those
+// that the user has the permission on to accepted. It returns the other repositories (such as
+// those that were created after the set was computed), which the authz provider must be asked
+// about.
+func acceptStoredPerms(set *UserPermissionSet, repos map[authz.Repo]struct{}, reposByName map[api.RepoName]*types.Repo, accepted map[api.RepoName]struct{}) (unknown map[authz.Repo]struct{}) {
+	unknown = make(map[authz.Repo]struct{})
+	for repo := range repos {
+		r, ok := reposByName[repo.RepoName]
+		if ok {
+			_, ok = set.ClaimedRepoIDs[r.ID]
+		}
+		if !ok {
+			unknown[repo] = struct{}{}
+			continue
+		}
+		if _, ok := set.RepoIDs[r.ID]; ok {
+			accepted[repo.RepoName] = struct{}{}
+		}
+	}
+	return unknown
+}
+
+// SyncUserPermissions computes the read permissions of the user on all repositories from the authz
+// providers and stores them.
+func SyncUserPermissions(ctx context.Context, userID int32) error {
+	user, err := Users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	accts, err := ExternalAccounts.List(ctx, ExternalAccountsListOptions{UserID: userID})
+	if err != nil {
+		return err
+	}
+
+	// The permissions are valid for the repositories that exist when they start being computed.
+	syncedAt := time.Now()
+
+	// 🚨 SECURITY: List all repositories as an internal actor. The list is only used to compute the
+	// user's permissions.
+	repoList, err := Repos.List(actor.WithActor(ctx, &actor.Actor{Internal: true}), ReposListOptions{Enabled: true, Disabled: true})
+	if err != nil {
+		return errors.Wrap(err, "listing repositories")
+	}
+	reposByName := make(map[api.RepoName]*types.Repo, len(repoList))
+	for _, repo := range repoList {
+		reposByName[repo.Name] = repo
+	}
+
+	// Like authzFilter, give each provider the repositories that no previous provider claimed.
+	_, authzProviders := authz.GetProviders()
+	unverified := authz.ToRepos(repoList)
+	var sets []*UserPermissionSet
+	for _, authzProvider := range authzProviders {
+		mine, others := authzProvider.Repos(ctx, unverified)
+		unverified = others
+		if !isSyncedAuthzProvider(authzProvider) {
+			continue
+		}
+
+		set := &UserPermissionSet{
+			UserID:         userID,
+			Perm:           authz.Read,
+			ServiceType:    authzProvider.ServiceType(),
+			ServiceID:      authzProvider.ServiceID(),
+			RepoIDs:        make(map[api.RepoID]struct{}),
+			ClaimedRepoIDs: make(map[api.RepoID]struct{}, len(mine)),
+			UpdatedAt:      syncedAt,
+		}
+		for repo := range mine {
+			set.ClaimedRepoIDs[reposByName[repo.RepoName].ID] = struct{}{}
+		}
+		if len(mine) > 0 {
+			providerAcct, err := authzProviderAccount(ctx, user, accts, authzProvider)
+			if err != nil {
+				return err
+			}
+			perms, err := authzProvider.RepoPerms(ctx, providerAcct, mine)
+			if err != nil {
+				return errors.Wrapf(err, "computing permissions from authz provider %s %s", authzProvider.ServiceType(), authzProvider.ServiceID())
+			}
+			for repo := range mine {
+				if perms[repo.RepoName][authz.Read] {
+					set.RepoIDs[reposByName[repo.RepoName].ID] = struct{}{}
+				}
+			}
+		}
+		sets = append(sets, set)
+	}
+	return UserPermissions.Set(ctx, userID, authz.Read, sets)
+}
+
+// hasSyncedAuthzProviders reports whether any authz provider's permissions are computed by the
+// permissions syncer.
+func hasSyncedAuthzProviders() bool {
+	_, authzProviders := authz.GetProviders()
+	for _, p := range authzProviders {
+		if isSyncedAuthzProvider(p) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	userPermissionsSyncsMu sync.Mutex
+	userPermissionsSyncs   = map[int32]time.Time{} // user ID -> when their last on-demand sync started
+)
+
+var mockScheduleUserPermissionsSync func(userID int32)
+
+// ScheduleUserPermissionsSync computes and stores the permissions of the user in the background (if
+// background permissions syncing is enabled). It is called when the user signs in and when their
+// permissions are needed but have not been computed yet or have expired. It does nothing if a sync
+// of the user was started less than a minute ago, so that the user's requests don't each start a
+// sync.
+func ScheduleUserPermissionsSync(userID int32) {
+	if mockScheduleUserPermissionsSync != nil {
+		mockScheduleUserPermissionsSync(userID)
+		return
+	}
+	if !conf.PermissionsBackgroundSyncEnabled() || !hasSyncedAuthzProviders() {
+		return
+	}
+
+	userPermissionsSyncsMu.Lock()
+	if t, ok := userPermissionsSyncs[userID]; ok && time.Since(t) < time.Minute {
+		userPermissionsSyncsMu.Unlock()
+		return
+	}
+	userPermissionsSyncs[userID] = time.Now()
+	for id, t := range userPermissionsSyncs {
+		if time.Since(t) >= time.Minute {
+			delete(userPermissionsSyncs, id)
+		}
+	}
+	userPermissionsSyncsMu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), userPermissionsSyncTimeout)
+		defer cancel()
+		if err := SyncUserPermissions(ctx, userID); err != nil {
+			log15.Error("Unable to sync user's repository permissions.", "user", userID, "error", err)
+		}
+	}()
+}
+
+// StartPermissionsSyncer runs the permissions syncer, which recomputes the permissions of the users
+// whose permissions were computed more than userPermissionsSyncInterval() ago (or never) while
+// background permissions syncing is enabled. It never returns.
+//
+// Only one frontend runs the permissions syncer at a time, which is guaranteed with a distributed
+// lock.
+func StartPermissionsSyncer() {
+	for {
+		if !conf.PermissionsBackgroundSyncEnabled() || !hasSyncedAuthzProviders() {
+			time.Sleep(time.Minute)
+			continue
+		}
+
+		ctx, release, ok := rcache.TryAcquireMutex(context.Background(), "permissionsSyncer")
+		if !ok {
+			// Failed to acquire the mutex. Wait before trying again.
+			time.Sleep(30 * time.Second)
+			continue
+		}
+		syncStaleUserPermissions(ctx)
+		release()
+	}
+}
+
+// syncStaleUserPermissions recomputes the permissions of users whose permissions are stale until
+// ctx is done or background permissions syncing is disabled. Users whose sync fails are retried
+// after userPermissionsSyncInterval().
+func syncStaleUserPermissions(ctx context.Context) {
+	failed := map[int32]time.Time{}
+	for ctx.Err() == nil && conf.PermissionsBackgroundSyncEnabled() && hasSyncedAuthzProviders() {
+		interval := userPermissionsSyncInterval()
+		var exclude []int32
+		for id, t := range failed {
+			if time.Since(t) >= interval {
+				delete(failed, id)
+				continue
+			}
+			exclude = append(exclude, id)
+		}
+
+		userIDs, err := UserPermissions.ListUserIDsToSync(ctx, authz.Read, time.Now().Add(-interval), exclude, userPermissionsSyncBatchSize)
+		if err != nil {
+			log15.Error("Unable to list users whose repository permissions must be synced.", "error", err)
+		}
+		for _, userID := range userIDs {
+			syncCtx, cancel := context.WithTimeout(ctx, userPermissionsSyncTimeout)
+			err := SyncUserPermissions(syncCtx, userID)
+			cancel()
+			if err != nil {
+				log15.Error("Unable to sync user's repository permissions.", "user", userID, "error", err)
+				failed[userID] = time.Now()
+			}
+		}
+
+		if len(userIDs) == 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Minute):
+			}
+		}
+	}
+}
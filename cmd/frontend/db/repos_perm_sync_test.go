@@ -0,0 +1,216 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz/explicit"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func mockPermissionsBackgroundSync() func() {
+	conf.Mock(&schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{PermissionsBackgroundSync: "enabled"},
+	})
+	return func() {
+		conf.Mock(nil)
+		Mocks.UserPermissions = MockUserPermissions{}
+	}
+}
+
+// mockCachingAuthzProvider is a MockAuthzProvider that caches permissions for cacheTTL.
+type mockCachingAuthzProvider struct {
+	*MockAuthzProvider
+	cacheTTL time.Duration
+}
+
+func (m mockCachingAuthzProvider) CacheTTL() time.Duration { return m.cacheTTL }
+
+func Test_authzFilter_storedPermissions(t *testing.T) {
+	defer mockPermissionsBackgroundSync()()
+	var scheduled []int32
+	mockScheduleUserPermissionsSync = func(userID int32) { scheduled = append(scheduled, userID) }
+	defer func() { mockScheduleUserPermissionsSync = nil }()
+
+	syncedAt := time.Now().Add(-20 * time.Minute)
+	Mocks.UserPermissions.ListForUser = func(userID int32, perm authz.Perm) ([]*UserPermissionSet, error) {
+		if userID != 1 || perm != authz.Read {
+			t.Errorf("got user %d and perm %q, want user 1 and perm read", userID, perm)
+		}
+		return []*UserPermissionSet{{
+			UserID:         1,
+			Perm:           authz.Read,
+			ServiceType:    "gitlab",
+			ServiceID:      "https://gitlab.mine/",
+			RepoIDs:        map[api.RepoID]struct{}{1: {}},
+			ClaimedRepoIDs: map[api.RepoID]struct{}{1: {}, 2: {}},
+			UpdatedAt:      syncedAt,
+		}}, nil
+	}
+
+	var (
+		synced    = &types.Repo{ID: 1, Name: "gitlab.mine/u1/synced"}
+		revoked   = &types.Repo{ID: 2, Name: "gitlab.mine/u1/revoked"}
+		created   = &types.Repo{ID: 3, Name: "gitlab.mine/u1/created"}
+		unclaimed = &types.Repo{ID: 4, Name: "github.com/foo/bar"}
+	)
+	gitlab := &MockAuthzProvider{
+		serviceID:   "https://gitlab.mine/",
+		serviceType: "gitlab",
+		repos: map[api.RepoName]struct{}{
+			synced.Name:  {},
+			revoked.Name: {},
+			created.Name: {},
+		},
+		perms: map[extsvc.ExternalAccount]map[api.RepoName]map[authz.Perm]bool{
+			*acct(1, "gitlab", "https://gitlab.mine/", "u1"): {
+				synced.Name:  {authz.Read: true},
+				revoked.Name: {authz.Read: true},
+				created.Name: {authz.Read: true},
+			},
+		},
+	}
+	call := authzFilter_call{
+		description:  "u1",
+		user:         &types.User{ID: 1},
+		userAccounts: []*extsvc.ExternalAccount{acct(1, "gitlab", "https://gitlab.mine/", "u1")},
+		repos:        []*types.Repo{synced, revoked, created, unclaimed},
+		perm:         authz.Read,
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		scheduled = nil
+		call := call
+		call.expFilteredRepos = []*types.Repo{synced, created, unclaimed}
+		authzFilter_Test{
+			description:         "stored permissions are used for repositories that the provider was the source of permissions for",
+			authzAllowByDefault: true,
+			authzProviders:      []authz.Provider{mockCachingAuthzProvider{gitlab, time.Hour}},
+			calls:               []authzFilter_call{call},
+		}.run(t)
+		if len(scheduled) != 0 {
+			t.Errorf("got scheduled syncs for users %v, want none", scheduled)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		scheduled = nil
+		call := call
+		call.expFilteredRepos = []*types.Repo{synced, revoked, created, unclaimed}
+		authzFilter_Test{
+			description:         "stored permissions older than the provider's cache TTL are not used",
+			authzAllowByDefault: true,
+			authzProviders:      []authz.Provider{mockCachingAuthzProvider{gitlab, 10 * time.Minute}},
+			calls:               []authzFilter_call{call},
+		}.run(t)
+		if want := []int32{1}; !reflect.DeepEqual(scheduled, want) {
+			t.Errorf("got scheduled syncs for users %v, want %v", scheduled, want)
+		}
+	})
+
+	t.Run("external accounts not listed", func(t *testing.T) {
+		authz.SetProviders(true, []authz.Provider{mockCachingAuthzProvider{gitlab, time.Hour}})
+		Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+			return &types.User{ID: 1}, nil
+		}
+		Mocks.ExternalAccounts.List = func(ExternalAccountsListOptions) ([]*extsvc.ExternalAccount, error) {
+			t.Error("external accounts were listed")
+			return nil, nil
+		}
+		defer func() { Mocks.Users = MockUsers{}; Mocks.ExternalAccounts = MockExternalAccounts{} }()
+
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+		filteredRepos, err := authzFilter(ctx, []*types.Repo{synced, revoked, unclaimed}, authz.Read)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []*types.Repo{synced, unclaimed}; !reflect.DeepEqual(filteredRepos, want) {
+			t.Errorf("got filtered repos %v, want %v", filteredRepos, want)
+		}
+	})
+}
+
+func TestSyncUserPermissions(t *testing.T) {
+	defer mockPermissionsBackgroundSync()()
+	defer func() { Mocks.RepoPermissions = MockRepoPermissions{} }()
+
+	authz.SetProviders(true, []authz.Provider{
+		&MockAuthzProvider{
+			serviceID:   "https://gitlab.mine/",
+			serviceType: "gitlab",
+			repos: map[api.RepoName]struct{}{
+				"gitlab.mine/u1/r1": {},
+				"gitlab.mine/u1/r2": {},
+			},
+			perms: map[extsvc.ExternalAccount]map[api.RepoName]map[authz.Perm]bool{
+				*acct(1, "gitlab", "https://gitlab.mine/", "u1"): {
+					"gitlab.mine/u1/r1": {authz.Read: true},
+					"gitlab.mine/u1/r2": {authz.Read: false},
+				},
+			},
+		},
+		explicit.NewProvider(RepoPermissions),
+	})
+	Mocks.RepoPermissions.Patterns = func() ([]string, error) { return []string{"^gitolite.mine/"}, nil }
+	Mocks.RepoPermissions.ListForUser = func(userID int32) ([]*types.RepoPermission, error) {
+		t.Error("explicit permissions were computed")
+		return nil, nil
+	}
+
+	Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+		return &types.User{ID: id}, nil
+	}
+	Mocks.ExternalAccounts.List = func(ExternalAccountsListOptions) ([]*extsvc.ExternalAccount, error) {
+		return []*extsvc.ExternalAccount{acct(1, "gitlab", "https://gitlab.mine/", "u1")}, nil
+	}
+	Mocks.Repos.List = func(ctx context.Context, opt ReposListOptions) ([]*types.Repo, error) {
+		if !actor.FromContext(ctx).Internal {
+			t.Error("repositories were not listed as an internal actor")
+		}
+		return []*types.Repo{
+			{ID: 1, Name: "gitlab.mine/u1/r1"},
+			{ID: 2, Name: "gitlab.mine/u1/r2"},
+			{ID: 3, Name: "gitolite.mine/r3"},
+			{ID: 4, Name: "github.com/foo/bar"},
+		}, nil
+	}
+	var stored []*UserPermissionSet
+	Mocks.UserPermissions.Set = func(userID int32, perm authz.Perm, sets []*UserPermissionSet) error {
+		if userID != 1 || perm != authz.Read {
+			t.Errorf("got user %d and perm %q, want user 1 and perm read", userID, perm)
+		}
+		stored = sets
+		return nil
+	}
+
+	before := time.Now()
+	if err := SyncUserPermissions(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("got %d stored permission sets, want 1", len(stored))
+	}
+	if stored[0].UpdatedAt.Before(before) {
+		t.Errorf("got updated at %s, want after %s", stored[0].UpdatedAt, before)
+	}
+	stored[0].UpdatedAt = time.Time{}
+	want := &UserPermissionSet{
+		UserID:         1,
+		Perm:           authz.Read,
+		ServiceType:    "gitlab",
+		ServiceID:      "https://gitlab.mine/",
+		RepoIDs:        map[api.RepoID]struct{}{1: {}},
+		ClaimedRepoIDs: map[api.RepoID]struct{}{1: {}, 2: {}},
+	}
+	if !reflect.DeepEqual(stored[0], want) {
+		t.Errorf("got stored permissions %+v, want %+v", stored[0], want)
+	}
+}
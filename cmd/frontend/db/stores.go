@@ -6,6 +6,7 @@ var (
 	DiscussionComments        = &discussionComments{}
 	DiscussionMailReplyTokens = &discussionMailReplyTokens{}
 	Repos                     = &repos{}
+	RepoPermissions           = &repoPermissions{}
 	Phabricator               = &phabricator{}
 	SavedQueries              = &savedQueries{}
 	Orgs                      = &orgs{}
@@ -13,6 +14,7 @@ var (
 	Settings                  = &settings{}
 	Users                     = &users{}
 	UserEmails                = &userEmails{}
+	UserPermissions           = &userPermissions{}
 	SiteConfig                = &siteConfig{}
 	CertCache                 = &certCache{}
 
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+// UserPermissionSet is the set of repositories on which a user has a permission according to an
+// authz provider, as computed by the background permissions syncer (see SyncUserPermissions).
+type UserPermissionSet struct {
+	UserID      int32
+	Perm        authz.Perm
+	ServiceType string // the authz provider's service type
+	ServiceID   string // the authz provider's service ID
+
+	// RepoIDs are the IDs of the repositories on which the user has the permission, among the
+	// repositories that the authz provider was the source of permissions for.
+	RepoIDs map[api.RepoID]struct{}
+
+	// ClaimedRepoIDs are the IDs of the repositories that the authz provider was the source of
+	// permissions for (after the repositories of the authz providers before it were excluded).
+	ClaimedRepoIDs map[api.RepoID]struct{}
+
+	// UpdatedAt is when the permissions were computed. They are only valid for repositories that
+	// were created before then.
+	UpdatedAt time.Time
+}
+
+// userPermissions is the store of the users' repository permissions that were computed by the
+// background permissions syncer.
+type userPermissions struct{}
+
+// ListForUser lists the stored permission sets of the user for the permission (one for each
+// authz provider).
+func (s *userPermissions) ListForUser(ctx context.Context, userID int32, perm authz.Perm) ([]*UserPermissionSet, error) {
+	if Mocks.UserPermissions.ListForUser != nil {
+		return Mocks.UserPermissions.ListForUser(userID, perm)
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx,
+		"SELECT service_type, service_id, repo_ids, claimed_repo_ids, updated_at FROM user_permissions WHERE user_id=$1 AND permission=$2",
+		userID, string(perm),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sets []*UserPermissionSet
+	for rows.Next() {
+		set := UserPermissionSet{UserID: userID, Perm: perm}
+		var repoIDs, claimedRepoIDs []int64
+		if err := rows.Scan(&set.ServiceType, &set.ServiceID, pq.Array(&repoIDs), pq.Array(&claimedRepoIDs), &set.UpdatedAt); err != nil {
+			return nil, err
+		}
+		set.RepoIDs = repoIDSet(repoIDs)
+		set.ClaimedRepoIDs = repoIDSet(claimedRepoIDs)
+		sets = append(sets, &set)
+	}
+	return sets, rows.Err()
+}
+
+// Set replaces all stored permission sets of the user for the permission with the given sets.
+func (s *userPermissions) Set(ctx context.Context, userID int32, perm authz.Perm, sets []*UserPermissionSet) error {
+	if Mocks.UserPermissions.Set != nil {
+		return Mocks.UserPermissions.Set(userID, perm, sets)
+	}
+
+	return Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM user_permissions WHERE user_id=$1 AND permission=$2", userID, string(perm)); err != nil {
+			return err
+		}
+		for _, set := range sets {
+			if _, err := tx.ExecContext(ctx,
+				"INSERT INTO user_permissions(user_id, permission, service_type, service_id, repo_ids, claimed_repo_ids, updated_at) VALUES($1, $2, $3, $4, $5, $6, $7)",
+				userID, string(perm), set.ServiceType, set.ServiceID, pq.Array(repoIDList(set.RepoIDs)), pq.Array(repoIDList(set.ClaimedRepoIDs)), set.UpdatedAt,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListUserIDsToSync lists the IDs of the users (other than site admins, who have access to all
+// repositories) whose stored permissions were computed before syncedBefore or were never computed,
+// least recently computed first. Users in exclude are omitted.
+func (s *userPermissions) ListUserIDsToSync(ctx context.Context, perm authz.Perm, syncedBefore time.Time, exclude []int32, limit int) ([]int32, error) {
+	if Mocks.UserPermissions.ListUserIDsToSync != nil {
+		return Mocks.UserPermissions.ListUserIDsToSync(perm, syncedBefore, exclude, limit)
+	}
+
+	excludeIDs := make([]int64, len(exclude))
+	for i, id := range exclude {
+		excludeIDs[i] = int64(id)
+	}
+	q := sqlf.Sprintf(`
+SELECT users.id FROM users
+LEFT JOIN user_permissions ON user_permissions.user_id=users.id AND user_permissions.permission=%s
+WHERE users.deleted_at IS NULL AND NOT users.site_admin AND users.id <> ALL(%s)
+GROUP BY users.id
+HAVING MIN(user_permissions.updated_at) IS NULL OR MIN(user_permissions.updated_at) < %s
+ORDER BY MIN(user_permissions.updated_at) ASC NULLS FIRST, users.id ASC
+LIMIT %d`,
+		string(perm), pq.Array(excludeIDs), syncedBefore, limit,
+	)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, rows.Err()
+}
+
+func repoIDSet(ids []int64) map[api.RepoID]struct{} {
+	set := make(map[api.RepoID]struct{}, len(ids))
+	for _, id := range ids {
+		set[api.RepoID(id)] = struct{}{}
+	}
+	return set
+}
+
+func repoIDList(set map[api.RepoID]struct{}) []int64 {
+	ids := make([]int64, 0, len(set))
+	for id := range set {
+		ids = append(ids, int64(id))
+	}
+	return ids
+}
+
+type MockUserPermissions struct {
+	ListForUser       func(userID int32, perm authz.Perm) ([]*UserPermissionSet, error)
+	Set               func(userID int32, perm authz.Perm, sets []*UserPermissionSet) error
+	ListUserIDsToSync func(perm authz.Perm, syncedBefore time.Time, exclude []int32, limit int) ([]int32, error)
+}
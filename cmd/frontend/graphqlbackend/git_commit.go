@@ -176,6 +176,9 @@ func (r *gitCommitResolver) Ancestors(ctx context.Context, args *struct {
 		query:         args.Query,
 		path:          args.Path,
 		repo:          r.repo,
+		// The last commit of a path is requested for every entry of a tree
+		// listing at once, so batch those lookups.
+		batch: args.ConnectionArgs.First != nil && *args.ConnectionArgs.First == 1 && args.Path != nil,
 	}
 }
 
@@ -18,6 +18,9 @@ type gitCommitConnectionResolver struct {
 	author *string
 	after  *string
 
+	// batch allows the git command to be batched with concurrent ones.
+	batch bool
+
 	repo *repositoryResolver
 
 	// cache results because it is used by multiple fields
@@ -56,6 +59,7 @@ func (r *gitCommitConnectionResolver) compute(ctx context.Context) ([]*git.Commi
 			Author:       author,
 			After:        after,
 			Path:         path,
+			Batch:        r.batch,
 		})
 	}
 
@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/suspiciousnames"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/actor"
@@ -111,8 +112,17 @@ func (o *OrgResolver) settingsSubject() api.SettingsSubject {
 }
 
 func (o *OrgResolver) LatestSettings(ctx context.Context) (*settingsResolver, error) {
+	return o.latestSettings(ctx, authz.ScopeSettingsRead)
+}
+
+// latestSettings returns the organization's latest settings if the viewer may read them with an
+// access token that has the given read-only scope (if any).
+func (o *OrgResolver) latestSettings(ctx context.Context, scope string) (*settingsResolver, error) {
 	// 🚨 SECURITY: Only organization members and site admins may access the settings, because they
 	// may contains secrets or other sensitive data.
+	if err := authz.CheckScope(ctx, scope); err != nil {
+		return nil, err
+	}
 	if err := backend.CheckOrgAccess(ctx, o.org.ID); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,70 @@
+package graphqlbackend
+
+import (
+	"context"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+// repositoryPermissionResolver resolves a repository permission that a site admin granted
+// explicitly to a user or an organization.
+type repositoryPermissionResolver struct {
+	permission types.RepoPermission
+}
+
+func repositoryPermissionByID(ctx context.Context, id graphql.ID) (*repositoryPermissionResolver, error) {
+	// 🚨 SECURITY: Only site admins can view repository permissions.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	permissionID, err := unmarshalRepositoryPermissionID(id)
+	if err != nil {
+		return nil, err
+	}
+	permission, err := db.RepoPermissions.GetByID(ctx, permissionID)
+	if err != nil {
+		return nil, err
+	}
+	return &repositoryPermissionResolver{permission: *permission}, nil
+}
+
+func marshalRepositoryPermissionID(id int32) graphql.ID {
+	return relay.MarshalID("RepositoryPermission", id)
+}
+
+func unmarshalRepositoryPermissionID(id graphql.ID) (permissionID int32, err error) {
+	err = relay.UnmarshalSpec(id, &permissionID)
+	return
+}
+
+func (r *repositoryPermissionResolver) ID() graphql.ID {
+	return marshalRepositoryPermissionID(r.permission.ID)
+}
+
+func (r *repositoryPermissionResolver) User(ctx context.Context) (*UserResolver, error) {
+	if r.permission.UserID == 0 {
+		return nil, nil
+	}
+	return UserByIDInt32(ctx, r.permission.UserID)
+}
+
+func (r *repositoryPermissionResolver) Organization(ctx context.Context) (*OrgResolver, error) {
+	if r.permission.OrgID == 0 {
+		return nil, nil
+	}
+	return OrgByIDInt32(ctx, r.permission.OrgID)
+}
+
+func (r *repositoryPermissionResolver) RepositoryPattern() string { return r.permission.RepoPattern }
+
+func (r *repositoryPermissionResolver) Permission() string { return r.permission.Permission }
+
+func (r *repositoryPermissionResolver) CreatedAt() string {
+	return r.permission.CreatedAt.Format(time.RFC3339)
+}
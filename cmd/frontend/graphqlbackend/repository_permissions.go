@@ -0,0 +1,150 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+)
+
+func (*schemaResolver) AddRepositoryPermission(ctx context.Context, args *struct {
+	User              *graphql.ID
+	Organization      *graphql.ID
+	RepositoryPattern string
+	Permission        string
+}) (*repositoryPermissionResolver, error) {
+	// 🚨 SECURITY: Only site admins can grant repository permissions.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if !conf.ExplicitPermissionsEnabled() {
+		// Don't let site admins believe that the permission restricts access to the repositories.
+		return nil, errors.New("explicit repository permissions are disabled (enable them with experimentalFeatures.explicitPermissions in the site configuration)")
+	}
+
+	p := &types.RepoPermission{
+		RepoPattern: args.RepositoryPattern,
+		Permission:  args.Permission,
+	}
+	if args.User != nil {
+		var err error
+		if p.UserID, err = UnmarshalUserID(*args.User); err != nil {
+			return nil, err
+		}
+	}
+	if args.Organization != nil {
+		var err error
+		if p.OrgID, err = UnmarshalOrgID(*args.Organization); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := db.RepoPermissions.Create(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return &repositoryPermissionResolver{permission: *created}, nil
+}
+
+func (*schemaResolver) RemoveRepositoryPermission(ctx context.Context, args *struct {
+	RepositoryPermission graphql.ID
+}) (*EmptyResponse, error) {
+	// 🚨 SECURITY: Only site admins can revoke repository permissions.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	id, err := unmarshalRepositoryPermissionID(args.RepositoryPermission)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.RepoPermissions.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+func (r *siteResolver) RepositoryPermissions(ctx context.Context, args *struct {
+	graphqlutil.ConnectionArgs
+	User         *graphql.ID
+	Organization *graphql.ID
+}) (*repositoryPermissionConnectionResolver, error) {
+	// 🚨 SECURITY: Only site admins can list repository permissions.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var opt db.RepoPermissionsListOptions
+	if args.User != nil {
+		var err error
+		if opt.UserID, err = UnmarshalUserID(*args.User); err != nil {
+			return nil, err
+		}
+	}
+	if args.Organization != nil {
+		var err error
+		if opt.OrgID, err = UnmarshalOrgID(*args.Organization); err != nil {
+			return nil, err
+		}
+	}
+	args.ConnectionArgs.Set(&opt.LimitOffset)
+	return &repositoryPermissionConnectionResolver{opt: opt}, nil
+}
+
+// repositoryPermissionConnectionResolver resolves a list of repository permissions.
+//
+// 🚨 SECURITY: When instantiating a repositoryPermissionConnectionResolver value, the caller MUST
+// check permissions.
+type repositoryPermissionConnectionResolver struct {
+	opt db.RepoPermissionsListOptions
+
+	// cache results because they are used by multiple fields
+	once        sync.Once
+	permissions []*types.RepoPermission
+	err         error
+}
+
+func (r *repositoryPermissionConnectionResolver) compute(ctx context.Context) ([]*types.RepoPermission, error) {
+	r.once.Do(func() {
+		opt2 := r.opt
+		if opt2.LimitOffset != nil {
+			tmp := *opt2.LimitOffset
+			opt2.LimitOffset = &tmp
+			opt2.Limit++ // so we can detect if there is a next page
+		}
+
+		r.permissions, r.err = db.RepoPermissions.List(ctx, opt2)
+	})
+	return r.permissions, r.err
+}
+
+func (r *repositoryPermissionConnectionResolver) Nodes(ctx context.Context) ([]*repositoryPermissionResolver, error) {
+	permissions, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var l []*repositoryPermissionResolver
+	for _, permission := range permissions {
+		l = append(l, &repositoryPermissionResolver{permission: *permission})
+	}
+	return l, nil
+}
+
+func (r *repositoryPermissionConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.RepoPermissions.Count(ctx, r.opt)
+	return int32(count), err
+}
+
+func (r *repositoryPermissionConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	permissions, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt.LimitOffset != nil && len(permissions) > r.opt.Limit), nil
+}
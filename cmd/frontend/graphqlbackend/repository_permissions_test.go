@@ -0,0 +1,115 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestMutation_AddRepositoryPermission(t *testing.T) {
+	t.Run("authenticated as site admin", func(t *testing.T) {
+		resetMocks()
+		conf.Mock(&schema.SiteConfiguration{ExperimentalFeatures: &schema.ExperimentalFeatures{ExplicitPermissions: "enabled"}})
+		defer conf.Mock(nil)
+		db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: true}, nil
+		}
+		calledCreate := false
+		db.Mocks.RepoPermissions.Create = func(p *types.RepoPermission) (*types.RepoPermission, error) {
+			calledCreate = true
+			if want := (types.RepoPermission{OrgID: 2, RepoPattern: "gitolite.example.com/.*", Permission: "read"}); *p != want {
+				t.Errorf("got permission %+v, want %+v", *p, want)
+			}
+			created := *p
+			created.ID = 1
+			return &created, nil
+		}
+
+		gqltesting.RunTests(t, []*gqltesting.Test{
+			{
+				Context: actor.WithActor(context.Background(), &actor.Actor{UID: 1}),
+				Schema:  GraphQLSchema,
+				Query: `
+				mutation {
+					addRepositoryPermission(organization: "T3JnOjI=", repositoryPattern: "gitolite.example.com/.*", permission: "read") {
+						id
+						repositoryPattern
+						permission
+					}
+				}
+			`,
+				ExpectedResult: `
+				{
+					"addRepositoryPermission": {
+						"id": "UmVwb3NpdG9yeVBlcm1pc3Npb246MQ==",
+						"repositoryPattern": "gitolite.example.com/.*",
+						"permission": "read"
+					}
+				}
+			`,
+			},
+		})
+		if !calledCreate {
+			t.Error("!calledCreate")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		resetMocks()
+		db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: true}, nil
+		}
+		db.Mocks.RepoPermissions.Create = func(p *types.RepoPermission) (*types.RepoPermission, error) {
+			t.Fatal("Create was called")
+			return nil, nil
+		}
+
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+		result, err := (&schemaResolver{}).AddRepositoryPermission(ctx, &struct {
+			User              *graphql.ID
+			Organization      *graphql.ID
+			RepositoryPattern string
+			Permission        string
+		}{RepositoryPattern: ".*", Permission: "read"})
+		if err == nil {
+			t.Error("got nil error, want disabled error")
+		}
+		if result != nil {
+			t.Errorf("got result %v, want nil", result)
+		}
+	})
+
+	// 🚨 SECURITY: This tests that only site admins can grant repository permissions.
+	t.Run("authenticated as non-site-admin", func(t *testing.T) {
+		resetMocks()
+		db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+			return &types.User{ID: 1}, nil
+		}
+		db.Mocks.RepoPermissions.Create = func(p *types.RepoPermission) (*types.RepoPermission, error) {
+			t.Fatal("Create was called")
+			return nil, nil
+		}
+
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+		result, err := (&schemaResolver{}).AddRepositoryPermission(ctx, &struct {
+			User              *graphql.ID
+			Organization      *graphql.ID
+			RepositoryPattern string
+			Permission        string
+		}{RepositoryPattern: ".*", Permission: "read"})
+		if want := backend.ErrMustBeSiteAdmin; err != want {
+			t.Errorf("got error %v, want %v", err, want)
+		}
+		if result != nil {
+			t.Errorf("got result %v, want nil", result)
+		}
+	})
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/zoekt"
 	zoektquery "github.com/google/zoekt/query"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
 )
 
 func (r *repositoryResolver) TextSearchIndex() *repositoryTextSearchIndexResolver {
@@ -77,9 +78,8 @@ func (r *repositoryTextSearchIndexStatus) IndexShardsCount() int32 {
 }
 
 func (r *repositoryTextSearchIndexResolver) Refs(ctx context.Context) ([]*repositoryTextSearchIndexedRef, error) {
-	// We assume that the default branch for enabled repositories is always configured to be indexed.
-	//
-	// TODO(sqs): support configuring which branches should be indexed (add'l branches, not default branch, etc.).
+	// We assume that the default branch for enabled repositories is always configured to be indexed,
+	// in addition to the branches configured in search.index.branches.
 	defaultBranchRef, err := r.repo.DefaultBranch(ctx)
 	if err != nil {
 		return nil, err
@@ -88,6 +88,11 @@ func (r *repositoryTextSearchIndexResolver) Refs(ctx context.Context) ([]*reposi
 		return []*repositoryTextSearchIndexedRef{}, nil
 	}
 	refNames := []string{defaultBranchRef.name}
+	for _, branch := range conf.SearchIndexBranches(string(r.repo.repo.Name)) {
+		if name := "refs/heads/" + branch; name != defaultBranchRef.name {
+			refNames = append(refNames, name)
+		}
+	}
 
 	refs := make([]*repositoryTextSearchIndexedRef, len(refNames))
 	for i, refName := range refNames {
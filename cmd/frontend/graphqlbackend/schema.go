@@ -93,6 +93,24 @@ type Mutation {
     #
     # Only site admins may perform this mutation.
     updateAllMirrorRepositories: EmptyResponse!
+    # Moves a repository to the front of the update queue of the repository update scheduler (see
+    # Site.updateScheduler), so that it is updated next. Fails if the update scheduler is not enabled.
+    #
+    # Only site admins may perform this mutation.
+    bumpRepositoryUpdate(
+        # The name of the repository, as in ScheduledRepositoryUpdate.name.
+        name: String!
+    ): EmptyResponse!
+    # Pauses or resumes scheduled updates of the repositories of an update scheduler source (see
+    # UpdateSchedulerSource). Fails if the update scheduler is not enabled.
+    #
+    # Only site admins may perform this mutation.
+    setUpdateSchedulerSourcePaused(
+        # The name of the source, as in UpdateSchedulerSource.name.
+        source: String!
+        # Whether to pause (true) or resume (false) scheduled updates.
+        paused: Boolean!
+    ): EmptyResponse!
     # Deletes a repository and all data associated with it, irreversibly.
     #
     # If the repository was added because it was present in the site configuration (directly,
@@ -155,10 +173,19 @@ type Mutation {
     #
     # - "user:all": Full control of all resources accessible to the user account.
     # - "site-admin:sudo": Ability to perform any action as any other user. (Only site admins may create tokens
-    #   with this scope.)
+    #   with this scope, and only together with "user:all".)
+    # - "search:read": Read access to search results and saved searches.
+    # - "repo:read": Read access to repositories and their contents.
+    # - "settings:read": Read access to settings.
+    #
+    # Every access token must have the "user:all" scope or at least one of the read-only scopes ("search:read",
+    # "repo:read", and "settings:read"). An access token without the "user:all" scope can't be used to perform
+    # mutations, and it can only be used to query the fields that its scopes cover.
+    #
+    # If expiresAt (an RFC 3339 date) is given, the access token can't be used after that date.
     #
     # Only the user or site admins may perform this mutation.
-    createAccessToken(user: ID!, scopes: [String!]!, note: String!): CreateAccessTokenResult!
+    createAccessToken(user: ID!, scopes: [String!]!, note: String!, expiresAt: String): CreateAccessTokenResult!
     # Deletes and immediately revokes the specified access token, specified by either its ID or by the token
     # itself.
     #
@@ -169,6 +196,27 @@ type Mutation {
     #
     # Only site admins or the user who is associated with the external account may perform this mutation.
     deleteExternalAccount(externalAccount: ID!): EmptyResponse!
+    # Grants a repository permission to a user or an organization (exactly one of which must be specified). The
+    # permission applies to all repositories whose entire names match repositoryPattern, a case-insensitive
+    # regular expression. The only supported permission is "read".
+    #
+    # Repositories whose permissions are provided by a code host (see the "authorization" property of code host
+    # connections) are not affected. All other repositories whose names match the pattern of any repository
+    # permission are only accessible to the users and organization members that they are granted to.
+    #
+    # Requires the experimentalFeatures.explicitPermissions site configuration property to be "enabled".
+    #
+    # Only site admins may perform this mutation.
+    addRepositoryPermission(
+        user: ID
+        organization: ID
+        repositoryPattern: String!
+        permission: String!
+    ): RepositoryPermission!
+    # Revokes a repository permission.
+    #
+    # Only site admins may perform this mutation.
+    removeRepositoryPermission(repositoryPermission: ID!): EmptyResponse!
     # Invite the user with the given username to join the organization. The invited user account must already
     # exist.
     #
@@ -840,6 +888,11 @@ type SearchResults {
     elapsedMilliseconds: Int!
     # Dynamic filters generated by the search results
     dynamicFilters: [SearchFilter!]!
+    # Exact counts of the results grouped as specified by the countby: keyword of the
+    # query (e.g., "countby:repo"), in descending order of count. It is empty if the
+    # query has no countby: keyword. Only the results that were found are counted, so
+    # the counts are incomplete if limitHit is true.
+    aggregates: [SearchAggregate!]!
 }
 
 # Statistics about search results.
@@ -864,6 +917,16 @@ type SearchFilter {
     kind: String!
 }
 
+# A group of search results and the number of matches in it.
+type SearchAggregate {
+    # The value that the results in the group have in common: a repository name, a file path
+    # (prefixed by its repository name), a commit author ("Name <email>") or the text matched by
+    # the pattern's capture group.
+    value: String!
+    # The number of matches in the group (as in resultCount).
+    count: Int!
+}
+
 # A search suggestion.
 union SearchSuggestion = Repository | File | Symbol
 
@@ -1892,6 +1955,10 @@ type FileMatch {
     lineMatches: [LineMatch!]!
     # Whether or not the limit was hit.
     limitHit: Boolean!
+    # The relevance score of the match, between 0 and 1. Search results are ordered by
+    # descending score. The score is only meaningful relative to the scores of other
+    # results of the same search.
+    score: Float!
 }
 
 # A line match.
@@ -2085,6 +2152,8 @@ type AccessToken implements Node {
     createdAt: String!
     # The date when the access token was last used to authenticate a request.
     lastUsedAt: String
+    # The date after which the access token can't be used, or null if it never expires.
+    expiresAt: String
 }
 
 # A list of access tokens.
@@ -2164,6 +2233,33 @@ type ExternalAccount implements Node {
     accountData: JSONValue
 }
 
+# A repository permission that a site admin granted to a user or an organization.
+type RepositoryPermission implements Node {
+    # The unique ID for the repository permission.
+    id: ID!
+    # The user who is granted the permission, if it is granted to a user.
+    user: User
+    # The organization whose members are granted the permission, if it is granted to an organization.
+    organization: Org
+    # The case-insensitive regular expression that matches the names of the repositories the permission applies to.
+    repositoryPattern: String!
+    # The permission (currently always "read").
+    permission: String!
+    # The date when the permission was granted.
+    createdAt: String!
+}
+
+# A list of repository permissions.
+type RepositoryPermissionConnection {
+    # A list of repository permissions.
+    nodes: [RepositoryPermission!]!
+    # The total count of repository permissions in the connection. This total count may be larger than the number
+    # of nodes in this object when the result is paginated.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
 # An active user session.
 type Session {
     # Whether the user can sign out of this session on Sourcegraph.
@@ -2587,6 +2683,18 @@ type Site implements SettingsSubject {
         # Include only external accounts with this client ID.
         clientID: String
     ): ExternalAccountConnection!
+    # A list of all repository permissions that were granted explicitly by site admins (see
+    # Mutation.addRepositoryPermission).
+    #
+    # Only site admins can access this field.
+    repositoryPermissions(
+        # Returns the first n repository permissions from the list.
+        first: Int
+        # Include only repository permissions granted directly to this user.
+        user: ID
+        # Include only repository permissions granted to this organization.
+        organization: ID
+    ): RepositoryPermissionConnection!
     # The build version of the Sourcegraph software that is running on this site (of the form
     # NNNNN_YYYY-MM-DD_XXXXX, like 12345_2018-01-01_abcdef).
     buildVersion: String!
@@ -2618,6 +2726,60 @@ type Site implements SettingsSubject {
         # Months of history.
         months: Int
     ): SiteUsageStatistics!
+    # The status of the repository update scheduler. Only visible to site admins.
+    #
+    # Null if the update scheduler is not enabled (with the experimentalFeatures.updateScheduler2 site
+    # configuration property).
+    updateScheduler: UpdateScheduler
+}
+
+# The status of the repository update scheduler, which periodically updates repositories from their code
+# hosts.
+type UpdateScheduler {
+    # The repositories that are scheduled for updates or queued for an update, ordered by their position in the
+    # update queue, followed by repositories that are updating and then by the time that they are due to be
+    # updated.
+    repositories(
+        # Returns the first n repositories from the list.
+        first: Int
+        # Return only repositories whose name contains this string.
+        query: String
+    ): [ScheduledRepositoryUpdate!]!
+    # The configuration sources (such as code host connections) of the scheduled repositories.
+    sources: [UpdateSchedulerSource!]!
+}
+
+# The update schedule of a repository.
+type ScheduledRepositoryUpdate {
+    # The repository's name.
+    name: String!
+    # When the repository is next queued for an update. Null if the repository is not in the schedule (e.g.,
+    # because its source is paused).
+    due: String
+    # The number of seconds between scheduled updates of the repository. This grows for repositories that
+    # don't change often.
+    intervalSeconds: Int!
+    # The error of the last update of the repository, if any.
+    lastError: String
+    # The repository's position in the update queue, starting at 1. Null if the repository is not queued.
+    queuePosition: Int
+    # Whether the repository is being updated.
+    updating: Boolean!
+}
+
+# A configuration source of the repositories in the update scheduler.
+type UpdateSchedulerSource {
+    # The name of the source, such as "github:https://github.com#1a2b3c4d".
+    name: String!
+    # The number of repositories from the source.
+    repositoryCount: Int!
+    # Whether scheduled updates of the source's repositories are paused.
+    paused: Boolean!
+    # When the source's repositories were last synced from the code host, or null if they have
+    # not been synced yet (or the source is not a code host connection that is synced).
+    lastSyncAt: String
+    # The error that occurred when listing the source's repositories in the last sync, if any.
+    lastSyncError: String
 }
 
 # The configuration for a site.
@@ -78,6 +78,10 @@ type searchResolver struct {
 
 	query *query.Query // the parsed search query
 
+	// stream, if non-nil, is sent results as they are found (see
+	// StreamSearch).
+	stream searchStream
+
 	// Cached resolveRepositories results.
 	reposMu                   sync.Mutex
 	repoRevs, missingRepoRevs []*search.RepositoryRevisions
@@ -114,6 +118,9 @@ func (r *searchResolver) maxResults() int32 {
 			return int32(n)
 		}
 	}
+	if countBy, _ := r.query.StringValue(query.FieldCountBy); countBy != "" {
+		return defaultMaxCountByResults
+	}
 	return defaultMaxSearchResults
 }
 
@@ -229,6 +236,9 @@ func (r *searchResolver) resolveRepositories(ctx context.Context, effectiveRepoF
 		noArchived:       archived == No || archived == False,
 	})
 	tr.LazyPrintf("resolveRepositories - done")
+	if err == nil {
+		repoRevs, repoResults, err = r.filterReposByRepoFields(ctx, repoRevs, repoResults)
+	}
 	if effectiveRepoFieldValues == nil {
 		r.repoRevs = repoRevs
 		r.missingRepoRevs = missingRepoRevs
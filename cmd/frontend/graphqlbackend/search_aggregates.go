@@ -0,0 +1,137 @@
+package graphqlbackend
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+)
+
+// defaultMaxCountByResults is the default result limit for queries with a
+// countby: field. It is higher than defaultMaxSearchResults because the counts
+// are only exact if the limit is not hit.
+const defaultMaxCountByResults = 10000
+
+// countBy describes how to group search results into aggregates, as specified
+// by the countby: field of a query.
+type countBy struct {
+	field string // "repo", "file", "author" or "capture"
+
+	// captures are the search patterns whose first capture group is counted
+	// (for "capture" only). There is one for each disjunct of the query.
+	captures []*regexp.Regexp
+}
+
+// countBy returns how to group the results of the query into aggregates, or
+// nil if the query has no countby: field.
+func (r *searchResolver) countBy() (*countBy, error) {
+	field, _ := r.query.StringValue(query.FieldCountBy)
+	switch field {
+	case "":
+		return nil, nil
+	case "repo", "file", "author":
+		return &countBy{field: field}, nil
+	case "capture":
+		patterns, err := r.getPatternInfos()
+		if err != nil {
+			return nil, err
+		}
+		cb := &countBy{field: field}
+		for _, p := range patterns {
+			if !p.IsRegExp {
+				return nil, fmt.Errorf("countby:capture is only supported for regexp patterns")
+			}
+			expr := p.Pattern
+			if !p.IsCaseSensitive {
+				expr = "(?i:" + expr + ")"
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, err
+			}
+			if re.NumSubexp() == 0 {
+				return nil, fmt.Errorf("countby:capture requires a search pattern with a capture group, such as %q", `import "(\S+)"`)
+			}
+			cb.captures = append(cb.captures, re)
+		}
+		return cb, nil
+	default:
+		return nil, fmt.Errorf("invalid countby:%q (valid values are: repo, file, author, capture)", field)
+	}
+}
+
+// aggregate groups the results according to cb and returns the number of
+// matches in each group, sorted by descending count.
+func (cb *countBy) aggregate(results []*searchResultResolver) []*searchAggregateResolver {
+	counts := map[string]int32{}
+	for _, result := range results {
+		switch cb.field {
+		case "repo":
+			repo, _ := getSearchResultURIs(result)
+			switch {
+			case result.fileMatch != nil:
+				if result.fileMatch.inputRev != nil && *result.fileMatch.inputRev != "" {
+					repo += "@" + *result.fileMatch.inputRev
+				}
+			case result.diff != nil:
+				repo = string(result.diff.commit.repo.repo.Name)
+			}
+			counts[repo] += result.resultCount()
+
+		case "file":
+			if fm := result.fileMatch; fm != nil {
+				file := string(fm.repo.Name)
+				if fm.inputRev != nil && *fm.inputRev != "" {
+					file += "@" + *fm.inputRev
+				}
+				counts[file+"/"+fm.JPath] += result.resultCount()
+			}
+
+		case "author":
+			if result.diff != nil {
+				author := result.diff.commit.author.person
+				counts[fmt.Sprintf("%s <%s>", author.name, author.email)]++
+			}
+
+		case "capture":
+			if result.fileMatch == nil {
+				continue
+			}
+			for _, lm := range result.fileMatch.JLineMatches {
+				for _, re := range cb.captures {
+					matches := re.FindAllStringSubmatchIndex(lm.JPreview, -1)
+					for _, m := range matches {
+						if m[2] >= 0 {
+							counts[lm.JPreview[m[2]:m[3]]]++
+						}
+					}
+					if len(matches) > 0 {
+						break // don't count the same text again for another disjunct
+					}
+				}
+			}
+		}
+	}
+
+	aggregates := make([]*searchAggregateResolver, 0, len(counts))
+	for value, count := range counts {
+		aggregates = append(aggregates, &searchAggregateResolver{value: value, count: count})
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		if aggregates[i].count != aggregates[j].count {
+			return aggregates[i].count > aggregates[j].count
+		}
+		return aggregates[i].value < aggregates[j].value
+	})
+	return aggregates
+}
+
+// searchAggregateResolver is a resolver for the GraphQL type `SearchAggregate`
+type searchAggregateResolver struct {
+	value string
+	count int32
+}
+
+func (r *searchAggregateResolver) Value() string { return r.value }
+func (r *searchAggregateResolver) Count() int32  { return r.count }
@@ -0,0 +1,92 @@
+package graphqlbackend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestCountBy_aggregate(t *testing.T) {
+	repoA := &types.Repo{Name: "a"}
+	repoB := &types.Repo{Name: "b"}
+	rev := "v1"
+	results := []*searchResultResolver{
+		{fileMatch: &fileMatchResolver{JPath: "x.go", repo: repoA, JLineMatches: []*lineMatch{
+			{JPreview: `import "fmt"`},
+			{JPreview: `import "os"; import "fmt"`},
+		}}},
+		{fileMatch: &fileMatchResolver{JPath: "y.go", repo: repoA, JLineMatches: []*lineMatch{
+			{JPreview: `import "fmt"`},
+		}}},
+		{fileMatch: &fileMatchResolver{JPath: "x.go", repo: repoA, inputRev: &rev, JLineMatches: []*lineMatch{
+			{JPreview: `import "os"`},
+		}}},
+		{repo: &repositoryResolver{repo: repoB}},
+		{diff: &commitSearchResultResolver{commit: &gitCommitResolver{
+			repo:   &repositoryResolver{repo: repoB},
+			author: signatureResolver{person: &personResolver{name: "Alice", email: "alice@example.com"}},
+		}}},
+		{diff: &commitSearchResultResolver{commit: &gitCommitResolver{
+			repo:   &repositoryResolver{repo: repoB},
+			author: signatureResolver{person: &personResolver{name: "Alice", email: "alice@example.com"}},
+		}}},
+		{diff: &commitSearchResultResolver{commit: &gitCommitResolver{
+			repo:   &repositoryResolver{repo: repoB},
+			author: signatureResolver{person: &personResolver{name: "Bob", email: "bob@example.com"}},
+		}}},
+	}
+
+	tests := map[string]map[string]int32{
+		`countby:repo`:                    {"a": 3, "a@v1": 1, "b": 4},
+		`countby:file`:                    {"a/x.go": 2, "a/y.go": 1, "a@v1/x.go": 1},
+		`countby:author`:                  {"Alice <alice@example.com>": 2, "Bob <bob@example.com>": 1},
+		`countby:capture import\s+.(\w+)`: {"fmt": 3, "os": 2},
+		`countby:capture IMPORT\s+.(\w+)`: {"fmt": 3, "os": 2},
+	}
+	for queryStr, want := range tests {
+		t.Run(queryStr, func(t *testing.T) {
+			q, err := query.ParseAndCheck(queryStr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cb, err := (&searchResolver{query: q}).countBy()
+			if err != nil {
+				t.Fatal(err)
+			}
+			aggregates := cb.aggregate(results)
+			got := map[string]int32{}
+			for i, a := range aggregates {
+				if i > 0 && a.Count() > aggregates[i-1].Count() {
+					t.Errorf("aggregates not sorted by descending count: %d > %d", a.Count(), aggregates[i-1].Count())
+				}
+				got[a.Value()] = a.Count()
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSearchResolver_countBy(t *testing.T) {
+	tests := map[string]bool{ // query -> whether an error is expected
+		"foo":                     false,
+		"foo countby:repo":        false,
+		"foo countby:x":           true,
+		`f(o)o countby:capture`:   false,
+		"foo countby:capture":     true,
+		`"f(o)o" countby:capture`: true,
+		`f(:[x]) countby:capture patterntype:structural`: true,
+	}
+	for queryStr, wantErr := range tests {
+		q, err := query.ParseAndCheck(queryStr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (&searchResolver{query: q}).countBy(); (err != nil) != wantErr {
+			t.Errorf("%q: got error %v, want error %v", queryStr, err, wantErr)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package graphqlbackend
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+// doPartitionedResults searches each partition of a query that uses the "OR"
+// operator (see (*query.Query).Partition) separately and returns the union of
+// the results.
+//
+// The partitions are not streamed, because a file that matches several
+// partitions must be merged into a single result. Instead, the union of the
+// results is sent to r.stream (if any) once all partitions have been searched.
+func (r *searchResolver) doPartitionedResults(ctx context.Context, forceOnlyResultType string, partitions []*query.Query) (*searchResultsResolver, error) {
+	start := time.Now()
+
+	var (
+		wg        sync.WaitGroup
+		resolvers = make([]*searchResultsResolver, len(partitions))
+		errs      = make([]error, len(partitions))
+	)
+	for i, q := range partitions {
+		i, q := i, q
+		wg.Add(1)
+		goroutine.Go(func() {
+			defer wg.Done()
+			resolvers[i], errs[i] = (&searchResolver{root: r.root, query: q}).doResults(ctx, forceOnlyResultType)
+		})
+	}
+	wg.Wait()
+
+	var (
+		merged      = &searchResultsResolver{start: start, searchResultsCommon: searchResultsCommon{maxResultsCount: r.maxResults()}}
+		fileMatches = map[string]*fileMatchResolver{}
+		repos       = map[api.RepoName]bool{}
+		multiErr    *multierror.Error
+	)
+	for i, res := range resolvers {
+		if errs[i] != nil {
+			multiErr = multierror.Append(multiErr, errs[i])
+		}
+		if res == nil {
+			continue
+		}
+		merged.searchResultsCommon.update(res.searchResultsCommon)
+		if merged.alert == nil {
+			merged.alert = res.alert
+		}
+		for _, result := range res.results {
+			switch {
+			case result.fileMatch != nil:
+				if m, ok := fileMatches[result.fileMatch.uri]; ok {
+					mergeFileMatch(m, result.fileMatch)
+					continue
+				}
+				fileMatches[result.fileMatch.uri] = result.fileMatch
+			case result.repo != nil:
+				if repos[result.repo.repo.Name] {
+					continue
+				}
+				repos[result.repo.repo.Name] = true
+			}
+			merged.results = append(merged.results, result)
+		}
+	}
+
+	// As in doResults, only report errors if there are no results.
+	if len(merged.results) > 0 && multiErr != nil {
+		log15.Error("Errors during search", "error", multiErr)
+		multiErr = nil
+	}
+
+	rankResults(merged.results)
+	// The stats are not sent, because the caller of a streaming search
+	// records the stats of the returned results once the search completes.
+	r.sendResults(merged.results, nil)
+	return merged, multiErr.ErrorOrNil()
+}
+
+// mergeFileMatch merges the line matches and symbols of src (a match for the
+// same file) into dst.
+func mergeFileMatch(dst, src *fileMatchResolver) {
+	dst.JLimitHit = dst.JLimitHit || src.JLimitHit
+	dst.JLineMatches = mergeLineMatches(dst.JLineMatches, src.JLineMatches)
+	dst.symbols = appendUniqueSymbols(dst.symbols, src.symbols)
+	if src.zoektScore > dst.zoektScore {
+		dst.zoektScore = src.zoektScore
+	}
+	dst.definesSymbol = dst.definesSymbol || src.definesSymbol
+}
+
+// mergeLineMatches returns the union of the line matches a and b, ordered by
+// line number. Matches on the same line are combined.
+func mergeLineMatches(a, b []*lineMatch) []*lineMatch {
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return b
+	}
+
+	byLine := make(map[int32]*lineMatch, len(a)+len(b))
+	merged := make([]*lineMatch, 0, len(a)+len(b))
+	for _, lm := range append(append([]*lineMatch{}, a...), b...) {
+		m, ok := byLine[lm.JLineNumber]
+		if !ok {
+			m = &lineMatch{JPreview: lm.JPreview, JLineNumber: lm.JLineNumber}
+			byLine[lm.JLineNumber] = m
+			merged = append(merged, m)
+		}
+		m.JLimitHit = m.JLimitHit || lm.JLimitHit
+	outer:
+		for _, ol := range lm.JOffsetAndLengths {
+			for _, ol2 := range m.JOffsetAndLengths {
+				if ol == ol2 {
+					continue outer
+				}
+			}
+			m.JOffsetAndLengths = append(m.JOffsetAndLengths, ol)
+		}
+	}
+	for _, m := range merged {
+		sort.Slice(m.JOffsetAndLengths, func(i, j int) bool { return m.JOffsetAndLengths[i][0] < m.JOffsetAndLengths[j][0] })
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].JLineNumber < merged[j].JLineNumber })
+	return merged
+}
+
+// appendUniqueSymbols appends the symbols in src that are not already in dst.
+func appendUniqueSymbols(dst, src []*symbolResolver) []*symbolResolver {
+outer:
+	for _, s := range src {
+		for _, s2 := range dst {
+			if s.symbol == s2.symbol {
+				continue outer
+			}
+		}
+		dst = append(dst, s)
+	}
+	return dst
+}
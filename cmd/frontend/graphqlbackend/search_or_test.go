@@ -0,0 +1,86 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestMergeLineMatches(t *testing.T) {
+	a := []*lineMatch{
+		{JPreview: "foo bar", JLineNumber: 1, JOffsetAndLengths: [][2]int32{{0, 3}}},
+		{JPreview: "baz", JLineNumber: 5, JOffsetAndLengths: [][2]int32{{0, 3}}},
+	}
+	b := []*lineMatch{
+		{JPreview: "foo bar", JLineNumber: 1, JOffsetAndLengths: [][2]int32{{4, 3}, {0, 3}}},
+		{JPreview: "qux", JLineNumber: 3, JOffsetAndLengths: [][2]int32{{0, 3}}, JLimitHit: true},
+	}
+	want := []*lineMatch{
+		{JPreview: "foo bar", JLineNumber: 1, JOffsetAndLengths: [][2]int32{{0, 3}, {4, 3}}},
+		{JPreview: "qux", JLineNumber: 3, JOffsetAndLengths: [][2]int32{{0, 3}}, JLimitHit: true},
+		{JPreview: "baz", JLineNumber: 5, JOffsetAndLengths: [][2]int32{{0, 3}}},
+	}
+	if got := mergeLineMatches(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDoPartitionedResults_stream(t *testing.T) {
+	repo := &types.Repo{ID: 1, Name: "repo"}
+	db.Mocks.Repos.List = func(_ context.Context, op db.ReposListOptions) ([]*types.Repo, error) {
+		return []*types.Repo{repo}, nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+	db.Mocks.Repos.MockGetByName(t, "repo", 1)
+
+	mockSearchRepositories = func(args *search.Args) ([]*searchResultResolver, *searchResultsCommon, error) {
+		return nil, &searchResultsCommon{}, nil
+	}
+	defer func() { mockSearchRepositories = nil }()
+
+	// Each partition matches a different line of the same file.
+	var (
+		mu    sync.Mutex
+		calls int32
+	)
+	mockSearchFilesInRepos = func(args *search.Args) ([]*fileMatchResolver, *searchResultsCommon, error) {
+		mu.Lock()
+		calls++
+		line := calls
+		mu.Unlock()
+		return []*fileMatchResolver{
+			{uri: "git://repo#file", JPath: "file", repo: repo, JLineMatches: []*lineMatch{{JPreview: "foo", JLineNumber: line, JOffsetAndLengths: [][2]int32{{0, 3}}}}},
+		}, &searchResultsCommon{searched: []*types.Repo{repo}}, nil
+	}
+	defer func() { mockSearchFilesInRepos = nil }()
+
+	var w recordingEventWriter
+	if err := StreamSearch(context.Background(), "(repo:repo foo) OR (repo:re foo)", &w); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.events["error"]) != 0 {
+		t.Fatalf("got errors %v", w.events["error"])
+	}
+	if calls != 2 {
+		t.Fatalf("got %d searches, want one per partition", calls)
+	}
+
+	// The file is sent once, with the line matches of both partitions.
+	wantMatches := []interface{}{[]SearchStreamMatch{&SearchStreamFileMatch{
+		Type:       "file",
+		Repository: "repo",
+		Path:       "file",
+		LineMatches: []*SearchStreamLineMatch{
+			{Preview: "foo", LineNumber: 1, OffsetAndLengths: [][2]int32{{0, 3}}},
+			{Preview: "foo", LineNumber: 2, OffsetAndLengths: [][2]int32{{0, 3}}},
+		},
+	}}}
+	if !reflect.DeepEqual(w.events["matches"], wantMatches) {
+		t.Errorf("got matches %+v, want %+v", w.events["matches"], wantMatches)
+	}
+}
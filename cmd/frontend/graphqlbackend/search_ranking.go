@@ -0,0 +1,197 @@
+package graphqlbackend
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/trace"
+)
+
+// The weights of the signals that make up the relevance score of a file
+// match. They add up to less than 1, so that file matches rank below
+// repository matches (which have a score of 1).
+const (
+	rankWeightZoekt      = 0.3  // the zoekt score, relative to the best zoekt score in the results
+	rankWeightDensity    = 0.2  // the number of matches in the file
+	rankWeightDefinition = 0.25 // whether the file defines a matching symbol
+	rankWeightDepth      = 0.15 // how close the file is to the repository root
+
+	// rankMaxDensity is the number of matches in a file at which the match
+	// density signal is saturated.
+	rankMaxDensity = 10
+
+	// rankPenalty multiplies the score of files matched by
+	// rankPenaltyPathPatterns.
+	rankPenalty = 0.5
+)
+
+// rankPenaltyPathPatterns match the paths of files that are usually less
+// interesting than other files, such as tests and vendored dependencies.
+var rankPenaltyPathPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`_test\.go$`),
+	regexp.MustCompile(`\.(test|spec)\.[^/]+$`),
+	regexp.MustCompile(`(^|/)(tests?|__tests__|testdata)/`),
+	regexp.MustCompile(`(^|/)(vendor|node_modules|third_party)/`),
+}
+
+// Looking up symbol definitions (if enabled) delays every search, so it only
+// checks the repositories with the most file matches and gives up quickly.
+const (
+	rankMaxSymbolRepos = 5
+	rankMaxSymbols     = 100
+	rankSymbolsTimeout = 250 * time.Millisecond
+)
+
+// rankResults sorts the repository and file matches in results in descending
+// order of relevance, and sets the score of each file match. Results with the
+// same score are ordered by repository name and path, as in sortResults.
+// Other results (commits and diffs) are already in the desired order, so they
+// keep their positions.
+func rankResults(results []*searchResultResolver) {
+	var (
+		positions []int
+		ranked    []*searchResultResolver
+	)
+	for i, result := range results {
+		if result.repo != nil || result.fileMatch != nil {
+			positions = append(positions, i)
+			ranked = append(ranked, result)
+		}
+	}
+	sortResults(ranked)
+
+	var maxZoektScore float64
+	for _, result := range ranked {
+		if fm := result.fileMatch; fm != nil && fm.zoektScore > maxZoektScore {
+			maxZoektScore = fm.zoektScore
+		}
+	}
+	for _, result := range ranked {
+		if fm := result.fileMatch; fm != nil {
+			fm.score = fileMatchScore(fm, maxZoektScore)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score() > ranked[j].score()
+	})
+	for i, pos := range positions {
+		results[pos] = ranked[i]
+	}
+}
+
+// fileMatchScore returns the relevance score of fm, which is in the range
+// [0, 1). maxZoektScore is the highest zoekt score of all file matches in the
+// results, or 0 if there are none.
+func fileMatchScore(fm *fileMatchResolver, maxZoektScore float64) float64 {
+	var score float64
+	if maxZoektScore > 0 {
+		score += rankWeightZoekt * fm.zoektScore / maxZoektScore
+	}
+
+	var matches int
+	for _, lm := range fm.JLineMatches {
+		matches += len(lm.JOffsetAndLengths)
+	}
+	if matches > rankMaxDensity {
+		matches = rankMaxDensity
+	}
+	score += rankWeightDensity * float64(matches) / rankMaxDensity
+
+	if fm.definesSymbol || len(fm.symbols) > 0 {
+		score += rankWeightDefinition
+	}
+
+	depth := strings.Count(strings.Trim(fm.JPath, "/"), "/")
+	score += rankWeightDepth / float64(1+depth)
+
+	for _, p := range rankPenaltyPathPatterns {
+		if p.MatchString(fm.JPath) {
+			score *= rankPenalty
+			break
+		}
+	}
+	return score
+}
+
+// score returns the relevance score of the result, as computed by
+// rankResults.
+func (g *searchResultResolver) score() float64 {
+	switch {
+	case g.repo != nil:
+		return 1
+	case g.fileMatch != nil:
+		return g.fileMatch.score
+	default:
+		// Diffs and commits are already in the desired order.
+		return 0
+	}
+}
+
+// lookUpDefinitions asks the symbols service which of the fileMatches (keyed
+// by URI) define a symbol matching the search pattern, and marks them so that
+// rankResults ranks them higher. Errors are ignored, because the lookup only
+// affects the order of the results. It is only called if the
+// searchDefinitionRanking experimental feature is enabled.
+func lookUpDefinitions(ctx context.Context, args *search.Args, fileMatches map[string]*fileMatchResolver) {
+	if args.Pattern.Pattern == "" || !args.Pattern.IsRegExp {
+		return
+	}
+
+	// Only check the repositories with the most file matches.
+	matchCount := map[api.RepoName]int{}
+	for _, fm := range fileMatches {
+		if fm.repo != nil {
+			matchCount[fm.repo.Name] += len(fm.JLineMatches)
+		}
+	}
+	var repos []*search.RepositoryRevisions
+	for _, repoRev := range args.Repos {
+		if matchCount[repoRev.Repo.Name] > 0 {
+			repos = append(repos, repoRev)
+		}
+	}
+	if len(repos) == 0 {
+		return
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		return matchCount[repos[i].Repo.Name] > matchCount[repos[j].Repo.Name]
+	})
+	if len(repos) > rankMaxSymbolRepos {
+		repos = repos[:rankMaxSymbolRepos]
+	}
+
+	// Leave at least half of the remaining search time to return the results.
+	timeout := rankSymbolsTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline) / 2; remaining < timeout {
+			timeout = remaining
+		}
+	}
+	if timeout <= 0 {
+		return
+	}
+
+	tr, ctx := trace.New(ctx, "lookUpDefinitions", "")
+	defer tr.Finish()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	symbolArgs := *args
+	symbolArgs.Repos = repos
+	symbolFileMatches, _, err := searchSymbols(ctx, &symbolArgs, rankMaxSymbols)
+	if err != nil {
+		tr.LazyPrintf("error: %s", err)
+	}
+	for _, symbolFileMatch := range symbolFileMatches {
+		if fm, ok := fileMatches[symbolFileMatch.uri]; ok {
+			fm.definesSymbol = true
+		}
+	}
+	tr.LazyPrintf("%d repos, %d files with symbols", len(repos), len(symbolFileMatches))
+}
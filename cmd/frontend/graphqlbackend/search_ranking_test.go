@@ -0,0 +1,77 @@
+package graphqlbackend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestRankResults(t *testing.T) {
+	repo := &types.Repo{Name: "r"}
+	fileMatch := func(path string, matches int) *fileMatchResolver {
+		lm := &lineMatch{}
+		for i := 0; i < matches; i++ {
+			lm.JOffsetAndLengths = append(lm.JOffsetAndLengths, [2]int32{int32(i), 1})
+		}
+		return &fileMatchResolver{JPath: path, repo: repo, JLineMatches: []*lineMatch{lm}}
+	}
+
+	deep := fileMatch("a/b/c/deep.go", 1)
+	main := fileMatch("main.go", 1)
+	other := fileMatch("other.go", 1)
+	test := fileMatch("main_test.go", 5)
+	def := fileMatch("x/def.go", 1)
+	def.definesSymbol = true
+	vendored := fileMatch("vendor/y.go", 1)
+	vendored.zoektScore = 10
+
+	results := []*searchResultResolver{
+		{fileMatch: deep},
+		{fileMatch: other},
+		{fileMatch: test},
+		{fileMatch: vendored},
+		{fileMatch: main},
+		{repo: &repositoryResolver{repo: repo}},
+		{fileMatch: def},
+	}
+	rankResults(results)
+
+	var got []string
+	for _, result := range results {
+		if result.repo != nil {
+			got = append(got, "repo:"+string(result.repo.repo.Name))
+		} else {
+			got = append(got, result.fileMatch.JPath)
+		}
+	}
+	want := []string{"repo:r", "x/def.go", "vendor/y.go", "main.go", "other.go", "main_test.go", "a/b/c/deep.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, result := range results {
+		if fm := result.fileMatch; fm != nil && (fm.Score() <= 0 || fm.Score() >= 1) {
+			t.Errorf("%s: got score %v, want a score in (0, 1)", fm.JPath, fm.Score())
+		}
+	}
+}
+
+func TestRankResults_commitsKeepTheirPositions(t *testing.T) {
+	repo := &types.Repo{Name: "r"}
+	low := &fileMatchResolver{JPath: "a/b/low.go", repo: repo}
+	high := &fileMatchResolver{JPath: "high.go", repo: repo, definesSymbol: true}
+	commit1, commit2 := &commitSearchResultResolver{}, &commitSearchResultResolver{}
+
+	results := []*searchResultResolver{
+		{diff: commit1},
+		{fileMatch: low},
+		{diff: commit2},
+		{fileMatch: high},
+	}
+	rankResults(results)
+
+	if results[0].diff != commit1 || results[1].fileMatch != high || results[2].diff != commit2 || results[3].fileMatch != low {
+		t.Errorf("got results %+v, want commits in place and high.go before a/b/low.go", results)
+	}
+}
@@ -0,0 +1,160 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/neelance/parallel"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/trace"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// filterReposByRepoFields filters repoRevs (and repoResults, which holds the
+// corresponding repository suggestions) to the repositories that satisfy the
+// repohasfile: and repohascommitafter: fields of the query.
+func (r *searchResolver) filterReposByRepoFields(ctx context.Context, repoRevs []*search.RepositoryRevisions, repoResults []*searchSuggestionResolver) (_ []*search.RepositoryRevisions, _ []*searchSuggestionResolver, err error) {
+	hasFilePatterns, minusHasFilePatterns := r.query.RegexpPatterns(query.FieldRepoHasFile)
+	commitAfter, _ := r.query.StringValue(query.FieldRepoHasCommitAfter)
+	if len(hasFilePatterns) == 0 && len(minusHasFilePatterns) == 0 && commitAfter == "" {
+		return repoRevs, repoResults, nil
+	}
+
+	tr, ctx := trace.New(ctx, "filterReposByRepoFields", fmt.Sprintf("repohasfile: %v, -repohasfile: %v, repohascommitafter: %q", hasFilePatterns, minusHasFilePatterns, commitAfter))
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	// keep reports whether each repository should be kept. Each filter
+	// removes the repositories that don't satisfy it.
+	keep := make(map[api.RepoName]bool, len(repoRevs))
+	for _, repoRev := range repoRevs {
+		keep[repoRev.Repo.Name] = true
+	}
+
+	for _, pattern := range hasFilePatterns {
+		hasFile, err := reposWithFile(ctx, repoRevs, pattern, r.query)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "repohasfile:%s", pattern)
+		}
+		for name := range keep {
+			keep[name] = keep[name] && hasFile[name]
+		}
+	}
+	for _, pattern := range minusHasFilePatterns {
+		hasFile, err := reposWithFile(ctx, repoRevs, pattern, r.query)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "-repohasfile:%s", pattern)
+		}
+		for name := range keep {
+			keep[name] = keep[name] && !hasFile[name]
+		}
+	}
+	if commitAfter != "" {
+		hasCommit, err := reposWithCommitAfter(ctx, repoRevs, commitAfter)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "repohascommitafter:%s", commitAfter)
+		}
+		for name := range keep {
+			keep[name] = keep[name] && hasCommit[name]
+		}
+	}
+
+	filteredRepoRevs := repoRevs[:0:0]
+	for _, repoRev := range repoRevs {
+		if keep[repoRev.Repo.Name] {
+			filteredRepoRevs = append(filteredRepoRevs, repoRev)
+		}
+	}
+	filteredRepoResults := repoResults[:0:0]
+	for _, result := range repoResults {
+		if repo, ok := result.ToRepository(); ok && !keep[repo.repo.Name] {
+			continue
+		}
+		filteredRepoResults = append(filteredRepoResults, result)
+	}
+	tr.LazyPrintf("kept %d of %d repos", len(filteredRepoRevs), len(repoRevs))
+	return filteredRepoRevs, filteredRepoResults, nil
+}
+
+// reposWithFile returns the set of repositories in repoRevs that contain a
+// file whose path matches pattern (at the revisions being searched).
+func reposWithFile(ctx context.Context, repoRevs []*search.RepositoryRevisions, pattern string, q *query.Query) (map[api.RepoName]bool, error) {
+	args := search.Args{
+		Pattern: &search.PatternInfo{
+			IsRegExp: true,
+			// We need a match in every repository that has a matching file, so
+			// don't stop early. One match per repository is enough.
+			FileMatchLimit:               int32(len(repoRevs)),
+			IncludePatterns:              []string{pattern},
+			PathPatternsAreRegExps:       true,
+			PathPatternsAreCaseSensitive: q.IsCaseSensitive(),
+			PatternMatchesPath:           true,
+		},
+		RepoFileMatchLimit: 1,
+		Repos:              repoRevs,
+		Query:              q,
+	}
+	matches, _, err := searchFilesInRepos(ctx, &args)
+	if err != nil {
+		return nil, err
+	}
+	hasFile := make(map[api.RepoName]bool)
+	for _, m := range matches {
+		hasFile[m.repo.Name] = true
+	}
+	return hasFile, nil
+}
+
+// reposWithCommitAfter returns the set of repositories in repoRevs that have
+// a commit (reachable from the revisions being searched) after the given
+// date, which may be any date accepted by git's --after flag (e.g., "1 week
+// ago" or "2018-10-01").
+func reposWithCommitAfter(ctx context.Context, repoRevs []*search.RepositoryRevisions, after string) (map[api.RepoName]bool, error) {
+	var (
+		run       = parallel.NewRun(20)
+		mu        sync.Mutex
+		hasCommit = make(map[api.RepoName]bool)
+	)
+	for _, repoRev := range repoRevs {
+		repoRev := repoRev
+		for _, rev := range repoRev.RevSpecs() {
+			rev := rev
+			if rev == "" {
+				rev = "HEAD"
+			}
+			run.Acquire()
+			goroutine.Go(func() {
+				defer run.Release()
+				// Do not trigger a repo-updater lookup, because that would slow
+				// down checking many repositories by a lot.
+				commits, err := git.Commits(ctx, repoRev.GitserverRepo(), git.CommitsOptions{Range: rev, After: after, N: 1})
+				if err != nil {
+					// Repositories that are missing, still cloning or lack
+					// the revision are treated as not having a recent commit.
+					if git.IsRevisionNotFound(err) || vcs.IsRepoNotExist(err) {
+						return
+					}
+					run.Error(err)
+					return
+				}
+				if len(commits) > 0 {
+					mu.Lock()
+					hasCommit[repoRev.Repo.Name] = true
+					mu.Unlock()
+				}
+			})
+		}
+	}
+	if err := run.Wait(); err != nil {
+		return nil, err
+	}
+	return hasCommit, nil
+}
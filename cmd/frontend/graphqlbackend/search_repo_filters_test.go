@@ -0,0 +1,78 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestSearchResolver_filterReposByRepoFields(t *testing.T) {
+	repoA := &types.Repo{Name: "a"}
+	repoB := &types.Repo{Name: "b"}
+	repoC := &types.Repo{Name: "c"}
+	repoRevs := []*search.RepositoryRevisions{{Repo: repoA}, {Repo: repoB}, {Repo: repoC}}
+	repoResults := []*searchSuggestionResolver{
+		{result: &repositoryResolver{repo: repoA}},
+		{result: &repositoryResolver{repo: repoB}},
+		{result: &repositoryResolver{repo: repoC}},
+	}
+
+	// Repositories a and b have a go.mod, and b and c have a README.
+	mockSearchFilesInRepos = func(args *search.Args) ([]*fileMatchResolver, *searchResultsCommon, error) {
+		if !args.Pattern.PatternMatchesPath || len(args.Pattern.IncludePatterns) != 1 {
+			t.Errorf("unexpected pattern %+v", args.Pattern)
+		}
+		if args.RepoFileMatchLimit != 1 || args.Pattern.FileMatchLimit != int32(len(args.Repos)) {
+			t.Errorf("got file match limits %d per repository and %d in total, want 1 per repository", args.RepoFileMatchLimit, args.Pattern.FileMatchLimit)
+		}
+		switch args.Pattern.IncludePatterns[0] {
+		case "go.mod$":
+			return []*fileMatchResolver{{JPath: "go.mod", repo: repoA}, {JPath: "x/go.mod", repo: repoB}}, &searchResultsCommon{}, nil
+		case "README":
+			return []*fileMatchResolver{{JPath: "README", repo: repoB}, {JPath: "README.md", repo: repoC}}, &searchResultsCommon{}, nil
+		}
+		return nil, &searchResultsCommon{}, nil
+	}
+	defer func() { mockSearchFilesInRepos = nil }()
+
+	tests := map[string][]api.RepoName{
+		"foo":                      {"a", "b", "c"},
+		"foo repohasfile:go.mod$":  {"a", "b"},
+		"foo -repohasfile:go.mod$": {"c"},
+		"foo repohasfile:go.mod$ repohasfile:README":  {"b"},
+		"foo repohasfile:go.mod$ -repohasfile:README": {"a"},
+		"foo repohasfile:nomatch":                     nil,
+	}
+	for queryStr, want := range tests {
+		t.Run(queryStr, func(t *testing.T) {
+			q, err := query.ParseAndCheck(queryStr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &searchResolver{query: q}
+			gotRepoRevs, gotRepoResults, err := r.filterReposByRepoFields(context.Background(), repoRevs, repoResults)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, gotResults []api.RepoName
+			for _, repoRev := range gotRepoRevs {
+				got = append(got, repoRev.Repo.Name)
+			}
+			for _, result := range gotRepoResults {
+				repo, _ := result.ToRepository()
+				gotResults = append(gotResults, repo.repo.Name)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got repos %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(gotResults, want) {
+				t.Errorf("got repository results %v, want %v", gotResults, want)
+			}
+		})
+	}
+}
@@ -28,8 +28,12 @@ func searchRepositories(ctx context.Context, args *search.Args, limit int32) (re
 		query.FieldCount:     struct{}{},
 		query.FieldMax:       struct{}{},
 		query.FieldTimeout:   struct{}{},
+		query.FieldCountBy:   struct{}{},
 		query.FieldFork:      struct{}{},
 		query.FieldArchived:  struct{}{},
+
+		query.FieldRepoHasFile:        struct{}{},
+		query.FieldRepoHasCommitAfter: struct{}{},
 	}
 	// Don't return repo results if the search contains fields that aren't on the whitelist.
 	// Matching repositories based whether they contain files at a certain path (etc.) is not yet implemented.
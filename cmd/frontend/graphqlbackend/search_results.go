@@ -26,6 +26,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
 	"github.com/sourcegraph/sourcegraph/pkg/rcache"
 	"github.com/sourcegraph/sourcegraph/pkg/trace"
@@ -149,6 +150,7 @@ type searchResultsResolver struct {
 	alert            *searchAlert
 	start            time.Time // when the results started being computed
 	repoToMatchCount map[string]int
+	countBy          *countBy // how to aggregate the results, or nil if the query has no countby: field
 }
 
 func (sr *searchResultsResolver) Results() []*searchResultResolver {
@@ -173,6 +175,13 @@ func (sr *searchResultsResolver) ApproximateResultCount() string {
 
 func (sr *searchResultsResolver) Alert() *searchAlert { return sr.alert }
 
+func (sr *searchResultsResolver) Aggregates() []*searchAggregateResolver {
+	if sr.countBy == nil {
+		return []*searchAggregateResolver{}
+	}
+	return sr.countBy.aggregate(sr.results)
+}
+
 func (sr *searchResultsResolver) ElapsedMilliseconds() int32 {
 	return int32(time.Since(sr.start).Nanoseconds() / int64(time.Millisecond))
 }
@@ -201,60 +210,81 @@ var commonFileFilters = []struct {
 }
 
 func (sr *searchResultsResolver) DynamicFilters() []*searchFilterResolver {
-	filters := map[string]*searchFilterResolver{}
-	sr.repoToMatchCount = make(map[string]int)
-	add := func(value string, label string, count int, limitHit bool, kind string) {
-		sf, ok := filters[value]
-		if !ok {
-			sf = &searchFilterResolver{
-				value:    value,
-				label:    label,
-				count:    int32(count),
-				limitHit: limitHit,
-				kind:     kind,
-			}
-			filters[value] = sf
-		} else {
-			sf.count = int32(count)
+	var filters searchFilters
+	filters.add(sr.results)
+	sr.repoToMatchCount = filters.repoToMatchCount
+	return filters.compute(sr.searchResultsCommon.partial)
+}
+
+// searchFilters accumulates the dynamic filters for search results. Results
+// can be added as they are found, so that the filters don't need to be
+// recomputed over all results.
+type searchFilters struct {
+	filters          map[string]*searchFilterResolver
+	repoToMatchCount map[string]int
+}
+
+func (f *searchFilters) addFilter(value string, label string, count int, limitHit bool, kind string) {
+	sf, ok := f.filters[value]
+	if !ok {
+		sf = &searchFilterResolver{
+			value:    value,
+			label:    label,
+			count:    int32(count),
+			limitHit: limitHit,
+			kind:     kind,
 		}
+		f.filters[value] = sf
+	} else {
+		sf.count = int32(count)
+	}
+
+	sf.score++
+}
 
-		sf.score++
+func (f *searchFilters) addRepoFilter(uri string, rev string, lineMatchCount int) {
+	filter := fmt.Sprintf(`repo:^%s$`, regexp.QuoteMeta(uri))
+	if rev != "" {
+		filter = filter + fmt.Sprintf(`@%s`, regexp.QuoteMeta(rev))
 	}
+	// Increment number of matches per repo.
+	f.repoToMatchCount[uri] += lineMatchCount
+	repoCount := f.repoToMatchCount[uri]
+	// Whether the repository was only partially searched is only known once
+	// it has been searched, so limitHit is set by compute.
+	f.addFilter(filter, uri, repoCount, false, "repo")
+}
 
-	addRepoFilter := func(uri string, rev string, lineMatchCount int) {
-		filter := fmt.Sprintf(`repo:^%s$`, regexp.QuoteMeta(uri))
-		if rev != "" {
-			filter = filter + fmt.Sprintf(`@%s`, regexp.QuoteMeta(rev))
-		}
-		_, limitHit := sr.searchResultsCommon.partial[api.RepoName(uri)]
-		// Increment number of matches per repo.
-		sr.repoToMatchCount[uri] += lineMatchCount
-		repoCount := sr.repoToMatchCount[uri]
-		add(filter, uri, repoCount, limitHit, "repo")
-	}
-	addFileFilter := func(filematchPath string, lineMatchCount int, limitHit bool) {
-		if ext := path.Ext(filematchPath); ext != "" {
-			value := fmt.Sprintf(`file:%s$`, regexp.QuoteMeta(ext))
-			add(value, value, lineMatchCount, false, "file")
-		}
-		for _, ff := range commonFileFilters {
-			if ff.Regexp.MatchString(filematchPath) {
-				add(ff.Filter, ff.Filter, lineMatchCount, limitHit, "file")
-			}
+func (f *searchFilters) addFileFilter(filematchPath string, lineMatchCount int, limitHit bool) {
+	if ext := path.Ext(filematchPath); ext != "" {
+		value := fmt.Sprintf(`file:%s$`, regexp.QuoteMeta(ext))
+		f.addFilter(value, value, lineMatchCount, false, "file")
+	}
+	for _, ff := range commonFileFilters {
+		if ff.Regexp.MatchString(filematchPath) {
+			f.addFilter(ff.Filter, ff.Filter, lineMatchCount, limitHit, "file")
 		}
 	}
+}
 
-	for _, result := range sr.results {
+// add adds the filters for results.
+func (f *searchFilters) add(results []*searchResultResolver) {
+	if f.filters == nil {
+		f.filters = map[string]*searchFilterResolver{}
+		f.repoToMatchCount = make(map[string]int)
+	}
+
+	for _, result := range results {
 		if result.fileMatch != nil {
 			rev := ""
 			if result.fileMatch.inputRev != nil {
 				rev = *result.fileMatch.inputRev
 			}
-			addRepoFilter(string(result.fileMatch.repo.Name), rev, len(result.fileMatch.LineMatches()))
-			addFileFilter(result.fileMatch.JPath, len(result.fileMatch.LineMatches()), result.fileMatch.JLimitHit)
+			f.addRepoFilter(string(result.fileMatch.repo.Name), rev, len(result.fileMatch.LineMatches()))
+			f.addFileFilter(result.fileMatch.JPath, len(result.fileMatch.LineMatches()), result.fileMatch.JLimitHit)
 
 			if len(result.fileMatch.symbols) > 0 {
-				add("type:symbol", "type:symbol", 1, result.fileMatch.JLimitHit, "symbol")
+				f.addFilter("type:symbol", "type:symbol", 1, result.fileMatch.JLimitHit, "symbol")
 			}
 		}
 
@@ -262,17 +292,22 @@ func (sr *searchResultsResolver) DynamicFilters() []*searchFilterResolver {
 			// It should be fine to leave this blank since revision specifiers
 			// can only be used with the 'repo:' scope. In that case,
 			// we shouldn't be getting any repositoy name matches back.
-			addRepoFilter(result.repo.URI(), "", 1)
+			f.addRepoFilter(result.repo.URI(), "", 1)
 		}
 	}
+}
 
-	filterSlice := make([]*searchFilterResolver, 0, len(filters))
-	repoFilterSlice := make([]*searchFilterResolver, 0, len(filters)/2) // heuristic - half of all filters are repo filters.
-	for _, f := range filters {
-		if f.kind == "repo" {
-			repoFilterSlice = append(repoFilterSlice, f)
+// compute returns the filters for the results added so far. partial is the
+// set of repositories that were only partially searched.
+func (f *searchFilters) compute(partial map[api.RepoName]struct{}) []*searchFilterResolver {
+	filterSlice := make([]*searchFilterResolver, 0, len(f.filters))
+	repoFilterSlice := make([]*searchFilterResolver, 0, len(f.filters)/2) // heuristic - half of all filters are repo filters.
+	for _, sf := range f.filters {
+		if sf.kind == "repo" {
+			_, sf.limitHit = partial[api.RepoName(sf.label)]
+			repoFilterSlice = append(repoFilterSlice, sf)
 		} else {
-			filterSlice = append(filterSlice, f)
+			filterSlice = append(filterSlice, sf)
 		}
 	}
 	sort.Slice(filterSlice, func(i, j int) bool {
@@ -439,11 +474,16 @@ loop:
 
 func (r *searchResolver) Results(ctx context.Context) (*searchResultsResolver, error) {
 	start := time.Now()
+	countBy, err := r.countBy()
+	if err != nil {
+		return nil, err
+	}
 	rr, err := r.doResults(ctx, "")
 	if err != nil {
 		log15.Debug("graphql search failed", "query", r.rawQuery(), "duration", time.Since(start), "error", err)
 		return nil, err
 	}
+	rr.countBy = countBy
 	log15.Debug("graphql search success", "query", r.rawQuery(), "count", rr.ResultCount(), "duration", time.Since(start))
 	return rr, nil
 }
@@ -549,12 +589,46 @@ func (r *searchResolver) Stats(ctx context.Context) (stats *searchResultsStats,
 
 }
 
+// getPatternInfo returns the pattern to search for. For a query that uses the
+// "OR" operator, it only reflects the fields common to all disjuncts; use
+// getPatternInfos to get the pattern for each disjunct.
 func (r *searchResolver) getPatternInfo() (*search.PatternInfo, error) {
+	return r.patternInfoForQuery(r.query)
+}
+
+// getPatternInfos returns the patterns to search for, one for each of the
+// query's disjuncts.
+func (r *searchResolver) getPatternInfos() ([]*search.PatternInfo, error) {
+	disjuncts := r.query.Disjuncts()
+	patterns := make([]*search.PatternInfo, len(disjuncts))
+	for i, q := range disjuncts {
+		p, err := r.patternInfoForQuery(q)
+		if err != nil {
+			return nil, err
+		}
+		patterns[i] = p
+	}
+	return patterns, nil
+}
+
+func (r *searchResolver) patternInfoForQuery(q *query.Query) (*search.PatternInfo, error) {
+	isStructuralPat := false
+	switch patternType, _ := q.StringValue(query.FieldPatternType); patternType {
+	case "", "regexp":
+		// default
+	case "structural":
+		isStructuralPat = true
+	default:
+		return nil, fmt.Errorf("invalid patterntype:%q (valid values are: regexp, structural)", patternType)
+	}
+
 	var patternsToCombine []string
-	for _, v := range r.query.Values(query.FieldDefault) {
+	for _, v := range q.Values(query.FieldDefault) {
 		// Treat quoted strings as literal strings to match, not regexps.
 		var pattern string
 		switch {
+		case v.String != nil && isStructuralPat:
+			pattern = *v.String
 		case v.String != nil:
 			pattern = regexp.QuoteMeta(*v.String)
 		case v.Regexp != nil:
@@ -567,10 +641,10 @@ func (r *searchResolver) getPatternInfo() (*search.PatternInfo, error) {
 	}
 
 	// Handle file: and -file: filters.
-	includePatterns, excludePatterns := r.query.RegexpPatterns(query.FieldFile)
+	includePatterns, excludePatterns := q.RegexpPatterns(query.FieldFile)
 
 	// Handle lang: and -lang: filters.
-	langIncludePatterns, langExcludePatterns, err := langIncludeExcludePatterns(r.query.StringValues(query.FieldLang))
+	langIncludePatterns, langExcludePatterns, err := langIncludeExcludePatterns(q.StringValues(query.FieldLang))
 	if err != nil {
 		return nil, err
 	}
@@ -579,12 +653,19 @@ func (r *searchResolver) getPatternInfo() (*search.PatternInfo, error) {
 
 	patternInfo := &search.PatternInfo{
 		IsRegExp:                     true,
-		IsCaseSensitive:              r.query.IsCaseSensitive(),
+		IsCaseSensitive:              q.IsCaseSensitive(),
 		FileMatchLimit:               r.maxResults(),
 		Pattern:                      regexpPatternMatchingExprsInOrder(patternsToCombine),
 		IncludePatterns:              includePatterns,
 		PathPatternsAreRegExps:       true,
-		PathPatternsAreCaseSensitive: r.query.IsCaseSensitive(),
+		PathPatternsAreCaseSensitive: q.IsCaseSensitive(),
+	}
+	if isStructuralPat {
+		// Whitespace in a structural pattern matches any whitespace, so the
+		// terms are joined with spaces.
+		patternInfo.Pattern = strings.Join(patternsToCombine, " ")
+		patternInfo.IsRegExp = false
+		patternInfo.IsStructuralPat = true
 	}
 	if len(excludePatterns) > 0 {
 		patternInfo.ExcludePattern = unionRegExps(excludePatterns)
@@ -632,6 +713,14 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		tr.Finish()
 	}()
 
+	// Disjuncts of an "OR" query that differ only in the pattern-related fields
+	// are searched together (see search.Args.Alternatives). Others (e.g.,
+	// "repo:a or repo:b") need separate searches.
+	if partitions := r.query.Partition(query.FieldDefault, query.FieldFile, query.FieldLang); len(partitions) > 1 {
+		tr.LazyPrintf("searching %d partitions", len(partitions))
+		return r.doPartitionedResults(ctx, forceOnlyResultType, partitions)
+	}
+
 	start := time.Now()
 
 	ctx, cancel, err := r.withTimeout(ctx)
@@ -660,18 +749,21 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		return &searchResultsResolver{alert: alert, start: start}, nil
 	}
 
-	p, err := r.getPatternInfo()
+	patterns, err := r.getPatternInfos()
 	if err != nil {
 		return nil, err
 	}
 	args := search.Args{
-		Pattern:         p,
+		Pattern:         patterns[0],
+		Alternatives:    patterns[1:],
 		Repos:           repos,
 		Query:           r.query,
 		UseFullDeadline: r.searchTimeoutFieldSet(),
 	}
-	if err := args.Pattern.Validate(); err != nil {
-		return nil, &badRequestError{err}
+	for _, p := range args.Patterns() {
+		if err := p.Validate(); err != nil {
+			return nil, &badRequestError{err}
+		}
 	}
 
 	// Determine which types of results to return.
@@ -684,12 +776,24 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			resultTypes = []string{"file", "path", "repo", "ref"}
 		}
 	}
+	if args.Pattern.IsStructuralPat {
+		// Structural patterns only match file contents.
+		var fileResultTypes []string
+		for _, resultType := range resultTypes {
+			if resultType == "file" {
+				fileResultTypes = append(fileResultTypes, resultType)
+			}
+		}
+		resultTypes = fileResultTypes
+	}
 	seenResultTypes := make(map[string]struct{}, len(resultTypes))
 	for _, resultType := range resultTypes {
-		if resultType == "file" {
-			args.Pattern.PatternMatchesContent = true
-		} else if resultType == "path" {
-			args.Pattern.PatternMatchesPath = true
+		for _, p := range args.Patterns() {
+			if resultType == "file" {
+				p.PatternMatchesContent = true
+			} else if resultType == "path" {
+				p.PatternMatchesPath = true
+			}
 		}
 	}
 	tr.LazyPrintf("resultTypes: %v", resultTypes)
@@ -735,22 +839,34 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			goroutine.Go(func() {
 				defer wg.Done()
 
-				repoResults, repoCommon, err := searchRepositories(ctx, &args, r.maxResults())
-				// Timeouts are reported through searchResultsCommon so don't report an error for them
-				if err != nil && !isContextError(ctx, err) {
-					multiErrMu.Lock()
-					multiErr = multierror.Append(multiErr, errors.Wrap(err, "repository search failed"))
-					multiErrMu.Unlock()
-				}
-				if repoResults != nil {
+				seen := map[api.RepoName]bool{}
+				for _, args := range args.Split() {
+					repoResults, repoCommon, err := searchRepositories(ctx, args, r.maxResults())
+					// Timeouts are reported through searchResultsCommon so don't report an error for them
+					if err != nil && !isContextError(ctx, err) {
+						multiErrMu.Lock()
+						multiErr = multierror.Append(multiErr, errors.Wrap(err, "repository search failed"))
+						multiErrMu.Unlock()
+					}
+					var newResults []*searchResultResolver
+					for _, result := range repoResults {
+						if result.repo != nil {
+							if seen[result.repo.repo.Name] {
+								continue
+							}
+							seen[result.repo.repo.Name] = true
+						}
+						newResults = append(newResults, result)
+					}
 					resultsMu.Lock()
-					results = append(results, repoResults...)
+					results = append(results, newResults...)
 					resultsMu.Unlock()
-				}
-				if repoCommon != nil {
-					commonMu.Lock()
-					common.update(*repoCommon)
-					commonMu.Unlock()
+					if repoCommon != nil {
+						commonMu.Lock()
+						common.update(*repoCommon)
+						commonMu.Unlock()
+					}
+					r.sendResults(newResults, repoCommon)
 				}
 			})
 		case "symbol":
@@ -759,30 +875,33 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			goroutine.Go(func() {
 				defer wg.Done()
 
-				symbolFileMatches, symbolsCommon, err := searchSymbols(ctx, &args, int(r.maxResults()))
-				// Timeouts are reported through searchResultsCommon so don't report an error for them
-				if err != nil && !isContextError(ctx, err) {
-					multiErrMu.Lock()
-					multiErr = multierror.Append(multiErr, errors.Wrap(err, "symbol search failed"))
-					multiErrMu.Unlock()
-				}
-				for _, symbolFileMatch := range symbolFileMatches {
-					key := symbolFileMatch.uri
-					fileMatchesMu.Lock()
-					if m, ok := fileMatches[key]; ok {
-						m.symbols = symbolFileMatch.symbols
-					} else {
-						fileMatches[key] = symbolFileMatch
-						resultsMu.Lock()
-						results = append(results, &searchResultResolver{fileMatch: symbolFileMatch})
-						resultsMu.Unlock()
+				for _, args := range args.Split() {
+					symbolFileMatches, symbolsCommon, err := searchSymbols(ctx, args, int(r.maxResults()))
+					// Timeouts are reported through searchResultsCommon so don't report an error for them
+					if err != nil && !isContextError(ctx, err) {
+						multiErrMu.Lock()
+						multiErr = multierror.Append(multiErr, errors.Wrap(err, "symbol search failed"))
+						multiErrMu.Unlock()
 					}
-					fileMatchesMu.Unlock()
-				}
-				if symbolsCommon != nil {
-					commonMu.Lock()
-					common.update(*symbolsCommon)
-					commonMu.Unlock()
+					for _, symbolFileMatch := range symbolFileMatches {
+						key := symbolFileMatch.uri
+						fileMatchesMu.Lock()
+						if m, ok := fileMatches[key]; ok {
+							m.symbols = appendUniqueSymbols(m.symbols, symbolFileMatch.symbols)
+						} else {
+							fileMatches[key] = symbolFileMatch
+							resultsMu.Lock()
+							results = append(results, &searchResultResolver{fileMatch: symbolFileMatch})
+							resultsMu.Unlock()
+						}
+						fileMatchesMu.Unlock()
+					}
+					if symbolsCommon != nil {
+						commonMu.Lock()
+						common.update(*symbolsCommon)
+						commonMu.Unlock()
+					}
+					r.sendResults(fileMatchesToSearchResults(symbolFileMatches), symbolsCommon)
 				}
 			})
 		case "file", "path":
@@ -796,7 +915,9 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			goroutine.Go(func() {
 				defer wg.Done()
 
-				fileResults, fileCommon, err := searchFilesInRepos(ctx, &args)
+				// File matches are sent to r.stream by searchFilesInRepos as
+				// each repository is searched.
+				fileResults, fileCommon, err := searchFilesInReposStream(ctx, &args, r.stream)
 				// Timeouts are reported through searchResultsCommon so don't report an error for them
 				if err != nil && !isContextError(ctx, err) {
 					multiErrMu.Lock()
@@ -830,22 +951,25 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			wg.Add(1)
 			goroutine.Go(func() {
 				defer wg.Done()
-				diffResults, diffCommon, err := searchCommitDiffsInRepos(ctx, &args)
-				// Timeouts are reported through searchResultsCommon so don't report an error for them
-				if err != nil && !isContextError(ctx, err) {
-					multiErrMu.Lock()
-					multiErr = multierror.Append(multiErr, errors.Wrap(err, "diff search failed"))
-					multiErrMu.Unlock()
-				}
-				if diffResults != nil {
-					resultsMu.Lock()
-					results = append(results, diffResults...)
-					resultsMu.Unlock()
-				}
-				if diffCommon != nil {
-					commonMu.Lock()
-					common.update(*diffCommon)
-					commonMu.Unlock()
+				for _, args := range args.Split() {
+					diffResults, diffCommon, err := searchCommitDiffsInRepos(ctx, args)
+					// Timeouts are reported through searchResultsCommon so don't report an error for them
+					if err != nil && !isContextError(ctx, err) {
+						multiErrMu.Lock()
+						multiErr = multierror.Append(multiErr, errors.Wrap(err, "diff search failed"))
+						multiErrMu.Unlock()
+					}
+					if diffResults != nil {
+						resultsMu.Lock()
+						results = append(results, diffResults...)
+						resultsMu.Unlock()
+					}
+					if diffCommon != nil {
+						commonMu.Lock()
+						common.update(*diffCommon)
+						commonMu.Unlock()
+					}
+					r.sendResults(diffResults, diffCommon)
 				}
 			})
 		case "commit":
@@ -854,22 +978,25 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			goroutine.Go(func() {
 				defer wg.Done()
 
-				commitResults, commitCommon, err := searchCommitLogInRepos(ctx, &args)
-				// Timeouts are reported through searchResultsCommon so don't report an error for them
-				if err != nil && !isContextError(ctx, err) {
-					multiErrMu.Lock()
-					multiErr = multierror.Append(multiErr, errors.Wrap(err, "commit search failed"))
-					multiErrMu.Unlock()
-				}
-				if commitResults != nil {
-					resultsMu.Lock()
-					results = append(results, commitResults...)
-					resultsMu.Unlock()
-				}
-				if commitCommon != nil {
-					commonMu.Lock()
-					common.update(*commitCommon)
-					commonMu.Unlock()
+				for _, args := range args.Split() {
+					commitResults, commitCommon, err := searchCommitLogInRepos(ctx, args)
+					// Timeouts are reported through searchResultsCommon so don't report an error for them
+					if err != nil && !isContextError(ctx, err) {
+						multiErrMu.Lock()
+						multiErr = multierror.Append(multiErr, errors.Wrap(err, "commit search failed"))
+						multiErrMu.Unlock()
+					}
+					if commitResults != nil {
+						resultsMu.Lock()
+						results = append(results, commitResults...)
+						resultsMu.Unlock()
+					}
+					if commitCommon != nil {
+						commonMu.Lock()
+						common.update(*commitCommon)
+						commonMu.Unlock()
+					}
+					r.sendResults(commitResults, commitCommon)
 				}
 			})
 		}
@@ -905,7 +1032,12 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		multiErr = nil
 	}
 
-	sortResults(results)
+	if _, searchedSymbols := seenResultTypes["symbol"]; searchedFileContentsOrPaths && !searchedSymbols && conf.SearchDefinitionRankingEnabled() {
+		// Files that define a matching symbol rank higher. (If symbols were
+		// searched, they are already merged into the file matches.)
+		lookUpDefinitions(ctx, &args, fileMatches)
+	}
+	rankResults(results)
 
 	resultsResolver := searchResultsResolver{
 		start:               start,
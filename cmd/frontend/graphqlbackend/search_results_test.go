@@ -224,6 +224,21 @@ func TestSearchResolver_getPatternInfo(t *testing.T) {
 			PathPatternsAreRegExps: true,
 			ExcludePattern:         `f|(\.graphql$|\.gql$)`,
 		},
+		"fmt.Sprintf(:[args]) patterntype:structural": {
+			Pattern:                "fmt.Sprintf(:[args])",
+			IsStructuralPat:        true,
+			PathPatternsAreRegExps: true,
+		},
+		`"f(:[a], :[b])" x patterntype:structural`: {
+			Pattern:                "f(:[a], :[b]) x",
+			IsStructuralPat:        true,
+			PathPatternsAreRegExps: true,
+		},
+		"p patterntype:regexp": {
+			Pattern:                "p",
+			IsRegExp:               true,
+			PathPatternsAreRegExps: true,
+		},
 	}
 	for queryStr, want := range tests {
 		t.Run(queryStr, func(t *testing.T) {
@@ -245,6 +260,17 @@ func TestSearchResolver_getPatternInfo(t *testing.T) {
 	}
 }
 
+func TestSearchResolver_getPatternInfo_invalidPatternType(t *testing.T) {
+	query, err := query.ParseAndCheck("p patterntype:foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr := searchResolver{query: query}
+	if _, err := sr.getPatternInfo(); err == nil {
+		t.Error("got nil error")
+	}
+}
+
 func TestSearchResolver_DynamicFilters(t *testing.T) {
 	repo := &types.Repo{
 		Name: "testRepo",
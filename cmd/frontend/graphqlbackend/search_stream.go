@@ -0,0 +1,396 @@
+package graphqlbackend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+// searchStream receives the results of a search as the search backends find
+// them. Implementations must be safe for concurrent use.
+type searchStream interface {
+	// send is called with each batch of new results, along with the stats
+	// of the (partial) search that found them that were not sent before.
+	// common may be nil.
+	send(results []*searchResultResolver, common *searchResultsCommon)
+}
+
+// sendResults sends results to r.stream, if any.
+func (r *searchResolver) sendResults(results []*searchResultResolver, common *searchResultsCommon) {
+	if r.stream != nil {
+		r.stream.send(results, common)
+	}
+}
+
+// searchResultsCommonDelta tracks which stats of a searchResultsCommon that a
+// search is still adding to have been sent to a searchStream, so that each
+// stat is sent only once. It assumes that stats are only ever appended.
+type searchResultsCommonDelta struct {
+	reposSent                                  bool
+	searched, indexed, cloning, missing, timed int // number of repos sent
+	resultCount                                int32
+	partial                                    map[api.RepoName]struct{}
+}
+
+// next returns the stats in c that have not been returned by an earlier call.
+func (d *searchResultsCommonDelta) next(c *searchResultsCommon) *searchResultsCommon {
+	unsent := func(repos []*types.Repo, sent *int) []*types.Repo {
+		// Copy, because searchResultsCommon.update sorts its argument.
+		newRepos := append([]*types.Repo(nil), repos[*sent:]...)
+		*sent = len(repos)
+		return newRepos
+	}
+	delta := &searchResultsCommon{
+		limitHit:         c.limitHit,
+		indexUnavailable: c.indexUnavailable,
+		searched:         unsent(c.searched, &d.searched),
+		indexed:          unsent(c.indexed, &d.indexed),
+		cloning:          unsent(c.cloning, &d.cloning),
+		missing:          unsent(c.missing, &d.missing),
+		timedout:         unsent(c.timedout, &d.timed),
+		resultCount:      c.resultCount - d.resultCount,
+		partial:          make(map[api.RepoName]struct{}),
+	}
+	if !d.reposSent {
+		delta.repos = c.repos
+		d.reposSent = true
+	}
+	d.resultCount = c.resultCount
+	if d.partial == nil {
+		d.partial = make(map[api.RepoName]struct{}, len(c.partial))
+	}
+	for repo := range c.partial {
+		if _, ok := d.partial[repo]; !ok {
+			delta.partial[repo] = struct{}{}
+			d.partial[repo] = struct{}{}
+		}
+	}
+	return delta
+}
+
+func fileMatchesToSearchResults(fileMatches []*fileMatchResolver) []*searchResultResolver {
+	results := make([]*searchResultResolver, len(fileMatches))
+	for i, fm := range fileMatches {
+		results[i] = &searchResultResolver{fileMatch: fm}
+	}
+	return results
+}
+
+// SearchEventWriter writes the events of a streaming search (see
+// StreamSearch) to a client.
+type SearchEventWriter interface {
+	// Event writes an event with the given name. data is marshaled as JSON.
+	Event(name string, data interface{}) error
+}
+
+// searchStreamFiltersInterval is the minimum time between two "filters"
+// events, so that clients aren't sent the (mostly unchanged) filters after
+// every batch of results.
+const searchStreamFiltersInterval = 500 * time.Millisecond
+
+// StreamSearch runs a search for rawQuery and writes its results to w as the
+// search backends find them, instead of waiting for the search to complete.
+// The events are:
+//
+//	matches   a batch of new results ([]SearchStreamMatch)
+//	progress  the stats of the search so far (SearchStreamProgress)
+//	filters   the dynamic filters for the results so far ([]SearchStreamFilter)
+//	alert     an alert for the search (SearchStreamAlert)
+//	error     an error that stopped the search (SearchStreamError)
+//	done      the search is complete, no more events follow
+//
+// Results are sent unordered and are not deduplicated across search backends
+// (e.g., a file with both text and symbol matches is sent twice). Errors
+// during the search are written as events. The returned error is only
+// non-nil if writing to w failed.
+func StreamSearch(ctx context.Context, rawQuery string, w SearchEventWriter) error {
+	s := &searchStreamWriter{w: w, start: time.Now()}
+
+	q, err := query.ParseAndCheck(rawQuery)
+	if err != nil {
+		s.event("error", SearchStreamError{Message: err.Error()})
+		s.event("done", struct{}{})
+		return s.err
+	}
+
+	r := &searchResolver{root: &schemaResolver{}, query: q, stream: s}
+	res, err := r.doResults(ctx, "")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if res != nil {
+		// Stats that are only known once the search completes, such as the
+		// repositories that were matched.
+		s.common.update(res.searchResultsCommon)
+	}
+	s.writeProgress(true)
+	s.writeFilters()
+	if res != nil && res.alert != nil {
+		s.event("alert", newSearchStreamAlert(res.alert))
+	}
+	if err != nil {
+		s.event("error", SearchStreamError{Message: err.Error()})
+	}
+	s.event("done", struct{}{})
+	return s.err
+}
+
+// searchStreamWriter is the searchStream used by StreamSearch. It writes
+// the results it is sent to a SearchEventWriter. Results are not retained
+// once they have been written; only the stats and dynamic filters for them
+// are kept.
+type searchStreamWriter struct {
+	w     SearchEventWriter
+	start time.Time
+
+	mu          sync.Mutex
+	common      searchResultsCommon // the stats sent so far
+	filters     searchFilters       // the dynamic filters for the results sent so far
+	matchCount  int32
+	filtersSent time.Time
+	err         error // the first error writing to w
+}
+
+func (s *searchStreamWriter) send(results []*searchResultResolver, common *searchResultsCommon) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(results) > 0 {
+		matches := make([]SearchStreamMatch, 0, len(results))
+		for _, result := range results {
+			if m := newSearchStreamMatch(result); m != nil {
+				matches = append(matches, m)
+			}
+		}
+		s.event("matches", matches)
+		s.filters.add(results)
+		for _, result := range results {
+			s.matchCount += result.resultCount()
+		}
+	}
+	if common != nil {
+		s.common.update(*common)
+	}
+	s.writeProgress(false)
+	if len(results) > 0 && time.Since(s.filtersSent) >= searchStreamFiltersInterval {
+		s.writeFilters()
+	}
+}
+
+// event writes an event to s.w. Once a write fails, all later events are
+// dropped. The caller must hold s.mu (or have exclusive access to s).
+func (s *searchStreamWriter) event(name string, data interface{}) {
+	if s.err != nil {
+		return
+	}
+	s.err = s.w.Event(name, data)
+}
+
+// writeProgress assumes the caller holds s.mu.
+func (s *searchStreamWriter) writeProgress(done bool) {
+	common := &s.common
+	s.event("progress", SearchStreamProgress{
+		Done:                 done,
+		MatchCount:           s.matchCount,
+		LimitHit:             common.limitHit,
+		RepositoriesCount:    len(common.repos),
+		RepositoriesSearched: len(common.searched),
+		RepositoriesIndexed:  len(common.indexed),
+		Cloning:              repoNames(common.cloning),
+		Missing:              repoNames(common.missing),
+		Timedout:             repoNames(common.timedout),
+		IndexUnavailable:     common.indexUnavailable,
+		DurationMs:           int32(time.Since(s.start) / time.Millisecond),
+	})
+}
+
+// writeFilters assumes the caller holds s.mu.
+func (s *searchStreamWriter) writeFilters() {
+	dynamicFilters := s.filters.compute(s.common.partial)
+	filters := make([]SearchStreamFilter, len(dynamicFilters))
+	for i, f := range dynamicFilters {
+		filters[i] = SearchStreamFilter{
+			Value:    f.value,
+			Label:    f.label,
+			Count:    f.count,
+			LimitHit: f.limitHit,
+			Kind:     f.kind,
+		}
+	}
+	s.event("filters", filters)
+	s.filtersSent = time.Now()
+}
+
+func repoNames(repos []*types.Repo) []string {
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = string(repo.Name)
+	}
+	return names
+}
+
+// SearchStreamMatch is a result of a streaming search. It is one of
+// *SearchStreamFileMatch, *SearchStreamRepoMatch, or *SearchStreamCommitMatch.
+type SearchStreamMatch interface {
+	searchStreamMatch()
+}
+
+// SearchStreamFileMatch is a file that matched a streaming search.
+type SearchStreamFileMatch struct {
+	Type        string                   `json:"type"` // always "file"
+	Repository  string                   `json:"repository"`
+	Commit      string                   `json:"commit,omitempty"`
+	Path        string                   `json:"path"`
+	LineMatches []*SearchStreamLineMatch `json:"lineMatches,omitempty"`
+	Symbols     []*SearchStreamSymbol    `json:"symbols,omitempty"`
+	LimitHit    bool                     `json:"limitHit"`
+}
+
+// SearchStreamLineMatch is a line of a file that matched a streaming search.
+type SearchStreamLineMatch struct {
+	Preview          string     `json:"preview"`
+	LineNumber       int32      `json:"lineNumber"`
+	OffsetAndLengths [][2]int32 `json:"offsetAndLengths"`
+}
+
+// SearchStreamSymbol is a symbol in a file that matched a streaming search.
+type SearchStreamSymbol struct {
+	Name          string `json:"name"`
+	ContainerName string `json:"containerName,omitempty"`
+	Kind          string `json:"kind"`
+	URL           string `json:"url"`
+}
+
+// SearchStreamRepoMatch is a repository whose name matched a streaming
+// search.
+type SearchStreamRepoMatch struct {
+	Type       string `json:"type"` // always "repo"
+	Repository string `json:"repository"`
+}
+
+// SearchStreamCommitMatch is a commit or diff that matched a streaming
+// search.
+type SearchStreamCommitMatch struct {
+	Type       string `json:"type"` // always "commit"
+	Repository string `json:"repository"`
+	OID        string `json:"oid"`
+	URL        string `json:"url"`
+	Subject    string `json:"subject"`
+	Preview    string `json:"preview,omitempty"`
+}
+
+func (*SearchStreamFileMatch) searchStreamMatch()   {}
+func (*SearchStreamRepoMatch) searchStreamMatch()   {}
+func (*SearchStreamCommitMatch) searchStreamMatch() {}
+
+func newSearchStreamMatch(result *searchResultResolver) SearchStreamMatch {
+	switch {
+	case result.fileMatch != nil:
+		fm := result.fileMatch
+		m := &SearchStreamFileMatch{
+			Type:       "file",
+			Repository: string(fm.repo.Name),
+			Commit:     string(fm.commitID),
+			Path:       fm.JPath,
+			LimitHit:   fm.JLimitHit,
+		}
+		for _, lm := range fm.JLineMatches {
+			m.LineMatches = append(m.LineMatches, &SearchStreamLineMatch{
+				Preview:          lm.JPreview,
+				LineNumber:       lm.JLineNumber,
+				OffsetAndLengths: lm.JOffsetAndLengths,
+			})
+		}
+		for _, sym := range fm.symbols {
+			s := &SearchStreamSymbol{
+				Name: sym.Name(),
+				Kind: sym.Kind(),
+				URL:  sym.URL(),
+			}
+			if containerName := sym.ContainerName(); containerName != nil {
+				s.ContainerName = *containerName
+			}
+			m.Symbols = append(m.Symbols, s)
+		}
+		return m
+	case result.repo != nil:
+		return &SearchStreamRepoMatch{
+			Type:       "repo",
+			Repository: result.repo.Name(),
+		}
+	case result.diff != nil:
+		commit := result.diff.commit
+		m := &SearchStreamCommitMatch{
+			Type:       "commit",
+			Repository: commit.repo.Name(),
+			OID:        string(commit.oid),
+			URL:        commit.URL(),
+			Subject:    commit.Subject(),
+		}
+		if result.diff.diffPreview != nil {
+			m.Preview = result.diff.diffPreview.value
+		} else if result.diff.messagePreview != nil {
+			m.Preview = result.diff.messagePreview.value
+		}
+		return m
+	}
+	return nil
+}
+
+// SearchStreamProgress describes the progress of a streaming search.
+type SearchStreamProgress struct {
+	Done                 bool     `json:"done"` // whether the search is complete
+	MatchCount           int32    `json:"matchCount"`
+	LimitHit             bool     `json:"limitHit"`
+	RepositoriesCount    int      `json:"repositoriesCount"` // only known once the search is complete
+	RepositoriesSearched int      `json:"repositoriesSearched"`
+	RepositoriesIndexed  int      `json:"repositoriesIndexed"`
+	Cloning              []string `json:"cloning"`
+	Missing              []string `json:"missing"`
+	Timedout             []string `json:"timedout"`
+	IndexUnavailable     bool     `json:"indexUnavailable"`
+	DurationMs           int32    `json:"durationMs"`
+}
+
+// SearchStreamFilter is a dynamic filter for the results of a streaming
+// search.
+type SearchStreamFilter struct {
+	Value    string `json:"value"`
+	Label    string `json:"label"`
+	Count    int32  `json:"count"`
+	LimitHit bool   `json:"limitHit"`
+	Kind     string `json:"kind"`
+}
+
+// SearchStreamAlert is an alert for a streaming search.
+type SearchStreamAlert struct {
+	Title           string                      `json:"title"`
+	Description     string                      `json:"description,omitempty"`
+	ProposedQueries []SearchStreamProposedQuery `json:"proposedQueries,omitempty"`
+}
+
+// SearchStreamProposedQuery is a query proposed by a SearchStreamAlert.
+type SearchStreamProposedQuery struct {
+	Description string `json:"description,omitempty"`
+	Query       string `json:"query"`
+}
+
+func newSearchStreamAlert(alert *searchAlert) SearchStreamAlert {
+	a := SearchStreamAlert{Title: alert.title, Description: alert.description}
+	for _, pq := range alert.proposedQueries {
+		a.ProposedQueries = append(a.ProposedQueries, SearchStreamProposedQuery{
+			Description: pq.description,
+			Query:       pq.query,
+		})
+	}
+	return a
+}
+
+// SearchStreamError is an error that stopped a streaming search.
+type SearchStreamError struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,155 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+type recordingEventWriter struct {
+	names  []string
+	events map[string][]interface{}
+}
+
+func (w *recordingEventWriter) Event(name string, data interface{}) error {
+	w.names = append(w.names, name)
+	if w.events == nil {
+		w.events = map[string][]interface{}{}
+	}
+	w.events[name] = append(w.events[name], data)
+	return nil
+}
+
+func TestStreamSearch(t *testing.T) {
+	t.Run("results", func(t *testing.T) {
+		repo := &types.Repo{ID: 1, Name: "repo"}
+		db.Mocks.Repos.List = func(_ context.Context, op db.ReposListOptions) ([]*types.Repo, error) {
+			return []*types.Repo{repo}, nil
+		}
+		defer func() { db.Mocks = db.MockStores{} }()
+		db.Mocks.Repos.MockGetByName(t, "repo", 1)
+
+		mockSearchRepositories = func(args *search.Args) ([]*searchResultResolver, *searchResultsCommon, error) {
+			return nil, &searchResultsCommon{}, nil
+		}
+		defer func() { mockSearchRepositories = nil }()
+
+		mockSearchFilesInRepos = func(args *search.Args) ([]*fileMatchResolver, *searchResultsCommon, error) {
+			return []*fileMatchResolver{
+				{uri: "git://repo?rev#dir/file", JPath: "dir/file", repo: repo, JLineMatches: []*lineMatch{{JPreview: "foo", JLineNumber: 123, JOffsetAndLengths: [][2]int32{{0, 3}}}}},
+			}, &searchResultsCommon{searched: []*types.Repo{repo}}, nil
+		}
+		defer func() { mockSearchFilesInRepos = nil }()
+
+		var w recordingEventWriter
+		if err := StreamSearch(context.Background(), "foo", &w); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := w.names[len(w.names)-1]; got != "done" {
+			t.Errorf("got last event %q, want done", got)
+		}
+		if len(w.events["error"]) != 0 {
+			t.Errorf("got errors %v", w.events["error"])
+		}
+
+		wantMatches := []interface{}{[]SearchStreamMatch{&SearchStreamFileMatch{
+			Type:        "file",
+			Repository:  "repo",
+			Path:        "dir/file",
+			LineMatches: []*SearchStreamLineMatch{{Preview: "foo", LineNumber: 123, OffsetAndLengths: [][2]int32{{0, 3}}}},
+		}}}
+		if !reflect.DeepEqual(w.events["matches"], wantMatches) {
+			t.Errorf("got matches %+v, want %+v", w.events["matches"], wantMatches)
+		}
+
+		progress := w.events["progress"]
+		last := progress[len(progress)-1].(SearchStreamProgress)
+		if !last.Done || last.MatchCount != 1 || last.RepositoriesSearched != 1 {
+			t.Errorf("got final progress %+v, want done with 1 match in 1 searched repository", last)
+		}
+		if len(w.events["filters"]) == 0 {
+			t.Error("got no filters")
+		}
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		var w recordingEventWriter
+		if err := StreamSearch(context.Background(), "case:maybe", &w); err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"error", "done"}; !reflect.DeepEqual(w.names, want) {
+			t.Errorf("got events %v, want %v", w.names, want)
+		}
+	})
+}
+
+func TestSearchResultsCommonDelta(t *testing.T) {
+	a, b := &types.Repo{ID: 1, Name: "a"}, &types.Repo{ID: 2, Name: "b"}
+	common := &searchResultsCommon{
+		repos:    []*types.Repo{a, b},
+		searched: []*types.Repo{a},
+		partial:  map[api.RepoName]struct{}{"a": {}},
+	}
+	common.resultCount = 3
+
+	var d searchResultsCommonDelta
+	first := d.next(common)
+	if !reflect.DeepEqual(first.repos, []*types.Repo{a, b}) || !reflect.DeepEqual(first.searched, []*types.Repo{a}) || first.resultCount != 3 || len(first.partial) != 1 {
+		t.Errorf("got first delta %+v, want all stats", first)
+	}
+
+	common.searched = append(common.searched, b)
+	common.timedout = append(common.timedout, b)
+	common.partial["b"] = struct{}{}
+	common.resultCount = 5
+	second := d.next(common)
+	want := &searchResultsCommon{
+		searched:    []*types.Repo{b},
+		timedout:    []*types.Repo{b},
+		partial:     map[api.RepoName]struct{}{"b": {}},
+		resultCount: 2,
+	}
+	if !reflect.DeepEqual(second, want) {
+		t.Errorf("got second delta %+v, want %+v", second, want)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	a, b := &types.Repo{ID: 1, Name: "a"}, &types.Repo{ID: 2, Name: "b"}
+	results := []*searchResultResolver{
+		{fileMatch: &fileMatchResolver{JPath: "main.go", repo: a, JLineMatches: []*lineMatch{{JLineNumber: 1}}}},
+		{fileMatch: &fileMatchResolver{JPath: "vendor/x.go", repo: a, JLineMatches: []*lineMatch{{JLineNumber: 1}, {JLineNumber: 2}}}},
+		{fileMatch: &fileMatchResolver{JPath: "README.md", repo: b, JLimitHit: true}},
+	}
+	partial := map[api.RepoName]struct{}{"b": {}}
+	sortFilters := func(filters []*searchFilterResolver) []*searchFilterResolver {
+		sort.Slice(filters, func(i, j int) bool { return filters[i].value < filters[j].value })
+		return filters
+	}
+
+	// Adding the results in batches (as a streaming search does) results in
+	// the same filters as computing them over all results at once.
+	var filters searchFilters
+	filters.add(results[:1])
+	filters.add(results[1:])
+	got := sortFilters(filters.compute(partial))
+
+	sr := &searchResultsResolver{results: results, searchResultsCommon: searchResultsCommon{partial: partial}}
+	want := sortFilters(sr.DynamicFilters())
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got filters %+v, want %+v", got, want)
+	}
+
+	for _, f := range got {
+		if f.kind == "repo" && f.limitHit != (f.label == "b") {
+			t.Errorf("got limitHit %v for filter %q, want it only for the partially searched repository b", f.limitHit, f.value)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
 	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
 )
 
@@ -67,23 +68,30 @@ func (r *settingsCascade) Subjects(ctx context.Context) ([]*settingsSubject, err
 	return subjects, nil
 }
 
-// viewerFinalSettings returns the final (merged) settings for the viewer.
+// viewerFinalSettings returns the final (merged) settings for the viewer. It is used by search, so
+// access tokens with the "search:read" scope may be used.
 func viewerFinalSettings(ctx context.Context) (*configurationResolver, error) {
 	cascade, err := (&schemaResolver{}).ViewerSettings(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return cascade.Merged(ctx)
+	return cascade.merged(ctx, authz.ScopeSearchRead), nil
 }
 
 func (r *settingsCascade) Final(ctx context.Context) (string, error) {
+	return r.final(ctx, authz.ScopeSettingsRead)
+}
+
+// final returns the final (merged) settings of the cascade if the viewer may read them with an
+// access token that has the given read-only scope (if any).
+func (r *settingsCascade) final(ctx context.Context, scope string) (string, error) {
 	var allSettings []string
 	subjects, err := r.Subjects(ctx)
 	if err != nil {
 		return "", err
 	}
 	for _, s := range subjects {
-		settings, err := s.LatestSettings(ctx)
+		settings, err := s.latestSettings(ctx, scope)
 		if err != nil {
 			return "", err
 		}
@@ -97,12 +105,16 @@ func (r *settingsCascade) Final(ctx context.Context) (string, error) {
 
 // DEPRECATED (in the GraphQL API)
 func (r *settingsCascade) Merged(ctx context.Context) (*configurationResolver, error) {
+	return r.merged(ctx, authz.ScopeSettingsRead), nil
+}
+
+func (r *settingsCascade) merged(ctx context.Context, scope string) *configurationResolver {
 	var messages []string
-	s, err := r.Final(ctx)
+	s, err := r.final(ctx, scope)
 	if err != nil {
 		messages = append(messages, err.Error())
 	}
-	return &configurationResolver{contents: string(s), messages: messages}, nil
+	return &configurationResolver{contents: string(s), messages: messages}
 }
 
 // deeplyMergedSettingsFields contains the names of top-level settings fields whose values should be
@@ -7,6 +7,7 @@ import (
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
 )
@@ -38,7 +39,7 @@ func settingsSubjectByID(ctx context.Context, id graphql.ID) (*settingsSubject,
 
 	case *UserResolver:
 		// 🚨 SECURITY: Only the user and site admins are allowed to view the user's settings.
-		if err := backend.CheckSiteAdminOrSameUser(ctx, s.user.ID); err != nil {
+		if err := backend.CheckSiteAdminOrSameUserScope(ctx, s.user.ID, authz.ScopeSettingsRead); err != nil {
 			return nil, err
 		}
 		return &settingsSubject{user: s}, nil
@@ -170,9 +171,26 @@ func (s *settingsSubject) ConfigurationCascade() (*settingsCascade, error) {
 	return s.SettingsCascade()
 }
 
-// readSettings unmarshals s's latest settings into v.
+// latestSettings is like LatestSettings, except that access tokens with the given read-only scope
+// (instead of the "settings:read" scope) may be used to read the settings. It is used when only
+// the parts of the settings that the scope covers are returned (such as saved queries).
+func (s *settingsSubject) latestSettings(ctx context.Context, scope string) (*settingsResolver, error) {
+	switch {
+	case s.site != nil:
+		return s.site.LatestSettings(ctx)
+	case s.org != nil:
+		return s.org.latestSettings(ctx, scope)
+	case s.user != nil:
+		return s.user.latestSettings(ctx, scope)
+	default:
+		return nil, errUnknownSettingsSubject
+	}
+}
+
+// readSettings unmarshals s's latest settings into v. It is used to read saved queries, so access
+// tokens with the "search:read" scope may be used.
 func (s *settingsSubject) readSettings(ctx context.Context, v interface{}) error {
-	settings, err := s.LatestSettings(ctx)
+	settings, err := s.latestSettings(ctx, authz.ScopeSearchRead)
 	if err != nil {
 		return err
 	}
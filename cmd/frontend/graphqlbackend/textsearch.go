@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -33,6 +34,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/pkg/env"
 	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/searcher/protocol"
 	"github.com/sourcegraph/sourcegraph/pkg/trace"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
 	zoektpkg "github.com/sourcegraph/sourcegraph/pkg/zoekt"
@@ -70,6 +72,12 @@ type fileMatchResolver struct {
 	// preserve the original revision specifier from the user instead of navigating them to the
 	// absolute commit ID when they select a result.
 	inputRev *string
+
+	// Signals for ranking the result (see rankResults).
+	zoektScore    float64 // the score assigned by zoekt, or 0 for unindexed results
+	definesSymbol bool    // whether the file defines a symbol matching the search pattern
+
+	score float64 // the relevance score computed by rankResults
 }
 
 func (fm *fileMatchResolver) Key() string {
@@ -104,6 +112,10 @@ func (fm *fileMatchResolver) LineMatches() []*lineMatch {
 	return fm.JLineMatches
 }
 
+func (fm *fileMatchResolver) Score() float64 {
+	return fm.score
+}
+
 func (fm *fileMatchResolver) LimitHit() bool {
 	return fm.JLimitHit
 }
@@ -136,9 +148,11 @@ func (lm *lineMatch) LimitHit() bool {
 	return lm.JLimitHit
 }
 
-// textSearch searches repo@commit with p.
+// textSearch searches repo@commit with p. If onMatch is non-nil, it is called
+// with each match as soon as searcher sends it, and with every match that is
+// returned.
 // Note: the returned matches do not set fileMatch.uri
-func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p *search.PatternInfo, fetchTimeout time.Duration) (matches []*fileMatchResolver, limitHit bool, err error) {
+func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p *search.PatternInfo, fetchTimeout time.Duration, onMatch func(*fileMatchResolver)) (matches []*fileMatchResolver, limitHit bool, err error) {
 	if searcherURLs == nil {
 		return nil, false, errors.New("a searcher service has not been configured")
 	}
@@ -188,6 +202,9 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 	if p.IsCaseSensitive {
 		q.Set("IsCaseSensitive", "true")
 	}
+	if p.IsStructuralPat {
+		q.Set("IsStructuralPat", "true")
+	}
 	if p.PathPatternsAreRegExps {
 		q.Set("PathPatternsAreRegExps", "true")
 	}
@@ -198,6 +215,9 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 	// these fields from old frontends that do not (and provide a default in the latter case).
 	q.Set("PatternMatchesContent", strconv.FormatBool(p.PatternMatchesContent))
 	q.Set("PatternMatchesPath", strconv.FormatBool(p.PatternMatchesPath))
+	// Ask for a streaming response so that we keep the matches found so far
+	// if the search is canceled or times out.
+	q.Set("Stream", "true")
 	rawQuery := q.Encode()
 
 	// Searcher caches the file contents for repo@commit since it is
@@ -211,7 +231,15 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 		excludedSearchURLs = map[string]bool{}
 		attempt            = 0
 		maxAttempts        = 2
+		matchSent          = false // whether a match was passed to onMatch
 	)
+	if onMatch != nil {
+		sendMatch := onMatch
+		onMatch = func(fm *fileMatchResolver) {
+			matchSent = true
+			sendMatch(fm)
+		}
+	}
 	for {
 		attempt++
 
@@ -231,7 +259,7 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 
 		url := searcherURL + "?" + rawQuery
 		tr.LazyPrintf("attempt %d: %s", attempt, url)
-		matches, limitHit, err = textSearchURL(ctx, url)
+		matches, limitHit, err = textSearchURL(ctx, url, onMatch)
 		// Useful trace for debugging:
 		//
 		// tr.LazyPrintf("%d matches, limitHit=%v, err=%v, ctx.Err()=%v", len(matches), limitHit, err, ctx.Err())
@@ -244,8 +272,10 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 			return nil, false, err
 		}
 
-		// If not temporary or our last attempt then don't try again.
-		if !errcode.IsTemporary(err) || attempt == maxAttempts {
+		// If not temporary or our last attempt then don't try again. Also
+		// don't try again if matches were already passed to onMatch,
+		// because the retry would pass them again.
+		if !errcode.IsTemporary(err) || attempt == maxAttempts || matchSent {
 			return nil, false, err
 		}
 
@@ -255,7 +285,7 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 	}
 }
 
-func textSearchURL(ctx context.Context, url string) ([]*fileMatchResolver, bool, error) {
+func textSearchURL(ctx context.Context, url string, onMatch func(*fileMatchResolver)) ([]*fileMatchResolver, bool, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, false, err
@@ -291,6 +321,12 @@ func textSearchURL(ctx context.Context, url string) ([]*fileMatchResolver, bool,
 		return nil, false, errors.WithStack(&searcherError{StatusCode: resp.StatusCode, Message: string(body)})
 	}
 
+	// BACKCOMPAT: Searchers that do not support streaming ignore the Stream
+	// parameter and send a single JSON object.
+	if resp.Header.Get("Content-Type") == protocol.StreamContentType {
+		return decodeSearcherStream(ctx, resp.Body, onMatch)
+	}
+
 	r := struct {
 		Matches     []*fileMatchResolver
 		LimitHit    bool
@@ -303,9 +339,56 @@ func textSearchURL(ctx context.Context, url string) ([]*fileMatchResolver, bool,
 	if r.DeadlineHit {
 		err = context.DeadlineExceeded
 	}
+	if onMatch != nil {
+		for _, fm := range r.Matches {
+			onMatch(fm)
+		}
+	}
 	return r.Matches, r.LimitHit, err
 }
 
+// decodeSearcherStream decodes a streaming response from searcher (see
+// protocol.StreamEvent). If the response is cut short, it returns the matches
+// that were received along with the error. If onMatch is non-nil, it is called
+// with each match as soon as it is decoded.
+func decodeSearcherStream(ctx context.Context, body io.Reader, onMatch func(*fileMatchResolver)) (matches []*fileMatchResolver, limitHit bool, err error) {
+	dec := json.NewDecoder(body)
+	for {
+		// Same as protocol.StreamEvent, but unmarshals the match directly
+		// into a resolver.
+		var event struct {
+			Match   *fileMatchResolver
+			Trailer *protocol.StreamTrailer
+		}
+		if err := dec.Decode(&event); err != nil {
+			// If we were canceled or timed out, report just that, so that
+			// the caller can use the partial results.
+			if ctx.Err() != nil {
+				return matches, false, ctx.Err()
+			}
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return matches, false, errors.Wrap(err, "searcher response invalid")
+		}
+		switch {
+		case event.Match != nil:
+			matches = append(matches, event.Match)
+			if onMatch != nil {
+				onMatch(event.Match)
+			}
+		case event.Trailer != nil:
+			if event.Trailer.Error != "" {
+				return matches, event.Trailer.LimitHit, errors.WithStack(&searcherError{StatusCode: http.StatusInternalServerError, Message: event.Trailer.Error})
+			}
+			if event.Trailer.DeadlineHit {
+				err = context.DeadlineExceeded
+			}
+			return matches, event.Trailer.LimitHit, err
+		}
+	}
+}
+
 type searcherError struct {
 	StatusCode int
 	Message    string
@@ -325,9 +408,18 @@ func (e *searcherError) Error() string {
 
 var mockSearchFilesInRepo func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.PatternInfo, fetchTimeout time.Duration) (matches []*fileMatchResolver, limitHit bool, err error)
 
-func searchFilesInRepo(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.PatternInfo, fetchTimeout time.Duration) (matches []*fileMatchResolver, limitHit bool, err error) {
+// searchFilesInRepo searches repo@rev with info. If onMatch is non-nil, it is
+// called with each match as soon as it is found, and with every match that is
+// returned.
+func searchFilesInRepo(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.PatternInfo, fetchTimeout time.Duration, onMatch func(*fileMatchResolver)) (matches []*fileMatchResolver, limitHit bool, err error) {
 	if mockSearchFilesInRepo != nil {
-		return mockSearchFilesInRepo(ctx, repo, gitserverRepo, rev, info, fetchTimeout)
+		matches, limitHit, err = mockSearchFilesInRepo(ctx, repo, gitserverRepo, rev, info, fetchTimeout)
+		if onMatch != nil {
+			for _, fm := range matches {
+				onMatch(fm)
+			}
+		}
+		return matches, limitHit, err
 	}
 
 	// Do not trigger a repo-updater lookup (e.g.,
@@ -339,44 +431,123 @@ func searchFilesInRepo(ctx context.Context, repo *types.Repo, gitserverRepo gits
 		return nil, false, err
 	}
 
-	matches, limitHit, err = textSearch(ctx, gitserverRepo, commit, info, fetchTimeout)
-
 	var workspace string
 	if rev != "" {
 		workspace = "git://" + string(repo.Name) + "?" + url.QueryEscape(rev) + "#"
 	} else {
 		workspace = "git://" + string(repo.Name) + "#"
 	}
-	for _, fm := range matches {
+	setLocation := func(fm *fileMatchResolver) {
 		fm.uri = workspace + fm.JPath
 		fm.repo = repo
 		fm.commitID = commit
 		fm.inputRev = &rev
 	}
 
+	var onSearcherMatch func(*fileMatchResolver)
+	if onMatch != nil {
+		onSearcherMatch = func(fm *fileMatchResolver) {
+			setLocation(fm)
+			onMatch(fm)
+		}
+	}
+	matches, limitHit, err = textSearch(ctx, gitserverRepo, commit, info, fetchTimeout, onSearcherMatch)
+	if onMatch == nil {
+		// Otherwise, onSearcherMatch already did this before the match was
+		// passed on.
+		for _, fm := range matches {
+			setLocation(fm)
+		}
+	}
+
+	return matches, limitHit, err
+}
+
+// searchFilesInRepoPatterns is like searchFilesInRepo, except that it returns
+// the files that match any of the patterns. If there are multiple patterns,
+// onMatch (if non-nil) is only called once the matches of all patterns have
+// been merged.
+func searchFilesInRepoPatterns(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, patterns []*search.PatternInfo, fetchTimeout time.Duration, onMatch func(*fileMatchResolver)) (matches []*fileMatchResolver, limitHit bool, err error) {
+	if len(patterns) == 1 {
+		return searchFilesInRepo(ctx, repo, gitserverRepo, rev, patterns[0], fetchTimeout, onMatch)
+	}
+
+	byPath := map[string]*fileMatchResolver{}
+	for _, p := range patterns {
+		var patternMatches []*fileMatchResolver
+		var patternLimitHit bool
+		patternMatches, patternLimitHit, err = searchFilesInRepo(ctx, repo, gitserverRepo, rev, p, fetchTimeout, nil)
+		limitHit = limitHit || patternLimitHit
+		for _, fm := range patternMatches {
+			if m, ok := byPath[fm.JPath]; ok {
+				mergeFileMatch(m, fm)
+				continue
+			}
+			byPath[fm.JPath] = fm
+			matches = append(matches, fm)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if onMatch != nil {
+		for _, fm := range matches {
+			onMatch(fm)
+		}
+	}
 	return matches, limitHit, err
 }
 
-func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*search.RepositoryRevisions, useFullDeadline bool) (fm []*fileMatchResolver, limitHit bool, reposLimitHit map[string]struct{}, err error) {
+// zoektSearch searches repos using zoekt. Each repository is searched at its
+// default branch, or at the indexed branch given by its revspec (see
+// zoektIndexedRepos).
+func zoektSearch(ctx context.Context, args *search.Args, repos []*search.RepositoryRevisions, useFullDeadline bool) (fm []*fileMatchResolver, limitHit bool, reposLimitHit map[string]struct{}, err error) {
 	if len(repos) == 0 {
 		return nil, false, nil, nil
 	}
+	query := args.Pattern
 
-	// Tell zoekt which repos to search
-	repoSet := &zoektquery.RepoSet{Set: make(map[string]bool, len(repos))}
-	repoMap := make(map[api.RepoName]*types.Repo, len(repos))
+	// Tell zoekt which repos to search, grouped by the branch to search.
+	repoSets := map[string]*zoektquery.RepoSet{}
+	repoMap := make(map[api.RepoName]*search.RepositoryRevisions, len(repos))
 	for _, repoRev := range repos {
+		branch := zoektBranch(repoRev)
+		repoSet, ok := repoSets[branch]
+		if !ok {
+			repoSet = &zoektquery.RepoSet{Set: map[string]bool{}}
+			repoSets[branch] = repoSet
+		}
 		repoSet.Set[string(repoRev.Repo.Name)] = true
-		repoMap[api.RepoName(strings.ToLower(string(repoRev.Repo.Name)))] = repoRev.Repo
+		repoMap[api.RepoName(strings.ToLower(string(repoRev.Repo.Name)))] = repoRev
+	}
+	branchNames := make([]string, 0, len(repoSets))
+	for branch := range repoSets {
+		branchNames = append(branchNames, branch)
+	}
+	sort.Strings(branchNames)
+	repoQueries := make([]zoektquery.Q, len(branchNames))
+	for i, branch := range branchNames {
+		repoQueries[i] = zoektquery.NewAnd(repoSets[branch], &zoektquery.Branch{Pattern: branch})
+	}
+
+	// Look up the indexed commits of non-default branches, so that results
+	// link to the commit that was searched.
+	var indexedCommits map[string]api.CommitID
+	if len(branchNames) > 1 || branchNames[0] != "HEAD" {
+		list, err := zoektCache.ListAll(ctx)
+		if err != nil {
+			return nil, false, nil, err
+		}
+		indexedCommits = zoektIndexedBranches(list)
 	}
 
-	queryExceptRepos, err := queryToZoektQuery(query)
+	queryExceptRepos, err := patternsToZoektQuery(args.Patterns())
 	if err != nil {
 		return nil, false, nil, err
 	}
-	finalQuery := zoektquery.NewAnd(repoSet, queryExceptRepos)
+	finalQuery := zoektquery.NewAnd(zoektquery.NewOr(repoQueries...), queryExceptRepos)
 
-	tr, ctx := trace.New(ctx, "zoekt.Search", fmt.Sprintf("%d %+v", len(repoSet.Set), finalQuery.String()))
+	tr, ctx := trace.New(ctx, "zoekt.Search", fmt.Sprintf("%d %+v", len(repos), finalQuery.String()))
 	defer func() {
 		tr.SetError(err)
 		if len(fm) > 0 {
@@ -424,6 +595,9 @@ func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*se
 	if searchOpts.MaxDocDisplayCount < 2000 {
 		searchOpts.MaxDocDisplayCount = 2000
 	}
+	if args.RepoFileMatchLimit > 0 && searchOpts.ShardMaxMatchCount > int(args.RepoFileMatchLimit) {
+		searchOpts.ShardMaxMatchCount = int(args.RepoFileMatchLimit)
+	}
 
 	if userProbablyWantsToWaitLonger := query.FileMatchLimit > defaultMaxSearchResults; userProbablyWantsToWaitLonger {
 		searchOpts.MaxWallTime *= time.Duration(3 * float64(query.FileMatchLimit) / float64(defaultMaxSearchResults))
@@ -458,6 +632,31 @@ func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*se
 	if err != nil {
 		return nil, false, nil, err
 	}
+
+	// The branch query matches branch names by substring, so drop files
+	// that are not in the exact branch we searched for. Also drop files
+	// beyond args.RepoFileMatchLimit (a repository may have several shards).
+	files := resp.Files[:0]
+	repoFileCount := map[string]int32{}
+	for _, file := range resp.Files {
+		repoRev, ok := repoMap[api.RepoName(strings.ToLower(file.Repository))]
+		if !ok {
+			continue
+		}
+		if args.RepoFileMatchLimit > 0 && repoFileCount[file.Repository] >= args.RepoFileMatchLimit {
+			continue
+		}
+		branch := zoektBranch(repoRev)
+		for _, b := range file.Branches {
+			if b == branch {
+				files = append(files, file)
+				repoFileCount[file.Repository]++
+				break
+			}
+		}
+	}
+	resp.Files = files
+
 	limitHit = resp.FilesSkipped+resp.ShardsSkipped > 0
 	// Repositories that weren't fully evaluated because they hit the Zoekt or Sourcegraph file match limits.
 	reposLimitHit = make(map[string]struct{})
@@ -525,13 +724,21 @@ func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*se
 				})
 			}
 		}
+		repoRev := repoMap[api.RepoName(strings.ToLower(string(file.Repository)))]
 		matches[i] = &fileMatchResolver{
 			JPath:        file.FileName,
 			JLineMatches: lines,
 			JLimitHit:    fileLimitHit,
 			uri:          fmt.Sprintf("git://%s#%s", file.Repository, file.FileName),
-			repo:         repoMap[api.RepoName(strings.ToLower(string(file.Repository)))],
-			commitID:     "", // zoekt only searches default branch
+			repo:         repoRev.Repo,
+			commitID:     "", // default branch
+			zoektScore:   file.Score,
+		}
+		if branch := zoektBranch(repoRev); branch != "HEAD" {
+			rev := repoRev.RevSpecs()[0]
+			matches[i].uri = fmt.Sprintf("git://%s?%s#%s", file.Repository, url.QueryEscape(rev), file.FileName)
+			matches[i].commitID = indexedCommits[zoektBranchKey(repoRev.Repo.Name, branch)]
+			matches[i].inputRev = &rev
 		}
 	}
 
@@ -618,15 +825,33 @@ func queryToZoektQuery(query *search.PatternInfo) (zoektquery.Q, error) {
 	return zoektquery.Simplify(zoektquery.NewAnd(and...)), nil
 }
 
+// patternsToZoektQuery returns a zoekt query that matches files matching any
+// of the patterns.
+func patternsToZoektQuery(patterns []*search.PatternInfo) (zoektquery.Q, error) {
+	if len(patterns) == 1 {
+		return queryToZoektQuery(patterns[0])
+	}
+	or := make([]zoektquery.Q, len(patterns))
+	for i, p := range patterns {
+		q, err := queryToZoektQuery(p)
+		if err != nil {
+			return nil, err
+		}
+		or[i] = q
+	}
+	return zoektquery.Simplify(zoektquery.NewOr(or...)), nil
+}
+
 func zoektIndexedRepos(ctx context.Context, repos []*search.RepositoryRevisions) (indexed, unindexed []*search.RepositoryRevisions, err error) {
 	if !searchIndexEnabled() {
 		return nil, repos, nil
 	}
 	for _, repoRev := range repos {
-		// We search HEAD using zoekt
+		// We search HEAD and the branches configured in
+		// search.index.branches using zoekt.
 		if revspecs := repoRev.RevSpecs(); len(revspecs) > 0 {
 			// TODO(sqs): search all revspecs
-			if revspecs[0] == "" {
+			if revspecs[0] == "" || len(revspecs) == 1 && isIndexableBranch(repoRev.Repo.Name, revspecs[0]) {
 				indexed = append(indexed, repoRev)
 			} else {
 				unindexed = append(unindexed, repoRev)
@@ -646,16 +871,12 @@ func zoektIndexedRepos(ctx context.Context, repos []*search.RepositoryRevisions)
 		return nil, repos, err
 	}
 
-	// Everything currently in indexed is at HEAD. Filter out repos which
-	// zoekt hasn't indexed yet.
-	set := map[string]bool{}
-	for _, repo := range resp.Repos {
-		set[repo.Repository.Name] = true
-	}
-	head := indexed
+	// Filter out repos (and branches) which zoekt hasn't indexed yet.
+	branches := zoektIndexedBranches(resp)
+	candidates := indexed
 	indexed = indexed[:0]
-	for _, repoRev := range head {
-		if set[string(repoRev.Repo.Name)] {
+	for _, repoRev := range candidates {
+		if _, ok := branches[zoektBranchKey(repoRev.Repo.Name, zoektBranch(repoRev))]; ok {
 			indexed = append(indexed, repoRev)
 		} else {
 			unindexed = append(unindexed, repoRev)
@@ -665,12 +886,62 @@ func zoektIndexedRepos(ctx context.Context, repos []*search.RepositoryRevisions)
 	return indexed, unindexed, nil
 }
 
+// isIndexableBranch reports whether rev is a branch of repo that is configured
+// to be indexed (see conf.SearchIndexBranches).
+func isIndexableBranch(repo api.RepoName, rev string) bool {
+	rev = strings.TrimPrefix(rev, "refs/heads/")
+	for _, branch := range conf.SearchIndexBranches(string(repo)) {
+		if branch == rev {
+			return true
+		}
+	}
+	return false
+}
+
+// zoektBranch returns the name of the zoekt branch to search for repoRev.
+// zoekt names the default branch "HEAD".
+func zoektBranch(repoRev *search.RepositoryRevisions) string {
+	if revspecs := repoRev.RevSpecs(); len(revspecs) > 0 && revspecs[0] != "" {
+		return strings.TrimPrefix(revspecs[0], "refs/heads/")
+	}
+	return "HEAD"
+}
+
+// zoektBranchKey returns the key for a repository's branch in the map
+// returned by zoektIndexedBranches.
+func zoektBranchKey(repo api.RepoName, branch string) string {
+	return strings.ToLower(string(repo)) + "@" + branch
+}
+
+// zoektIndexedBranches returns the commit indexed for each repository branch
+// in list, keyed by zoektBranchKey.
+func zoektIndexedBranches(list *zoekt.RepoList) map[string]api.CommitID {
+	branches := map[string]api.CommitID{}
+	for _, repo := range list.Repos {
+		for _, branch := range repo.Repository.Branches {
+			branches[zoektBranchKey(api.RepoName(repo.Repository.Name), branch.Name)] = api.CommitID(branch.Version)
+		}
+	}
+	return branches
+}
+
 var mockSearchFilesInRepos func(args *search.Args) ([]*fileMatchResolver, *searchResultsCommon, error)
 
 // searchFilesInRepos searches a set of repos for a pattern.
 func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatchResolver, common *searchResultsCommon, err error) {
+	return searchFilesInReposStream(ctx, args, nil)
+}
+
+// searchFilesInReposStream is like searchFilesInRepos, except that it also
+// sends the matches in each repository to stream (if non-nil) as soon as the
+// repository has been searched.
+func searchFilesInReposStream(ctx context.Context, args *search.Args, stream searchStream) (res []*fileMatchResolver, common *searchResultsCommon, err error) {
 	if mockSearchFilesInRepos != nil {
-		return mockSearchFilesInRepos(args)
+		res, common, err = mockSearchFilesInRepos(args)
+		if stream != nil && common != nil {
+			stream.send(fileMatchesToSearchResults(res), common)
+		}
+		return res, common, err
 	}
 
 	tr, ctx := trace.New(ctx, "searchFilesInRepos", fmt.Sprintf("query: %+v, numRepoRevs: %d", args.Pattern, len(args.Repos)))
@@ -697,7 +968,11 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		common.repos[i] = repo.Repo
 	}
 
-	if args.Pattern.IsEmpty() {
+	isEmpty := true
+	for _, p := range args.Patterns() {
+		isEmpty = isEmpty && p.IsEmpty()
+	}
+	if isEmpty {
 		// Empty query isn't an error, but it has no results.
 		return nil, common, nil
 	}
@@ -731,16 +1006,28 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		}
 	}
 
+	// Indexed search does not support structural patterns, so searcher
+	// handles all repositories.
+	for _, p := range args.Patterns() {
+		if p.IsStructuralPat && len(zoektRepos) > 0 {
+			tr.LazyPrintf("structural pattern, bypassing zoekt (using searcher) for %d indexed repos", len(zoektRepos))
+			searcherRepos = append(searcherRepos, zoektRepos...)
+			zoektRepos = nil
+		}
+	}
+
 	var (
 		wg                sync.WaitGroup
 		mu                sync.Mutex
 		unflattened       [][]*fileMatchResolver
 		flattenedSize     int
-		overLimitCanceled bool // canceled because we were over the limit
+		overLimitCanceled bool                     // canceled because we were over the limit
+		sentCommon        searchResultsCommonDelta // the stats sent to stream so far
 	)
 
-	// addMatches assumes the caller holds mu.
-	addMatches := func(matches []*fileMatchResolver) {
+	// addMatches assumes the caller holds mu. If sent is true, the matches
+	// were already sent to stream as they were found.
+	addMatches := func(matches []*fileMatchResolver, sent bool) {
 		if len(matches) > 0 {
 			common.resultCount += int32(len(matches))
 			sort.Slice(matches, func(i, j int) bool {
@@ -760,6 +1047,13 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 				cancel()
 			}
 		}
+		if stream != nil {
+			var results []*searchResultResolver
+			if !sent {
+				results = fileMatchesToSearchResults(matches)
+			}
+			stream.send(results, sentCommon.next(common))
+		}
 	}
 
 	var fetchTimeout time.Duration
@@ -778,6 +1072,19 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		fetchTimeout = 500 * time.Millisecond
 	}
 
+	// Searcher limits the number of file matches per request, that is, per
+	// repository.
+	searcherPatterns := args.Patterns()
+	if args.RepoFileMatchLimit > 0 {
+		for i, p := range searcherPatterns {
+			if p.FileMatchLimit > args.RepoFileMatchLimit {
+				p2 := *p
+				p2.FileMatchLimit = args.RepoFileMatchLimit
+				searcherPatterns[i] = &p2
+			}
+		}
+	}
+
 	for _, repoRev := range searcherRepos {
 		if len(repoRev.Revs) == 0 {
 			continue
@@ -790,7 +1097,15 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		go func(repoRev search.RepositoryRevisions) {
 			defer wg.Done()
 			rev := repoRev.RevSpecs()[0] // TODO(sqs): search multiple revs
-			matches, repoLimitHit, searchErr := searchFilesInRepo(ctx, repoRev.Repo, repoRev.GitserverRepo(), rev, args.Pattern, fetchTimeout)
+			var onMatch func(*fileMatchResolver)
+			if stream != nil {
+				// Send each match as soon as searcher finds it, instead of
+				// once the whole repository has been searched.
+				onMatch = func(fm *fileMatchResolver) {
+					stream.send(fileMatchesToSearchResults([]*fileMatchResolver{fm}), nil)
+				}
+			}
+			matches, repoLimitHit, searchErr := searchFilesInRepoPatterns(ctx, repoRev.Repo, repoRev.GitserverRepo(), rev, searcherPatterns, fetchTimeout, onMatch)
 			if searchErr != nil {
 				tr.LogFields(otlog.String("repo", string(repoRev.Repo.Name)), otlog.String("searchErr", searchErr.Error()), otlog.Bool("timeout", errcode.IsTimeout(searchErr)), otlog.Bool("temporary", errcode.IsTemporary(searchErr)))
 			}
@@ -817,7 +1132,7 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 				tr.LazyPrintf("cancel due to error: %v", err)
 				cancel()
 			}
-			addMatches(matches)
+			addMatches(matches, onMatch != nil)
 		}(*repoRev)
 	}
 
@@ -825,7 +1140,7 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 	go func() {
 		// TODO limitHit, handleRepoSearchResult
 		defer wg.Done()
-		matches, limitHit, reposLimitHit, searchErr := zoektSearchHEAD(ctx, args.Pattern, zoektRepos, args.UseFullDeadline)
+		matches, limitHit, reposLimitHit, searchErr := zoektSearch(ctx, args, zoektRepos, args.UseFullDeadline)
 		mu.Lock()
 		defer mu.Unlock()
 		if ctx.Err() == nil {
@@ -849,7 +1164,7 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 			tr.LazyPrintf("cancel indexed search due to error: %v", err)
 			cancel()
 		}
-		addMatches(matches)
+		addMatches(matches, false)
 	}()
 
 	wg.Wait()
@@ -2,21 +2,31 @@ package graphqlbackend
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/google/zoekt"
 	zoektquery "github.com/google/zoekt/query"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/endpoint"
 	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/searcher/protocol"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 func TestQueryToZoektQuery(t *testing.T) {
@@ -108,6 +118,33 @@ func TestQueryToZoektQuery(t *testing.T) {
 	}
 }
 
+func TestPatternsToZoektQuery(t *testing.T) {
+	patterns := []*search.PatternInfo{
+		{
+			IsRegExp:               true,
+			Pattern:                "foo",
+			IncludePatterns:        []string{`\.go$`},
+			PathPatternsAreRegExps: true,
+		},
+		{
+			IsRegExp:               true,
+			Pattern:                "bar",
+			PathPatternsAreRegExps: true,
+		},
+	}
+	want, err := zoektquery.Parse(`(foo case:no f:\.go$) or (bar case:no)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := patternsToZoektQuery(patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !queryEqual(got, want) {
+		t.Fatalf("mismatched queries\ngot  %s\nwant %s", got.String(), want.String())
+	}
+}
+
 func queryEqual(a zoektquery.Q, b zoektquery.Q) bool {
 	sortChildren := func(q zoektquery.Q) zoektquery.Q {
 		switch s := q.(type) {
@@ -205,6 +242,170 @@ func TestSearchFilesInRepos(t *testing.T) {
 	}
 }
 
+func TestDecodeSearcherStream(t *testing.T) {
+	const (
+		match1  = `{"Match":{"Path":"a.go","LineMatches":[{"Preview":"foo","LineNumber":1,"OffsetAndLengths":[[0,3]]}]}}` + "\n"
+		match2  = `{"Match":{"Path":"b.go","LimitHit":true}}` + "\n"
+		trailer = `{"Trailer":{"LimitHit":true}}` + "\n"
+	)
+	wantMatches := []*fileMatchResolver{
+		{JPath: "a.go", JLineMatches: []*lineMatch{{JPreview: "foo", JLineNumber: 1, JOffsetAndLengths: [][2]int32{{0, 3}}}}},
+		{JPath: "b.go", JLimitHit: true},
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		matches, limitHit, err := decodeSearcherStream(context.Background(), strings.NewReader(match1+match2+trailer), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !limitHit {
+			t.Error("!limitHit")
+		}
+		if !reflect.DeepEqual(matches, wantMatches) {
+			t.Errorf("got %+v, want %+v", matches, wantMatches)
+		}
+	})
+
+	t.Run("deadline hit", func(t *testing.T) {
+		_, _, err := decodeSearcherStream(context.Background(), strings.NewReader(match1+`{"Trailer":{"DeadlineHit":true}}`), nil)
+		if err != context.DeadlineExceeded {
+			t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("error trailer", func(t *testing.T) {
+		matches, _, err := decodeSearcherStream(context.Background(), strings.NewReader(match1+`{"Trailer":{"Error":"boom"}}`), nil)
+		if err == nil || err.Error() != "boom" {
+			t.Errorf("got error %v, want boom", err)
+		}
+		if len(matches) != 1 {
+			t.Errorf("got %d matches, want 1", len(matches))
+		}
+	})
+
+	t.Run("no trailer", func(t *testing.T) {
+		matches, _, err := decodeSearcherStream(context.Background(), strings.NewReader(match1+match2), nil)
+		if err == nil {
+			t.Fatal("expected an error for a response without a trailer")
+		}
+		if !reflect.DeepEqual(matches, wantMatches) {
+			t.Errorf("got %+v, want %+v", matches, wantMatches)
+		}
+	})
+}
+
+// chanSearchStream is a searchStream that sends each non-empty batch of
+// results to the channel.
+type chanSearchStream chan []*searchResultResolver
+
+func (c chanSearchStream) send(results []*searchResultResolver, common *searchResultsCommon) {
+	if len(results) > 0 {
+		c <- results
+	}
+}
+
+func TestSearchFilesInReposStream(t *testing.T) {
+	// Searcher sends a match, but doesn't end its response until the test
+	// has seen the match on the stream.
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", protocol.StreamContentType)
+		fmt.Fprintln(w, `{"Match":{"Path":"main.go"}}`)
+		w.(http.Flusher).Flush()
+		<-release
+		fmt.Fprintln(w, `{"Trailer":{}}`)
+	}))
+	defer ts.Close()
+	var releaseOnce sync.Once
+	releaseSearcher := func() { releaseOnce.Do(func() { close(release) }) }
+	defer releaseSearcher()
+
+	defer func(orig *endpoint.Map) { searcherURLs = orig }(searcherURLs)
+	searcherURLs = endpoint.New(ts.URL)
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		return "1234567890123456789012345678901234567890", nil
+	}
+	defer git.ResetMocks()
+	indexDisabled := false
+	conf.Mock(&schema.SiteConfiguration{SearchIndexEnabled: &indexDisabled})
+	defer conf.Mock(nil)
+
+	q, err := query.ParseAndCheck("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := &search.Args{
+		Pattern: &search.PatternInfo{
+			FileMatchLimit: defaultMaxSearchResults,
+			Pattern:        "foo",
+		},
+		Repos: makeRepositoryRevisions("foo/one"),
+		Query: q,
+	}
+
+	stream := make(chanSearchStream, 10)
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := searchFilesInReposStream(context.Background(), args, stream)
+		done <- err
+	}()
+
+	select {
+	case results := <-stream:
+		if len(results) != 1 || results[0].fileMatch == nil || results[0].fileMatch.uri != "git://foo/one#main.go" {
+			t.Errorf("got streamed results %+v, want the match in foo/one main.go", results)
+		}
+	case err := <-done:
+		t.Fatalf("search completed before the match was streamed (err: %v)", err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the match to be streamed")
+	}
+
+	releaseSearcher()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(stream) != 0 {
+		t.Errorf("got %d more batches of streamed results, want the match to be streamed only once", len(stream))
+	}
+}
+
+func TestZoektIndexedBranches(t *testing.T) {
+	conf.Mock(&schema.SiteConfiguration{SearchIndexBranches: map[string][]string{"foo/bar": {"release-2.0"}}})
+	defer conf.Mock(nil)
+
+	list := &zoekt.RepoList{Repos: []*zoekt.RepoListEntry{
+		{Repository: zoekt.Repository{Name: "foo/bar", Branches: []zoekt.RepositoryBranch{{Name: "HEAD", Version: "a"}, {Name: "release-2.0", Version: "b"}}}},
+		{Repository: zoekt.Repository{Name: "foo/baz", Branches: []zoekt.RepositoryBranch{{Name: "HEAD", Version: "c"}}}},
+	}}
+	want := map[string]api.CommitID{"foo/bar@HEAD": "a", "foo/bar@release-2.0": "b", "foo/baz@HEAD": "c"}
+	if got := zoektIndexedBranches(list); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, test := range []struct {
+		repoRev   string
+		branch    string
+		indexable bool
+	}{
+		{repoRev: "foo/bar", branch: "HEAD"},
+		{repoRev: "foo/bar@release-2.0", branch: "release-2.0", indexable: true},
+		{repoRev: "foo/bar@refs/heads/release-2.0", branch: "release-2.0", indexable: true},
+		{repoRev: "foo/bar@dev", branch: "dev"},
+		{repoRev: "foo/baz@release-2.0", branch: "release-2.0"},
+	} {
+		repoRev := makeRepositoryRevisions(test.repoRev)[0]
+		if branch := zoektBranch(repoRev); branch != test.branch {
+			t.Errorf("%s: got branch %q, want %q", test.repoRev, branch, test.branch)
+		}
+		if test.branch != "HEAD" {
+			if indexable := isIndexableBranch(repoRev.Repo.Name, repoRev.RevSpecs()[0]); indexable != test.indexable {
+				t.Errorf("%s: got indexable %v, want %v", test.repoRev, indexable, test.indexable)
+			}
+		}
+	}
+}
+
 func makeRepositoryRevisions(repos ...string) []*search.RepositoryRevisions {
 	r := make([]*search.RepositoryRevisions, len(repos))
 	for i, repospec := range repos {
@@ -0,0 +1,145 @@
+package graphqlbackend
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+)
+
+func (r *siteResolver) UpdateScheduler(ctx context.Context) (*updateSchedulerResolver, error) {
+	// 🚨 SECURITY: The update schedule reveals the names of all repositories (and their update
+	// errors), so only site admins may view it.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	status, err := repoupdater.DefaultClient.SchedulerStatus(ctx)
+	if err == repoupdater.ErrSchedulerDisabled {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updateSchedulerResolver{status: status}, nil
+}
+
+func (r *schemaResolver) BumpRepositoryUpdate(ctx context.Context, args *struct {
+	Name string
+}) (*EmptyResponse, error) {
+	// 🚨 SECURITY: Only site admins may change the update schedule.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := repoupdater.DefaultClient.SchedulerBumpRepo(ctx, api.RepoName(args.Name)); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+func (r *schemaResolver) SetUpdateSchedulerSourcePaused(ctx context.Context, args *struct {
+	Source string
+	Paused bool
+}) (*EmptyResponse, error) {
+	// 🚨 SECURITY: Only site admins may change the update schedule.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := repoupdater.DefaultClient.SchedulerPauseSource(ctx, args.Source, args.Paused); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+type updateSchedulerResolver struct {
+	status *protocol.SchedulerStatus
+}
+
+func (r *updateSchedulerResolver) Repositories(args *struct {
+	First *int32
+	Query *string
+}) []*scheduledRepositoryUpdateResolver {
+	var repos []*scheduledRepositoryUpdateResolver
+	for _, repo := range r.status.Repos {
+		if args.First != nil && len(repos) >= int(*args.First) {
+			break
+		}
+		if args.Query != nil && !strings.Contains(strings.ToLower(string(repo.Name)), strings.ToLower(*args.Query)) {
+			continue
+		}
+		repos = append(repos, &scheduledRepositoryUpdateResolver{repo: repo})
+	}
+	return repos
+}
+
+func (r *updateSchedulerResolver) Sources() []*updateSchedulerSourceResolver {
+	sources := make([]*updateSchedulerSourceResolver, len(r.status.Sources))
+	for i, source := range r.status.Sources {
+		sources[i] = &updateSchedulerSourceResolver{source: source}
+	}
+	return sources
+}
+
+type scheduledRepositoryUpdateResolver struct {
+	repo *protocol.ScheduledRepo
+}
+
+func (r *scheduledRepositoryUpdateResolver) Name() string { return string(r.repo.Name) }
+
+func (r *scheduledRepositoryUpdateResolver) Due() *string {
+	if r.repo.Due.IsZero() {
+		return nil
+	}
+	return strptr(r.repo.Due.Format(time.RFC3339))
+}
+
+func (r *scheduledRepositoryUpdateResolver) IntervalSeconds() int32 {
+	return int32(r.repo.Interval / time.Second)
+}
+
+func (r *scheduledRepositoryUpdateResolver) LastError() *string {
+	if r.repo.LastError == "" {
+		return nil
+	}
+	return &r.repo.LastError
+}
+
+func (r *scheduledRepositoryUpdateResolver) QueuePosition() *int32 {
+	if r.repo.QueuePosition == 0 {
+		return nil
+	}
+	n := int32(r.repo.QueuePosition)
+	return &n
+}
+
+func (r *scheduledRepositoryUpdateResolver) Updating() bool { return r.repo.Updating }
+
+type updateSchedulerSourceResolver struct {
+	source *protocol.SchedulerSource
+}
+
+func (r *updateSchedulerSourceResolver) Name() string { return r.source.Name }
+
+func (r *updateSchedulerSourceResolver) RepositoryCount() int32 { return int32(r.source.Repos) }
+
+func (r *updateSchedulerSourceResolver) Paused() bool { return r.source.Paused }
+
+func (r *updateSchedulerSourceResolver) LastSyncAt() *string {
+	if r.source.LastSync.IsZero() {
+		return nil
+	}
+	return strptr(r.source.LastSync.Format(time.RFC3339))
+}
+
+func (r *updateSchedulerSourceResolver) LastSyncError() *string {
+	if r.source.LastSyncError == "" {
+		return nil
+	}
+	return &r.source.LastSyncError
+}
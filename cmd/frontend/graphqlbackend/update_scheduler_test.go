@@ -0,0 +1,186 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+)
+
+func TestSiteUpdateScheduler(t *testing.T) {
+	resetMocks()
+
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{SiteAdmin: true}, nil
+	}
+
+	repoupdater.MockSchedulerStatus = func(context.Context) (*protocol.SchedulerStatus, error) {
+		return &protocol.SchedulerStatus{
+			Repos: []*protocol.ScheduledRepo{
+				{Name: "github.com/gorilla/mux", QueuePosition: 1, Due: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), Interval: time.Hour, LastError: "boom"},
+				{Name: "github.com/gorilla/schema", Updating: true},
+				{Name: "github.com/golang/go", Interval: time.Minute},
+			},
+			Sources: []*protocol.SchedulerSource{
+				{Name: "github:https://github.com#1a2b3c4d", Repos: 3, Paused: true, LastSync: time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC), LastSyncError: "rate limited"},
+			},
+		}, nil
+	}
+	defer func() { repoupdater.MockSchedulerStatus = nil }()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					site {
+						updateScheduler {
+							repositories(query: "gorilla") {
+								name
+								due
+								intervalSeconds
+								lastError
+								queuePosition
+								updating
+							}
+							sources {
+								name
+								repositoryCount
+								paused
+								lastSyncAt
+								lastSyncError
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"site": {
+						"updateScheduler": {
+							"repositories": [
+								{
+									"name": "github.com/gorilla/mux",
+									"due": "2018-01-01T00:00:00Z",
+									"intervalSeconds": 3600,
+									"lastError": "boom",
+									"queuePosition": 1,
+									"updating": false
+								},
+								{
+									"name": "github.com/gorilla/schema",
+									"due": null,
+									"intervalSeconds": 0,
+									"lastError": null,
+									"queuePosition": null,
+									"updating": true
+								}
+							],
+							"sources": [
+								{
+									"name": "github:https://github.com#1a2b3c4d",
+									"repositoryCount": 3,
+									"paused": true,
+									"lastSyncAt": "2018-01-02T00:00:00Z",
+									"lastSyncError": "rate limited"
+								}
+							]
+						}
+					}
+				}
+			`,
+		},
+	})
+}
+
+func TestUpdateSchedulerMutations(t *testing.T) {
+	resetMocks()
+
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{SiteAdmin: true}, nil
+	}
+
+	var bumped []api.RepoName
+	repoupdater.MockSchedulerBumpRepo = func(ctx context.Context, repo api.RepoName) error {
+		bumped = append(bumped, repo)
+		return nil
+	}
+	defer func() { repoupdater.MockSchedulerBumpRepo = nil }()
+
+	paused := map[string]bool{}
+	repoupdater.MockSchedulerPauseSource = func(ctx context.Context, source string, p bool) error {
+		paused[source] = p
+		return nil
+	}
+	defer func() { repoupdater.MockSchedulerPauseSource = nil }()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				mutation {
+					bumpRepositoryUpdate(name: "github.com/gorilla/mux") {
+						alwaysNil
+					}
+					setUpdateSchedulerSourcePaused(source: "github:https://github.com#1a2b3c4d", paused: true) {
+						alwaysNil
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"bumpRepositoryUpdate": {
+						"alwaysNil": null
+					},
+					"setUpdateSchedulerSourcePaused": {
+						"alwaysNil": null
+					}
+				}
+			`,
+		},
+	})
+
+	if want := []api.RepoName{"github.com/gorilla/mux"}; !reflect.DeepEqual(bumped, want) {
+		t.Errorf("got bumped repos %q, want %q", bumped, want)
+	}
+	if want := map[string]bool{"github:https://github.com#1a2b3c4d": true}; !reflect.DeepEqual(paused, want) {
+		t.Errorf("got paused sources %v, want %v", paused, want)
+	}
+}
+
+func TestUpdateSchedulerMutations_nonSiteAdmin(t *testing.T) {
+	resetMocks()
+
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{}, nil
+	}
+	repoupdater.MockSchedulerBumpRepo = func(ctx context.Context, repo api.RepoName) error {
+		t.Error("non-site-admin bumped a repository")
+		return nil
+	}
+	defer func() { repoupdater.MockSchedulerBumpRepo = nil }()
+	repoupdater.MockSchedulerPauseSource = func(ctx context.Context, source string, p bool) error {
+		t.Error("non-site-admin paused a source")
+		return nil
+	}
+	defer func() { repoupdater.MockSchedulerPauseSource = nil }()
+
+	ctx := context.Background()
+	if _, err := (&schemaResolver{}).BumpRepositoryUpdate(ctx, &struct{ Name string }{Name: "github.com/gorilla/mux"}); err != backend.ErrMustBeSiteAdmin {
+		t.Errorf("got error %v, want %v", err, backend.ErrMustBeSiteAdmin)
+	}
+	if _, err := (&schemaResolver{}).SetUpdateSchedulerSourcePaused(ctx, &struct {
+		Source string
+		Paused bool
+	}{Source: "github:https://github.com#1a2b3c4d", Paused: true}); err != backend.ErrMustBeSiteAdmin {
+		t.Errorf("got error %v, want %v", err, backend.ErrMustBeSiteAdmin)
+	}
+}
@@ -44,6 +44,7 @@ func TestAllowAnonymousRequest(t *testing.T) {
 		{req: req("GET", "/doesnt/exist"), want: false},
 		{req: req("POST", "/doesnt/exist"), want: false},
 		{req: req("POST", "/.api/telemetry/log/v1/production"), want: true},
+		{req: req("POST", "/.api/webhooks/github"), want: true},
 	}
 	for _, test := range tests {
 		t.Run(fmt.Sprintf("%s %s", test.req.Method, test.req.URL), func(t *testing.T) {
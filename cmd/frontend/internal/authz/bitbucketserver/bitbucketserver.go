@@ -0,0 +1,338 @@
+// Package bitbucketserver contains an authorization provider for Bitbucket Server.
+package bitbucketserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/auth"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+type pcache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, b []byte)
+	Delete(key string)
+}
+
+// Identity providers, i.e., the ways in which Sourcegraph users are mapped to Bitbucket Server
+// users. See the docstring of BitbucketServerAuthzProviderOp.IdentityProvider.
+const (
+	IdentityProviderUsername        = "username"
+	IdentityProviderExternalAccount = "externalAccount"
+)
+
+// BitbucketServerAuthzProvider is an implementation of AuthzProvider that provides repository
+// permissions as determined from a Bitbucket Server instance API. It impersonates users (through
+// an application link with OAuth) to list the repositories they can read. For documentation of
+// specific fields, see the docstrings of BitbucketServerAuthzProviderOp.
+type BitbucketServerAuthzProvider struct {
+	client           *bitbucketserver.Client // authenticated with the connection's credentials
+	oauthClient      *bitbucketserver.Client // authenticated with the application link
+	codeHost         *bitbucketserver.CodeHost
+	identityProvider string
+	authnConfigID    auth.ProviderConfigID
+	cache            pcache
+	cacheTTL         time.Duration
+}
+
+var _ authz.Provider = ((*BitbucketServerAuthzProvider)(nil))
+
+type cacheVal struct {
+	// Repos is the set of repositories (by external repository ID, "PROJECTKEY/repo-slug") that
+	// a Bitbucket Server user can read.
+	Repos map[string]struct{} `json:"repos"`
+
+	// TTL is the ttl of the cache entry. This must be checked for equality in case the TTL has
+	// changed (and the cache entry should therefore be invalidated).
+	TTL time.Duration `json:"ttl"`
+}
+
+type BitbucketServerAuthzProviderOp struct {
+	// BaseURL is the URL of the Bitbucket Server instance.
+	BaseURL *url.URL
+
+	// Token, or Username and Password, are the credentials of the Bitbucket Server connection.
+	// They are used to determine which repositories are public, for users who can't be mapped to
+	// a Bitbucket Server user.
+	//
+	// 🚨 SECURITY: These values contain secret information that must not be shown to
+	// non-site-admins.
+	Token              string
+	Username, Password string
+
+	// OAuth is the OAuth consumer of the incoming application link that is used to impersonate
+	// Bitbucket Server users. The application link must allow user impersonation.
+	//
+	// 🚨 SECURITY: This value contains secret information that must not be shown to non-site-admins.
+	OAuth *bitbucketserver.OAuth
+
+	// IdentityProvider is how Sourcegraph users are mapped to Bitbucket Server users. If it is
+	// IdentityProviderUsername, a Sourcegraph user is the Bitbucket Server user with the same
+	// username. If it is IdentityProviderExternalAccount, a Sourcegraph user is the Bitbucket
+	// Server user whose username is the account ID of the user's external account from the authn
+	// provider identified by AuthnConfigID.
+	IdentityProvider string
+
+	// AuthnConfigID identifies the authn provider whose external accounts identify users, if
+	// IdentityProvider is IdentityProviderExternalAccount.
+	AuthnConfigID auth.ProviderConfigID
+
+	// CacheTTL is the TTL of cached permissions lists from the Bitbucket Server API.
+	CacheTTL time.Duration
+
+	// MockCache, if non-nil, replaces the default Redis-based cache with the supplied cache mock.
+	// Should only be used in tests.
+	MockCache pcache
+}
+
+func NewProvider(op BitbucketServerAuthzProviderOp) *BitbucketServerAuthzProvider {
+	baseURL := *op.BaseURL
+	extsvc.NormalizeBaseURL(&baseURL)
+	httpClient := &http.Client{Transport: bitbucketserver.WithRequestCounter(http.DefaultTransport)}
+	p := &BitbucketServerAuthzProvider{
+		client: &bitbucketserver.Client{
+			URL:        &baseURL,
+			Token:      op.Token,
+			Username:   op.Username,
+			Password:   op.Password,
+			HTTPClient: httpClient,
+		},
+		oauthClient: &bitbucketserver.Client{
+			URL:        &baseURL,
+			OAuth:      op.OAuth,
+			HTTPClient: httpClient,
+		},
+		codeHost:         bitbucketserver.NewCodeHost(&baseURL),
+		identityProvider: op.IdentityProvider,
+		authnConfigID:    op.AuthnConfigID,
+		cache:            op.MockCache,
+		cacheTTL:         op.CacheTTL,
+	}
+	if p.cache == nil {
+		p.cache = rcache.NewWithTTL(fmt.Sprintf("bitbucketServerAuthz:%s", op.BaseURL.String()), int(math.Ceil(op.CacheTTL.Seconds())))
+	}
+	return p
+}
+
+func (p *BitbucketServerAuthzProvider) Validate() (problems []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, c := range []struct {
+		client *bitbucketserver.Client
+		desc   string
+	}{
+		{p.client, "connection credentials"},
+		{p.oauthClient, "application link OAuth consumer"},
+	} {
+		if _, _, err := c.client.Repos(ctx, &bitbucketserver.PageToken{Limit: 1}); err != nil {
+			if err == ctx.Err() {
+				problems = append(problems, fmt.Sprintf("Bitbucket Server API did not respond within 5s (%s)", err.Error()))
+				break
+			}
+			problems = append(problems, fmt.Sprintf("could not list repositories with the %s (%s)", c.desc, err.Error()))
+		}
+	}
+	return problems
+}
+
+func (p *BitbucketServerAuthzProvider) ServiceID() string {
+	return p.codeHost.ServiceID()
+}
+
+func (p *BitbucketServerAuthzProvider) ServiceType() string {
+	return p.codeHost.ServiceType()
+}
+
+func (p *BitbucketServerAuthzProvider) CacheTTL() time.Duration {
+	return p.cacheTTL
+}
+
+func (p *BitbucketServerAuthzProvider) RepoPerms(ctx context.Context, account *extsvc.ExternalAccount, repos map[authz.Repo]struct{}) (map[api.RepoName]map[authz.Perm]bool, error) {
+	accountID := "" // empty means public / unauthenticated to the code host
+	if account != nil && account.ServiceID == p.codeHost.ServiceID() && account.ServiceType == p.codeHost.ServiceType() {
+		accountID = account.AccountID
+	}
+
+	myRepos, _ := p.Repos(ctx, repos)
+	var accessibleRepos map[string]struct{}
+	if r, exists := p.getCachedAccessList(accountID); exists {
+		accessibleRepos = r
+	} else {
+		var err error
+		accessibleRepos, err = p.fetchUserAccessList(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+
+		accessibleReposB, err := json.Marshal(cacheVal{
+			Repos: accessibleRepos,
+			TTL:   p.cacheTTL,
+		})
+		if err != nil {
+			return nil, err
+		}
+		p.cache.Set(accountID, accessibleReposB)
+	}
+
+	perms := make(map[api.RepoName]map[authz.Perm]bool)
+	for repo := range myRepos {
+		perms[repo.RepoName] = map[authz.Perm]bool{}
+		if _, isAccessible := accessibleRepos[repo.ExternalRepoSpec.ID]; isAccessible {
+			perms[repo.RepoName][authz.Read] = true
+		}
+	}
+	return perms, nil
+}
+
+func (p *BitbucketServerAuthzProvider) Repos(ctx context.Context, repos map[authz.Repo]struct{}) (mine map[authz.Repo]struct{}, others map[authz.Repo]struct{}) {
+	return authz.GetCodeHostRepos(p.codeHost, repos)
+}
+
+func (p *BitbucketServerAuthzProvider) FetchAccount(ctx context.Context, user *types.User, current []*extsvc.ExternalAccount) (mine *extsvc.ExternalAccount, err error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	var username string
+	switch p.identityProvider {
+	case IdentityProviderUsername:
+		username = user.Username
+	case IdentityProviderExternalAccount:
+		// resolve the Bitbucket Server username using the authn provider (specified by p.authnConfigID)
+		authnProvider := getProviderByConfigID(p.authnConfigID)
+		if authnProvider == nil {
+			return nil, nil
+		}
+		for _, acct := range current {
+			if acct.ServiceID == authnProvider.CachedInfo().ServiceID && acct.ServiceType == authnProvider.ConfigID().Type {
+				username = acct.AccountID
+				break
+			}
+		}
+	}
+	if username == "" {
+		return nil, nil
+	}
+
+	bbUser, err := p.fetchUserByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if bbUser == nil {
+		return nil, nil
+	}
+
+	jsonBBUser, err := json.Marshal(bbUser)
+	if err != nil {
+		return nil, err
+	}
+	accountData := json.RawMessage(jsonBBUser)
+	return &extsvc.ExternalAccount{
+		UserID: user.ID,
+		ExternalAccountSpec: extsvc.ExternalAccountSpec{
+			ServiceType: p.codeHost.ServiceType(),
+			ServiceID:   p.codeHost.ServiceID(),
+			AccountID:   bbUser.Name,
+		},
+		ExternalAccountData: extsvc.ExternalAccountData{
+			AccountData: &accountData,
+		},
+	}, nil
+}
+
+// fetchUserByUsername returns the active Bitbucket Server user with the given username (compared
+// case-insensitively, like Bitbucket Server does), or nil if there is none.
+func (p *BitbucketServerAuthzProvider) fetchUserByUsername(ctx context.Context, username string) (*bitbucketserver.User, error) {
+	page := &bitbucketserver.PageToken{Limit: 100}
+	for page.HasMore() {
+		users, next, err := p.client.Users(ctx, username, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			if strings.EqualFold(u.Name, username) && u.Active {
+				return u, nil
+			}
+		}
+		page = next
+	}
+	return nil, nil
+}
+
+// getCachedAccessList returns the list of repositories accessible to a user from the cache and
+// whether the cache entry exists.
+func (p *BitbucketServerAuthzProvider) getCachedAccessList(accountID string) (map[string]struct{}, bool) {
+	cachedReposB, exists := p.cache.Get(accountID)
+	if !exists {
+		return nil, false
+	}
+	var r cacheVal
+	if err := json.Unmarshal(cachedReposB, &r); err != nil || r.TTL == 0 || r.TTL > p.cacheTTL {
+		if err != nil {
+			log15.Warn("Failed to unmarshal repo perm cache entry", "err", err.Error())
+		}
+		p.cache.Delete(accountID)
+		return nil, false
+	}
+	return r.Repos, true
+}
+
+// fetchUserAccessList fetches the list of repositories that are readable to a user from the
+// Bitbucket Server API. If username is empty, only public repositories are readable.
+func (p *BitbucketServerAuthzProvider) fetchUserAccessList(ctx context.Context, username string) (map[string]struct{}, error) {
+	client := p.client
+	if username != "" {
+		var err error
+		if client, err = p.oauthClient.Sudo(username); err != nil {
+			return nil, err
+		}
+	}
+
+	repoIDs := make(map[string]struct{})
+	var iters = 0
+	page := &bitbucketserver.PageToken{Limit: 1000}
+	for page.HasMore() {
+		if iters >= 100 && iters%100 == 0 {
+			log15.Warn("Excessively many Bitbucket Server API requests to fetch complete user authz list", "iters", iters, "username", username, "host", p.codeHost.ServiceID())
+		}
+
+		repos, next, err := client.Repos(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range repos {
+			if username == "" && !repo.Public && (repo.Project == nil || !repo.Project.Public) {
+				continue
+			}
+			repoIDs[externalRepoID(repo)] = struct{}{}
+		}
+		page = next
+		iters++
+	}
+	return repoIDs, nil
+}
+
+// externalRepoID returns the external repository ID of a Bitbucket Server repository, which
+// matches the ID that repo-updater assigns.
+func externalRepoID(repo *bitbucketserver.Repo) string {
+	project := "UNKNOWN"
+	if repo.Project != nil {
+		project = repo.Project.Key
+	}
+	return project + "/" + repo.Slug
+}
+
+var getProviderByConfigID = auth.GetProviderByConfigID
@@ -0,0 +1,421 @@
+package bitbucketserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/auth"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func Test_BitbucketServer_RepoPerms(t *testing.T) {
+	bb := newMockBitbucketServer(t)
+	defer bb.Close()
+
+	p := newTestProvider(t, bb, IdentityProviderUsername, auth.ProviderConfigID{})
+	repos := map[authz.Repo]struct{}{
+		repo("bitbucket.mine/PUB/public", "PUB/public", bb.URL+"/"):               {},
+		repo("bitbucket.mine/OPEN/a", "OPEN/a", bb.URL+"/"):                       {},
+		repo("bitbucket.mine/PRIV/alice", "PRIV/alice", bb.URL+"/"):               {},
+		repo("bitbucket.mine/PRIV/shared", "PRIV/shared", bb.URL+"/"):             {},
+		repo("github.com/foo/bar", "MDEwOlJlcG9zaXRvcnkx", "https://github.com/"): {},
+	}
+
+	tests := []struct {
+		description string
+		account     *extsvc.ExternalAccount
+		expPerms    map[api.RepoName]map[authz.Perm]bool
+	}{
+		{
+			description: "no account sees public repos",
+			account:     nil,
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"bitbucket.mine/PUB/public":  {authz.Read: true},
+				"bitbucket.mine/OPEN/a":      {authz.Read: true},
+				"bitbucket.mine/PRIV/alice":  {},
+				"bitbucket.mine/PRIV/shared": {},
+			},
+		},
+		{
+			description: "account of another code host sees public repos",
+			account:     acct(1, "github", "https://github.com/", "alice"),
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"bitbucket.mine/PUB/public":  {authz.Read: true},
+				"bitbucket.mine/OPEN/a":      {authz.Read: true},
+				"bitbucket.mine/PRIV/alice":  {},
+				"bitbucket.mine/PRIV/shared": {},
+			},
+		},
+		{
+			description: "alice sees her repos",
+			account:     acct(1, bitbucketserver.ServiceType, bb.URL+"/", "alice"),
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"bitbucket.mine/PUB/public":  {authz.Read: true},
+				"bitbucket.mine/OPEN/a":      {authz.Read: true},
+				"bitbucket.mine/PRIV/alice":  {authz.Read: true},
+				"bitbucket.mine/PRIV/shared": {authz.Read: true},
+			},
+		},
+		{
+			description: "bob sees shared repos",
+			account:     acct(2, bitbucketserver.ServiceType, bb.URL+"/", "bob"),
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"bitbucket.mine/PUB/public":  {authz.Read: true},
+				"bitbucket.mine/OPEN/a":      {authz.Read: true},
+				"bitbucket.mine/PRIV/alice":  {},
+				"bitbucket.mine/PRIV/shared": {authz.Read: true},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			perms, err := p.RepoPerms(context.Background(), test.account, repos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(perms, test.expPerms) {
+				t.Errorf("got perms %s, want %s", asJSON(t, perms), asJSON(t, test.expPerms))
+			}
+		})
+	}
+}
+
+func Test_BitbucketServer_RepoPerms_cache(t *testing.T) {
+	bb := newMockBitbucketServer(t)
+	defer bb.Close()
+
+	p := newTestProvider(t, bb, IdentityProviderUsername, auth.ProviderConfigID{})
+	repos := map[authz.Repo]struct{}{
+		repo("bitbucket.mine/PRIV/alice", "PRIV/alice", bb.URL+"/"): {},
+	}
+	alice := acct(1, bitbucketserver.ServiceType, bb.URL+"/", "alice")
+
+	for i := 0; i < 2; i++ {
+		if _, err := p.RepoPerms(context.Background(), alice, repos); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := map[string]int{"alice": 1}; !reflect.DeepEqual(bb.repoListings, want) {
+		t.Errorf("got repo listings %v, want %v", bb.repoListings, want)
+	}
+
+	// A cache entry with a TTL greater than the provider's is invalidated.
+	p.cacheTTL = 30 * time.Minute
+	if _, err := p.RepoPerms(context.Background(), alice, repos); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"alice": 2}; !reflect.DeepEqual(bb.repoListings, want) {
+		t.Errorf("got repo listings %v, want %v", bb.repoListings, want)
+	}
+}
+
+func Test_BitbucketServer_FetchAccount(t *testing.T) {
+	bb := newMockBitbucketServer(t)
+	defer bb.Close()
+
+	auth.MockProviders = []auth.Provider{
+		mockAuthnProvider{
+			configID:  auth.ProviderConfigID{ID: "okta.mine", Type: "saml"},
+			serviceID: "https://okta.mine/",
+		},
+	}
+	defer func() { auth.MockProviders = nil }()
+
+	tests := []struct {
+		description      string
+		identityProvider string
+		authnConfigID    auth.ProviderConfigID
+		user             *types.User
+		current          []*extsvc.ExternalAccount
+		expMine          *extsvc.ExternalAccount
+	}{
+		{
+			description:      "username matches",
+			identityProvider: IdentityProviderUsername,
+			user:             &types.User{ID: 123, Username: "alice"},
+			expMine:          acct(123, bitbucketserver.ServiceType, bb.URL+"/", "alice"),
+		},
+		{
+			description:      "username matches case-insensitively",
+			identityProvider: IdentityProviderUsername,
+			user:             &types.User{ID: 123, Username: "Bob"},
+			expMine:          acct(123, bitbucketserver.ServiceType, bb.URL+"/", "bob"),
+		},
+		{
+			description:      "username is only a prefix of a Bitbucket Server username",
+			identityProvider: IdentityProviderUsername,
+			user:             &types.User{ID: 123, Username: "ali"},
+			expMine:          nil,
+		},
+		{
+			description:      "username of inactive user",
+			identityProvider: IdentityProviderUsername,
+			user:             &types.User{ID: 123, Username: "carol"},
+			expMine:          nil,
+		},
+		{
+			description:      "external account matches",
+			identityProvider: IdentityProviderExternalAccount,
+			authnConfigID:    auth.ProviderConfigID{ID: "okta.mine", Type: "saml"},
+			user:             &types.User{ID: 123, Username: "alice.smith"},
+			current: []*extsvc.ExternalAccount{
+				acct(1, "saml", "nomatch", "bob"),
+				acct(1, "saml", "https://okta.mine/", "alice"),
+			},
+			expMine: acct(123, bitbucketserver.ServiceType, bb.URL+"/", "alice"),
+		},
+		{
+			description:      "no external account of the authn provider",
+			identityProvider: IdentityProviderExternalAccount,
+			authnConfigID:    auth.ProviderConfigID{ID: "okta.mine", Type: "saml"},
+			user:             &types.User{ID: 123, Username: "alice"},
+			current:          []*extsvc.ExternalAccount{acct(1, "nomatch", "https://okta.mine/", "alice")},
+			expMine:          nil,
+		},
+		{
+			description:      "authn provider does not exist",
+			identityProvider: IdentityProviderExternalAccount,
+			authnConfigID:    auth.ProviderConfigID{ID: "nomatch", Type: "saml"},
+			user:             &types.User{ID: 123, Username: "alice"},
+			current:          []*extsvc.ExternalAccount{acct(1, "saml", "https://okta.mine/", "alice")},
+			expMine:          nil,
+		},
+		{
+			description:      "no user",
+			identityProvider: IdentityProviderUsername,
+			user:             nil,
+			expMine:          nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			p := newTestProvider(t, bb, test.identityProvider, test.authnConfigID)
+			mine, err := p.FetchAccount(context.Background(), test.user, test.current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mine != nil {
+				mine.AccountData = nil
+			}
+			if !reflect.DeepEqual(mine, test.expMine) {
+				t.Errorf("got account %s, want %s", asJSON(t, mine), asJSON(t, test.expMine))
+			}
+		})
+	}
+}
+
+func Test_BitbucketServer_Repos(t *testing.T) {
+	p := NewProvider(BitbucketServerAuthzProviderOp{
+		BaseURL:   mustURL(t, "https://bitbucket.mine/"),
+		CacheTTL:  time.Hour,
+		MockCache: make(mockCache),
+	})
+	repos := map[authz.Repo]struct{}{
+		repo("bitbucket.mine/PUB/public", "PUB/public", "https://bitbucket.mine/"): {},
+		repo("other.mine/PUB/public", "PUB/public", "https://other.mine/"):         {},
+		repo("github.com/foo/bar", "MDEwOlJlcG9zaXRvcnkx", "https://github.com/"):  {},
+	}
+	mine, others := p.Repos(context.Background(), repos)
+	if want := map[authz.Repo]struct{}{
+		repo("bitbucket.mine/PUB/public", "PUB/public", "https://bitbucket.mine/"): {},
+	}; !reflect.DeepEqual(mine, want) {
+		t.Errorf("got mine %v, want %v", mine, want)
+	}
+	if want := map[authz.Repo]struct{}{
+		repo("other.mine/PUB/public", "PUB/public", "https://other.mine/"):        {},
+		repo("github.com/foo/bar", "MDEwOlJlcG9zaXRvcnkx", "https://github.com/"): {},
+	}; !reflect.DeepEqual(others, want) {
+		t.Errorf("got others %v, want %v", others, want)
+	}
+}
+
+// mockBitbucketServer is a Bitbucket Server API that serves the repositories readable by the
+// connection's token or by the user impersonated through OAuth, and the users.
+type mockBitbucketServer struct {
+	*httptest.Server
+
+	// repoListings counts the listings of repositories (not the requests for each page) by
+	// username ("" for the token).
+	repoListings map[string]int
+}
+
+var (
+	mockRepos = []*bitbucketserver.Repo{
+		{Slug: "public", Public: true, Project: &bitbucketserver.Project{Key: "PUB"}},
+		{Slug: "a", Project: &bitbucketserver.Project{Key: "OPEN", Public: true}},
+		{Slug: "alice", Project: &bitbucketserver.Project{Key: "PRIV"}},
+		{Slug: "shared", Project: &bitbucketserver.Project{Key: "PRIV"}},
+	}
+	mockReadable = map[string][]string{ // username -> readable private repos
+		"":      {"PRIV/alice", "PRIV/shared"}, // the connection's token can read all repos
+		"alice": {"PRIV/alice", "PRIV/shared"},
+		"bob":   {"PRIV/shared"},
+	}
+	mockUsers = []*bitbucketserver.User{
+		{Name: "alice", Slug: "alice", ID: 1, Active: true},
+		{Name: "alice2", Slug: "alice2", ID: 2, Active: true},
+		{Name: "bob", Slug: "bob", ID: 3, Active: true},
+		{Name: "carol", Slug: "carol", ID: 4, Active: false},
+	}
+)
+
+func newMockBitbucketServer(t *testing.T) *mockBitbucketServer {
+	m := &mockBitbucketServer{repoListings: map[string]int{}}
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authz := r.Header.Get("Authorization")
+		username := r.URL.Query().Get("user_id")
+		switch {
+		case strings.HasPrefix(authz, "OAuth ") && strings.Contains(authz, `oauth_consumer_key="sourcegraph"`):
+		case authz == "Bearer t0k3n" && username == "":
+		default:
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		var values []interface{}
+		switch r.URL.Path {
+		case "/rest/api/1.0/repos":
+			if start == 0 {
+				m.repoListings[username]++
+			}
+			readable := map[string]bool{}
+			for _, id := range mockReadable[username] {
+				readable[id] = true
+			}
+			for _, repo := range mockRepos {
+				if repo.Public || repo.Project.Public || readable[externalRepoID(repo)] {
+					values = append(values, repo)
+				}
+			}
+		case "/rest/api/1.0/users":
+			filter := r.URL.Query().Get("filter")
+			for _, u := range mockUsers {
+				if strings.HasPrefix(u.Name, strings.ToLower(filter)) {
+					values = append(values, u)
+				}
+			}
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		// Serve one value per page to exercise pagination.
+		page := map[string]interface{}{"start": start, "limit": 1, "isLastPage": true}
+		if start < len(values) {
+			page["values"] = values[start : start+1]
+			page["size"] = 1
+			if start+1 < len(values) {
+				page["isLastPage"] = false
+				page["nextPageStart"] = start + 1
+			}
+		}
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			t.Error(err)
+		}
+	}))
+	return m
+}
+
+func newTestProvider(t *testing.T, bb *mockBitbucketServer, identityProvider string, authnConfigID auth.ProviderConfigID) *BitbucketServerAuthzProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewProvider(BitbucketServerAuthzProviderOp{
+		BaseURL:          mustURL(t, bb.URL),
+		Token:            "t0k3n",
+		OAuth:            &bitbucketserver.OAuth{ConsumerKey: "sourcegraph", SigningKey: key},
+		IdentityProvider: identityProvider,
+		AuthnConfigID:    authnConfigID,
+		CacheTTL:         time.Hour,
+		MockCache:        make(mockCache),
+	})
+}
+
+type mockCache map[string]string
+
+func (m mockCache) Get(key string) ([]byte, bool) {
+	v, ok := m[key]
+	return []byte(v), ok
+}
+func (m mockCache) Set(key string, b []byte) {
+	m[key] = string(b)
+}
+func (m mockCache) Delete(key string) {
+	delete(m, key)
+}
+
+type mockAuthnProvider struct {
+	configID  auth.ProviderConfigID
+	serviceID string
+}
+
+func (m mockAuthnProvider) ConfigID() auth.ProviderConfigID {
+	return m.configID
+}
+
+func (m mockAuthnProvider) Config() schema.AuthProviders {
+	panic("should not be called")
+}
+
+func (m mockAuthnProvider) CachedInfo() *auth.ProviderInfo {
+	return &auth.ProviderInfo{ServiceID: m.serviceID}
+}
+
+func (m mockAuthnProvider) Refresh(ctx context.Context) error {
+	panic("should not be called")
+}
+
+func acct(userID int32, serviceType, serviceID, accountID string) *extsvc.ExternalAccount {
+	return &extsvc.ExternalAccount{
+		UserID: userID,
+		ExternalAccountSpec: extsvc.ExternalAccountSpec{
+			ServiceType: serviceType,
+			ServiceID:   serviceID,
+			AccountID:   accountID,
+		},
+	}
+}
+
+func repo(name, id, serviceID string) authz.Repo {
+	return authz.Repo{
+		RepoName: api.RepoName(name),
+		ExternalRepoSpec: api.ExternalRepoSpec{
+			ID:          id,
+			ServiceType: bitbucketserver.ServiceType,
+			ServiceID:   serviceID,
+		},
+	}
+}
+
+func mustURL(t *testing.T, u string) *url.URL {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func asJSON(t *testing.T, v interface{}) string {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
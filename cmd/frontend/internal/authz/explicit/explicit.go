@@ -0,0 +1,202 @@
+// Package explicit contains an authorization provider for the repository permissions that site
+// admins grant explicitly on Sourcegraph (instead of deriving them from a code host).
+package explicit
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// ServiceType and ServiceID identify the accounts of the explicit permissions provider. Such an
+// account is the Sourcegraph user itself (its AccountID is the user ID), so it is computed on demand
+// and never stored as an external account of the user.
+const (
+	ServiceType = "sourcegraph"
+	ServiceID   = "explicit"
+)
+
+// Store is the storage of explicit repository permissions.
+type Store interface {
+	// Patterns returns the repository patterns of all permissions.
+	Patterns(ctx context.Context) ([]string, error)
+
+	// ListForUser returns the permissions that are granted to the user, directly or through the
+	// user's organizations.
+	ListForUser(ctx context.Context, userID int32) ([]*types.RepoPermission, error)
+}
+
+// Provider is an implementation of authz.Provider that provides the repository permissions that are
+// granted explicitly (by site admins) to users and organizations.
+//
+// It is the source of permissions for the repositories whose names match the pattern of any
+// permission, and it must be registered after all code host authz providers, so that it only claims
+// repositories that have no code host permissions (such as repositories from `repos.list` or
+// Gitolite). On those repositories, users only have the permissions that were granted to them. It is
+// only registered if explicit permissions are enabled in the site config.
+type Provider struct {
+	store Store
+}
+
+var _ authz.Provider = ((*Provider)(nil))
+
+func NewProvider(store Store) *Provider {
+	return &Provider{store: store}
+}
+
+// CompilePattern compiles a repository pattern of an explicit permission. Patterns are regular
+// expressions that are matched case-insensitively against repository names (like repository names
+// on Sourcegraph). They must match the whole name, so that a pattern such as
+// "github.com/myorg/docs" doesn't also grant access to "github.com/myorg/docs-internal".
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, errors.New("empty repository pattern")
+	}
+	re, err := regexp.Compile("(?i)^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid repository pattern %q", pattern)
+	}
+	return re, nil
+}
+
+var (
+	compiledMu sync.Mutex
+	compiled   = map[string]*regexp.Regexp{}
+)
+
+// maxCompiled is the maximum number of compiled patterns that are cached.
+const maxCompiled = 1000
+
+// compileCached is like CompilePattern, but it caches the compiled patterns, because the patterns
+// of all permissions are matched whenever repositories are accessed.
+func compileCached(pattern string) (*regexp.Regexp, error) {
+	compiledMu.Lock()
+	re, ok := compiled[pattern]
+	compiledMu.Unlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := CompilePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	compiledMu.Lock()
+	if len(compiled) >= maxCompiled {
+		compiled = map[string]*regexp.Regexp{} // patterns of revoked permissions are never used again
+	}
+	compiled[pattern] = re
+	compiledMu.Unlock()
+	return re, nil
+}
+
+func (p *Provider) Repos(ctx context.Context, repos map[authz.Repo]struct{}) (mine map[authz.Repo]struct{}, others map[authz.Repo]struct{}) {
+	mine, others = make(map[authz.Repo]struct{}), make(map[authz.Repo]struct{})
+
+	patterns, err := p.store.Patterns(ctx)
+	if err != nil {
+		// 🚨 SECURITY: Claim all repositories, so that permissions are not granted by default. The
+		// error will recur when their permissions are computed.
+		log15.Error("Unable to list explicit repository permissions.", "error", err)
+		return repos, others
+	}
+	var res []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := compileCached(pattern)
+		if err != nil {
+			log15.Warn("Ignoring invalid explicit repository permission.", "error", err)
+			continue
+		}
+		res = append(res, re)
+	}
+
+	for repo := range repos {
+		if matchesAny(res, string(repo.RepoName)) {
+			mine[repo] = struct{}{}
+		} else {
+			others[repo] = struct{}{}
+		}
+	}
+	return mine, others
+}
+
+func (p *Provider) RepoPerms(ctx context.Context, account *extsvc.ExternalAccount, repos map[authz.Repo]struct{}) (map[api.RepoName]map[authz.Perm]bool, error) {
+	var userID int32 // zero means unauthenticated, who are granted no permissions
+	if account != nil && account.ServiceType == ServiceType && account.ServiceID == ServiceID {
+		userID = account.UserID
+	}
+
+	// The permissions that the user has on the repositories, by pattern.
+	granted := make(map[authz.Perm][]*regexp.Regexp)
+	if userID != 0 && len(repos) > 0 {
+		rps, err := p.store.ListForUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, rp := range rps {
+			re, err := compileCached(rp.RepoPattern)
+			if err != nil {
+				log15.Warn("Ignoring invalid explicit repository permission.", "id", rp.ID, "error", err)
+				continue
+			}
+			perm := authz.Perm(rp.Permission)
+			granted[perm] = append(granted[perm], re)
+		}
+	}
+
+	perms := make(map[api.RepoName]map[authz.Perm]bool, len(repos))
+	for repo := range repos {
+		perms[repo.RepoName] = map[authz.Perm]bool{}
+		for perm, res := range granted {
+			if matchesAny(res, string(repo.RepoName)) {
+				perms[repo.RepoName][perm] = true
+			}
+		}
+	}
+	return perms, nil
+}
+
+// FetchAccount returns the account that identifies the user to this provider, which is the user
+// itself.
+func (p *Provider) FetchAccount(ctx context.Context, user *types.User, current []*extsvc.ExternalAccount) (mine *extsvc.ExternalAccount, err error) {
+	if user == nil {
+		return nil, nil
+	}
+	return &extsvc.ExternalAccount{
+		UserID: user.ID,
+		ExternalAccountSpec: extsvc.ExternalAccountSpec{
+			ServiceType: ServiceType,
+			ServiceID:   ServiceID,
+			AccountID:   strconv.FormatInt(int64(user.ID), 10),
+		},
+	}, nil
+}
+
+func (p *Provider) ServiceType() string {
+	return ServiceType
+}
+
+func (p *Provider) ServiceID() string {
+	return ServiceID
+}
+
+func (p *Provider) Validate() (problems []string) {
+	return nil
+}
+
+func matchesAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
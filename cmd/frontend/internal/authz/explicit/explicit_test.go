@@ -0,0 +1,204 @@
+package explicit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+)
+
+type mockStore struct {
+	perms []*types.RepoPermission
+	orgs  map[int32][]int32 // user ID -> org IDs
+	err   error
+}
+
+func (s *mockStore) Patterns(ctx context.Context) ([]string, error) {
+	var patterns []string
+	for _, p := range s.perms {
+		patterns = append(patterns, p.RepoPattern)
+	}
+	return patterns, s.err
+}
+
+func (s *mockStore) ListForUser(ctx context.Context, userID int32) ([]*types.RepoPermission, error) {
+	var perms []*types.RepoPermission
+	for _, p := range s.perms {
+		if p.UserID == userID {
+			perms = append(perms, p)
+			continue
+		}
+		for _, orgID := range s.orgs[userID] {
+			if p.OrgID == orgID {
+				perms = append(perms, p)
+			}
+		}
+	}
+	return perms, s.err
+}
+
+func Test_Explicit_Repos(t *testing.T) {
+	p := NewProvider(&mockStore{perms: []*types.RepoPermission{
+		{ID: 1, UserID: 1, RepoPattern: "gitolite.mine/secret/.*", Permission: "read"},
+		{ID: 2, OrgID: 1, RepoPattern: ".*/Shared", Permission: "read"},
+		{ID: 3, OrgID: 1, RepoPattern: "(", Permission: "read"}, // invalid, ignored
+	}})
+	repos := map[authz.Repo]struct{}{
+		repo("gitolite.mine/secret/a"): {},
+		repo("gitolite.mine/shared"):   {},
+		repo("gitolite.mine/public"):   {},
+		repo("GITOLITE.mine/Secret/b"): {},
+		repo("gitolite.mine/shared-2"): {},
+	}
+	mine, others := p.Repos(context.Background(), repos)
+	if want := map[authz.Repo]struct{}{
+		repo("gitolite.mine/secret/a"): {},
+		repo("gitolite.mine/shared"):   {},
+		repo("GITOLITE.mine/Secret/b"): {},
+	}; !reflect.DeepEqual(mine, want) {
+		t.Errorf("got mine %v, want %v", mine, want)
+	}
+	if want := map[authz.Repo]struct{}{
+		repo("gitolite.mine/public"):   {},
+		repo("gitolite.mine/shared-2"): {}, // patterns match whole names
+	}; !reflect.DeepEqual(others, want) {
+		t.Errorf("got others %v, want %v", others, want)
+	}
+
+	// All repositories are claimed when the permissions can't be listed, so that access is not
+	// granted by default.
+	p = NewProvider(&mockStore{err: errors.New("x")})
+	mine, others = p.Repos(context.Background(), repos)
+	if !reflect.DeepEqual(mine, repos) || len(others) != 0 {
+		t.Errorf("got mine %v and others %v, want all repos to be mine", mine, others)
+	}
+}
+
+func Test_Explicit_RepoPerms(t *testing.T) {
+	p := NewProvider(&mockStore{
+		perms: []*types.RepoPermission{
+			{ID: 1, UserID: 1, RepoPattern: "gitolite.mine/secret/.*", Permission: "read"},
+			{ID: 2, OrgID: 1, RepoPattern: "^gitolite.mine/shared$", Permission: "read"},
+		},
+		orgs: map[int32][]int32{1: {1}, 2: {1}},
+	})
+	repos := map[authz.Repo]struct{}{
+		repo("gitolite.mine/secret/a"): {},
+		repo("gitolite.mine/shared"):   {},
+	}
+
+	tests := []struct {
+		description string
+		account     *extsvc.ExternalAccount
+		expPerms    map[api.RepoName]map[authz.Perm]bool
+	}{
+		{
+			description: "user with direct and organization permissions",
+			account:     mustFetchAccount(t, p, 1),
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"gitolite.mine/secret/a": {authz.Read: true},
+				"gitolite.mine/shared":   {authz.Read: true},
+			},
+		},
+		{
+			description: "user with organization permissions",
+			account:     mustFetchAccount(t, p, 2),
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"gitolite.mine/secret/a": {},
+				"gitolite.mine/shared":   {authz.Read: true},
+			},
+		},
+		{
+			description: "user without permissions",
+			account:     mustFetchAccount(t, p, 3),
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"gitolite.mine/secret/a": {},
+				"gitolite.mine/shared":   {},
+			},
+		},
+		{
+			description: "account of another provider",
+			account: &extsvc.ExternalAccount{
+				UserID:              1,
+				ExternalAccountSpec: extsvc.ExternalAccountSpec{ServiceType: "gitlab", ServiceID: "https://gitlab.mine/", AccountID: "1"},
+			},
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"gitolite.mine/secret/a": {},
+				"gitolite.mine/shared":   {},
+			},
+		},
+		{
+			description: "unauthenticated",
+			account:     nil,
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"gitolite.mine/secret/a": {},
+				"gitolite.mine/shared":   {},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			perms, err := p.RepoPerms(context.Background(), test.account, repos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(perms, test.expPerms) {
+				t.Errorf("got perms %v, want %v", perms, test.expPerms)
+			}
+		})
+	}
+}
+
+func Test_Explicit_FetchAccount(t *testing.T) {
+	p := NewProvider(&mockStore{})
+	got := mustFetchAccount(t, p, 123)
+	want := &extsvc.ExternalAccount{
+		UserID:              123,
+		ExternalAccountSpec: extsvc.ExternalAccountSpec{ServiceType: ServiceType, ServiceID: ServiceID, AccountID: "123"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got account %+v, want %+v", got, want)
+	}
+
+	if acct, err := p.FetchAccount(context.Background(), nil, nil); acct != nil || err != nil {
+		t.Errorf("got account %+v and error %v for no user, want nil", acct, err)
+	}
+}
+
+func TestCompilePattern(t *testing.T) {
+	for _, pattern := range []string{"", "("} {
+		if _, err := CompilePattern(pattern); err == nil {
+			t.Errorf("got no error for pattern %q", pattern)
+		}
+	}
+	re, err := CompilePattern("github.com/Foo/.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !re.MatchString("github.com/foo/bar") {
+		t.Error("pattern does not match case-insensitively")
+	}
+	if re.MatchString("mirror/github.com/foo/bar") {
+		t.Error("pattern matches in the middle of the name")
+	}
+	if re, err := CompilePattern("a|b"); err != nil || re.MatchString("ab") {
+		t.Errorf("pattern with alternatives is not anchored as a whole (error %v)", err)
+	}
+}
+
+func mustFetchAccount(t *testing.T, p *Provider, userID int32) *extsvc.ExternalAccount {
+	acct, err := p.FetchAccount(context.Background(), &types.User{ID: userID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acct
+}
+
+func repo(name string) authz.Repo {
+	return authz.Repo{RepoName: api.RepoName(name)}
+}
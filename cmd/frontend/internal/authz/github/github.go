@@ -0,0 +1,227 @@
+// Package github contains an authorization provider for GitHub.
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+type pcache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, b []byte)
+	Delete(key string)
+}
+
+// GitHubAuthzProvider is an implementation of AuthzProvider that provides repository permissions as
+// determined from a GitHub instance API. A user can read the repositories that are visible to the
+// GitHub account they signed in with (which is the external account created by the GitHub OAuth
+// authn provider). Users without such an account can only read public repositories. For
+// documentation of specific fields, see the docstrings of GitHubAuthzProviderOp.
+type GitHubAuthzProvider struct {
+	client   *github.Client
+	apiURL   *url.URL
+	codeHost *github.CodeHost
+	cache    pcache
+	cacheTTL time.Duration
+}
+
+var _ authz.Provider = ((*GitHubAuthzProvider)(nil))
+
+type cacheVal struct {
+	// Repos is the set of repositories (by GitHub GraphQL node ID) whose permissions have been
+	// fetched for a GitHub user, and whether the user can read each of them.
+	Repos map[string]bool `json:"repos"`
+
+	// Expires is when the cache entry expires. Repositories that are added to an existing cache
+	// entry expire with it, so that no permission is cached for longer than the TTL.
+	Expires time.Time `json:"expires"`
+
+	// TTL is the ttl of the cache entry. This must be checked for equality in case the TTL has
+	// changed (and the cache entry should therefore be invalidated).
+	TTL time.Duration `json:"ttl"`
+}
+
+type GitHubAuthzProviderOp struct {
+	// BaseURL is the URL of the GitHub instance (https://github.com or the GitHub Enterprise URL).
+	BaseURL *url.URL
+
+	// Token is the access token of the GitHub connection. It is used to determine which
+	// repositories are public, for users who did not sign in with GitHub.
+	//
+	// 🚨 SECURITY: This value contains secret information that must not be shown to non-site-admins.
+	Token string
+
+	// CacheTTL is the TTL of cached permissions from the GitHub API.
+	CacheTTL time.Duration
+
+	// MockCache, if non-nil, replaces the default Redis-based cache with the supplied cache mock.
+	// Should only be used in tests.
+	MockCache pcache
+}
+
+func NewProvider(op GitHubAuthzProviderOp) *GitHubAuthzProvider {
+	apiURL := githubAPIURL(op.BaseURL)
+	p := &GitHubAuthzProvider{
+		client:   github.NewClient(apiURL, op.Token, nil, nil),
+		apiURL:   apiURL,
+		codeHost: github.NewCodeHost(op.BaseURL),
+		cache:    op.MockCache,
+		cacheTTL: op.CacheTTL,
+	}
+	if p.cache == nil {
+		p.cache = rcache.NewWithTTL(fmt.Sprintf("githubAuthz:%s", op.BaseURL.String()), int(math.Ceil(op.CacheTTL.Seconds())))
+	}
+	return p
+}
+
+// githubAPIURL returns the API URL of the GitHub instance at baseURL. GitHub.com's API is hosted on
+// api.github.com, and GitHub Enterprise's under /api.
+func githubAPIURL(baseURL *url.URL) *url.URL {
+	if hostname := strings.ToLower(baseURL.Hostname()); hostname == "github.com" || hostname == "www.github.com" {
+		return &url.URL{Scheme: "https", Host: "api.github.com", Path: "/"}
+	}
+	if baseURL.Path == "" || baseURL.Path == "/" {
+		return baseURL.ResolveReference(&url.URL{Path: "/api"})
+	}
+	return baseURL
+}
+
+func (p *GitHubAuthzProvider) Validate() (problems []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, _, _, err := p.client.ListViewerRepositories(ctx, 1); err != nil {
+		if err == ctx.Err() {
+			problems = append(problems, fmt.Sprintf("GitHub API did not respond within 5s (%s)", err.Error()))
+		} else {
+			problems = append(problems, fmt.Sprintf("could not list repositories with the access token (%s)", err.Error()))
+		}
+	}
+	return problems
+}
+
+func (p *GitHubAuthzProvider) ServiceID() string {
+	return p.codeHost.ServiceID()
+}
+
+func (p *GitHubAuthzProvider) ServiceType() string {
+	return p.codeHost.ServiceType()
+}
+
+func (p *GitHubAuthzProvider) CacheTTL() time.Duration {
+	return p.cacheTTL
+}
+
+func (p *GitHubAuthzProvider) RepoPerms(ctx context.Context, account *extsvc.ExternalAccount, repos map[authz.Repo]struct{}) (map[api.RepoName]map[authz.Perm]bool, error) {
+	accountID := "" // empty means public / unauthenticated to the code host
+	var userToken string
+	if account != nil && account.ServiceID == p.codeHost.ServiceID() && account.ServiceType == p.codeHost.ServiceType() {
+		_, tok, err := github.GetExternalAccountData(&account.ExternalAccountData)
+		if err != nil {
+			return nil, err
+		}
+		if tok != nil && tok.AccessToken != "" {
+			accountID, userToken = account.AccountID, tok.AccessToken
+		}
+	}
+
+	myRepos, _ := p.Repos(ctx, repos)
+
+	cached, exists := p.getCachedPerms(accountID)
+	if !exists {
+		cached = cacheVal{
+			Repos:   make(map[string]bool),
+			Expires: time.Now().Add(p.cacheTTL),
+			TTL:     p.cacheTTL,
+		}
+	}
+	var missing []string
+	for repo := range myRepos {
+		if _, ok := cached.Repos[repo.ExternalRepoSpec.ID]; !ok {
+			missing = append(missing, repo.ExternalRepoSpec.ID)
+		}
+	}
+	if len(missing) > 0 {
+		readable, err := p.fetchReadable(ctx, userToken, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range missing {
+			cached.Repos[id] = readable[id]
+		}
+
+		cachedB, err := json.Marshal(cached)
+		if err != nil {
+			return nil, err
+		}
+		p.cache.Set(accountID, cachedB)
+	}
+
+	perms := make(map[api.RepoName]map[authz.Perm]bool)
+	for repo := range myRepos {
+		perms[repo.RepoName] = map[authz.Perm]bool{}
+		if cached.Repos[repo.ExternalRepoSpec.ID] {
+			perms[repo.RepoName][authz.Read] = true
+		}
+	}
+	return perms, nil
+}
+
+func (p *GitHubAuthzProvider) Repos(ctx context.Context, repos map[authz.Repo]struct{}) (mine map[authz.Repo]struct{}, others map[authz.Repo]struct{}) {
+	return authz.GetCodeHostRepos(p.codeHost, repos)
+}
+
+// FetchAccount always returns nil, because GitHub accounts are created (along with the OAuth token
+// that RepoPerms uses) when a user signs in with the GitHub OAuth authn provider.
+func (p *GitHubAuthzProvider) FetchAccount(ctx context.Context, user *types.User, current []*extsvc.ExternalAccount) (mine *extsvc.ExternalAccount, err error) {
+	return nil, nil
+}
+
+// getCachedPerms returns the cached permissions of a user and whether the cache entry exists.
+func (p *GitHubAuthzProvider) getCachedPerms(accountID string) (cacheVal, bool) {
+	cachedB, exists := p.cache.Get(accountID)
+	if !exists {
+		return cacheVal{}, false
+	}
+	var v cacheVal
+	if err := json.Unmarshal(cachedB, &v); err != nil || v.TTL == 0 || v.TTL > p.cacheTTL || !time.Now().Before(v.Expires) || v.Repos == nil {
+		if err != nil {
+			log15.Warn("Failed to unmarshal repo perm cache entry", "err", err.Error())
+		}
+		p.cache.Delete(accountID)
+		return cacheVal{}, false
+	}
+	return v, true
+}
+
+// fetchReadable fetches which of the repositories (by GitHub GraphQL node ID) are readable from the
+// GitHub API. If userToken is empty, only public repositories are readable.
+func (p *GitHubAuthzProvider) fetchReadable(ctx context.Context, userToken string, ids []string) (map[string]bool, error) {
+	client := p.client
+	if userToken != "" {
+		client = github.NewClient(p.apiURL, userToken, nil, nil)
+	}
+	ghRepos, err := client.GetRepositoriesByNodeIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	readable := make(map[string]bool, len(ghRepos))
+	for _, r := range ghRepos {
+		// Repositories that are visible to the user are readable, and repositories that are
+		// visible to the connection's token are readable if they're public.
+		readable[r.ID] = userToken != "" || !r.IsPrivate
+	}
+	return readable, nil
+}
@@ -0,0 +1,394 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/github"
+	"golang.org/x/oauth2"
+)
+
+func Test_GitHub_RepoPerms(t *testing.T) {
+	githubMock := newMockGitHub(t,
+		map[string]bool{
+			"MDEwOlJlcG9zaXRvcnkx": true,  // github.mine/bl/repo-1
+			"MDEwOlJlcG9zaXRvcnky": true,  // github.mine/kl/repo-1
+			"MDEwOlJlcG9zaXRvcnkz": true,  // github.mine/org/repo-1
+			"MDEwOlJlcG9zaXRvcnk0": false, // github.mine/public/repo-1
+		},
+		map[string][]string{
+			"bl-token": {"MDEwOlJlcG9zaXRvcnkx", "MDEwOlJlcG9zaXRvcnkz", "MDEwOlJlcG9zaXRvcnk0"},
+			"kl-token": {"MDEwOlJlcG9zaXRvcnky", "MDEwOlJlcG9zaXRvcnkz", "MDEwOlJlcG9zaXRvcnk0"},
+		})
+	defer githubMock.Close()
+	serviceID := githubMock.serviceID()
+
+	repos := map[authz.Repo]struct{}{
+		repo("github.mine/bl/repo-1", github.ServiceType, serviceID, "MDEwOlJlcG9zaXRvcnkx"):               struct{}{},
+		repo("github.mine/kl/repo-1", github.ServiceType, serviceID, "MDEwOlJlcG9zaXRvcnky"):               struct{}{},
+		repo("github.mine/org/repo-1", github.ServiceType, serviceID, "MDEwOlJlcG9zaXRvcnkz"):              struct{}{},
+		repo("github.mine/public/repo-1", github.ServiceType, serviceID, "MDEwOlJlcG9zaXRvcnk0"):           struct{}{},
+		repo("github.mine/gone/repo-1", github.ServiceType, serviceID, "MDEwOlJlcG9zaXRvcnk5"):             struct{}{},
+		repo("github.com/other/repo-1", github.ServiceType, "https://github.com/", "MDEwOlJlcG9zaXRvcnkx"): struct{}{},
+	}
+	tests := []struct {
+		description string
+		account     *extsvc.ExternalAccount
+		expPerms    map[api.RepoName]map[authz.Perm]bool
+	}{
+		{
+			description: "bl user has expected perms",
+			account:     acct(1, github.ServiceType, serviceID, "101", "bl-token"),
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"github.mine/bl/repo-1":     map[authz.Perm]bool{authz.Read: true},
+				"github.mine/kl/repo-1":     map[authz.Perm]bool{},
+				"github.mine/org/repo-1":    map[authz.Perm]bool{authz.Read: true},
+				"github.mine/public/repo-1": map[authz.Perm]bool{authz.Read: true},
+				"github.mine/gone/repo-1":   map[authz.Perm]bool{},
+			},
+		},
+		{
+			description: "kl user has expected perms",
+			account:     acct(2, github.ServiceType, serviceID, "201", "kl-token"),
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"github.mine/bl/repo-1":     map[authz.Perm]bool{},
+				"github.mine/kl/repo-1":     map[authz.Perm]bool{authz.Read: true},
+				"github.mine/org/repo-1":    map[authz.Perm]bool{authz.Read: true},
+				"github.mine/public/repo-1": map[authz.Perm]bool{authz.Read: true},
+				"github.mine/gone/repo-1":   map[authz.Perm]bool{},
+			},
+		},
+		{
+			description: "account without a token only has access to public repos",
+			account:     acct(3, github.ServiceType, serviceID, "301", ""),
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"github.mine/bl/repo-1":     map[authz.Perm]bool{},
+				"github.mine/kl/repo-1":     map[authz.Perm]bool{},
+				"github.mine/org/repo-1":    map[authz.Perm]bool{},
+				"github.mine/public/repo-1": map[authz.Perm]bool{authz.Read: true},
+				"github.mine/gone/repo-1":   map[authz.Perm]bool{},
+			},
+		},
+		{
+			description: "account of another code host only has access to public repos",
+			account:     acct(1, github.ServiceType, "https://github.com/", "101", "bl-token"),
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"github.mine/bl/repo-1":     map[authz.Perm]bool{},
+				"github.mine/kl/repo-1":     map[authz.Perm]bool{},
+				"github.mine/org/repo-1":    map[authz.Perm]bool{},
+				"github.mine/public/repo-1": map[authz.Perm]bool{authz.Read: true},
+				"github.mine/gone/repo-1":   map[authz.Perm]bool{},
+			},
+		},
+		{
+			description: "no account only has access to public repos",
+			account:     nil,
+			expPerms: map[api.RepoName]map[authz.Perm]bool{
+				"github.mine/bl/repo-1":     map[authz.Perm]bool{},
+				"github.mine/kl/repo-1":     map[authz.Perm]bool{},
+				"github.mine/org/repo-1":    map[authz.Perm]bool{},
+				"github.mine/public/repo-1": map[authz.Perm]bool{authz.Read: true},
+				"github.mine/gone/repo-1":   map[authz.Perm]bool{},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Logf("Test case %q", test.description)
+
+		// Recreate the authz provider cache every time, before running twice (once uncached, once cached)
+		ctx := context.Background()
+		authzProvider := NewProvider(GitHubAuthzProviderOp{
+			BaseURL:   githubMock.baseURL(t),
+			Token:     "connection-token",
+			CacheTTL:  3 * time.Hour,
+			MockCache: make(mockCache),
+		})
+		for i := 0; i < 2; i++ {
+			t.Logf("iter %d", i)
+			perms, err := authzProvider.RepoPerms(ctx, test.account, repos)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				continue
+			}
+			if !reflect.DeepEqual(perms, test.expPerms) {
+				t.Errorf("expected %s, but got %s", asJSON(t, test.expPerms), asJSON(t, perms))
+			}
+		}
+	}
+}
+
+func Test_GitHub_RepoPerms_cache(t *testing.T) {
+	githubMock := newMockGitHub(t,
+		map[string]bool{
+			"MDEwOlJlcG9zaXRvcnkx": true,  // github.mine/bl/repo-1
+			"MDEwOlJlcG9zaXRvcnky": false, // github.mine/public/repo-1
+		},
+		map[string][]string{
+			"bl-token": {"MDEwOlJlcG9zaXRvcnkx", "MDEwOlJlcG9zaXRvcnky"},
+		})
+	defer githubMock.Close()
+	serviceID := githubMock.serviceID()
+
+	ctx := context.Background()
+	cache := make(mockCache)
+	authzProvider := NewProvider(GitHubAuthzProviderOp{
+		BaseURL:   githubMock.baseURL(t),
+		Token:     "connection-token",
+		CacheTTL:  3 * time.Hour,
+		MockCache: cache,
+	})
+	bl := acct(1, github.ServiceType, serviceID, "101", "bl-token")
+	repo1 := map[authz.Repo]struct{}{repo("github.mine/bl/repo-1", github.ServiceType, serviceID, "MDEwOlJlcG9zaXRvcnkx"): struct{}{}}
+	repo2 := map[authz.Repo]struct{}{repo("github.mine/public/repo-1", github.ServiceType, serviceID, "MDEwOlJlcG9zaXRvcnky"): struct{}{}}
+
+	checkReqs := func(exp map[string][]string) {
+		t.Helper()
+		if !reflect.DeepEqual(githubMock.reqs, exp) {
+			t.Errorf("Unexpected cache behavior. Expected underlying requests to be %v, but got %v", exp, githubMock.reqs)
+		}
+	}
+
+	if _, err := authzProvider.RepoPerms(ctx, bl, repo1); err != nil {
+		t.Fatal(err)
+	}
+	checkReqs(map[string][]string{"bl-token": {"MDEwOlJlcG9zaXRvcnkx"}})
+
+	// Cached permissions are not fetched again.
+	if _, err := authzProvider.RepoPerms(ctx, bl, repo1); err != nil {
+		t.Fatal(err)
+	}
+	checkReqs(map[string][]string{"bl-token": {"MDEwOlJlcG9zaXRvcnkx"}})
+
+	// Only the permissions of repositories that are not cached are fetched.
+	if _, err := authzProvider.RepoPerms(ctx, bl, map[authz.Repo]struct{}{
+		repo("github.mine/bl/repo-1", github.ServiceType, serviceID, "MDEwOlJlcG9zaXRvcnkx"):     struct{}{},
+		repo("github.mine/public/repo-1", github.ServiceType, serviceID, "MDEwOlJlcG9zaXRvcnky"): struct{}{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	checkReqs(map[string][]string{"bl-token": {"MDEwOlJlcG9zaXRvcnkx", "MDEwOlJlcG9zaXRvcnky"}})
+
+	// Public permissions are cached separately.
+	if _, err := authzProvider.RepoPerms(ctx, nil, repo2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authzProvider.RepoPerms(ctx, nil, repo2); err != nil {
+		t.Fatal(err)
+	}
+	checkReqs(map[string][]string{"bl-token": {"MDEwOlJlcG9zaXRvcnkx", "MDEwOlJlcG9zaXRvcnky"}, "connection-token": {"MDEwOlJlcG9zaXRvcnky"}})
+
+	// Expired cache entries are refetched.
+	var v cacheVal
+	if err := json.Unmarshal([]byte(cache["101"]), &v); err != nil {
+		t.Fatal(err)
+	}
+	v.Expires = time.Now().Add(-time.Minute)
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache["101"] = string(b)
+	if _, err := authzProvider.RepoPerms(ctx, bl, repo1); err != nil {
+		t.Fatal(err)
+	}
+	checkReqs(map[string][]string{"bl-token": {"MDEwOlJlcG9zaXRvcnkx", "MDEwOlJlcG9zaXRvcnky", "MDEwOlJlcG9zaXRvcnkx"}, "connection-token": {"MDEwOlJlcG9zaXRvcnky"}})
+
+	// A lower TTL invalidates the cache entry.
+	authzProvider.cacheTTL = time.Hour
+	if _, err := authzProvider.RepoPerms(ctx, bl, repo1); err != nil {
+		t.Fatal(err)
+	}
+	checkReqs(map[string][]string{"bl-token": {"MDEwOlJlcG9zaXRvcnkx", "MDEwOlJlcG9zaXRvcnky", "MDEwOlJlcG9zaXRvcnkx", "MDEwOlJlcG9zaXRvcnkx"}, "connection-token": {"MDEwOlJlcG9zaXRvcnky"}})
+}
+
+func Test_GitHub_Repos(t *testing.T) {
+	authzProvider := NewProvider(GitHubAuthzProviderOp{
+		BaseURL:   mustURL(t, "https://github.mine"),
+		MockCache: make(mockCache),
+	})
+	repos := map[authz.Repo]struct{}{
+		repo("github.mine/bl/repo-1", "", "", ""):                   struct{}{},
+		repo("a", github.ServiceType, "https://github.mine/", "23"): struct{}{},
+		repo("b", github.ServiceType, "https://github.com/", "34"):  struct{}{},
+		repo("c", "gitlab", "https://github.mine/", "45"):           struct{}{},
+	}
+	mine, others := authzProvider.Repos(context.Background(), repos)
+	if exp := map[authz.Repo]struct{}{repo("a", github.ServiceType, "https://github.mine/", "23"): struct{}{}}; !reflect.DeepEqual(mine, exp) {
+		t.Errorf("expected mine to be %v, but got %v", exp, mine)
+	}
+	if exp := map[authz.Repo]struct{}{
+		repo("github.mine/bl/repo-1", "", "", ""):                  struct{}{},
+		repo("b", github.ServiceType, "https://github.com/", "34"): struct{}{},
+		repo("c", "gitlab", "https://github.mine/", "45"):          struct{}{},
+	}; !reflect.DeepEqual(others, exp) {
+		t.Errorf("expected others to be %v, but got %v", exp, others)
+	}
+}
+
+func Test_githubAPIURL(t *testing.T) {
+	for baseURL, exp := range map[string]string{
+		"https://github.com":             "https://api.github.com/",
+		"https://www.github.com/":        "https://api.github.com/",
+		"https://github.mine":            "https://github.mine/api",
+		"https://github.mine/":           "https://github.mine/api",
+		"https://github.mine/prefix/api": "https://github.mine/prefix/api",
+	} {
+		if got := githubAPIURL(mustURL(t, baseURL)).String(); got != exp {
+			t.Errorf("for base URL %q, expected API URL %q, but got %q", baseURL, exp, got)
+		}
+	}
+}
+
+// mockGitHub is an httptest stand-in for the GraphQL API of a GitHub Enterprise instance. It
+// answers node queries with the repositories that are visible to the request's access token. The
+// connection's token ("connection-token") can see all repositories.
+type mockGitHub struct {
+	*httptest.Server
+	t *testing.T
+
+	// private is the set of all repositories on the instance (by GraphQL node ID), and whether
+	// each of them is private
+	private map[string]bool
+
+	// acls is a map from a user's access token to the list of repositories visible to them
+	acls map[string][]string
+
+	// reqs records the repositories that were requested with each access token
+	mu   sync.Mutex
+	reqs map[string][]string
+}
+
+func newMockGitHub(t *testing.T, private map[string]bool, acls map[string][]string) *mockGitHub {
+	m := &mockGitHub{t: t, private: private, acls: acls, reqs: make(map[string][]string)}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serveGraphQL))
+	return m
+}
+
+func (m *mockGitHub) baseURL(t *testing.T) *url.URL {
+	return mustURL(t, m.URL)
+}
+
+func (m *mockGitHub) serviceID() string {
+	return m.URL + "/"
+}
+
+func (m *mockGitHub) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" || r.URL.Path != "/api/graphql" {
+		m.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	var req struct {
+		Variables struct {
+			IDs []string `json:"ids"`
+		} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		m.t.Fatal(err)
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "bearer ")
+
+	m.mu.Lock()
+	m.reqs[token] = append(m.reqs[token], req.Variables.IDs...)
+	m.mu.Unlock()
+
+	visible := make(map[string]bool)
+	if token == "connection-token" {
+		for id := range m.private {
+			visible[id] = true
+		}
+	} else {
+		for _, id := range m.acls[token] {
+			visible[id] = true
+		}
+	}
+
+	type graphqlError struct {
+		Type    string        `json:"type"`
+		Path    []interface{} `json:"path"`
+		Message string        `json:"message"`
+	}
+	var resp struct {
+		Data struct {
+			Nodes []interface{} `json:"nodes"`
+		} `json:"data"`
+		Errors []graphqlError `json:"errors,omitempty"`
+	}
+	for i, id := range req.Variables.IDs {
+		if !visible[id] {
+			resp.Data.Nodes = append(resp.Data.Nodes, nil)
+			resp.Errors = append(resp.Errors, graphqlError{Type: "NOT_FOUND", Path: []interface{}{"nodes", i}, Message: "Could not resolve to a node with the global id of '" + id + "'"})
+			continue
+		}
+		resp.Data.Nodes = append(resp.Data.Nodes, map[string]interface{}{"id": id, "isPrivate": m.private[id]})
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		m.t.Fatal(err)
+	}
+}
+
+type mockCache map[string]string
+
+func (m mockCache) Get(key string) ([]byte, bool) {
+	v, ok := m[key]
+	return []byte(v), ok
+}
+func (m mockCache) Set(key string, b []byte) {
+	m[key] = string(b)
+}
+func (m mockCache) Delete(key string) {
+	delete(m, key)
+}
+
+func acct(userID int32, serviceType, serviceID, accountID, token string) *extsvc.ExternalAccount {
+	a := &extsvc.ExternalAccount{
+		UserID: userID,
+		ExternalAccountSpec: extsvc.ExternalAccountSpec{
+			ServiceType: serviceType,
+			ServiceID:   serviceID,
+			AccountID:   accountID,
+		},
+	}
+	if token != "" {
+		a.SetAuthData(&oauth2.Token{AccessToken: token})
+	}
+	return a
+}
+
+func repo(uri, serviceType, serviceID, id string) authz.Repo {
+	return authz.Repo{
+		RepoName: api.RepoName(uri),
+		ExternalRepoSpec: api.ExternalRepoSpec{
+			ID:          id,
+			ServiceType: serviceType,
+			ServiceID:   serviceID,
+		},
+	}
+}
+
+func mustURL(t *testing.T, u string) *url.URL {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func asJSON(t *testing.T, v interface{}) string {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -119,6 +119,10 @@ func (p *GitLabAuthzProvider) ServiceType() string {
 	return p.codeHost.ServiceType()
 }
 
+func (p *GitLabAuthzProvider) CacheTTL() time.Duration {
+	return p.cacheTTL
+}
+
 func (p *GitLabAuthzProvider) RepoPerms(ctx context.Context, account *extsvc.ExternalAccount, repos map[authz.Repo]struct{}) (map[api.RepoName]map[authz.Perm]bool, error) {
 	accountID := "" // empty means public / unauthenticated to the code host
 	if account != nil && account.ServiceID == p.codeHost.ServiceID() && account.ServiceType == p.codeHost.ServiceType() {
@@ -4,6 +4,7 @@ package authz
 
 import (
 	"context"
+	"time"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
@@ -82,6 +83,14 @@ type Provider interface {
 	Validate() (problems []string)
 }
 
+// CachingProvider is implemented by authz providers that cache the permissions they fetch from the
+// code host.
+type CachingProvider interface {
+	// CacheTTL returns how long permissions fetched from the code host are valid (the "ttl" of the
+	// code host connection's authorization config). Permissions are not cached if it is zero.
+	CacheTTL() time.Duration
+}
+
 type Repo struct {
 	// RepoName is the unique name of the repo on Sourcegraph.
 	RepoName api.RepoName
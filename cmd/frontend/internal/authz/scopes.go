@@ -1,13 +1,78 @@
 package authz
 
+import (
+	"context"
+	"errors"
+)
+
 const (
 	// Access token scopes.
 	ScopeUserAll       = "user:all"        // Full control of all resources accessible to the user account.
 	ScopeSiteAdminSudo = "site-admin:sudo" // Ability to perform any action as any other user.
+
+	// Read-only access token scopes. A token that has one or more of these scopes (and not
+	// ScopeUserAll) can only be used to read the resources that its scopes cover.
+	ScopeSearchRead   = "search:read"   // Read access to search results and saved searches.
+	ScopeRepoRead     = "repo:read"     // Read access to repositories and their contents.
+	ScopeSettingsRead = "settings:read" // Read access to settings.
 )
 
 // AllScopes is a list of all known access token scopes.
 var AllScopes = []string{
 	ScopeUserAll,
 	ScopeSiteAdminSudo,
+	ScopeSearchRead,
+	ScopeRepoRead,
+	ScopeSettingsRead,
+}
+
+// ReadOnlyScopes is a list of the read-only access token scopes.
+var ReadOnlyScopes = []string{
+	ScopeSearchRead,
+	ScopeRepoRead,
+	ScopeSettingsRead,
+}
+
+// IsReadOnlyScope reports whether scope is a read-only access token scope.
+func IsReadOnlyScope(scope string) bool {
+	for _, s := range ReadOnlyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey int
+
+const readOnlyScopesKey contextKey = iota
+
+// WithReadOnlyScopes returns a context for a request that is made with an access token that is
+// limited to the given read-only scopes.
+func WithReadOnlyScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, readOnlyScopesKey, scopes)
+}
+
+// ErrScopeNotPermitted is returned by CheckScope if the request's access token scopes don't permit
+// it.
+var ErrScopeNotPermitted = errors.New("not permitted by the access token's scopes")
+
+// CheckScope returns ErrScopeNotPermitted if the request is made with an access token that is
+// limited to read-only scopes (see WithReadOnlyScopes) that don't include scope. Requests that are
+// authenticated in other ways are not limited. Use ScopeUserAll to check that the request is not
+// limited to read-only scopes.
+//
+// 🚨 SECURITY: Resolvers of data that is reachable from the GraphQL fields that read-only scopes
+// permit must call this (or a check that calls it) if the scopes do not cover the data.
+func CheckScope(ctx context.Context, scope string) error {
+	scopes, ok := ctx.Value(readOnlyScopesKey).([]string)
+	if !ok {
+		return nil
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return nil
+		}
+	}
+	return ErrScopeNotPermitted
 }
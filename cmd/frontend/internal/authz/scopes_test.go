@@ -0,0 +1,28 @@
+package authz
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCheckScope(t *testing.T) {
+	readOnlyCtx := WithReadOnlyScopes(context.Background(), []string{ScopeRepoRead})
+
+	tests := map[string]struct {
+		ctx     context.Context
+		scope   string
+		wantErr error
+	}{
+		"no access token scopes":       {ctx: context.Background(), scope: ScopeUserAll},
+		"read-only scope included":     {ctx: readOnlyCtx, scope: ScopeRepoRead},
+		"read-only scope not included": {ctx: readOnlyCtx, scope: ScopeSettingsRead, wantErr: ErrScopeNotPermitted},
+		"full access":                  {ctx: readOnlyCtx, scope: ScopeUserAll, wantErr: ErrScopeNotPermitted},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := CheckScope(test.ctx, test.scope); err != test.wantErr {
+				t.Errorf("got error %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
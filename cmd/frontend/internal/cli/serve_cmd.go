@@ -148,6 +148,7 @@ func Main() error {
 	}
 
 	goroutine.Go(mailreply.StartWorker)
+	goroutine.Go(db.StartPermissionsSyncer)
 	go updatecheck.Start()
 	if hooks.AfterDBInit != nil {
 		hooks.AfterDBInit()
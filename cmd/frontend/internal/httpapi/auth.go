@@ -61,19 +61,30 @@ func AccessTokenAuthMiddleware(next http.Handler) http.Handler {
 			// Validate access token.
 			//
 			// 🚨 SECURITY: It's important we check for the correct scopes to know what this token
-			// is allowed to do.
-			var requiredScope string
+			// is allowed to do. Tokens without the "user:all" scope may only be used as permitted by
+			// their read-only scopes (see checkReadOnlyScopes).
+			var requiredScopes []string
 			if sudoUser == "" {
-				requiredScope = authz.ScopeUserAll
+				requiredScopes = append([]string{authz.ScopeUserAll}, authz.ReadOnlyScopes...)
 			} else {
-				requiredScope = authz.ScopeSiteAdminSudo
+				requiredScopes = []string{authz.ScopeSiteAdminSudo}
 			}
-			subjectUserID, err := db.AccessTokens.Lookup(r.Context(), token, requiredScope)
+			subjectUserID, scopes, err := db.AccessTokens.Lookup(r.Context(), token, requiredScopes)
 			if err != nil {
 				log15.Error("Invalid access token.", "token", token, "err", err)
 				http.Error(w, "Invalid access token.", http.StatusUnauthorized)
 				return
 			}
+			if limitedScopes := readOnlyScopes(scopes); limitedScopes != nil {
+				if err := checkReadOnlyScopes(r, limitedScopes); err != nil {
+					log15.Debug("Request is not permitted by access token's read-only scopes.", "requestURI", r.URL.RequestURI(), "scopes", scopes, "err", err)
+					http.Error(w, "Access token scopes do not permit this request: "+err.Error(), http.StatusForbidden)
+					return
+				}
+				// The data that the request accesses is checked against the scopes when it is
+				// resolved (see authz.CheckScope).
+				r = r.WithContext(authz.WithReadOnlyScopes(r.Context(), limitedScopes))
+			}
 
 			// Determine the actor's user ID.
 			var actorUserID int32
@@ -112,3 +123,18 @@ func AccessTokenAuthMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// readOnlyScopes returns the read-only scopes among the access token's scopes if the access token
+// is limited to them (i.e., if it doesn't have the "user:all" scope), and nil otherwise.
+func readOnlyScopes(scopes []string) []string {
+	var readOnly []string
+	for _, scope := range scopes {
+		if scope == authz.ScopeUserAll {
+			return nil
+		}
+		if authz.IsReadOnlyScope(scope) {
+			readOnly = append(readOnly, scope)
+		}
+	}
+	return readOnly
+}
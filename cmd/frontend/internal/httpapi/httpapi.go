@@ -39,6 +39,8 @@ func NewHandler(m *mux.Router) http.Handler {
 
 	m.Get(apirouter.Telemetry).Handler(trace.TraceRoute(telemetryHandler))
 
+	m.Get(apirouter.Webhooks).Handler(trace.TraceRoute(handler(serveWebhook)))
+
 	m.Get(apirouter.XLang).Handler(trace.TraceRoute(handler(serveXLang)))
 
 	if envvar.SourcegraphDotComMode() {
@@ -46,6 +48,7 @@ func NewHandler(m *mux.Router) http.Handler {
 	}
 
 	m.Get(apirouter.GraphQL).Handler(trace.TraceRoute(handler(serveGraphQL)))
+	m.Get(apirouter.SearchStream).Handler(trace.TraceRoute(handler(serveSearchStream)))
 
 	m.Get(apirouter.Registry).Handler(trace.TraceRoute(handler(registry.HandleRegistry)))
 
@@ -73,10 +76,13 @@ func NewInternalHandler(m *mux.Router) http.Handler {
 	m.Get(apirouter.ReposCreateIfNotExists).Handler(trace.TraceRoute(handler(serveReposCreateIfNotExists)))
 	m.Get(apirouter.ReposUpdateMetadata).Handler(trace.TraceRoute(handler(serveReposUpdateMetadata)))
 	m.Get(apirouter.ReposUpdateIndex).Handler(trace.TraceRoute(handler(serveReposUpdateIndex)))
+	m.Get(apirouter.ReposIndexBranches).Handler(trace.TraceRoute(handler(serveReposIndexBranches)))
 	m.Get(apirouter.ReposInventory).Handler(trace.TraceRoute(handler(serveReposInventory)))
 	m.Get(apirouter.ReposInventoryUncached).Handler(trace.TraceRoute(handler(serveReposInventoryUncached)))
 	m.Get(apirouter.ReposList).Handler(trace.TraceRoute(handler(serveReposList)))
 	m.Get(apirouter.ReposListEnabled).Handler(trace.TraceRoute(handler(serveReposListEnabled)))
+	m.Get(apirouter.ReposListByExtService).Handler(trace.TraceRoute(handler(serveReposListByExternalService)))
+	m.Get(apirouter.ReposMarkDeleted).Handler(trace.TraceRoute(handler(serveReposMarkDeleted)))
 	m.Get(apirouter.ReposGetByName).Handler(trace.TraceRoute(handler(serveReposGetByName)))
 	m.Get(apirouter.SettingsGetForSubject).Handler(trace.TraceRoute(handler(serveSettingsGetForSubject)))
 	m.Get(apirouter.SavedQueriesListAll).Handler(trace.TraceRoute(handler(serveSavedQueriesListAll)))
@@ -99,6 +105,7 @@ func NewInternalHandler(m *mux.Router) http.Handler {
 	m.Get(apirouter.GitUploadPack).Handler(trace.TraceRoute(handler(serveGitUploadPack)))
 	m.Get(apirouter.Telemetry).Handler(trace.TraceRoute(telemetryHandler))
 	m.Get(apirouter.GraphQL).Handler(trace.TraceRoute(handler(serveGraphQL)))
+	m.Get(apirouter.SearchStream).Handler(trace.TraceRoute(handler(serveSearchStream)))
 	m.Get(apirouter.ConfigurationRawJSON).Handler(trace.TraceRoute(handler(serveConfigurationRawJSON)))
 	m.Path("/ping").Methods("GET").Name("ping").HandlerFunc(handlePing)
 
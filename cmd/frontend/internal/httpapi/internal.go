@@ -94,6 +94,33 @@ func serveReposUpdateIndex(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// serveReposIndexBranches returns the branches of a repository that should be
+// indexed for search (used by zoekt-sourcegraph-indexserver). The default
+// branch is always included (as "HEAD"), followed by the branches configured
+// in the "search.index.branches" site configuration property. Branches that
+// do not exist are omitted.
+func serveReposIndexBranches(w http.ResponseWriter, r *http.Request) error {
+	var req api.RepoIndexBranchesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+
+	// Do not trigger a repo-updater lookup since this is a batch job.
+	repo := gitserver.Repo{Name: req.RepoName}
+	branches := []api.RepoIndexBranch{}
+	for _, name := range append([]string{"HEAD"}, conf.SearchIndexBranches(string(req.RepoName))...) {
+		commitID, err := git.ResolveRevision(r.Context(), repo, nil, name, nil)
+		if err != nil {
+			if git.IsRevisionNotFound(err) {
+				continue
+			}
+			return err
+		}
+		branches = append(branches, api.RepoIndexBranch{Name: name, CommitID: commitID})
+	}
+	return json.NewEncoder(w).Encode(branches)
+}
+
 func servePhabricatorRepoCreate(w http.ResponseWriter, r *http.Request) error {
 	var repo api.PhabricatorRepoCreateRequest
 	err := json.NewDecoder(r.Body).Decode(&repo)
@@ -211,6 +238,26 @@ func serveReposListEnabled(w http.ResponseWriter, r *http.Request) error {
 	return json.NewEncoder(w).Encode(names)
 }
 
+func serveReposListByExternalService(w http.ResponseWriter, r *http.Request) error {
+	var req api.ReposListByExternalServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+	repos, err := db.Repos.ListByExternalService(r.Context(), req.ServiceType, req.ServiceID)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(repos)
+}
+
+func serveReposMarkDeleted(w http.ResponseWriter, r *http.Request) error {
+	var req api.ReposMarkDeletedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+	return db.Repos.MarkDeleted(r.Context(), req.RepoName)
+}
+
 func serveSavedQueriesListAll(w http.ResponseWriter, r *http.Request) error {
 	// List settings for all users, orgs, etc.
 	settings, err := db.Settings.ListAll(r.Context())
@@ -7,14 +7,16 @@ import (
 )
 
 const (
-	GraphQL = "graphql"
-	XLang   = "xlang"
+	GraphQL      = "graphql"
+	SearchStream = "search.stream"
+	XLang        = "xlang"
 
 	Registry = "registry"
 
 	RepoShield  = "repo.shield"
 	RepoRefresh = "repo.refresh"
 	Telemetry   = "telemetry"
+	Webhooks    = "webhooks"
 
 	SavedQueriesListAll    = "internal.saved-queries.list-all"
 	SavedQueriesGetInfo    = "internal.saved-queries.get-info"
@@ -39,10 +41,13 @@ const (
 	PhabricatorRepoCreate  = "internal.phabricator.repo.create"
 	ReposCreateIfNotExists = "internal.repos.create-if-not-exists"
 	ReposGetByName         = "internal.repos.get-by-name"
+	ReposIndexBranches     = "internal.repos.index-branches"
 	ReposInventoryUncached = "internal.repos.inventory-uncached"
 	ReposInventory         = "internal.repos.inventory"
 	ReposList              = "internal.repos.list"
 	ReposListEnabled       = "internal.repos.list-enabled"
+	ReposListByExtService  = "internal.repos.list-by-external-service"
+	ReposMarkDeleted       = "internal.repos.mark-deleted"
 	ReposUpdateIndex       = "internal.repos.update-index"
 	ReposUpdateMetadata    = "internal.repos.update-metadata"
 	ConfigurationRawJSON   = "internal.configuration.raw-json"
@@ -60,7 +65,9 @@ func New(base *mux.Router) *mux.Router {
 	base.Path("/xlang/{LSPMethod:.*}").Methods("POST").Name(XLang)
 	addRegistryRoute(base)
 	addGraphQLRoute(base)
+	addSearchStreamRoute(base)
 	addTelemetryRoute(base)
+	addWebhooksRoute(base)
 
 	// repo contains routes that are NOT specific to a revision. In these routes, the URL may not contain a revspec after the repo (that is, no "github.com/foo/bar@myrevspec").
 	repoPath := `/repos/` + routevar.Repo
@@ -108,12 +115,16 @@ func NewInternal(base *mux.Router) *mux.Router {
 	base.Path("/repos/inventory").Methods("POST").Name(ReposInventory)
 	base.Path("/repos/list").Methods("POST").Name(ReposList)
 	base.Path("/repos/list-enabled").Methods("POST").Name(ReposListEnabled)
+	base.Path("/repos/list-by-external-service").Methods("POST").Name(ReposListByExtService)
+	base.Path("/repos/mark-deleted").Methods("POST").Name(ReposMarkDeleted)
 	base.Path("/repos/update-index").Methods("POST").Name(ReposUpdateIndex)
+	base.Path("/repos/index-branches").Methods("POST").Name(ReposIndexBranches)
 	base.Path("/repos/update-metadata").Methods("POST").Name(ReposUpdateMetadata)
 	base.Path("/repos/{RepoName:.*}").Methods("POST").Name(ReposGetByName)
 	base.Path("/configuration/raw-json").Methods("POST").Name(ConfigurationRawJSON)
 	addRegistryRoute(base)
 	addGraphQLRoute(base)
+	addSearchStreamRoute(base)
 	addTelemetryRoute(base)
 
 	return base
@@ -127,6 +138,14 @@ func addTelemetryRoute(m *mux.Router) {
 	m.Path("/telemetry/{TelemetryPath:.*}").Methods("POST").Name(Telemetry)
 }
 
+func addWebhooksRoute(m *mux.Router) {
+	m.Path("/webhooks/{CodeHost:github|gitlab|bitbucket-server}").Methods("POST").Name(Webhooks)
+}
+
 func addGraphQLRoute(m *mux.Router) {
 	m.Path("/graphql").Methods("POST").Name(GraphQL)
 }
+
+func addSearchStreamRoute(m *mux.Router) {
+	m.Path("/search/stream").Methods("GET").Name(SearchStream)
+}
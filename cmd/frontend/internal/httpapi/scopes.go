@@ -0,0 +1,300 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/pkg/routevar"
+)
+
+// Requests authenticated with an access token that has only read-only scopes (see
+// authz.ReadOnlyScopes) are checked by checkReadOnlyScopes before they are handled. They may only:
+//
+// - perform GraphQL queries (not mutations) whose top-level fields are listed in
+//   graphQLQueryFieldScopes, if the access token has the scope that each field requires
+// - perform streaming searches, with the "search:read" scope
+// - make GET and HEAD requests for the raw, blob, and tree pages of repositories, with the
+//   "repo:read" scope
+//
+// All other requests are rejected. The top-level GraphQL fields lead to other data (such as a
+// user's emails or the site configuration), so the scopes are also stored in the request context
+// and checked when the data is resolved (see authz.CheckScope): data that no read-only scope
+// covers requires site admin or same-user checks, which reject read-only scopes, and repositories
+// and settings require the corresponding scope.
+
+// graphQLQueryFieldScopes maps the top-level GraphQL query fields that may be used by access tokens
+// with read-only scopes to the scope that is required to use them. Fields that are not listed may
+// not be used (an empty scope means that any read-only scope suffices).
+var graphQLQueryFieldScopes = map[string]string{
+	"__typename": "",
+	"__schema":   "",
+	"__type":     "",
+
+	"search":       authz.ScopeSearchRead,
+	"savedQueries": authz.ScopeSearchRead,
+	"repoGroups":   authz.ScopeSearchRead,
+
+	"repository":      authz.ScopeRepoRead,
+	"repositories":    authz.ScopeRepoRead,
+	"phabricatorRepo": authz.ScopeRepoRead,
+
+	"settingsSubject":     authz.ScopeSettingsRead,
+	"viewerSettings":      authz.ScopeSettingsRead,
+	"viewerConfiguration": authz.ScopeSettingsRead,
+}
+
+// maxGraphQLRequestSize is the maximum size of a GraphQL request body that is checked by
+// checkReadOnlyScopes. Larger requests are rejected.
+const maxGraphQLRequestSize = 10 * 1024 * 1024
+
+// checkReadOnlyScopes returns an error if the request may not be made with an access token that has
+// (only) the given read-only scopes.
+//
+// 🚨 SECURITY: This is what restricts access tokens with read-only scopes, so it must reject every
+// request that it doesn't know to be permitted by the scopes.
+func checkReadOnlyScopes(r *http.Request, scopes []string) error {
+	hasScope := func(scope string) bool {
+		for _, s := range scopes {
+			if s == scope {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch r.URL.Path {
+	case "/.api/graphql":
+		if r.Method != "POST" {
+			return errors.New("method must be POST")
+		}
+		if r.Body == nil {
+			return errors.New("empty GraphQL request")
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxGraphQLRequestSize+1))
+		if err != nil {
+			return errors.Wrap(err, "reading GraphQL request")
+		}
+		if len(body) > maxGraphQLRequestSize {
+			return errors.New("GraphQL request is too large")
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body)) // for the GraphQL handler
+
+		var params struct {
+			Query string `json:"query"`
+		}
+		if err := json.Unmarshal(body, &params); err != nil {
+			return errors.Wrap(err, "parsing GraphQL request")
+		}
+		fields, err := graphQLQueryFields(params.Query)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			scope, ok := graphQLQueryFieldScopes[field]
+			if !ok {
+				return fmt.Errorf("GraphQL query field %q may not be used with read-only access token scopes", field)
+			}
+			if scope != "" && !hasScope(scope) {
+				return fmt.Errorf("GraphQL query field %q requires access token scope %q", field, scope)
+			}
+		}
+		return nil
+
+	case "/.api/search/stream":
+		if !hasScope(authz.ScopeSearchRead) {
+			return fmt.Errorf("searching requires access token scope %q", authz.ScopeSearchRead)
+		}
+		return nil
+
+	default:
+		if r.Method != "GET" && r.Method != "HEAD" {
+			return fmt.Errorf("access tokens with read-only scopes may not be used for %s requests", r.Method)
+		}
+		if !isRepoContentPath(r.URL.Path) {
+			return fmt.Errorf("access tokens with read-only scopes may not be used for %s", r.URL.Path)
+		}
+		if !hasScope(authz.ScopeRepoRead) {
+			return fmt.Errorf("this request requires access token scope %q", authz.ScopeRepoRead)
+		}
+		return nil
+	}
+}
+
+// isRepoContentPath reports whether the URL path is that of a raw, blob, or tree page of a
+// repository in the app (such as /github.com/foo/bar@master/-/raw/README.md).
+func isRepoContentPath(path string) bool {
+	if strings.HasPrefix(path, "/.") {
+		return false // API and other internal routes
+	}
+	delim := "/" + routevar.RepoPathDelim + "/"
+	i := strings.Index(path, delim)
+	if i <= 0 {
+		return false
+	}
+	route := path[i+len(delim):]
+	if j := strings.Index(route, "/"); j != -1 {
+		route = route[:j]
+	}
+	return route == "raw" || route == "blob" || route == "tree"
+}
+
+// graphQLQueryFields returns the names of the top-level fields selected by the operations in the
+// GraphQL document. It returns an error if the document contains any mutation or subscription
+// operations, or if an operation's top-level selections include fragments (whose fields would not
+// be returned).
+//
+// It only scans the document as far as needed to find the top-level fields; the GraphQL server
+// still parses and validates the document.
+func graphQLQueryFields(doc string) ([]string, error) {
+	tokens, err := scanGraphQL(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		fields     []string
+		depth      int  // selection set nesting depth
+		parens     int  // argument and variable definition nesting depth
+		definition bool // whether a definition has started at depth 0
+		fragment   bool // whether the current definition is a fragment definition
+	)
+	for i, tok := range tokens {
+		if parens > 0 {
+			// Skip arguments (which may contain braces in input object values).
+			switch tok {
+			case "(":
+				parens++
+			case ")":
+				parens--
+			}
+			continue
+		}
+
+		switch tok {
+		case "(":
+			parens++
+		case ")":
+			return nil, errors.New("unbalanced parentheses in GraphQL document")
+		case "{":
+			if depth == 0 && !definition {
+				// Query shorthand (an anonymous query without the "query" keyword).
+				definition = true
+			}
+			depth++
+		case "}":
+			if depth == 0 {
+				return nil, errors.New("unbalanced braces in GraphQL document")
+			}
+			depth--
+			if depth == 0 {
+				definition = false
+				fragment = false
+			}
+		default:
+			switch {
+			case depth == 0 && !definition:
+				// The first token of a definition determines what kind of definition it is.
+				definition = true
+				switch tok {
+				case "query":
+				case "fragment":
+					fragment = true
+				default:
+					return nil, fmt.Errorf("GraphQL %q operations may not be used with read-only access token scopes", tok)
+				}
+			case depth == 1 && !fragment:
+				if tok == "..." {
+					return nil, errors.New("GraphQL fragments may not be used in the top-level selections of queries with read-only access token scopes")
+				}
+				if !isGraphQLName(tok) {
+					continue
+				}
+				if i > 0 && tokens[i-1] == "@" {
+					continue // directive name
+				}
+				if i+1 < len(tokens) && tokens[i+1] == ":" {
+					continue // alias
+				}
+				fields = append(fields, tok)
+			}
+		}
+	}
+	if depth != 0 || parens != 0 {
+		return nil, errors.New("unterminated GraphQL document")
+	}
+	return fields, nil
+}
+
+// graphQLValueToken is the token that scanGraphQL returns for string and number values. It is not a
+// valid GraphQL name or punctuator.
+const graphQLValueToken = "<value>"
+
+// scanGraphQL splits a GraphQL document into its names and punctuators. String and number values
+// are returned as graphQLValueToken, and comments, commas, and whitespace are omitted.
+func scanGraphQL(doc string) (tokens []string, err error) {
+	for i := 0; i < len(doc); {
+		c := doc[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(doc) && doc[i] != '\n' && doc[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(doc[i:], `"""`):
+			end := strings.Index(strings.Replace(doc[i+3:], `\"""`, "xxxx", -1), `"""`)
+			if end == -1 {
+				return nil, errors.New("unterminated string in GraphQL document")
+			}
+			tokens = append(tokens, graphQLValueToken)
+			i += 3 + end + 3
+		case c == '"':
+			i++
+			for ; i < len(doc) && doc[i] != '"'; i++ {
+				if doc[i] == '\\' {
+					i++
+				} else if doc[i] == '\n' || doc[i] == '\r' {
+					break
+				}
+			}
+			if i >= len(doc) || doc[i] != '"' {
+				return nil, errors.New("unterminated string in GraphQL document")
+			}
+			tokens = append(tokens, graphQLValueToken)
+			i++
+		case strings.HasPrefix(doc[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case isGraphQLNameStart(c):
+			start := i
+			for i < len(doc) && (isGraphQLNameStart(doc[i]) || isDigit(doc[i])) {
+				i++
+			}
+			tokens = append(tokens, doc[start:i])
+		case isDigit(c) || c == '-':
+			for i < len(doc) && (isDigit(doc[i]) || strings.IndexByte("-+.eE", doc[i]) != -1) {
+				i++
+			}
+			tokens = append(tokens, graphQLValueToken)
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isGraphQLNameStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isGraphQLName(tok string) bool { return tok != "" && isGraphQLNameStart(tok[0]) }
@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraphQLQueryFields(t *testing.T) {
+	tests := map[string]struct {
+		doc        string
+		wantFields []string
+		wantErr    bool
+	}{
+		"shorthand": {
+			doc:        `{ search(query: "x") { results { resultCount } } }`,
+			wantFields: []string{"search"},
+		},
+		"named query with variables": {
+			doc: `query Foo($query: String!, $first: Int = 10) {
+				search(query: $query) { results { resultCount } }
+				repositories(first: $first) { nodes { name } }
+			}`,
+			wantFields: []string{"search", "repositories"},
+		},
+		"aliases, directives, and comments": {
+			doc: `query {
+				# settings { x }
+				a: repository(name: "settings") @include(if: true) { name }
+				__typename
+			}`,
+			wantFields: []string{"repository", "__typename"},
+		},
+		"input object argument": {
+			doc:        `{ repositories(first: 1, filter: { b: "}", c: [1, 2] }) { nodes { name } } viewerSettings { final } }`,
+			wantFields: []string{"repositories", "viewerSettings"},
+		},
+		"block string argument": {
+			doc:        `{ search(query: """x"" \""" } { y""") { results { resultCount } } site { id } }`,
+			wantFields: []string{"search", "site"},
+		},
+		"fragment definitions are not top-level fields": {
+			doc: `query { repository(name: "x") { ...F } }
+			fragment F on Repository { name settings { x } }`,
+			wantFields: []string{"repository"},
+		},
+		"operation named like a keyword": {
+			doc:        `query fragment { currentUser { username } }`,
+			wantFields: []string{"currentUser"},
+		},
+		"multiple operations": {
+			doc:        `query A { search { x } } query B { site { id } }`,
+			wantFields: []string{"search", "site"},
+		},
+		"mutation": {
+			doc:     `mutation { deleteRepository(repository: "x") { alwaysNil } }`,
+			wantErr: true,
+		},
+		"mutation after query": {
+			doc:     `query A { search { x } } mutation B { deleteRepository(repository: "x") { alwaysNil } }`,
+			wantErr: true,
+		},
+		"subscription": {
+			doc:     `subscription { x }`,
+			wantErr: true,
+		},
+		"top-level fragment spread": {
+			doc:     `query { ...F } fragment F on Query { site { id } }`,
+			wantErr: true,
+		},
+		"top-level inline fragment": {
+			doc:     `query { ... on Query { site { id } } }`,
+			wantErr: true,
+		},
+		"unterminated string": {
+			doc:     `{ search(query: "x) { x } }`,
+			wantErr: true,
+		},
+		"unbalanced braces": {
+			doc:     `{ search { x }`,
+			wantErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fields, err := graphQLQueryFields(test.doc)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(fields, test.wantFields) {
+				t.Errorf("got fields %q, want %q", fields, test.wantFields)
+			}
+		})
+	}
+}
+
+func TestIsRepoContentPath(t *testing.T) {
+	tests := map[string]bool{
+		"/github.com/foo/bar/-/raw/README.md":         true,
+		"/github.com/foo/bar@master/-/raw":            true,
+		"/github.com/foo/bar@v1.0/-/blob/cmd/main.go": true,
+		"/github.com/foo/bar/-/tree/cmd":              true,
+		"/github.com/foo/bar":                         false,
+		"/github.com/foo/bar/-/settings":              false,
+		"/github.com/foo/bar/-/settings/-/raw":        false,
+		"/github.com/foo/bar/-/rawx":                  false,
+		"/-/raw/README.md":                            false,
+		"/.api/repos/github.com/foo/bar/-/raw":        false,
+		"/site-admin/configuration":                   false,
+	}
+	for path, want := range tests {
+		if got := isRepoContentPath(path); got != want {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+}
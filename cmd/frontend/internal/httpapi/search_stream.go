@@ -0,0 +1,64 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+)
+
+// serveSearchStream runs the search query in the "q" URL query parameter and
+// streams its results to the client as Server-Sent Events (see
+// graphqlbackend.StreamSearch for the events).
+func serveSearchStream(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		return &errcode.HTTPErr{Status: http.StatusBadRequest, Err: errors.New("missing q parameter")}
+	}
+
+	ew := newEventStreamWriter(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // disable buffering by nginx
+	w.WriteHeader(http.StatusOK)
+
+	// Errors writing the response are caused by the client going away, so
+	// there is nothing left to report them to.
+	_ = graphqlbackend.StreamSearch(r.Context(), q, ew)
+	return nil
+}
+
+// eventStreamWriter writes Server-Sent Events
+// (https://www.w3.org/TR/eventsource/) with JSON data to an HTTP response.
+type eventStreamWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher // nil if w does not support flushing
+}
+
+func newEventStreamWriter(w http.ResponseWriter) *eventStreamWriter {
+	flusher, _ := w.(http.Flusher)
+	return &eventStreamWriter{w: w, flusher: flusher}
+}
+
+// Event implements graphqlbackend.SearchEventWriter.
+func (e *eventStreamWriter) Event(name string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// JSON does not contain raw newlines, so the data fits on one line.
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "event: %s\ndata: %s\n\n", name, b)
+	if _, err := e.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if e.flusher != nil {
+		e.flusher.Flush()
+	}
+	return nil
+}
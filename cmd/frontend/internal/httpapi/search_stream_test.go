@@ -0,0 +1,26 @@
+package httpapi
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEventStreamWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := newEventStreamWriter(rec)
+	if err := w.Event("progress", map[string]interface{}{"matchCount": 1, "preview": "a\nb"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Event("done", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "event: progress\ndata: {\"matchCount\":1,\"preview\":\"a\\nb\"}\n\n" +
+		"event: done\ndata: {}\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !rec.Flushed {
+		t.Error("response was not flushed")
+	}
+}
@@ -0,0 +1,213 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// maxWebhookPayloadSize is the largest webhook payload that we read. GitHub caps payloads at
+// 25 MB.
+const maxWebhookPayloadSize = 25 << 20
+
+var errInvalidWebhookSignature = &errcode.HTTPErr{
+	Status: http.StatusUnauthorized,
+	Err:    errors.New("webhook signature does not match the webhookSecret of any configured connection"),
+}
+
+// serveWebhook receives the push events of code hosts and updates the pushed repositories right
+// away, instead of when the update scheduler next gets to them (which can be hours later for
+// repositories that don't change often).
+//
+// A webhook is matched to a code host connection in site configuration by its signature (or secret
+// token), which must have been computed with the connection's webhookSecret.
+func serveWebhook(w http.ResponseWriter, r *http.Request) error {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxWebhookPayloadSize))
+	if err != nil {
+		return err
+	}
+
+	var repos []api.RepoName
+	switch mux.Vars(r)["CodeHost"] {
+	case "github":
+		repos, err = gitHubWebhookRepos(r.Header, body)
+	case "gitlab":
+		repos, err = gitLabWebhookRepos(r.Header, body)
+	case "bitbucket-server":
+		repos, err = bitbucketServerWebhookRepos(r.Header, body)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 🚨 SECURITY: The request is authenticated by its signature, which proves that it comes from
+	// the code host. Enqueuing an update does not reveal anything about the repository to the
+	// caller, so we look up the repository as an internal actor.
+	ctx := actor.WithActor(r.Context(), &actor.Actor{Internal: true})
+	for _, repo := range repos {
+		if err := enqueueWebhookRepoUpdate(ctx, repo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueueWebhookRepoUpdate enqueues an update of the repository, if it exists and is enabled.
+func enqueueWebhookRepoUpdate(ctx context.Context, name api.RepoName) error {
+	repo, err := backend.Repos.GetByName(ctx, name)
+	if errcode.IsNotFound(err) {
+		log15.Debug("Ignoring webhook for unknown repository.", "repo", name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !repo.Enabled {
+		return nil
+	}
+	gitserverRepo, err := backend.GitRepo(ctx, repo)
+	if err != nil {
+		return err
+	}
+	return repoupdater.DefaultClient.EnqueueRepoUpdate(ctx, gitserverRepo)
+}
+
+// gitHubWebhookRepos returns the names of the repositories that a GitHub webhook reports a push
+// to. See https://developer.github.com/webhooks/.
+func gitHubWebhookRepos(header http.Header, body []byte) ([]api.RepoName, error) {
+	for _, c := range conf.Get().Github {
+		if c.WebhookSecret == "" || !validHubSignature(header, body, c.WebhookSecret) {
+			continue
+		}
+		if header.Get("X-GitHub-Event") != "push" {
+			return nil, nil // e.g. the ping event sent when a webhook is created
+		}
+		var payload struct {
+			Repository struct {
+				FullName string `json:"full_name"`
+			} `json:"repository"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, badWebhookPayload(err)
+		}
+		return []api.RepoName{reposource.GitHubRepoName(c.RepositoryPathPattern, hostname(c.Url), payload.Repository.FullName)}, nil
+	}
+	return nil, errInvalidWebhookSignature
+}
+
+// gitLabWebhookRepos returns the names of the repositories that a GitLab webhook (or system hook)
+// reports a push to. See https://docs.gitlab.com/ee/user/project/integrations/webhooks.html.
+func gitLabWebhookRepos(header http.Header, body []byte) ([]api.RepoName, error) {
+	// GitLab sends the secret token itself, not a signature.
+	token := []byte(header.Get("X-Gitlab-Token"))
+	for _, c := range conf.Get().Gitlab {
+		if c.WebhookSecret == "" || subtle.ConstantTimeCompare(token, []byte(c.WebhookSecret)) != 1 {
+			continue
+		}
+		var payload struct {
+			ObjectKind string `json:"object_kind"`
+			Project    struct {
+				PathWithNamespace string `json:"path_with_namespace"`
+			} `json:"project"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, badWebhookPayload(err)
+		}
+		if payload.ObjectKind != "push" && payload.ObjectKind != "tag_push" {
+			return nil, nil
+		}
+		return []api.RepoName{reposource.GitLabRepoName(c.RepositoryPathPattern, hostname(c.Url), payload.Project.PathWithNamespace)}, nil
+	}
+	return nil, errInvalidWebhookSignature
+}
+
+// bitbucketServerWebhookRepos returns the names of the repositories that a Bitbucket Server
+// webhook reports a push to. See
+// https://confluence.atlassian.com/bitbucketserver/managing-webhooks-in-bitbucket-server-938025878.html.
+func bitbucketServerWebhookRepos(header http.Header, body []byte) ([]api.RepoName, error) {
+	for _, c := range conf.Get().BitbucketServer {
+		if c.WebhookSecret == "" || !validHubSignature(header, body, c.WebhookSecret) {
+			continue
+		}
+		if header.Get("X-Event-Key") != "repo:refs_changed" {
+			return nil, nil // e.g. diagnostics:ping
+		}
+		var payload struct {
+			Repository struct {
+				Slug    string `json:"slug"`
+				Project struct {
+					Key string `json:"key"`
+				} `json:"project"`
+			} `json:"repository"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, badWebhookPayload(err)
+		}
+		return []api.RepoName{reposource.BitbucketServerRepoName(c.RepositoryPathPattern, hostname(c.Url), payload.Repository.Project.Key, payload.Repository.Slug)}, nil
+	}
+	return nil, errInvalidWebhookSignature
+}
+
+// validHubSignature reports whether the X-Hub-Signature-256 (or X-Hub-Signature) header of a
+// webhook request is the HMAC of its body with the secret. The header value has the form
+// "sha256=<hex digest>" (or "sha1=<hex digest>").
+func validHubSignature(header http.Header, body []byte, secret string) bool {
+	sig := header.Get("X-Hub-Signature-256")
+	if sig == "" {
+		sig = header.Get("X-Hub-Signature")
+	}
+	i := strings.Index(sig, "=")
+	if i < 0 {
+		return false
+	}
+	var h func() hash.Hash
+	switch sig[:i] {
+	case "sha256":
+		h = sha256.New
+	case "sha1":
+		h = sha1.New
+	default:
+		return false
+	}
+	want, err := hex.DecodeString(sig[i+1:])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+func badWebhookPayload(err error) error {
+	return &errcode.HTTPErr{Status: http.StatusBadRequest, Err: errors.Wrap(err, "invalid webhook payload")}
+}
+
+// hostname returns the hostname of a code host connection's URL, as used in repository names.
+func hostname(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,140 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestWebhook(t *testing.T) {
+	conf.Mock(&schema.SiteConfiguration{
+		Github:          []*schema.GitHubConnection{{Url: "https://github.com", WebhookSecret: "s1"}},
+		Gitlab:          []*schema.GitLabConnection{{Url: "https://gitlab.com", WebhookSecret: "s2"}},
+		BitbucketServer: []*schema.BitbucketServerConnection{{Url: "https://bitbucket.example.com", WebhookSecret: "s3"}},
+	})
+	defer conf.Mock(nil)
+
+	var enqueued []api.RepoName
+	repoupdater.MockEnqueueRepoUpdate = func(ctx context.Context, repo gitserver.Repo) error {
+		enqueued = append(enqueued, repo.Name)
+		return nil
+	}
+	defer func() { repoupdater.MockEnqueueRepoUpdate = nil }()
+	repoupdater.MockRepoLookup = func(args protocol.RepoLookupArgs) (*protocol.RepoLookupResult, error) {
+		return &protocol.RepoLookupResult{Repo: &protocol.RepoInfo{Name: args.Repo}}, nil
+	}
+	defer func() { repoupdater.MockRepoLookup = nil }()
+	backend.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		return &types.Repo{Name: name, Enabled: name != "github.com/gorilla/disabled"}, nil
+	}
+	defer func() { backend.Mocks.Repos.GetByName = nil }()
+
+	sign := func(secret, body string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	const (
+		gitHubPush          = `{"repository":{"full_name":"gorilla/mux"}}`
+		gitHubPushDisabled  = `{"repository":{"full_name":"gorilla/disabled"}}`
+		gitLabPush          = `{"object_kind":"push","project":{"path_with_namespace":"gitlab-org/gitaly"}}`
+		bitbucketServerPush = `{"repository":{"slug":"mux","project":{"key":"GOR"}}}`
+	)
+
+	tests := map[string]struct {
+		path       string
+		header     map[string]string
+		body       string
+		wantStatus int
+		wantRepos  []api.RepoName
+	}{
+		"github push": {
+			path:       "/webhooks/github",
+			header:     map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign("s1", gitHubPush)},
+			body:       gitHubPush,
+			wantStatus: http.StatusOK,
+			wantRepos:  []api.RepoName{"github.com/gorilla/mux"},
+		},
+		"github ping": {
+			path:       "/webhooks/github",
+			header:     map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": sign("s1", `{}`)},
+			body:       `{}`,
+			wantStatus: http.StatusOK,
+		},
+		"github disabled repo": {
+			path:       "/webhooks/github",
+			header:     map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign("s1", gitHubPushDisabled)},
+			body:       gitHubPushDisabled,
+			wantStatus: http.StatusOK,
+		},
+		"github wrong secret": {
+			path:       "/webhooks/github",
+			header:     map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": sign("s2", gitHubPush)},
+			body:       gitHubPush,
+			wantStatus: http.StatusUnauthorized,
+		},
+		"github unsigned": {
+			path:       "/webhooks/github",
+			header:     map[string]string{"X-GitHub-Event": "push"},
+			body:       gitHubPush,
+			wantStatus: http.StatusUnauthorized,
+		},
+		"gitlab push": {
+			path:       "/webhooks/gitlab",
+			header:     map[string]string{"X-Gitlab-Token": "s2"},
+			body:       gitLabPush,
+			wantStatus: http.StatusOK,
+			wantRepos:  []api.RepoName{"gitlab.com/gitlab-org/gitaly"},
+		},
+		"gitlab wrong token": {
+			path:       "/webhooks/gitlab",
+			header:     map[string]string{"X-Gitlab-Token": "s1"},
+			body:       gitLabPush,
+			wantStatus: http.StatusUnauthorized,
+		},
+		"bitbucket server push": {
+			path:       "/webhooks/bitbucket-server",
+			header:     map[string]string{"X-Event-Key": "repo:refs_changed", "X-Hub-Signature": sign("s3", bitbucketServerPush)},
+			body:       bitbucketServerPush,
+			wantStatus: http.StatusOK,
+			wantRepos:  []api.RepoName{"bitbucket.example.com/GOR/mux"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			enqueued = nil
+			req, _ := http.NewRequest("POST", test.path, strings.NewReader(test.body))
+			for k, v := range test.header {
+				req.Header.Set(k, v)
+			}
+			resp, err := newTest().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != test.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, test.wantStatus)
+			}
+			if len(enqueued) != len(test.wantRepos) {
+				t.Fatalf("got enqueued repos %v, want %v", enqueued, test.wantRepos)
+			}
+			for i := range enqueued {
+				if enqueued[i] != test.wantRepos[i] {
+					t.Errorf("got enqueued repos %v, want %v", enqueued, test.wantRepos)
+				}
+			}
+		})
+	}
+}
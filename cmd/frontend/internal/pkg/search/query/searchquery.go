@@ -3,6 +3,8 @@
 package query
 
 import (
+	"strings"
+
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/syntax"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/types"
 )
@@ -19,6 +21,10 @@ const (
 	FieldLang      = "lang"
 	FieldType      = "type"
 
+	// For filtering repositories:
+	FieldRepoHasFile        = "repohasfile"
+	FieldRepoHasCommitAfter = "repohascommitafter"
+
 	// For diff and commit search only:
 	FieldBefore    = "before"
 	FieldAfter     = "after"
@@ -27,27 +33,33 @@ const (
 	FieldMessage   = "message"
 
 	// Temporary experimental fields:
-	FieldIndex   = "index"
-	FieldCount   = "count" // Searches that specify `count:` will fetch at least that number of results, or the full result set
-	FieldMax     = "max"   // Deprecated alias for count
-	FieldTimeout = "timeout"
+	FieldIndex       = "index"
+	FieldCount       = "count" // Searches that specify `count:` will fetch at least that number of results, or the full result set
+	FieldMax         = "max"   // Deprecated alias for count
+	FieldTimeout     = "timeout"
+	FieldPatternType = "patterntype" // "regexp" (default) or "structural"
+	FieldCountBy     = "countby"     // "repo", "file", "author" or "capture"
 )
 
 var (
 	regexpNegatableFieldType = types.FieldType{Literal: types.RegexpType, Quoted: types.RegexpType, Negatable: true}
 	stringFieldType          = types.FieldType{Literal: types.StringType, Quoted: types.StringType}
+	globalStringFieldType    = types.FieldType{Literal: types.StringType, Quoted: types.StringType, Global: true}
 
 	conf = types.Config{
 		FieldTypes: map[string]types.FieldType{
 			FieldDefault:   {Literal: types.RegexpType, Quoted: types.StringType},
-			FieldCase:      {Literal: types.BoolType, Quoted: types.BoolType, Singular: true},
+			FieldCase:      {Literal: types.BoolType, Quoted: types.BoolType, Singular: true, Global: true},
 			FieldRepo:      regexpNegatableFieldType,
-			FieldRepoGroup: types.FieldType{Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldRepoGroup: types.FieldType{Literal: types.StringType, Quoted: types.StringType, Singular: true, Global: true},
 			FieldFile:      regexpNegatableFieldType,
-			FieldFork:      {Literal: types.StringType, Quoted: types.StringType, Singular: true},
-			FieldArchived:  {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldFork:      {Literal: types.StringType, Quoted: types.StringType, Singular: true, Global: true},
+			FieldArchived:  {Literal: types.StringType, Quoted: types.StringType, Singular: true, Global: true},
 			FieldLang:      types.FieldType{Literal: types.StringType, Quoted: types.StringType, Negatable: true},
-			FieldType:      stringFieldType,
+			FieldType:      globalStringFieldType,
+
+			FieldRepoHasFile:        regexpNegatableFieldType,
+			FieldRepoHasCommitAfter: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 
 			FieldBefore:    stringFieldType,
 			FieldAfter:     stringFieldType,
@@ -56,10 +68,12 @@ var (
 			FieldMessage:   regexpNegatableFieldType,
 
 			// Experimental fields:
-			FieldIndex:   {Literal: types.StringType, Quoted: types.StringType, Singular: true},
-			FieldCount:   {Literal: types.StringType, Quoted: types.StringType, Singular: true},
-			FieldMax:     {Literal: types.StringType, Quoted: types.StringType, Singular: true},
-			FieldTimeout: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldIndex:       {Literal: types.StringType, Quoted: types.StringType, Singular: true, Global: true},
+			FieldCount:       {Literal: types.StringType, Quoted: types.StringType, Singular: true, Global: true},
+			FieldMax:         {Literal: types.StringType, Quoted: types.StringType, Singular: true, Global: true},
+			FieldTimeout:     {Literal: types.StringType, Quoted: types.StringType, Singular: true, Global: true},
+			FieldPatternType: {Literal: types.StringType, Quoted: types.StringType, Singular: true, Global: true},
+			FieldCountBy:     {Literal: types.StringType, Quoted: types.StringType, Singular: true, Global: true},
 		},
 		FieldAliases: map[string]string{
 			"r":        FieldRepo,
@@ -93,6 +107,9 @@ func parseAndCheck(conf *types.Config, input string) (*Query, error) {
 	if err != nil {
 		return nil, err
 	}
+	if isStructural(syntaxQuery.Expr) {
+		conf = structuralConf(conf)
+	}
 	checkedQuery, err := conf.Check(syntaxQuery)
 	if err != nil {
 		return nil, err
@@ -100,6 +117,66 @@ func parseAndCheck(conf *types.Config, input string) (*Query, error) {
 	return &Query{conf: conf, Query: checkedQuery}, nil
 }
 
+// isStructural reports whether exprs contain a patterntype:structural field.
+func isStructural(exprs []*syntax.Expr) bool {
+	for _, expr := range exprs {
+		if expr.Op != syntax.OpNone {
+			if isStructural(expr.Operands) {
+				return true
+			}
+			continue
+		}
+		if expr.Field == FieldPatternType && strings.Trim(expr.Value, `"'`) == "structural" {
+			return true
+		}
+	}
+	return false
+}
+
+// structuralConf returns a copy of conf in which the default field is
+// string-typed, because structural patterns are not regexps (e.g., ":[a] +
+// :[b]").
+func structuralConf(conf *types.Config) *types.Config {
+	fieldTypes := make(map[string]types.FieldType, len(conf.FieldTypes))
+	for field, fieldType := range conf.FieldTypes {
+		fieldTypes[field] = fieldType
+	}
+	if fieldType, ok := fieldTypes[FieldDefault]; ok {
+		fieldType.Literal = types.StringType
+		fieldType.Quoted = types.StringType
+		fieldTypes[FieldDefault] = fieldType
+	}
+	return &types.Config{FieldTypes: fieldTypes, FieldAliases: conf.FieldAliases}
+}
+
+// Disjuncts returns the conjunctive queries whose union q matches. For a query
+// that does not use the "OR" operator, it returns q.
+func (q *Query) Disjuncts() []*Query {
+	if len(q.Query.Disjuncts) == 0 {
+		return []*Query{q}
+	}
+	disjuncts := make([]*Query, len(q.Query.Disjuncts))
+	for i, d := range q.Query.Disjuncts {
+		disjuncts[i] = &Query{conf: q.conf, Query: d}
+	}
+	return disjuncts
+}
+
+// Partition groups q's disjuncts by their values for all fields other than the
+// given ones. See (*types.Query).Partition.
+func (q *Query) Partition(fields ...string) []*Query {
+	partitions := q.Query.Partition(fields...)
+	queries := make([]*Query, len(partitions))
+	for i, p := range partitions {
+		if p == q.Query {
+			queries[i] = q
+		} else {
+			queries[i] = &Query{conf: q.conf, Query: p}
+		}
+	}
+	return queries
+}
+
 // BoolValue returns the last boolean value (yes/no) for the field. For example, if the query is
 // "foo:yes foo:no foo:yes", then the last boolean value for the "foo" field is true ("yes"). The
 // default boolean value is false.
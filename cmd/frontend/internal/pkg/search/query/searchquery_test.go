@@ -131,6 +131,34 @@ func TestQuery_StringValues(t *testing.T) {
 	})
 }
 
+func TestQuery_Disjuncts(t *testing.T) {
+	conf := types.Config{
+		FieldTypes: map[string]types.FieldType{
+			FieldDefault: {Literal: types.RegexpType, Quoted: types.StringType},
+			FieldFile:    regexpNegatableFieldType,
+		},
+	}
+
+	query, err := parseAndCheck(&conf, "(a OR b) file:c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]string
+	for _, d := range query.Disjuncts() {
+		v, _ := d.RegexpPatterns(FieldFile)
+		got = append(got, v)
+	}
+	if want := [][]string{{"c"}, {"c"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if partitions := query.Partition(FieldDefault); len(partitions) != 1 || partitions[0] != query {
+		t.Errorf("got partitions %v, want only the query itself", partitions)
+	}
+	if partitions := query.Partition(); len(partitions) != 2 {
+		t.Errorf("got %d partitions, want 2", len(partitions))
+	}
+}
+
 func checkPanic(t *testing.T, msg string, f func()) {
 	t.Helper()
 	defer func() {
@@ -142,3 +170,31 @@ func checkPanic(t *testing.T, msg string, f func()) {
 	}()
 	f()
 }
+
+func TestParseAndCheck_structural(t *testing.T) {
+	conf := types.Config{
+		FieldTypes: map[string]types.FieldType{
+			FieldDefault:     {Literal: types.RegexpType, Quoted: types.StringType},
+			FieldPatternType: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+		},
+	}
+
+	if _, err := parseAndCheck(&conf, ":[a] + :[b]"); err == nil {
+		t.Error("got err == nil for an invalid regexp, want error")
+	}
+
+	query, err := parseAndCheck(&conf, ":[a] + :[b] patterntype:structural")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, v := range query.Values(FieldDefault) {
+		got = append(got, *v.String)
+	}
+	if want := []string{":[a]", "+", ":[b]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if conf.FieldTypes[FieldDefault].Literal != types.RegexpType {
+		t.Error("conf was modified")
+	}
+}
@@ -27,11 +27,20 @@ type context struct {
 //
 // BNF-ish query syntax:
 //
-//   exprList  := {exprSign} | exprSign (sep exprSign)*
+//   query     := {orExpr}
+//   orExpr    := andExpr (sep "OR" sep andExpr)*
+//   andExpr   := unaryExpr ((sep | sep "AND" sep) unaryExpr)*
+//   unaryExpr := "NOT" sep unaryExpr | {"-"} "(" orExpr ")" | exprSign
 //   exprSign  := {"-"} expr
-//   expr      := fieldExpr | lit | quoted | pattern
+//   expr      := fieldExpr | lit | ":" lit | quoted | pattern
 //   fieldExpr := lit ":" value
 //   value     := lit | quoted
+//
+// The keywords "AND", "OR" and "NOT" are operators unless they are quoted or
+// used as a field name or value. They must be written in upper case, so that
+// queries containing the words "and", "or" and "not" still search for them.
+// Adjacent expressions are implicitly AND-ed, and AND binds more tightly than
+// OR.
 func Parse(input string) (*Query, error) {
 	tokens := Scan(input)
 	p := parser{tokens: tokens}
@@ -68,30 +77,157 @@ func (p *parser) next() Token {
 	return Token{Type: TokenEOF}
 }
 
-// exprList := {exprSign} | exprSign (sep exprSign)*
+// exprList := {orExpr}
 func (p *parser) parseExprList(ctx context) (exprList []*Expr, err error) {
+	p.skipSep()
 	if p.peek().Type == TokenEOF {
 		return nil, nil
 	}
 
+	expr, err := p.parseOrExpr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.Type != TokenEOF {
+		return nil, &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want separator or EOF", tok.Type)}
+	}
+
+	// The top-level AND is implicit, so its operands are the query's
+	// expressions.
+	if expr.Op == OpAnd && !expr.Not {
+		return expr.Operands, nil
+	}
+	return []*Expr{expr}, nil
+}
+
+// orExpr := andExpr (sep "OR" sep andExpr)*
+func (p *parser) parseOrExpr(ctx context) (*Expr, error) {
+	pos := p.peek().Pos
+	var operands []*Expr
+	for {
+		expr, err := p.parseAndExpr(ctx)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, expr)
+
+		p.skipSep()
+		if !p.peekKeyword("OR") {
+			break
+		}
+		p.next()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &Expr{Pos: pos, Op: OpOr, Operands: operands}, nil
+}
+
+// andExpr := unaryExpr ((sep | sep "AND" sep) unaryExpr)*
+func (p *parser) parseAndExpr(ctx context) (*Expr, error) {
+	pos := p.peek().Pos
+	var operands []*Expr
 	for {
-		tok := p.peek()
-		if tok.Type == TokenEOF {
+		p.skipSep()
+		if tok := p.peek(); tok.Type == TokenEOF || tok.Type == TokenRParen || p.peekKeyword("OR") {
+			if len(operands) == 0 {
+				return nil, &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want expr", describeToken(tok))}
+			}
 			break
 		}
-		if tok.Type == TokenSep {
+		if len(operands) > 0 && p.peekKeyword("AND") {
 			p.next()
-			continue
+			p.skipSep()
+			if tok := p.peek(); tok.Type == TokenEOF || tok.Type == TokenRParen || p.peekKeyword("OR") {
+				return nil, &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want expr", describeToken(tok))}
+			}
+		}
+
+		expr, err := p.parseUnaryExpr(ctx)
+		if err != nil {
+			return nil, err
 		}
+		operands = append(operands, expr)
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &Expr{Pos: pos, Op: OpAnd, Operands: operands}, nil
+}
 
-		expr, err := p.parseExprSign(ctx)
+// unaryExpr := "NOT" sep unaryExpr | {"-"} "(" orExpr ")" | exprSign
+func (p *parser) parseUnaryExpr(ctx context) (*Expr, error) {
+	if p.peekKeyword("NOT") {
+		tok := p.next()
+		p.skipSep()
+		expr, err := p.parseUnaryExpr(ctx)
 		if err != nil {
 			return nil, err
 		}
-		exprList = append(exprList, expr)
+		expr.Not = !expr.Not
+		expr.Pos = tok.Pos
+		return expr, nil
+	}
+
+	not := false
+	tok := p.next()
+	if tok.Type == TokenMinus && p.peek().Type == TokenLParen {
+		not = true
+		tok = p.next()
+	}
+	if tok.Type != TokenLParen {
+		p.backup()
+		if not {
+			p.backup()
+		}
+		return p.parseExprSign(ctx)
+	}
+
+	p.skipSep()
+	if tok2 := p.peek(); tok2.Type == TokenRParen {
+		return nil, &ParseError{Pos: tok2.Pos, Msg: "empty group"}
+	}
+	expr, err := p.parseOrExpr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSep()
+	if tok2 := p.next(); tok2.Type != TokenRParen {
+		return nil, &ParseError{Pos: tok2.Pos, Msg: fmt.Sprintf("got %s, want %s", tok2.Type, TokenRParen)}
+	}
+	if expr.Op != OpNone {
+		expr.Pos = tok.Pos
+	}
+	if not {
+		expr.Not = !expr.Not
+	}
+	return expr, nil
+}
+
+// skipSep consumes any separator tokens at the current position.
+func (p *parser) skipSep() {
+	for p.peek().Type == TokenSep {
+		p.next()
+	}
+}
+
+// peekKeyword reports whether the next token is the given (upper-case)
+// operator keyword. A literal followed by a colon is a field name, not a
+// keyword.
+func (p *parser) peekKeyword(keyword string) bool {
+	tok := p.peek()
+	if tok.Type != TokenLiteral || tok.Value != keyword {
+		return false
 	}
+	return p.pos+1 >= len(p.tokens) || p.tokens[p.pos+1].Type != TokenColon
+}
 
-	return exprList, nil
+// describeToken returns a description of tok for use in error messages.
+func describeToken(tok Token) string {
+	if tok.Type == TokenLiteral {
+		return fmt.Sprintf("%q", tok.Value)
+	}
+	return tok.Type.String()
 }
 
 // exprSign := {"-"} expr
@@ -118,7 +254,7 @@ func (p *parser) parseExprSign(ctx context) (*Expr, error) {
 	return expr, nil
 }
 
-// expr := exprField | lit | quoted | pattern
+// expr := exprField | lit | ":" lit | quoted | pattern
 func (p *parser) parseExpr(ctx context) (*Expr, error) {
 	tok := p.next()
 	switch tok.Type {
@@ -129,29 +265,49 @@ func (p *parser) parseExpr(ctx context) (*Expr, error) {
 			valueTok := p.next()
 			switch valueTok.Type {
 			case TokenLiteral, TokenQuoted:
-				if tok3 := p.next(); tok3.Type != TokenSep && tok3.Type != TokenEOF {
-					return nil, &ParseError{Pos: tok3.Pos, Msg: fmt.Sprintf("got %s, want separator or EOF", tok3.Type)}
+				if err := p.expectExprEnd(); err != nil {
+					return nil, err
 				}
 				return &Expr{Pos: tok.Pos, Field: tok.Value, Value: valueTok.Value, ValueType: valueTok.Type}, nil
-			case TokenSep, TokenEOF:
+			case TokenSep, TokenEOF, TokenRParen:
+				p.backup()
 				return &Expr{Pos: tok.Pos, Field: tok.Value, Value: "", ValueType: TokenLiteral}, nil
 			default:
 				return nil, &ParseError{Pos: valueTok.Pos, Msg: fmt.Sprintf("got %s, want value", valueTok.Type)}
 			}
-		case TokenSep, TokenEOF:
+		case TokenSep, TokenEOF, TokenRParen:
+			p.backup()
 			return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
 		default:
 			panic("unreachable")
 		}
 	case TokenQuoted, TokenPattern:
-		tok2 := p.next()
-		switch tok2.Type {
-		case TokenSep, TokenEOF:
-			return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
-		default:
-			return nil, &ParseError{Pos: tok2.Pos, Msg: fmt.Sprintf("got %s, want separator or EOF", tok2.Type)}
+		if err := p.expectExprEnd(); err != nil {
+			return nil, err
+		}
+		return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
+	case TokenColon:
+		// A term that starts with a colon, such as the structural pattern
+		// hole ":[a]", is a literal (there is no field name).
+		if tok2 := p.peek(); tok2.Type == TokenLiteral {
+			p.next()
+			if err := p.expectExprEnd(); err != nil {
+				return nil, err
+			}
+			return &Expr{Pos: tok.Pos, Value: ":" + tok2.Value, ValueType: TokenLiteral}, nil
 		}
 	}
 
 	return nil, &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want expr", tok.Type)}
 }
+
+// expectExprEnd checks that the next token ends an expression (a separator, the
+// end of a group or EOF) without consuming it.
+func (p *parser) expectExprEnd() error {
+	tok := p.peek()
+	switch tok.Type {
+	case TokenSep, TokenEOF, TokenRParen:
+		return nil
+	}
+	return &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want separator or EOF", tok.Type)}
+}
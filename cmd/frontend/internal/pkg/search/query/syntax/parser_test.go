@@ -67,6 +67,121 @@ func TestParser(t *testing.T) {
 				{Field: "b", Value: "", ValueType: TokenLiteral},
 			},
 		},
+		"a AND b": {
+			wantExpr: []*Expr{
+				{Value: "a", ValueType: TokenLiteral},
+				{Value: "b", ValueType: TokenLiteral},
+			},
+			wantString: "a b",
+		},
+		"a OR b": {
+			wantExpr: []*Expr{{Op: OpOr, Operands: []*Expr{
+				{Value: "a", ValueType: TokenLiteral},
+				{Value: "b", ValueType: TokenLiteral},
+			}}},
+			wantString: "(a OR b)",
+		},
+		"a or b": {
+			wantExpr: []*Expr{
+				{Value: "a", ValueType: TokenLiteral},
+				{Value: "or", ValueType: TokenLiteral},
+				{Value: "b", ValueType: TokenLiteral},
+			},
+		},
+		"a and b": {
+			wantExpr: []*Expr{
+				{Value: "a", ValueType: TokenLiteral},
+				{Value: "and", ValueType: TokenLiteral},
+				{Value: "b", ValueType: TokenLiteral},
+			},
+		},
+		"not a": {
+			wantExpr: []*Expr{
+				{Value: "not", ValueType: TokenLiteral},
+				{Value: "a", ValueType: TokenLiteral},
+			},
+		},
+		"a b OR c": {
+			wantExpr: []*Expr{{Op: OpOr, Operands: []*Expr{
+				{Op: OpAnd, Operands: []*Expr{
+					{Value: "a", ValueType: TokenLiteral},
+					{Value: "b", ValueType: TokenLiteral},
+				}},
+				{Value: "c", ValueType: TokenLiteral},
+			}}},
+			wantString: "((a b) OR c)",
+		},
+		"(a OR b) c:d": {
+			wantExpr: []*Expr{
+				{Op: OpOr, Operands: []*Expr{
+					{Value: "a", ValueType: TokenLiteral},
+					{Value: "b", ValueType: TokenLiteral},
+				}},
+				{Field: "c", Value: "d", ValueType: TokenLiteral},
+			},
+		},
+		"c:d OR c:e": {
+			wantExpr: []*Expr{{Op: OpOr, Operands: []*Expr{
+				{Field: "c", Value: "d", ValueType: TokenLiteral},
+				{Field: "c", Value: "e", ValueType: TokenLiteral},
+			}}},
+			wantString: "(c:d OR c:e)",
+		},
+		"NOT a": {
+			wantExpr:   []*Expr{{Not: true, Value: "a", ValueType: TokenLiteral}},
+			wantString: "-a",
+		},
+		"NOT (a OR b)": {
+			wantExpr: []*Expr{{Not: true, Op: OpOr, Operands: []*Expr{
+				{Value: "a", ValueType: TokenLiteral},
+				{Value: "b", ValueType: TokenLiteral},
+			}}},
+		},
+		"-(a b)": {
+			wantExpr: []*Expr{{Not: true, Op: OpAnd, Operands: []*Expr{
+				{Value: "a", ValueType: TokenLiteral},
+				{Value: "b", ValueType: TokenLiteral},
+			}}},
+			wantString: "NOT (a b)",
+		},
+		"(a)": {
+			wantExpr:   []*Expr{{Value: "a", ValueType: TokenLiteral}},
+			wantString: "a",
+		},
+		"a:OR": {
+			wantExpr: []*Expr{{Field: "a", Value: "OR", ValueType: TokenLiteral}},
+		},
+		`"OR"`: {
+			wantExpr: []*Expr{{Value: `"OR"`, ValueType: TokenQuoted}},
+		},
+		"a OR": {
+			wantErr: &ParseError{Pos: 4, Msg: "got TokenEOF, want expr"},
+		},
+		"OR a": {
+			wantErr: &ParseError{Pos: 0, Msg: `got "OR", want expr`},
+		},
+		"a AND": {
+			wantErr: &ParseError{Pos: 5, Msg: "got TokenEOF, want expr"},
+		},
+		":[a] + :[b]": {
+			wantExpr: []*Expr{
+				{Value: ":[a]", ValueType: TokenLiteral},
+				{Value: "+", ValueType: TokenLiteral},
+				{Value: ":[b]", ValueType: TokenLiteral},
+			},
+		},
+		":": {
+			wantErr: &ParseError{Pos: 0, Msg: "got TokenColon, want expr"},
+		},
+		"a (b": {
+			wantExpr: []*Expr{
+				{Value: "a", ValueType: TokenLiteral},
+				{Value: "(b", ValueType: TokenLiteral},
+			},
+		},
+		"( )": {
+			wantErr: &ParseError{Pos: 2, Msg: "empty group"},
+		},
 		"--": {
 			wantErr: &ParseError{Pos: 1, Msg: "got TokenMinus, want expr"},
 		},
@@ -96,9 +211,7 @@ func TestParser(t *testing.T) {
 			if len(query.Expr) == 0 {
 				query.Expr = []*Expr{}
 			}
-			for _, expr := range query.Expr {
-				expr.Pos = 0
-			}
+			clearPos(query.Expr)
 			if !reflect.DeepEqual(query.Expr, test.wantExpr) {
 				t.Errorf("expr: %s\ngot  %v\nwant %v", input, query.Expr, test.wantExpr)
 			}
@@ -111,3 +224,12 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+// clearPos zeroes the positions of exprs and their operands, so that tests need
+// not specify them.
+func clearPos(exprs []*Expr) {
+	for _, expr := range exprs {
+		expr.Pos = 0
+		clearPos(expr.Operands)
+	}
+}
@@ -8,20 +8,64 @@ import (
 // A Query contains the parse tree of a query.
 type Query struct {
 	Input string  // the original input query string
-	Expr  []*Expr // expressions in this query
+	Expr  []*Expr // expressions in this query (implicitly AND-ed together)
 }
 
-// An Expr describes an expression in a query.
+// Operator is the boolean operator of a compound expression.
+type Operator int
+
+// All Operator values.
+const (
+	OpNone Operator = iota // not a compound expression
+	OpAnd                  // all operands must match
+	OpOr                   // at least one operand must match
+)
+
+func (op Operator) String() string {
+	switch op {
+	case OpAnd:
+		return "AND"
+	case OpOr:
+		return "OR"
+	default:
+		return ""
+	}
+}
+
+// An Expr describes an expression in a query. It is either a field
+// expression (such as "term" or "field:term") or, if Op != OpNone, a compound
+// expression that combines its Operands with a boolean operator.
 type Expr struct {
 	Pos       int       // the starting character position of the query expression
-	Not       bool      // the expression is negated (e.g., -term or -field:term)
+	Not       bool      // the expression is negated (e.g., -term, -field:term or NOT (a OR b))
 	Field     string    // the field that this expression applies to
 	Value     string    // the raw field value
 	ValueType TokenType // the type of the value
+
+	Op       Operator // the boolean operator of a compound expression (or OpNone)
+	Operands []*Expr  // the operands of a compound expression
 }
 
 func (e Expr) String() string {
 	var buf bytes.Buffer
+	if e.Op != OpNone {
+		if e.Not {
+			buf.WriteString("NOT ")
+		}
+		buf.WriteByte('(')
+		for i, operand := range e.Operands {
+			if i > 0 {
+				buf.WriteByte(' ')
+				if e.Op == OpOr {
+					buf.WriteString("OR ")
+				}
+			}
+			buf.WriteString(operand.String())
+		}
+		buf.WriteByte(')')
+		return buf.String()
+	}
+
 	if e.Not {
 		buf.WriteByte('-')
 	}
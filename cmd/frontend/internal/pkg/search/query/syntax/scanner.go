@@ -21,7 +21,9 @@ const (
 	TokenPattern
 	TokenColon
 	TokenMinus
-	TokenSep // separator (like a semicolon)
+	TokenSep    // separator (like a semicolon)
+	TokenLParen // opening parenthesis of a group
+	TokenRParen // closing parenthesis of a group
 )
 
 var singleCharTokens = map[rune]TokenType{
@@ -54,6 +56,10 @@ type scanner struct {
 	pos     int
 	prevPos int
 	start   int
+
+	groupDepth int  // number of currently open groups (TokenLParen without a matching TokenRParen)
+	litParens  int  // balance of parentheses in the literal currently being scanned
+	litEscape  bool // the previous rune in the literal currently being scanned was a backslash
 }
 
 func (s *scanner) next() rune {
@@ -112,6 +118,19 @@ func scanDefault(s *scanner) stateFn {
 			return scanDefault
 		}
 
+		if r == '(' && s.isGroupStart() {
+			s.next()
+			s.emit(TokenLParen)
+			s.groupDepth++
+			return scanDefault
+		}
+		if r == ')' && s.groupDepth > 0 {
+			s.next()
+			s.emit(TokenRParen)
+			s.groupDepth--
+			return scanDefault
+		}
+
 		if r == '"' || r == '\'' {
 			return scanQuoted
 		}
@@ -119,11 +138,75 @@ func scanDefault(s *scanner) stateFn {
 			return scanPattern
 		}
 
+		s.litParens, s.litEscape = 0, false
 		return scanText
 	}
 	return scanSpace
 }
 
+// isGroupStart reports whether the '(' at the current position opens a group.
+// It does so only if its matching ')' ends a term (i.e., is followed by
+// whitespace, another ')' or EOF). This keeps terms such as "(a|b)c" and
+// "(?i)foo" scanning as literals, as they did before groups were supported.
+func (s *scanner) isGroupStart() bool {
+	depth := 0
+	var quote rune
+	prev := ' '
+	for i, w := s.pos, 0; i < len(s.input); i += w {
+		var r rune
+		r, w = utf8.DecodeRuneInString(s.input[i:])
+		switch {
+		case r == '\\':
+			// Skip the escaped character.
+			_, w2 := utf8.DecodeRuneInString(s.input[i+w:])
+			w += w2
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case (r == '"' || r == '\'') && (unicode.IsSpace(prev) || prev == '(' || prev == ':'):
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth == 0 {
+				next := i + w
+				if next == len(s.input) {
+					return true
+				}
+				r2, _ := utf8.DecodeRuneInString(s.input[next:])
+				return unicode.IsSpace(r2) || r2 == ')'
+			}
+		}
+		prev = r
+	}
+	return false
+}
+
+// endsGroup reports whether r, the rune just scanned in a literal, closes the
+// innermost open group instead of being part of the literal. Parentheses that
+// are balanced within the literal (as in "foo(bar)") or escaped (as in "foo\)")
+// are part of the literal.
+func (s *scanner) endsGroup(r rune) bool {
+	if s.litEscape {
+		s.litEscape = false
+		return false
+	}
+	switch r {
+	case '\\':
+		s.litEscape = true
+	case '(':
+		s.litParens++
+	case ')':
+		if s.litParens == 0 && s.groupDepth > 0 {
+			return true
+		}
+		s.litParens--
+	}
+	return false
+}
+
 func scanText(s *scanner) stateFn {
 	// Characters that may come before a ':' (TokenColon) in a TokenLiteral.
 	preColonChars := "abcdefghijklmnopqrstuvwxyz0123456789"
@@ -133,7 +216,7 @@ func scanText(s *scanner) stateFn {
 			break
 		}
 		r := s.next()
-		if unicode.IsSpace(r) {
+		if unicode.IsSpace(r) || s.endsGroup(r) {
 			s.backup()
 			break
 		}
@@ -159,7 +242,7 @@ func scanValue(s *scanner) stateFn {
 		return scanDefault
 	}
 	r := s.peek()
-	if unicode.IsSpace(r) {
+	if unicode.IsSpace(r) || (r == ')' && s.groupDepth > 0) {
 		return scanDefault
 	}
 	if r == '"' || r == '\'' {
@@ -174,7 +257,7 @@ func scanLiteral(s *scanner) stateFn {
 			break
 		}
 		r := s.next()
-		if unicode.IsSpace(r) {
+		if unicode.IsSpace(r) || s.endsGroup(r) {
 			s.backup()
 			break
 		}
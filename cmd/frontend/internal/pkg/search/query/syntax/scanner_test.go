@@ -67,6 +67,20 @@ func TestScanner(t *testing.T) {
 		"a /b/ c":  {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenPattern, TokenSep, TokenLiteral}, wantValues: []string{"a", " ", "b", " ", "c"}},
 		"a /b c":   {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenPattern}, wantValues: []string{"a", " ", "b c"}},
 		"a /b c/":  {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenPattern}, wantValues: []string{"a", " ", "b c"}},
+		"(a)":      {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenRParen}, wantValues: []string{"(", "a", ")"}},
+		"(a b)":    {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"(", "a", " ", "b", ")"}},
+		"((a) b)":  {wantTypes: []TokenType{TokenLParen, TokenLParen, TokenLiteral, TokenRParen, TokenSep, TokenLiteral, TokenRParen}},
+		"(a:b)":    {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenColon, TokenLiteral, TokenRParen}, wantValues: []string{"(", "a", ":", "b", ")"}},
+		"(a: )":    {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenColon, TokenSep, TokenRParen}},
+		"(a:)":     {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenColon, TokenRParen}},
+		`("a)" b)`: {wantTypes: []TokenType{TokenLParen, TokenQuoted, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"(", `"a)"`, " ", "b", ")"}},
+		"(a(b) c)": {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"(", "a(b)", " ", "c", ")"}},
+		`(a\) b)`:  {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"(", `a\)`, " ", "b", ")"}},
+		"(a|b)c":   {wantTypes: []TokenType{TokenLiteral}, wantValues: []string{"(a|b)c"}},
+		"(?i)a":    {wantTypes: []TokenType{TokenLiteral}, wantValues: []string{"(?i)a"}},
+		"(a b":     {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenLiteral}, wantValues: []string{"(a", " ", "b"}},
+		"a)":       {wantTypes: []TokenType{TokenLiteral}, wantValues: []string{"a)"}},
+		"-(a)":     {wantTypes: []TokenType{TokenMinus, TokenLParen, TokenLiteral, TokenRParen}},
 	}
 	for input, test := range tests {
 		t.Run(input, func(t *testing.T) {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -33,28 +34,146 @@ type FieldType struct {
 	Singular  bool      // whether the field may only be used 0 or 1 times
 	Negatable bool      // whether the field can be matched negated (i.e., -field:value)
 
+	// Global is whether the field applies to the whole query (such as an
+	// option like "count:"), so it must have the same values in all of the
+	// alternatives of a query that uses the "OR" operator.
+	Global bool
+
 	// FeatureFlagEnabled returns true if this field is enabled.
 	// The field is always enabled if this is nil.
 	FeatureFlagEnabled func() bool
 }
 
+// maxDisjuncts is the maximum number of disjuncts that a query may expand to
+// when its "OR" expressions are distributed over its "AND" expressions.
+const maxDisjuncts = 32
+
 // Check typechecks the input query for field and type validity.
+//
+// Queries that use the "OR" operator are converted to disjunctive normal form
+// (an OR of conjunctive queries). Negations are pushed down to the field
+// expressions, so "NOT (a OR b)" is checked as "-a -b".
 func (c *Config) Check(query *syntax.Query) (*Query, error) {
-	checkedQuery := Query{
-		Syntax: query,
-		Fields: map[string][]*Value{},
+	conjuncts, err := c.checkOp(syntax.OpAnd, query.Expr, false)
+	if err != nil {
+		return nil, err
+	}
+
+	disjuncts := make([]*Query, len(conjuncts))
+	for i, conjunct := range conjuncts {
+		checkedQuery := Query{
+			Syntax: query,
+			Fields: map[string][]*Value{},
+		}
+		for _, v := range conjunct {
+			if v.fieldType.Singular && len(checkedQuery.Fields[v.field]) >= 1 {
+				return nil, &TypeError{Pos: v.syntax.Pos, Err: fmt.Errorf("field %q may not be used more than once", v.field)}
+			}
+			checkedQuery.Fields[v.field] = append(checkedQuery.Fields[v.field], v.Value)
+		}
+		disjuncts[i] = &checkedQuery
+	}
+	if len(disjuncts) == 1 {
+		return disjuncts[0], nil
+	}
+	common := commonFields(disjuncts)
+	if err := c.checkGlobalFields(disjuncts, common); err != nil {
+		return nil, err
 	}
-	for _, expr := range query.Expr {
-		field, fieldType, value, err := c.checkExpr(expr)
+	return &Query{
+		Syntax:    query,
+		Fields:    common,
+		Disjuncts: disjuncts,
+	}, nil
+}
+
+// checkGlobalFields checks that the values of global fields (see
+// FieldType.Global) are common to all disjuncts. Consumers of a disjunctive
+// query only see its common fields, so a global field that differs between
+// disjuncts would otherwise be silently ignored.
+func (c *Config) checkGlobalFields(disjuncts []*Query, common map[string][]*Value) error {
+	for _, d := range disjuncts {
+		fields := make([]string, 0, len(d.Fields))
+		for field := range d.Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			if !c.FieldTypes[field].Global {
+				continue
+			}
+			for _, v := range d.Fields[field] {
+				if !containsValue(common[field], v) {
+					return &TypeError{Pos: v.syntax.Pos, Err: fmt.Errorf("field %q must have the same value in all alternatives of an OR query", field)}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// A fieldValue is a checked field expression.
+type fieldValue struct {
+	field     string
+	fieldType FieldType
+	*Value
+}
+
+// A conjunct is a list of field values that must all match.
+type conjunct []fieldValue
+
+// checkOp checks the compound expression with the given operator and operands
+// (negated if not is true) and returns it in disjunctive normal form.
+func (c *Config) checkOp(op syntax.Operator, operands []*syntax.Expr, not bool) ([]conjunct, error) {
+	if not {
+		// De Morgan: not (a and b) == (not a) or (not b), and vice versa.
+		if op == syntax.OpAnd {
+			op = syntax.OpOr
+		} else {
+			op = syntax.OpAnd
+		}
+	}
+
+	conjuncts := []conjunct{nil}
+	if op == syntax.OpOr {
+		conjuncts = nil
+	}
+	for _, expr := range operands {
+		operand, err := c.checkTree(expr, not)
 		if err != nil {
 			return nil, err
 		}
-		if fieldType.Singular && len(checkedQuery.Fields[field]) >= 1 {
-			return nil, &TypeError{Pos: expr.Pos, Err: fmt.Errorf("field %q may not be used more than once", field)}
+		if op == syntax.OpOr {
+			conjuncts = append(conjuncts, operand...)
+		} else {
+			product := make([]conjunct, 0, len(conjuncts)*len(operand))
+			for _, a := range conjuncts {
+				for _, b := range operand {
+					product = append(product, append(append(conjunct{}, a...), b...))
+				}
+			}
+			conjuncts = product
+		}
+		if len(conjuncts) > maxDisjuncts {
+			return nil, &TypeError{Pos: expr.Pos, Err: fmt.Errorf("query is too complex (it expands to more than %d alternatives)", maxDisjuncts)}
 		}
-		checkedQuery.Fields[field] = append(checkedQuery.Fields[field], value)
 	}
-	return &checkedQuery, nil
+	return conjuncts, nil
+}
+
+// checkTree checks expr (negated if not is true) and returns it in disjunctive
+// normal form.
+func (c *Config) checkTree(expr *syntax.Expr, not bool) ([]conjunct, error) {
+	not = not != expr.Not
+	if expr.Op != syntax.OpNone {
+		return c.checkOp(expr.Op, expr.Operands, not)
+	}
+
+	field, fieldType, value, err := c.checkExpr(expr, not)
+	if err != nil {
+		return nil, err
+	}
+	return []conjunct{{{field: field, fieldType: fieldType, Value: value}}}, nil
 }
 
 func (c *Config) resolveField(field string, not bool) (resolvedField string, typ FieldType, err error) {
@@ -85,15 +204,18 @@ func (c *Config) resolveField(field string, not bool) (resolvedField string, typ
 	return field, typ, nil
 }
 
-func (c *Config) checkExpr(expr *syntax.Expr) (field string, fieldType FieldType, value *Value, err error) {
+// checkExpr checks the field expression expr. The not parameter reports whether
+// the expression is effectively negated (taking into account negated
+// enclosing expressions).
+func (c *Config) checkExpr(expr *syntax.Expr, not bool) (field string, fieldType FieldType, value *Value, err error) {
 	// Resolve field name.
-	resolvedField, fieldType, err := c.resolveField(expr.Field, expr.Not)
+	resolvedField, fieldType, err := c.resolveField(expr.Field, not)
 	if err != nil {
 		return "", FieldType{}, nil, &TypeError{Pos: expr.Pos, Err: err}
 	}
 
 	// Resolve value.
-	value = &Value{syntax: expr}
+	value = &Value{syntax: expr, not: not}
 	switch expr.ValueType {
 	case syntax.TokenLiteral:
 		if err := setValue(value, expr.Value, fieldType.Literal); err != nil {
@@ -45,6 +45,11 @@ func TestCheck(t *testing.T) {
 				Quoted:   BoolType,
 				Singular: true,
 			},
+			"g": {
+				Literal: StringType,
+				Quoted:  StringType,
+				Global:  true,
+			},
 		},
 		FieldAliases: map[string]string{
 			"f":  "",
@@ -100,6 +105,20 @@ func TestCheck(t *testing.T) {
 		"b:z":        {wantErr: &TypeError{Pos: 0, Err: errors.New(`invalid boolean "z"`)}},
 		`b:"z"`:      {wantErr: &TypeError{Pos: 0, Err: errors.New(`invalid boolean "z"`)}},
 		"z:a":        {wantErr: &TypeError{Pos: 0, Err: errors.New(`unrecognized field "z"`)}},
+		"a AND f:b": {want: map[string][]value{"": {
+			{Value: regexp.MustCompile("a")},
+			{Value: regexp.MustCompile("b")},
+		}}},
+		"NOT r:a":            {want: map[string][]value{"r": {{Not: true, Value: regexp.MustCompile("a")}}}},
+		"NOT (r:a OR r:b)":   {want: map[string][]value{"r": {{Not: true, Value: regexp.MustCompile("a")}, {Not: true, Value: regexp.MustCompile("b")}}}},
+		"NOT -r:a":           {want: map[string][]value{"r": {{Value: regexp.MustCompile("a")}}}},
+		"NOT (a OR b)":       {wantErr: &TypeError{Pos: 5, Err: errors.New(`negated terms (-term) are not yet supported`)}},
+		"b:yes (a OR b:no)":  {wantErr: &TypeError{Pos: 12, Err: errors.New(`field "b" may not be used more than once`)}},
+		"(a OR z:b)":         {wantErr: &TypeError{Pos: 6, Err: errors.New(`unrecognized field "z"`)}},
+		"(a g:x) OR (b g:x)": {want: map[string][]value{"g": {{Value: "x"}}}},
+		"(a g:x) OR (b g:y)": {wantErr: &TypeError{Pos: 3, Err: errors.New(`field "g" must have the same value in all alternatives of an OR query`)}},
+		"a OR (b g:x)":       {wantErr: &TypeError{Pos: 8, Err: errors.New(`field "g" must have the same value in all alternatives of an OR query`)}},
+		"(a OR b) (c OR d) (e OR f) (g OR h) (i OR j) (k OR l)": {wantErr: &TypeError{Pos: 45, Err: errors.New(`query is too complex (it expands to more than 32 alternatives)`)}},
 	}
 	for input, test := range tests {
 		t.Run(input, func(t *testing.T) {
@@ -144,3 +163,83 @@ func TestUnquoteString(t *testing.T) {
 		})
 	}
 }
+
+func TestCheck_disjunctive(t *testing.T) {
+	conf := Config{
+		FieldTypes: map[string]FieldType{
+			"":  {Literal: RegexpType, Quoted: StringType},
+			"r": {Literal: RegexpType, Quoted: RegexpType, Negatable: true},
+		},
+	}
+	toStrings := func(fields map[string][]*Value) map[string][]string {
+		m := make(map[string][]string, len(fields))
+		for f, vs := range fields {
+			for _, v := range vs {
+				s := v.Regexp.String()
+				if v.Not() {
+					s = "-" + s
+				}
+				m[f] = append(m[f], s)
+			}
+		}
+		return m
+	}
+
+	tests := map[string]struct {
+		wantFields    map[string][]string
+		wantDisjuncts []map[string][]string
+	}{
+		"a OR b": {
+			wantFields: map[string][]string{},
+			wantDisjuncts: []map[string][]string{
+				{"": {"a"}},
+				{"": {"b"}},
+			},
+		},
+		"(a OR b) r:c": {
+			wantFields: map[string][]string{"r": {"c"}},
+			wantDisjuncts: []map[string][]string{
+				{"": {"a"}, "r": {"c"}},
+				{"": {"b"}, "r": {"c"}},
+			},
+		},
+		"(a OR b) (r:c OR r:d)": {
+			wantFields: map[string][]string{},
+			wantDisjuncts: []map[string][]string{
+				{"": {"a"}, "r": {"c"}},
+				{"": {"a"}, "r": {"d"}},
+				{"": {"b"}, "r": {"c"}},
+				{"": {"b"}, "r": {"d"}},
+			},
+		},
+		"a NOT (r:b r:c)": {
+			wantFields: map[string][]string{"": {"a"}},
+			wantDisjuncts: []map[string][]string{
+				{"": {"a"}, "r": {"-b"}},
+				{"": {"a"}, "r": {"-c"}},
+			},
+		},
+	}
+	for input, test := range tests {
+		t.Run(input, func(t *testing.T) {
+			syntaxQuery, err := syntax.Parse(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query, err := conf.Check(syntaxQuery)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := toStrings(query.Fields); !reflect.DeepEqual(got, test.wantFields) {
+				t.Errorf("fields\ngot  %v\nwant %v", got, test.wantFields)
+			}
+			var got []map[string][]string
+			for _, d := range query.Disjuncts {
+				got = append(got, toStrings(d.Fields))
+			}
+			if !reflect.DeepEqual(got, test.wantDisjuncts) {
+				t.Errorf("disjuncts\ngot  %v\nwant %v", got, test.wantDisjuncts)
+			}
+		})
+	}
+}
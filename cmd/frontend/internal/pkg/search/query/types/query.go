@@ -1,7 +1,10 @@
 package types
 
 import (
+	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/syntax"
 )
@@ -9,7 +12,97 @@ import (
 // A Query is the typechecked representation of a search query.
 type Query struct {
 	Syntax *syntax.Query       // the query syntax
-	Fields map[string][]*Value // map of field name -> values
+	Fields map[string][]*Value // map of field name -> values (for a disjunctive query, only the values common to all disjuncts)
+
+	// Disjuncts is the list of conjunctive queries that a query using the
+	// "OR" operator matches the union of. It is nil for a conjunctive query.
+	Disjuncts []*Query
+}
+
+// Partition groups the disjuncts of q by their values for all fields other
+// than the given ones, and returns a query for each group. Each returned query
+// matches the union of its group of disjuncts, which differ only in the given
+// fields. If q is not disjunctive, it returns q.
+//
+// For example, partitioning "(a repo:x) OR (b repo:x) OR (c repo:y)" by the
+// default field returns the queries "(a OR b) repo:x" and "c repo:y".
+func (q *Query) Partition(fields ...string) []*Query {
+	if len(q.Disjuncts) == 0 {
+		return []*Query{q}
+	}
+
+	except := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		except[field] = true
+	}
+
+	var (
+		keys   []string
+		groups = map[string][]*Query{}
+	)
+	for _, d := range q.Disjuncts {
+		var parts []string
+		for field, values := range d.Fields {
+			if except[field] {
+				continue
+			}
+			for _, v := range values {
+				parts = append(parts, field+":"+v.key())
+			}
+		}
+		sort.Strings(parts)
+		key := strings.Join(parts, "\x00")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], d)
+	}
+
+	if len(keys) == 1 {
+		return []*Query{q}
+	}
+
+	partitions := make([]*Query, len(keys))
+	for i, key := range keys {
+		group := groups[key]
+		if len(group) == 1 {
+			partitions[i] = group[0]
+			continue
+		}
+		partitions[i] = &Query{
+			Syntax:    q.Syntax,
+			Fields:    commonFields(group),
+			Disjuncts: group,
+		}
+	}
+	return partitions
+}
+
+// commonFields returns the field values that are present in all of the
+// queries.
+func commonFields(queries []*Query) map[string][]*Value {
+	common := map[string][]*Value{}
+	for field, values := range queries[0].Fields {
+	outer:
+		for _, v := range values {
+			for _, q := range queries[1:] {
+				if !containsValue(q.Fields[field], v) {
+					continue outer
+				}
+			}
+			common[field] = append(common[field], v)
+		}
+	}
+	return common
+}
+
+func containsValue(values []*Value, v *Value) bool {
+	for _, v2 := range values {
+		if v2.key() == v.key() {
+			return true
+		}
+	}
+	return false
 }
 
 // ValueType is the set of types of values in queries.
@@ -25,15 +118,17 @@ const (
 // A Value is a field value in a query.
 type Value struct {
 	syntax *syntax.Expr // the underlying query expression
+	not    bool         // whether the value is negated (by its expression or an enclosing one)
 
 	String *string        // if a string value, the string value (with escape sequences interpreted)
 	Regexp *regexp.Regexp // if a regexp pattern, the compiled regular expression (call its String method to get source pattern string)
 	Bool   *bool          // if a bool value, the bool value
 }
 
-// Not returns whether the value is negated in the query (e.g., -value or -field:value).
+// Not returns whether the value is negated in the query (e.g., -value, -field:value
+// or NOT (field:value OR ...)).
 func (v *Value) Not() bool {
-	return v.syntax.Not
+	return v.not
 }
 
 // Value returns the value as an interface{}.
@@ -49,3 +144,9 @@ func (v *Value) Value() interface{} {
 		panic("no value")
 	}
 }
+
+// key returns a string that is equal for values with the same negation and
+// value.
+func (v *Value) key() string {
+	return fmt.Sprintf("%v:%T:%v", v.not, v.Value(), v.Value())
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/syntax"
+)
+
+func TestQuery_Partition(t *testing.T) {
+	conf := Config{
+		FieldTypes: map[string]FieldType{
+			"":  {Literal: RegexpType, Quoted: StringType},
+			"f": {Literal: RegexpType, Quoted: RegexpType, Negatable: true},
+			"r": {Literal: RegexpType, Quoted: RegexpType, Negatable: true},
+		},
+	}
+	toStrings := func(q *Query) []string {
+		var s []string
+		for _, d := range append([]*Query{q}, q.Disjuncts...) {
+			s = append(s, syntax.ExprString(exprs(d)))
+		}
+		return s
+	}
+
+	tests := map[string][][]string{
+		"a":                                 {{"a"}},
+		"a OR b":                            {{"", "a", "b"}},
+		"(a OR b) r:c":                      {{"r:c", "a r:c", "b r:c"}},
+		"(a f:x) OR (b f:y)":                {{"", "a f:x", "b f:y"}},
+		"(a r:x) OR (b r:x) OR (c r:y)":     {{"r:x", "a r:x", "b r:x"}, {"c r:y"}},
+		"(a r:x f:z) OR (b r:x) OR (a r:y)": {{"r:x", "a r:x f:z", "b r:x"}, {"a r:y"}},
+		"(r:x OR r:y)":                      {{"r:x"}, {"r:y"}},
+		"(a OR b) (r:x OR r:y)":             {{"r:x", "a r:x", "b r:x"}, {"r:y", "a r:y", "b r:y"}},
+		"(a OR b) (r:x OR -r:x)":            {{"r:x", "a r:x", "b r:x"}, {"-r:x", "a -r:x", "b -r:x"}},
+		"(a OR b) (r:x OR r:x)":             {{"r:x", "a r:x", "a r:x", "b r:x", "b r:x"}},
+		"(a OR (b f:z)) (r:x OR r:y) f:q":   {{"r:x f:q", "a r:x f:q", "b r:x f:z f:q"}, {"r:y f:q", "a r:y f:q", "b r:y f:z f:q"}},
+	}
+	for input, want := range tests {
+		t.Run(input, func(t *testing.T) {
+			syntaxQuery, err := syntax.Parse(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query, err := conf.Check(syntaxQuery)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got [][]string
+			for _, p := range query.Partition("", "f") {
+				got = append(got, toStrings(p))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %q\nwant %q", got, want)
+			}
+		})
+	}
+}
+
+// exprs returns the field expressions of the values in q, in a stable order.
+func exprs(q *Query) []*syntax.Expr {
+	var exprs []*syntax.Expr
+	for _, field := range []string{"", "r", "f"} {
+		for _, v := range q.Fields[field] {
+			expr := *v.syntax
+			expr.Not = v.Not()
+			exprs = append(exprs, &expr)
+		}
+	}
+	return exprs
+}
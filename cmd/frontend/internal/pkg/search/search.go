@@ -14,6 +14,7 @@ type PatternInfo struct {
 	IsRegExp        bool
 	IsWordMatch     bool
 	IsCaseSensitive bool
+	IsStructuralPat bool
 	FileMatchLimit  int32
 
 	// We do not support IsMultiline
@@ -69,6 +70,16 @@ type Args struct {
 	Pattern *PatternInfo
 	Repos   []*RepositoryRevisions
 
+	// Alternatives holds additional patterns for queries that use the "OR"
+	// operator. A result matches if it matches Pattern or any of the
+	// Alternatives.
+	Alternatives []*PatternInfo
+
+	// RepoFileMatchLimit, if non-zero, is the maximum number of file matches
+	// to find in each repository. Pattern.FileMatchLimit still limits the
+	// total number of file matches.
+	RepoFileMatchLimit int32
+
 	// Query is the parsed query from the user. You should be using Pattern
 	// instead, but Query is useful for checking extra fields that are set and
 	// ignored by Pattern, such as index:no
@@ -83,3 +94,26 @@ type Args struct {
 	// to true if the user requests a specific timeout or maximum result size.
 	UseFullDeadline bool
 }
+
+// Patterns returns Pattern followed by the Alternatives.
+func (a *Args) Patterns() []*PatternInfo {
+	return append([]*PatternInfo{a.Pattern}, a.Alternatives...)
+}
+
+// Split returns a copy of a for each of its patterns, without Alternatives.
+// It is used by search backends that do not support searching for alternative
+// patterns at once.
+func (a *Args) Split() []*Args {
+	if len(a.Alternatives) == 0 {
+		return []*Args{a}
+	}
+	patterns := a.Patterns()
+	split := make([]*Args, len(patterns))
+	for i, p := range patterns {
+		args := *a
+		args.Pattern = p
+		args.Alternatives = nil
+		split[i] = &args
+	}
+	return split
+}
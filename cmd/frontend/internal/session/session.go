@@ -178,7 +178,14 @@ func SetActor(w http.ResponseWriter, r *http.Request, actor *actor.Actor, expiry
 		}
 		value = &sessionInfo{Actor: actor, ExpiryPeriod: expiryPeriod, LastActive: time.Now()}
 	}
-	return SetData(w, r, "actor", value)
+	if err := SetData(w, r, "actor", value); err != nil {
+		return err
+	}
+	if actor.IsAuthenticated() {
+		// Refresh the user's repository permissions when the user signs in.
+		db.ScheduleUserPermissionsSync(actor.UID)
+	}
+	return nil
 }
 
 func hasSessionCookie(r *http.Request) bool {
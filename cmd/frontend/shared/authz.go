@@ -1,7 +1,9 @@
 package shared
 
 import (
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz/explicit"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/schema"
 	log15 "gopkg.in/inconshreveable/log15.v2"
@@ -14,6 +16,11 @@ func init() {
 	})
 	conf.Watch(func() {
 		allowAccessByDefault, authzProviders, _, _ := providersFromConfig(conf.Get())
+		if conf.ExplicitPermissionsEnabled() {
+			// The explicit permissions provider must come last, so that it only claims the
+			// repositories that no code host provider claims.
+			authzProviders = append(authzProviders, explicit.NewProvider(db.RepoPermissions))
+		}
 		authz.SetProviders(allowAccessByDefault, authzProviders)
 	})
 }
@@ -41,5 +48,15 @@ func providersFromConfig(cfg *schema.SiteConfiguration) (
 	seriousProblems = append(seriousProblems, glproblems...)
 	warnings = append(warnings, glwarnings...)
 
+	ghp, ghproblems, ghwarnings := githubProvidersFromConfig(cfg)
+	authzProviders = append(authzProviders, ghp...)
+	seriousProblems = append(seriousProblems, ghproblems...)
+	warnings = append(warnings, ghwarnings...)
+
+	bbsp, bbsproblems, bbswarnings := bitbucketServerProvidersFromConfig(cfg)
+	authzProviders = append(authzProviders, bbsp...)
+	seriousProblems = append(seriousProblems, bbsproblems...)
+	warnings = append(warnings, bbswarnings...)
+
 	return allowAccessByDefault, authzProviders, seriousProblems, warnings
 }
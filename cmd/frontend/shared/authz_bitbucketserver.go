@@ -0,0 +1,119 @@
+package shared
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/external/auth"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	permbbs "github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func bitbucketServerProvidersFromConfig(cfg *schema.SiteConfiguration) (
+	authzProviders []authz.Provider,
+	seriousProblems []string,
+	warnings []string,
+) {
+	// Authorization (i.e., permissions) providers
+	for _, bbs := range cfg.BitbucketServer {
+		if bbs.Authorization == nil {
+			continue
+		}
+
+		bbsURL, err := url.Parse(bbs.Url)
+		if err != nil {
+			seriousProblems = append(seriousProblems, fmt.Sprintf("Could not parse URL for Bitbucket Server instance %q: %s", bbs.Url, err))
+			continue // omit authz provider if could not parse URL
+		}
+
+		signingKey, err := bitbucketserver.ParseSigningKey(bbs.Authorization.Oauth.SigningKey)
+		if err != nil {
+			seriousProblems = append(seriousProblems, fmt.Sprintf("Could not parse `authorization.oauth.signingKey` for Bitbucket Server instance %q: %s", bbs.Url, err))
+			continue // omit authz provider if could not parse signing key
+		}
+
+		var ttl time.Duration
+		if bbs.Authorization.Ttl == "" {
+			ttl = time.Hour * 3
+		} else {
+			ttl, err = time.ParseDuration(bbs.Authorization.Ttl)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("Could not parse time duration %q, falling back to 3 hours.", bbs.Authorization.Ttl))
+				ttl = time.Hour * 3
+			}
+		}
+
+		idp := bbs.Authorization.IdentityProvider
+		op := permbbs.BitbucketServerAuthzProviderOp{
+			BaseURL:  bbsURL,
+			Token:    bbs.Token,
+			Username: bbs.Username,
+			Password: bbs.Password,
+			OAuth: &bitbucketserver.OAuth{
+				ConsumerKey: bbs.Authorization.Oauth.ConsumerKey,
+				SigningKey:  signingKey,
+			},
+			IdentityProvider: idp.Type,
+			AuthnConfigID:    auth.ProviderConfigID{ID: idp.AuthnConfigID, Type: idp.AuthnType},
+			CacheTTL:         ttl,
+		}
+		switch idp.Type {
+		case permbbs.IdentityProviderUsername:
+			if builtinSignupAllowed(cfg) {
+				// 🚨 SECURITY: Anyone could sign up with the username of a Bitbucket Server user and
+				// get their permissions.
+				seriousProblems = append(seriousProblems, fmt.Sprintf("Bitbucket Server instance %q resolves users by `username`, but the builtin authentication provider allows signup, so anyone could sign up with the username of a Bitbucket Server user. Use the \"externalAccount\" `authorization.identityProvider.type` instead. Repositories on this instance are inaccessible until this is fixed.", bbs.Url))
+				continue // omit authz provider
+			}
+		case permbbs.IdentityProviderExternalAccount:
+			if idp.AuthnConfigID == "" || idp.AuthnType == "" {
+				seriousProblems = append(seriousProblems, "`authorization.identityProvider.authnConfigID` and `authorization.identityProvider.authnType` were not specified, which means Bitbucket Server users cannot be resolved.")
+			} else if !hasAuthProvider(cfg, idp.AuthnConfigID, idp.AuthnType) {
+				seriousProblems = append(seriousProblems, fmt.Sprintf("Could not find item in `auth.providers` with config ID %q and type %q", idp.AuthnConfigID, idp.AuthnType))
+			}
+		default:
+			seriousProblems = append(seriousProblems, fmt.Sprintf("Unknown `authorization.identityProvider.type` %q, which means Bitbucket Server users cannot be resolved.", idp.Type))
+		}
+
+		authzProviders = append(authzProviders, NewBitbucketServerProvider(op))
+	}
+	for _, provider := range authzProviders {
+		for _, problem := range provider.Validate() {
+			warnings = append(warnings, fmt.Sprintf("Bitbucket Server config for %s was invalid: %s", provider.ServiceID(), problem))
+		}
+	}
+	return authzProviders, seriousProblems, warnings
+}
+
+// hasAuthProvider reports whether the site config has an OpenID Connect or SAML authn provider
+// with the given config ID and type.
+func hasAuthProvider(cfg *schema.SiteConfiguration, configID, typ string) bool {
+	for _, p := range cfg.AuthProviders {
+		if p.Openidconnect != nil && p.Openidconnect.ConfigID == configID && p.Openidconnect.Type == typ {
+			return true
+		}
+		if p.Saml != nil && p.Saml.ConfigID == configID && p.Saml.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinSignupAllowed reports whether the site config has a builtin authn provider that allows
+// users to sign up.
+func builtinSignupAllowed(cfg *schema.SiteConfiguration) bool {
+	for _, p := range cfg.AuthProviders {
+		if p.Builtin != nil && p.Builtin.AllowSignup {
+			return true
+		}
+	}
+	return false
+}
+
+// NewBitbucketServerProvider is a mockable constructor for new BitbucketServerAuthzProvider instances.
+var NewBitbucketServerProvider = func(op permbbs.BitbucketServerAuthzProviderOp) authz.Provider {
+	return permbbs.NewProvider(op)
+}
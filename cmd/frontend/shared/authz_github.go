@@ -0,0 +1,87 @@
+package shared
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	permgh "github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz/github"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func githubProvidersFromConfig(cfg *schema.SiteConfiguration) (
+	authzProviders []authz.Provider,
+	seriousProblems []string,
+	warnings []string,
+) {
+	// Authorization (i.e., permissions) providers
+	for _, gh := range cfg.Github {
+		if gh.Authorization == nil {
+			continue
+		}
+
+		ghURL, err := url.Parse(gh.Url)
+		if err != nil {
+			seriousProblems = append(seriousProblems, fmt.Sprintf("Could not parse URL for GitHub instance %q: %s", gh.Url, err))
+			continue // omit authz provider if could not parse URL
+		}
+
+		var ttl time.Duration
+		if gh.Authorization.Ttl == "" {
+			ttl = time.Hour * 3
+		} else {
+			ttl, err = time.ParseDuration(gh.Authorization.Ttl)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("Could not parse time duration %q, falling back to 3 hours.", gh.Authorization.Ttl))
+				ttl = time.Hour * 3
+			}
+		}
+
+		// Users are identified by the GitHub accounts they sign in with, so there must be a GitHub
+		// authn provider for the same GitHub instance.
+		if !hasGitHubAuthProvider(cfg, ghURL) {
+			seriousProblems = append(seriousProblems, fmt.Sprintf("Could not find a GitHub item in `auth.providers` with URL %q. Only public repositories on this GitHub instance will be accessible.", gh.Url))
+		}
+
+		authzProviders = append(authzProviders, NewGitHubProvider(permgh.GitHubAuthzProviderOp{
+			BaseURL:  ghURL,
+			Token:    gh.Token,
+			CacheTTL: ttl,
+		}))
+	}
+	for _, provider := range authzProviders {
+		for _, problem := range provider.Validate() {
+			warnings = append(warnings, fmt.Sprintf("GitHub config for %s was invalid: %s", provider.ServiceID(), problem))
+		}
+	}
+	return authzProviders, seriousProblems, warnings
+}
+
+// hasGitHubAuthProvider reports whether the site config has a GitHub authn provider for the GitHub
+// instance at baseURL.
+func hasGitHubAuthProvider(cfg *schema.SiteConfiguration, baseURL *url.URL) bool {
+	for _, p := range cfg.AuthProviders {
+		if p.Github == nil {
+			continue
+		}
+		rawURL := p.Github.Url
+		if rawURL == "" {
+			rawURL = "https://github.com/"
+		}
+		authnURL, err := url.Parse(rawURL)
+		if err != nil {
+			continue
+		}
+		if u := *baseURL; extsvc.NormalizeBaseURL(authnURL).String() == extsvc.NormalizeBaseURL(&u).String() {
+			return true
+		}
+	}
+	return false
+}
+
+// NewGitHubProvider is a mockable constructor for new GitHubAuthzProvider instances.
+var NewGitHubProvider = func(op permgh.GitHubAuthzProviderOp) authz.Provider {
+	return permgh.NewProvider(op)
+}
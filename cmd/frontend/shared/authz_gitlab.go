@@ -67,18 +67,7 @@ func gitlabProvidersFromConfig(cfg *schema.SiteConfiguration) (
 			seriousProblems = append(seriousProblems, "`authz.authnProvider.gitlabProvider` was not specified, which means GitLab users cannot be resolved.")
 		} else {
 			// Best-effort determine if the authz.authnConfigID field refers to an item in auth.provider
-			found := false
-			for _, p := range cfg.AuthProviders {
-				if p.Openidconnect != nil && p.Openidconnect.ConfigID == gl.Authorization.AuthnProvider.ConfigID && p.Openidconnect.Type == gl.Authorization.AuthnProvider.Type {
-					found = true
-					break
-				}
-				if p.Saml != nil && p.Saml.ConfigID == gl.Authorization.AuthnProvider.ConfigID && p.Saml.Type == gl.Authorization.AuthnProvider.Type {
-					found = true
-					break
-				}
-			}
-			if !found {
+			if !hasAuthProvider(cfg, gl.Authorization.AuthnProvider.ConfigID, gl.Authorization.AuthnProvider.Type) {
 				seriousProblems = append(seriousProblems, fmt.Sprintf("Could not find item in `auth.providers` with config ID %q and type %q", gl.Authorization.AuthnProvider.ConfigID, gl.Authorization.AuthnProvider.Type))
 			}
 		}
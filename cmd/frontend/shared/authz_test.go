@@ -2,7 +2,11 @@ package shared
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"net/url"
 	"reflect"
 	"testing"
@@ -10,10 +14,13 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/external/auth"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz"
+	permbbs "github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz/github"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/authz/gitlab"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -38,11 +45,68 @@ func (m newGitLabAuthzProviderParams) ServiceType() string {
 }
 func (m newGitLabAuthzProviderParams) Validate() []string { return nil }
 
+type newGitHubAuthzProviderParams struct {
+	Op github.GitHubAuthzProviderOp
+}
+
+func (m newGitHubAuthzProviderParams) RepoPerms(ctx context.Context, account *extsvc.ExternalAccount, repos map[authz.Repo]struct{}) (map[api.RepoName]map[authz.Perm]bool, error) {
+	panic("should never be called")
+}
+func (m newGitHubAuthzProviderParams) Repos(ctx context.Context, repos map[authz.Repo]struct{}) (mine map[authz.Repo]struct{}, others map[authz.Repo]struct{}) {
+	panic("should never be called")
+}
+func (m newGitHubAuthzProviderParams) FetchAccount(ctx context.Context, user *types.User, current []*extsvc.ExternalAccount) (mine *extsvc.ExternalAccount, err error) {
+	panic("should never be called")
+}
+func (m newGitHubAuthzProviderParams) ServiceID() string {
+	panic("should never be called")
+}
+func (m newGitHubAuthzProviderParams) ServiceType() string {
+	panic("should never be called")
+}
+func (m newGitHubAuthzProviderParams) Validate() []string { return nil }
+
+type newBitbucketServerAuthzProviderParams struct {
+	Op permbbs.BitbucketServerAuthzProviderOp
+}
+
+func (m newBitbucketServerAuthzProviderParams) RepoPerms(ctx context.Context, account *extsvc.ExternalAccount, repos map[authz.Repo]struct{}) (map[api.RepoName]map[authz.Perm]bool, error) {
+	panic("should never be called")
+}
+func (m newBitbucketServerAuthzProviderParams) Repos(ctx context.Context, repos map[authz.Repo]struct{}) (mine map[authz.Repo]struct{}, others map[authz.Repo]struct{}) {
+	panic("should never be called")
+}
+func (m newBitbucketServerAuthzProviderParams) FetchAccount(ctx context.Context, user *types.User, current []*extsvc.ExternalAccount) (mine *extsvc.ExternalAccount, err error) {
+	panic("should never be called")
+}
+func (m newBitbucketServerAuthzProviderParams) ServiceID() string {
+	panic("should never be called")
+}
+func (m newBitbucketServerAuthzProviderParams) ServiceType() string {
+	panic("should never be called")
+}
+func (m newBitbucketServerAuthzProviderParams) Validate() []string { return nil }
+
 func Test_providersFromConfig(t *testing.T) {
 	NewGitLabProvider = func(op gitlab.GitLabAuthzProviderOp) authz.Provider {
 		op.MockCache = nil // ignore cache value
 		return newGitLabAuthzProviderParams{op}
 	}
+	NewGitHubProvider = func(op github.GitHubAuthzProviderOp) authz.Provider {
+		op.MockCache = nil // ignore cache value
+		return newGitHubAuthzProviderParams{op}
+	}
+	NewBitbucketServerProvider = func(op permbbs.BitbucketServerAuthzProviderOp) authz.Provider {
+		op.MockCache = nil                                                   // ignore cache value
+		op.OAuth = &bitbucketserver.OAuth{ConsumerKey: op.OAuth.ConsumerKey} // ignore parsed signing key
+		return newBitbucketServerAuthzProviderParams{op}
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
 
 	tests := []struct {
 		description                  string
@@ -265,6 +329,234 @@ func Test_providersFromConfig(t *testing.T) {
 			expSeriousProblems: []string{"`authz.authnProvider.type` was not specified, which means GitLab users cannot be resolved."},
 			expWarnings:        []string{"Could not parse time duration \"invalid\", falling back to 3 hours."},
 		},
+		{
+			description: "1 GitHub auth provider, 1 GitHub with permissions",
+			cfg: schema.SiteConfiguration{
+				AuthProviders: []schema.AuthProviders{
+					schema.AuthProviders{
+						Github: &schema.GitHubAuthProvider{
+							Type: "github",
+							Url:  "https://github.mine/",
+						},
+					},
+				},
+				Github: []*schema.GitHubConnection{
+					{
+						Authorization: &schema.GitHubAuthorization{Ttl: "1h"},
+						Url:           "https://github.mine",
+						Token:         "asdf",
+					},
+				},
+			},
+			expAuthzAllowAccessByDefault: true,
+			expAuthzProviders: []authz.Provider{
+				newGitHubAuthzProviderParams{
+					Op: github.GitHubAuthzProviderOp{
+						BaseURL:  mustURLParse(t, "https://github.mine"),
+						Token:    "asdf",
+						CacheTTL: time.Hour,
+					},
+				},
+			},
+		},
+		{
+			description: "1 GitHub with permissions for a different GitHub auth provider",
+			cfg: schema.SiteConfiguration{
+				AuthProviders: []schema.AuthProviders{
+					schema.AuthProviders{
+						Github: &schema.GitHubAuthProvider{Type: "github"},
+					},
+				},
+				Github: []*schema.GitHubConnection{
+					{
+						Authorization: &schema.GitHubAuthorization{},
+						Url:           "https://github.mine",
+						Token:         "asdf",
+					},
+				},
+			},
+			expAuthzAllowAccessByDefault: false,
+			expAuthzProviders: []authz.Provider{
+				newGitHubAuthzProviderParams{
+					Op: github.GitHubAuthzProviderOp{
+						BaseURL:  mustURLParse(t, "https://github.mine"),
+						Token:    "asdf",
+						CacheTTL: 3 * time.Hour,
+					},
+				},
+			},
+			expSeriousProblems: []string{"Could not find a GitHub item in `auth.providers` with URL \"https://github.mine\". Only public repositories on this GitHub instance will be accessible."},
+		},
+		{
+			description: "1 Bitbucket Server with permissions by username",
+			cfg: schema.SiteConfiguration{
+				BitbucketServer: []*schema.BitbucketServerConnection{
+					{
+						Authorization: &schema.BitbucketServerAuthorization{
+							IdentityProvider: schema.BitbucketServerIdentityProvider{Type: "username"},
+							Oauth: schema.BitbucketServerOAuth{
+								ConsumerKey: "sourcegraph",
+								SigningKey:  signingKey,
+							},
+							Ttl: "1h",
+						},
+						Url:   "https://bitbucket.mine",
+						Token: "asdf",
+					},
+				},
+			},
+			expAuthzAllowAccessByDefault: true,
+			expAuthzProviders: []authz.Provider{
+				newBitbucketServerAuthzProviderParams{
+					Op: permbbs.BitbucketServerAuthzProviderOp{
+						BaseURL:          mustURLParse(t, "https://bitbucket.mine"),
+						Token:            "asdf",
+						OAuth:            &bitbucketserver.OAuth{ConsumerKey: "sourcegraph"},
+						IdentityProvider: "username",
+						CacheTTL:         time.Hour,
+					},
+				},
+			},
+		},
+		{
+			description: "1 Bitbucket Server with permissions by username, builtin auth provider with signup",
+			cfg: schema.SiteConfiguration{
+				AuthProviders: []schema.AuthProviders{
+					{Builtin: &schema.BuiltinAuthProvider{Type: "builtin", AllowSignup: true}},
+				},
+				BitbucketServer: []*schema.BitbucketServerConnection{
+					{
+						Authorization: &schema.BitbucketServerAuthorization{
+							IdentityProvider: schema.BitbucketServerIdentityProvider{Type: "username"},
+							Oauth: schema.BitbucketServerOAuth{
+								ConsumerKey: "sourcegraph",
+								SigningKey:  signingKey,
+							},
+						},
+						Url:   "https://bitbucket.mine",
+						Token: "asdf",
+					},
+				},
+			},
+			expAuthzAllowAccessByDefault: false,
+			expSeriousProblems:           []string{"Bitbucket Server instance \"https://bitbucket.mine\" resolves users by `username`, but the builtin authentication provider allows signup, so anyone could sign up with the username of a Bitbucket Server user. Use the \"externalAccount\" `authorization.identityProvider.type` instead. Repositories on this instance are inaccessible until this is fixed."},
+		},
+		{
+			description: "1 auth provider (okta), 1 Bitbucket Server referencing okta",
+			cfg: schema.SiteConfiguration{
+				AuthProviders: []schema.AuthProviders{
+					schema.AuthProviders{
+						Saml: &schema.SAMLAuthProvider{
+							ConfigID: "okta-config-id",
+							Type:     "saml",
+						},
+					},
+				},
+				BitbucketServer: []*schema.BitbucketServerConnection{
+					{
+						Authorization: &schema.BitbucketServerAuthorization{
+							IdentityProvider: schema.BitbucketServerIdentityProvider{
+								Type:          "externalAccount",
+								AuthnConfigID: "okta-config-id",
+								AuthnType:     "saml",
+							},
+							Oauth: schema.BitbucketServerOAuth{
+								ConsumerKey: "sourcegraph",
+								SigningKey:  signingKey,
+							},
+						},
+						Url:      "https://bitbucket.mine",
+						Username: "admin",
+						Password: "pw",
+					},
+				},
+			},
+			expAuthzAllowAccessByDefault: true,
+			expAuthzProviders: []authz.Provider{
+				newBitbucketServerAuthzProviderParams{
+					Op: permbbs.BitbucketServerAuthzProviderOp{
+						BaseURL:          mustURLParse(t, "https://bitbucket.mine"),
+						Username:         "admin",
+						Password:         "pw",
+						OAuth:            &bitbucketserver.OAuth{ConsumerKey: "sourcegraph"},
+						IdentityProvider: "externalAccount",
+						AuthnConfigID:    auth.ProviderConfigID{Type: "saml", ID: "okta-config-id"},
+						CacheTTL:         3 * time.Hour,
+					},
+				},
+			},
+		},
+		{
+			description: "1 Bitbucket Server referencing a missing auth provider",
+			cfg: schema.SiteConfiguration{
+				BitbucketServer: []*schema.BitbucketServerConnection{
+					{
+						Authorization: &schema.BitbucketServerAuthorization{
+							IdentityProvider: schema.BitbucketServerIdentityProvider{
+								Type:          "externalAccount",
+								AuthnConfigID: "okta-config-id",
+								AuthnType:     "saml",
+							},
+							Oauth: schema.BitbucketServerOAuth{
+								ConsumerKey: "sourcegraph",
+								SigningKey:  signingKey,
+							},
+						},
+						Url:   "https://bitbucket.mine",
+						Token: "asdf",
+					},
+				},
+			},
+			expAuthzAllowAccessByDefault: false,
+			expAuthzProviders: []authz.Provider{
+				newBitbucketServerAuthzProviderParams{
+					Op: permbbs.BitbucketServerAuthzProviderOp{
+						BaseURL:          mustURLParse(t, "https://bitbucket.mine"),
+						Token:            "asdf",
+						OAuth:            &bitbucketserver.OAuth{ConsumerKey: "sourcegraph"},
+						IdentityProvider: "externalAccount",
+						AuthnConfigID:    auth.ProviderConfigID{Type: "saml", ID: "okta-config-id"},
+						CacheTTL:         3 * time.Hour,
+					},
+				},
+			},
+			expSeriousProblems: []string{"Could not find item in `auth.providers` with config ID \"okta-config-id\" and type \"saml\""},
+		},
+		{
+			description: "1 Bitbucket Server with an invalid signing key",
+			cfg: schema.SiteConfiguration{
+				BitbucketServer: []*schema.BitbucketServerConnection{
+					{
+						Authorization: &schema.BitbucketServerAuthorization{
+							IdentityProvider: schema.BitbucketServerIdentityProvider{Type: "username"},
+							Oauth: schema.BitbucketServerOAuth{
+								ConsumerKey: "sourcegraph",
+								SigningKey:  "invalid",
+							},
+						},
+						Url:   "https://bitbucket.mine",
+						Token: "asdf",
+					},
+				},
+			},
+			expAuthzAllowAccessByDefault: false,
+			expAuthzProviders:            nil,
+			expSeriousProblems:           []string{"Could not parse `authorization.oauth.signingKey` for Bitbucket Server instance \"https://bitbucket.mine\": signing key is not a PEM-encoded private key"},
+		},
+		{
+			description: "1 GitHub with permissions disabled",
+			cfg: schema.SiteConfiguration{
+				Github: []*schema.GitHubConnection{
+					{
+						Url:   "https://github.mine",
+						Token: "asdf",
+					},
+				},
+			},
+			expAuthzAllowAccessByDefault: true,
+			expAuthzProviders:            nil,
+			expSeriousProblems:           nil,
+		},
 	}
 
 	for _, test := range tests {
@@ -40,6 +40,9 @@ type Repo struct {
 	// FreezeIndexedRevision, when true, tells the indexer not to update the indexed revision if it is already set.
 	// This is a kludge that lets us freeze the indexed repository revision for specific deployments
 	FreezeIndexedRevision bool
+	// DeletedAt is when this repository was found to be deleted on its code host, or nil if it
+	// wasn't. Deleted repositories are not listed, and they are restored if they reappear.
+	DeletedAt *time.Time
 }
 
 // DependencyReferencesOptions specifies options for querying dependency references.
@@ -87,6 +90,17 @@ type OrgMembership struct {
 	UpdatedAt time.Time
 }
 
+// RepoPermission is an explicit repository permission that a site admin granted to a user or to the
+// members of an organization, on the repositories whose names match a pattern.
+type RepoPermission struct {
+	ID          int32
+	UserID      int32  // the user who is granted the permission (0 if OrgID is set)
+	OrgID       int32  // the organization whose members are granted the permission (0 if UserID is set)
+	RepoPattern string // regular expression that matches repository names
+	Permission  string // the permission (such as "read")
+	CreatedAt   time.Time
+}
+
 type PhabricatorRepo struct {
 	ID       int32
 	Name     api.RepoName
@@ -21,6 +21,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/server"
 	"github.com/sourcegraph/sourcegraph/pkg/debugserver"
 	"github.com/sourcegraph/sourcegraph/pkg/env"
+	gitserverclient "github.com/sourcegraph/sourcegraph/pkg/gitserver"
 	"github.com/sourcegraph/sourcegraph/pkg/tracer"
 )
 
@@ -29,6 +30,7 @@ const janitorInterval = 24 * time.Hour
 var (
 	reposDir          = env.Get("SRC_REPOS_DIR", "/data/repos", "Root dir containing repos.")
 	runRepoCleanup, _ = strconv.ParseBool(env.Get("SRC_RUN_REPO_CLEANUP", "", "Periodically remove inactive repositories."))
+	serverAddr        = env.Get("SRC_GIT_SERVER_ADDR", "", "Address of this gitserver in SRC_GIT_SERVERS. Required when repositories are replicated.")
 )
 
 func main() {
@@ -47,6 +49,10 @@ func main() {
 		ReposDir:                reposDir,
 		DeleteStaleRepositories: runRepoCleanup,
 	}
+	if serverAddr != "" && gitserverclient.DefaultClient.Replicas > 1 {
+		gitserver.Addr = serverAddr
+		gitserver.ReplicaAddrs = gitserverclient.DefaultClient.AddrsForRepo
+	}
 	gitserver.RegisterMetrics()
 
 	if tmpDir, err := gitserver.SetupAndClearTmp(); err != nil {
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"path"
+	"strconv"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/repotrackutil"
+)
+
+// batchExecConcurrency is the maximum number of commands of a single batch
+// that are run (or whose results are waiting to be written) at the same time.
+const batchExecConcurrency = 8
+
+// batchExecMaxStdoutSize is the maximum size of the standard output of a
+// command of a batch, because it is held in memory until it is written. A
+// command whose output exceeds it is killed and fails.
+var batchExecMaxStdoutSize = 10 * 1024 * 1024
+
+// handleBatchExec runs the commands of a protocol.BatchExecRequest
+// concurrently, writing their results in order as soon as they are available.
+// It saves clients the overhead of an HTTP request for each command when they
+// run many small commands in the same repository.
+func (s *Server) handleBatchExec(w http.ResponseWriter, r *http.Request) {
+	span, ctx := opentracing.StartSpanFromContext(r.Context(), "Server.handleBatchExec")
+	defer span.Finish()
+
+	var req protocol.BatchExecRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Repo = protocol.NormalizeRepo(req.Repo)
+	for _, execReq := range req.Requests {
+		if execReq.Repo != "" && protocol.NormalizeRepo(execReq.Repo) != req.Repo {
+			http.Error(w, "all commands in a batch must be for the same repository", http.StatusBadRequest)
+			return
+		}
+	}
+	span.SetTag("repo", req.Repo)
+	span.SetTag("commands", len(req.Requests))
+
+	dir := path.Join(s.ReposDir, string(req.Repo))
+	if status := s.writeRepoNotCloned(ctx, w, req.Repo, req.URL, dir); status != "" {
+		return
+	}
+
+	// Flush the result of each command as soon as possible, so that clients
+	// with a context deadline see as many results as possible.
+	if fw := newFlushingResponseWriter(w); fw != nil {
+		w = fw
+		defer fw.Close()
+	}
+	w.WriteHeader(http.StatusOK)
+
+	type batchResult struct {
+		res    protocol.BatchExecResult
+		stdout []byte
+	}
+	results := make([]chan batchResult, len(req.Requests))
+	for i := range results {
+		results[i] = make(chan batchResult, 1)
+	}
+	// Commands are started in order, and each one keeps its slot until its
+	// result has been written, so that at most batchExecConcurrency results
+	// are held in memory. Because the slots are taken in order, the next
+	// result to write always has one.
+	sem := make(chan struct{}, batchExecConcurrency)
+	go func() {
+		for i, execReq := range req.Requests {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(execReq protocol.ExecRequest, result chan<- batchResult) {
+				res, stdout := s.execBatchCommand(ctx, req.Repo, req.URL, dir, execReq)
+				result <- batchResult{res: res, stdout: stdout}
+			}(execReq, results[i])
+		}
+	}()
+
+	enc := json.NewEncoder(w)
+	for _, result := range results {
+		var r batchResult
+		select {
+		case r = <-result:
+		case <-ctx.Done():
+			return
+		}
+		if err := enc.Encode(r.res); err != nil {
+			return
+		}
+		if _, err := w.Write(r.stdout); err != nil {
+			return
+		}
+		<-sem
+	}
+}
+
+// execBatchCommand runs one command of a batch and returns its result and
+// standard output.
+func (s *Server) execBatchCommand(ctx context.Context, repo api.RepoName, url, dir string, req protocol.ExecRequest) (protocol.BatchExecResult, []byte) {
+	start := time.Now()
+	cmdName := ""
+	if len(req.Args) > 0 {
+		cmdName = req.Args[0]
+	}
+	trackedRepo := repotrackutil.GetTrackedRepo(repo)
+	execRunning.WithLabelValues(cmdName, trackedRepo).Inc()
+
+	s.ensureRevision(ctx, repo, url, req.EnsureRevision, dir)
+
+	ctx, cancel := context.WithTimeout(ctx, shortGitCommandTimeout(req.Args))
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: batchExecMaxStdoutSize, onExceeded: cancel}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", req.Args...)
+	cmd.Dir = dir
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	var res protocol.BatchExecResult
+	var err error
+	res.ExitStatus, err = runCommand(ctx, cmd)
+	if stdout.exceeded {
+		err = fmt.Errorf("standard output exceeds the limit of %d bytes for a command of a batch", batchExecMaxStdoutSize)
+		stdout.Reset()
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	res.Stderr = stderr.String()
+	if len(res.Stderr) > 1024 {
+		res.Stderr = res.Stderr[:1024]
+	}
+	res.StdoutLen = stdout.Len()
+
+	execRunning.WithLabelValues(cmdName, trackedRepo).Dec()
+	execDuration.WithLabelValues(cmdName, trackedRepo, strconv.Itoa(res.ExitStatus)).Observe(time.Since(start).Seconds())
+	return res, stdout.Bytes()
+}
+
+// limitedBuffer is a bytes.Buffer that holds at most limit bytes. Once a
+// write would exceed the limit, the written data is discarded and onExceeded
+// is called.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit      int
+	onExceeded func()
+	exceeded   bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if !b.exceeded && b.Len()+len(p) > b.limit {
+		b.exceeded = true
+		b.onExceeded()
+	}
+	if b.exceeded {
+		// Report success, so that the command is not blocked writing to a
+		// pipe that is no longer read before it is killed.
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestBatchExec(t *testing.T) {
+	tests := []Test{
+		{
+			Name:         "Commands",
+			Request:      httptest.NewRequest("POST", "/batch-exec", strings.NewReader(`{"repo": "github.com/gorilla/mux", "requests": [{"args": ["testcommand"]}, {"repo": "github.com/gorilla/mux", "args": ["testerror"]}, {"args": ["testcommand"]}]}`)),
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: `{"exitStatus":42,"stderr":"teststderr","stdoutLen":10}
+teststdout{"error":"testerror","exitStatus":0,"stdoutLen":0}
+{"exitStatus":42,"stderr":"teststderr","stdoutLen":10}
+teststdout`,
+		},
+		{
+			Name:         "StdoutLimit",
+			Request:      httptest.NewRequest("POST", "/batch-exec", strings.NewReader(`{"repo": "github.com/gorilla/mux", "requests": [{"args": ["testlargeoutput"]}, {"args": ["testcommand"]}]}`)),
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: `{"error":"standard output exceeds the limit of 10 bytes for a command of a batch","exitStatus":0,"stdoutLen":0}
+{"exitStatus":42,"stderr":"teststderr","stdoutLen":10}
+teststdout`,
+		},
+		{
+			Name:         "NoCommands",
+			Request:      httptest.NewRequest("POST", "/batch-exec", strings.NewReader(`{"repo": "github.com/gorilla/mux"}`)),
+			ExpectedCode: http.StatusOK,
+		},
+		{
+			Name:         "DifferentRepos",
+			Request:      httptest.NewRequest("POST", "/batch-exec", strings.NewReader(`{"repo": "github.com/gorilla/mux", "requests": [{"repo": "my-mux", "args": ["testcommand"]}]}`)),
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: "all commands in a batch must be for the same repository",
+		},
+		{
+			Name:         "NonexistingRepo",
+			Request:      httptest.NewRequest("POST", "/batch-exec", strings.NewReader(`{"repo": "github.com/gorilla/doesnotexist", "requests": [{"args": ["testcommand"]}]}`)),
+			ExpectedCode: http.StatusNotFound,
+			ExpectedBody: `{"cloneInProgress":false}`,
+		},
+		{
+			Name:         "EmptyBody",
+			Request:      httptest.NewRequest("POST", "/batch-exec", nil),
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedBody: `EOF`,
+		},
+	}
+
+	s := &Server{ReposDir: "/testroot", skipCloneForTests: true}
+	h := s.Handler()
+
+	origRepoCloned := repoCloned
+	repoCloned = func(dir string) bool {
+		return dir == "/testroot/github.com/gorilla/mux"
+	}
+	defer func() { repoCloned = origRepoCloned }()
+
+	origMaxStdoutSize := batchExecMaxStdoutSize
+	batchExecMaxStdoutSize = len("teststdout")
+	defer func() { batchExecMaxStdoutSize = origMaxStdoutSize }()
+
+	runCommandMock = func(ctx context.Context, cmd *exec.Cmd) (int, error) {
+		switch cmd.Args[1] {
+		case "testcommand":
+			cmd.Stdout.Write([]byte("teststdout"))
+			cmd.Stderr.Write([]byte("teststderr"))
+			return 42, nil
+		case "testlargeoutput":
+			cmd.Stdout.Write([]byte("teststdout"))
+			cmd.Stdout.Write([]byte("+"))
+			if ctx.Err() == nil {
+				cmd.Stderr.Write([]byte("command was not canceled"))
+			}
+			return 0, nil
+		case "testerror":
+			return 0, errors.New("testerror")
+		}
+		return 0, nil
+	}
+	defer func() { runCommandMock = nil }()
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			w := httptest.ResponseRecorder{Body: new(bytes.Buffer)}
+			h.ServeHTTP(&w, test.Request)
+
+			res := w.Result()
+			if res.StatusCode != test.ExpectedCode {
+				t.Errorf("wrong status: expected %d, got %d", test.ExpectedCode, w.Code)
+			}
+
+			body, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(string(body)) != test.ExpectedBody {
+				t.Errorf("wrong body: expected %q, got %q", test.ExpectedBody, string(body))
+			}
+		})
+	}
+}
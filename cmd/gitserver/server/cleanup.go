@@ -25,6 +25,7 @@ import (
 func init() {
 	prometheus.MustRegister(reposRemoved)
 	prometheus.MustRegister(reposRecloned)
+	prometheus.MustRegister(maintenanceDuration)
 }
 
 // inactiveRepoTTL is the amount of time a repository will remain on a
@@ -44,13 +45,32 @@ var reposRecloned = prometheus.NewCounter(prometheus.CounterOpts{
 	Name:      "repos_recloned",
 	Help:      "number of repos removed and recloned due to age",
 })
+var maintenanceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "src",
+	Subsystem: "gitserver",
+	Name:      "maintenance_duration_seconds",
+	Help:      "time taken to run maintenance (git gc, commit-graph) on a repo",
+	Buckets:   []float64{1, 5, 15, 60, 300, 900, 1800, 3600},
+}, []string{"status"})
+
+// maintenanceCommands are run in order on each repo by the janitor. They keep
+// repos fast to read from without the cost of recloning them.
+var maintenanceCommands = [][]string{
+	// Repack when there are many loose objects or packs, writing a bitmap
+	// index when everything is repacked into one pack. Don't detach, so that
+	// the janitor knows when gc is done.
+	{"-c", "gc.autoDetach=false", "-c", "repack.writeBitmaps=true", "gc", "--auto", "--quiet"},
+	// The commit-graph speeds up commit traversal, e.g. for git log.
+	{"commit-graph", "write", "--reachable"},
+}
 
 // cleanupRepos walks the repos directory and performs maintenance tasks:
 //
 // 1. Remove corrupt repos.
 // 2. Remove stale lock files.
 // 3. Remove inactive repos on sourcegraph.com
-// 4. Reclone repos after a while. (simulate git gc)
+// 4. Run git gc and write the commit-graph.
+// 5. Reclone repos that have not been maintained for a while.
 func (s *Server) cleanupRepos() {
 	bCtx, bCancel := s.serverContext()
 	defer bCancel()
@@ -111,6 +131,14 @@ func (s *Server) cleanupRepos() {
 			return false, nil
 		}
 
+		// Repos that are maintained don't need to be recloned. Only reclone
+		// repos for which maintenance keeps failing.
+		if lastMaintained, err := getMaintenanceTime(gitDir); err != nil {
+			return false, err
+		} else if time.Since(lastMaintained) <= repoTTL {
+			return false, nil
+		}
+
 		ctx, cancel := context.WithTimeout(bCtx, longGitCommandTimeout)
 		defer cancel()
 
@@ -123,13 +151,64 @@ func (s *Server) cleanupRepos() {
 			return false, errors.Wrap(err, "failed to get remote URL")
 		}
 
-		if _, err := s.cloneRepo(ctx, repo, remoteURL, &cloneOptions{Block: true, Overwrite: true}); err != nil {
+		repoOpts, err := readCloneOptions(gitDir)
+		if err != nil {
+			return false, err
+		}
+
+		if _, err := s.cloneRepo(ctx, repo, remoteURL, &cloneOptions{Block: true, Overwrite: true, Repo: repoOpts}); err != nil {
 			return true, err
 		}
 		reposRecloned.Inc()
 		return true, nil
 	}
 
+	maybeSyncReplicas := func(gitDir string) (done bool, err error) {
+		repo := protocol.NormalizeRepo(api.RepoName(strings.TrimPrefix(filepath.Dir(gitDir), s.ReposDir+"/")))
+
+		ctx, cancel := context.WithTimeout(bCtx, time.Minute)
+		defer cancel()
+
+		remoteURL, err := repoRemoteURL(ctx, gitDir)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to get remote URL")
+		}
+
+		repoOpts, err := readCloneOptions(gitDir)
+		if err != nil {
+			return false, err
+		}
+		return false, s.syncReplicas(ctx, repo, remoteURL, repoOpts)
+	}
+
+	maintain := func(gitDir string) (done bool, err error) {
+		repoDir := filepath.Dir(gitDir)
+		if _, cloneInProgress := s.locker.Status(repoDir); cloneInProgress {
+			return false, nil
+		}
+
+		// Don't run git gc at the same time as a fetch. Both write packs and
+		// refs, and either can fail on the lock files of the other.
+		repo := protocol.NormalizeRepo(api.RepoName(strings.TrimPrefix(repoDir, s.ReposDir+"/")))
+		s.repoUpdateLocksMu.Lock()
+		mu := s.repoUpdateLocksLocked(repo).mu
+		s.repoUpdateLocksMu.Unlock()
+		mu.Lock()
+		defer mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(bCtx, longGitCommandTimeout)
+		defer cancel()
+
+		start := time.Now()
+		err = s.maintainRepo(ctx, gitDir)
+		status := "success"
+		if err != nil {
+			status = "failure"
+		}
+		maintenanceDuration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+		return false, err
+	}
+
 	removeStaleLocks := func(gitDir string) (done bool, err error) {
 		// if removing a lock fails, we still want to try the other locks.
 		var multi error
@@ -180,6 +259,12 @@ func (s *Server) cleanupRepos() {
 		// info/attributes.
 		{"ensure git attributes", ensureGitAttributes},
 	}
+	if s.ReplicaAddrs != nil {
+		// Replicas are also synced after each clone and update. This
+		// catches replicas that were unavailable at the time, or that were
+		// added since.
+		cleanups = append(cleanups, cleanupFn{"maybe sync replicas", maybeSyncReplicas})
+	}
 	if s.DeleteStaleRepositories {
 		// Sourcegraph.com can potentially clone all of github.com, so we
 		// delete repos which have not been used for a period of
@@ -188,9 +273,10 @@ func (s *Server) cleanupRepos() {
 		cleanups = append(cleanups, cleanupFn{"maybe remove inactive", maybeRemoveInactive})
 	}
 	// Old git clones accumulate loose git objects that waste space and
-	// slow down git operations. Periodically do a fresh clone to avoid
-	// these problems. git gc is slow and resource intensive. It is
-	// cheaper and faster to just reclone the repository.
+	// slow down git operations. Repos are maintained with git gc to avoid
+	// these problems, but if maintenance keeps failing we do a fresh clone
+	// instead.
+	cleanups = append(cleanups, cleanupFn{"maintain", maintain})
 	cleanups = append(cleanups, cleanupFn{"maybe reclone", maybeReclone})
 
 	filepath.Walk(s.ReposDir, func(gitDir string, fi os.FileInfo, fileErr error) error {
@@ -243,15 +329,27 @@ func (s *Server) removeRepoDirectory(dir string) error {
 
 	// Everything after this point is just cleanup, so any error that occurs
 	// should not be returned, just logged.
+	s.removeEmptyParentDirs(dir)
 
-	// Cleanup empty parent directories. We just attempt to remove and if we
-	// have a failure we assume it's due to the directory having other
-	// children. If we checked first we could race with someone else adding a
-	// new clone.
+	// Delete the atomically renamed dir. We do this last since if it fails we
+	// will rely on a janitor job to clean up for us.
+	if err := os.RemoveAll(filepath.Join(tmp, "repo")); err != nil {
+		log15.Warn("failed to cleanup after removing dir", "dir", dir, "error", err)
+	}
+
+	return nil
+}
+
+// removeEmptyParentDirs removes the parent directories of dir that are
+// empty, up until s.ReposDir. Errors are logged, not returned.
+func (s *Server) removeEmptyParentDirs(dir string) {
+	// We just attempt to remove and if we have a failure we assume it's due to
+	// the directory having other children. If we checked first we could race
+	// with someone else adding a new clone.
 	rootInfo, err := os.Stat(s.ReposDir)
 	if err != nil {
 		log15.Warn("Failed to stat ReposDir", "error", err)
-		return nil
+		return
 	}
 	current := dir
 	for {
@@ -269,7 +367,7 @@ func (s *Server) removeRepoDirectory(dir string) error {
 		}
 		if err != nil {
 			log15.Warn("failed to stat parent directory", "dir", current, "error", err)
-			return nil
+			return
 		}
 		if os.SameFile(rootInfo, info) {
 			// Stop, we are at the parent.
@@ -281,13 +379,27 @@ func (s *Server) removeRepoDirectory(dir string) error {
 			break
 		}
 	}
+}
 
-	// Delete the atomically renamed dir. We do this last since if it fails we
-	// will rely on a janitor job to clean up for us.
-	if err := os.RemoveAll(filepath.Join(tmp, "repo")); err != nil {
-		log15.Warn("failed to cleanup after removing dir", "dir", dir, "error", err)
+// maintainRepo runs maintenanceCommands on the repo at gitDir and removes
+// temporary packs left behind by interrupted fetches. On success it records
+// the time of the maintenance, see getMaintenanceTime.
+func (s *Server) maintainRepo(ctx context.Context, gitDir string) error {
+	s.cleanTmpFiles(filepath.Dir(gitDir))
+
+	for _, args := range maintenanceCommands {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = gitDir
+		if _, err := cmd.Output(); err != nil {
+			return wrapCmdError(cmd, err)
+		}
 	}
 
+	cmd := exec.Command("git", "config", "sourcegraph.maintenanceTimestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	cmd.Dir = gitDir
+	if _, err := cmd.Output(); err != nil {
+		return errors.Wrap(wrapCmdError(cmd, err), "failed to update maintenanceTimestamp")
+	}
 	return nil
 }
 
@@ -410,6 +522,29 @@ func getRecloneTime(gitDir string) (time.Time, error) {
 	return time.Unix(sec, 0), nil
 }
 
+// getMaintenanceTime returns the time maintainRepo last succeeded on the repo
+// at gitDir, or the zero time if it never has.
+func getMaintenanceTime(gitDir string) (time.Time, error) {
+	cmd := exec.Command("git", "config", "--get", "sourcegraph.maintenanceTimestamp")
+	cmd.Dir = gitDir
+	out, err := cmd.Output()
+	if err != nil {
+		// Exit code 1 means the key is not set.
+		if ee, ok := err.(*exec.ExitError); ok && ee.Sys().(syscall.WaitStatus).ExitStatus() == 1 {
+			return time.Time{}, nil
+		}
+		return time.Time{}, errors.Wrap(wrapCmdError(cmd, err), "failed to determine maintenance timestamp")
+	}
+
+	sec, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 0)
+	if err != nil {
+		// Treat a bad value like a missing one, so that maintenance
+		// overwrites it.
+		return time.Time{}, nil
+	}
+	return time.Unix(sec, 0), nil
+}
+
 // randDuration returns a psuedo-random duration between [0, d)
 func randDuration(d time.Duration) time.Duration {
 	return time.Duration(rand.Int63n(int64(d)))
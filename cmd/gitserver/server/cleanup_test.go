@@ -2,15 +2,23 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
 )
 
 const (
@@ -89,6 +97,11 @@ func TestCleanupExpired(t *testing.T) {
 	}
 	defer func() { repoRemoteURL = origRepoRemoteURL }()
 
+	// Repos are only recloned if maintenance keeps failing.
+	origMaintenanceCommands := maintenanceCommands
+	maintenanceCommands = [][]string{{"not-a-git-command"}}
+	defer func() { maintenanceCommands = origMaintenanceCommands }()
+
 	atime, err := os.Stat(filepath.Join(repoA, "HEAD"))
 	if err != nil {
 		t.Fatal(err)
@@ -124,6 +137,45 @@ func TestCleanupExpired(t *testing.T) {
 	}
 }
 
+func TestCleanupMaintain(t *testing.T) {
+	root, cleanup := tmpDir(t)
+	defer cleanup()
+
+	repo := filepath.Join(root, testRepoA)
+	for _, args := range [][]string{
+		{"init", repo},
+		{"-C", repo, "-c", "user.name=a", "-c", "user.email=a@a.com", "commit", "--allow-empty", "-m", "foo"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s (output: %s)", args, err, out)
+		}
+	}
+	gitDir := filepath.Join(repo, ".git")
+	mkFiles(t, root, testRepoA+"/.git/objects/pack/tmp_pack_stale")
+	stale := time.Now().Add(-2 * longGitCommandTimeout)
+	if err := os.Chtimes(filepath.Join(gitDir, "objects/pack/tmp_pack_stale"), stale, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{ReposDir: root}
+	s.Handler() // Handler as a side-effect sets up Server
+	s.cleanupRepos()
+
+	if _, err := os.Stat(filepath.Join(gitDir, "objects/info/commit-graph")); err != nil {
+		t.Errorf("expected commit-graph to be written: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "objects/pack/tmp_pack_stale")); !os.IsNotExist(err) {
+		t.Error("expected stale temporary pack to be removed")
+	}
+	lastMaintained, err := getMaintenanceTime(gitDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(lastMaintained) > time.Minute {
+		t.Errorf("expected recent maintenance time, got %s", lastMaintained)
+	}
+}
+
 func TestCleanupOldLocks(t *testing.T) {
 	root, cleanup := tmpDir(t)
 	defer cleanup()
@@ -191,6 +243,57 @@ func TestCleanupOldLocks(t *testing.T) {
 	)
 }
 
+func TestCleanupSyncReplicas(t *testing.T) {
+	root, cleanup := tmpDir(t)
+	defer cleanup()
+
+	mkFiles(t, root,
+		"github.com/foo/primary/.git/HEAD",
+		"github.com/foo/replica/.git/HEAD",
+	)
+
+	var mu sync.Mutex
+	var updated []protocol.RepoUpdateRequest
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/enqueue-repo-update" {
+			t.Errorf("unexpected request to replica: %s", r.URL)
+		}
+		var req protocol.RepoUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		updated = append(updated, req)
+		mu.Unlock()
+	}))
+	defer replica.Close()
+	replicaAddr := strings.TrimPrefix(replica.URL, "http://")
+
+	origRepoRemoteURL := repoRemoteURL
+	repoRemoteURL = func(ctx context.Context, dir string) (string, error) {
+		return "https://" + strings.TrimPrefix(filepath.Dir(dir), root+"/"), nil
+	}
+	defer func() { repoRemoteURL = origRepoRemoteURL }()
+
+	s := &Server{
+		ReposDir: root,
+		Addr:     "gitserver-0:3178",
+		ReplicaAddrs: func(ctx context.Context, repo api.RepoName) []string {
+			if repo == "github.com/foo/primary" {
+				return []string{"gitserver-0:3178", replicaAddr}
+			}
+			return []string{replicaAddr, "gitserver-0:3178"}
+		},
+	}
+	s.Handler() // Handler as a side-effect sets up Server
+	s.cleanupRepos()
+
+	want := []protocol.RepoUpdateRequest{{Repo: "github.com/foo/primary", URL: "https://github.com/foo/primary"}}
+	if !reflect.DeepEqual(updated, want) {
+		t.Errorf("got replica updates %+v, want %+v", updated, want)
+	}
+}
+
 func TestSetupAndClearTmp(t *testing.T) {
 	root, cleanup := tmpDir(t)
 	defer cleanup()
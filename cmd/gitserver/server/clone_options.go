@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// defaultRefspecs are the refspecs fetched for repos that are not cloned with
+// protocol.CloneOptions.
+var defaultRefspecs = []string{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*", "+refs/pull/*:refs/pull/*"}
+
+var cloneFilterPattern = regexp.MustCompile(`^(blob:none|blob:limit=[0-9]+[kmg]?|tree:[0-9]+)$`)
+
+// validateCloneOptions returns an error if opts can't be passed to git
+// safely.
+func validateCloneOptions(opts *protocol.CloneOptions) error {
+	if opts.Filter != "" && !cloneFilterPattern.MatchString(opts.Filter) {
+		return fmt.Errorf("invalid clone filter %q", opts.Filter)
+	}
+	if len(opts.ExcludeRefs) > 0 && !supportsNegativeRefspecs() {
+		return errors.New("excludeRefs requires git 2.29 or later on gitserver")
+	}
+	for _, ref := range append(append([]string{}, opts.Refs...), opts.ExcludeRefs...) {
+		if !strings.HasPrefix(ref, "refs/") || strings.ContainsAny(ref, ":^ ") {
+			return fmt.Errorf("invalid ref pattern %q (must start with refs/)", ref)
+		}
+	}
+	return nil
+}
+
+// supportsNegativeRefspecs reports whether the installed git supports
+// negative refspecs, which fetchRefspecs uses for excluded refs. They were
+// added in git 2.29.
+var supportsNegativeRefspecs = func() bool {
+	gitVersionOnce.Do(func() {
+		out, err := exec.Command("git", "version").Output()
+		if err != nil {
+			log15.Warn("Failed to determine git version", "error", err)
+			return
+		}
+		gitMajor, gitMinor, _ = parseGitVersion(string(out))
+	})
+	return gitMajor > 2 || (gitMajor == 2 && gitMinor >= 29)
+}
+
+var (
+	gitVersionOnce     sync.Once
+	gitMajor, gitMinor int
+)
+
+var gitVersionPattern = regexp.MustCompile(`^git version ([0-9]+)\.([0-9]+)`)
+
+// parseGitVersion returns the major and minor version in the output of git
+// version, such as "git version 2.29.2".
+func parseGitVersion(out string) (major, minor int, ok bool) {
+	m := gitVersionPattern.FindStringSubmatch(out)
+	if m == nil {
+		return 0, 0, false
+	}
+	major, _ = strconv.Atoi(m[1])
+	minor, _ = strconv.Atoi(m[2])
+	return major, minor, true
+}
+
+// isFullClone reports whether opts clone a repo in full, like no options.
+func isFullClone(opts *protocol.CloneOptions) bool {
+	return opts == nil || (opts.Filter == "" && len(opts.Refs) == 0 && len(opts.ExcludeRefs) == 0)
+}
+
+// fetchRefspecs returns the refspecs to fetch for a repo with the given
+// options, which may be nil. Excluded refs are negative refspecs.
+func fetchRefspecs(opts *protocol.CloneOptions) []string {
+	if opts == nil || (len(opts.Refs) == 0 && len(opts.ExcludeRefs) == 0) {
+		return defaultRefspecs
+	}
+	var refspecs []string
+	if len(opts.Refs) == 0 {
+		refspecs = append(refspecs, defaultRefspecs...)
+	}
+	for _, ref := range opts.Refs {
+		refspecs = append(refspecs, "+"+ref+":"+ref)
+	}
+	for _, ref := range opts.ExcludeRefs {
+		refspecs = append(refspecs, "^"+ref)
+	}
+	return refspecs
+}
+
+// fetchRemote returns the remote to fetch the repo at dir from. Partial
+// clones must fetch from their promisor remote (origin), otherwise git
+// fetches the objects that the filter leaves out. Mercurial repos must be
+// fetched from origin too (see hg.go). In both cases origin is first set to
+// url, so that fetches use the current URL and credentials of the repo.
+func fetchRemote(ctx context.Context, dir, url string, opts *protocol.CloneOptions) (string, error) {
+	if (opts == nil || opts.Filter == "") && !isMercurialURL(url) {
+		return url, nil
+	}
+	cmd := exec.CommandContext(ctx, "git", "config", "remote.origin.url", gitRemoteURL(url))
+	cmd.Dir = dir
+	if _, err := cmd.Output(); err != nil {
+		return "", errors.Wrap(wrapCmdError(cmd, err), "failed to update origin URL")
+	}
+	return "origin", nil
+}
+
+// readCloneOptions returns the clone options stored in the repo at dir by
+// writeCloneOptions, or nil if the repo was cloned in full.
+func readCloneOptions(dir string) (*protocol.CloneOptions, error) {
+	cmd := exec.Command("git", "config", "--get", "sourcegraph.cloneOptions")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		// Exit code 1 means the key is not set.
+		if ee, ok := err.(*exec.ExitError); ok && ee.Sys().(syscall.WaitStatus).ExitStatus() == 1 {
+			return nil, nil
+		}
+		return nil, errors.Wrap(wrapCmdError(cmd, err), "failed to read clone options")
+	}
+	var opts protocol.CloneOptions
+	if err := json.Unmarshal(out, &opts); err != nil {
+		return nil, errors.Wrap(err, "invalid clone options")
+	}
+	return &opts, nil
+}
+
+// writeCloneOptions stores opts in the repo at dir, so that later fetches use
+// them. If opts has a filter, it also configures origin as the promisor
+// remote that git fetches missing objects from.
+func writeCloneOptions(dir string, opts *protocol.CloneOptions) error {
+	b, err := json.Marshal(opts)
+	if err != nil {
+		return err
+	}
+	configs := [][2]string{{"sourcegraph.cloneOptions", string(b)}}
+	if opts.Filter != "" {
+		configs = append(configs,
+			[2]string{"core.repositoryformatversion", "1"},
+			[2]string{"extensions.partialClone", "origin"},
+			[2]string{"remote.origin.promisor", "true"},
+			[2]string{"remote.origin.partialclonefilter", opts.Filter},
+		)
+	}
+	for _, kv := range configs {
+		cmd := exec.Command("git", "config", kv[0], kv[1])
+		cmd.Dir = dir
+		if _, err := cmd.Output(); err != nil {
+			return errors.Wrap(wrapCmdError(cmd, err), "failed to write clone options")
+		}
+	}
+	return nil
+}
+
+// updateCloneOptions replaces the clone options stored in the repo at dir
+// with opts, if they are different. Once a repo is a partial clone, it stays
+// one, because it is missing objects.
+func updateCloneOptions(dir string, opts *protocol.CloneOptions) error {
+	current, err := readCloneOptions(dir)
+	if err != nil {
+		return err
+	}
+	if isFullClone(opts) {
+		if current == nil {
+			return nil
+		}
+		cmd := exec.Command("git", "config", "--unset", "sourcegraph.cloneOptions")
+		cmd.Dir = dir
+		if _, err := cmd.Output(); err != nil {
+			return errors.Wrap(wrapCmdError(cmd, err), "failed to remove clone options")
+		}
+		return nil
+	}
+	if reflect.DeepEqual(current, opts) {
+		return nil
+	}
+	return writeCloneOptions(dir, opts)
+}
+
+// initClone creates an empty bare repo at dir for a clone with the given
+// options. Unlike git clone --mirror, it does not configure refspecs for
+// origin, so that only the refs passed to git fetch are fetched.
+func initClone(ctx context.Context, dir, url string, opts *protocol.CloneOptions) error {
+	cmd := exec.CommandContext(ctx, "git", "init", "--bare", dir)
+	if _, err := cmd.Output(); err != nil {
+		return wrapCmdError(cmd, err)
+	}
+	cmd = exec.CommandContext(ctx, "git", "config", "remote.origin.url", url)
+	cmd.Dir = dir
+	if _, err := cmd.Output(); err != nil {
+		return wrapCmdError(cmd, err)
+	}
+	return writeCloneOptions(dir, opts)
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Mercurial repositories are mirrored with the git-remote-hg remote helper,
+// which must be installed (along with hg) on gitserver. It converts a
+// Mercurial repository incrementally: it stores the mapping between the
+// changesets and the commits it created under $GIT_DIR/hg/<remote>/, so each
+// fetch only converts new changesets. The mapping is only kept for named
+// remotes, so repos are always fetched from origin, never from a URL.
+//
+// The remote URLs of Mercurial repositories have the form hg+<url> (e.g.
+// hg+https://hg.example.com/repo), or git's form hg::<url>.
+
+// isMercurialURL reports whether url is the remote URL of a Mercurial
+// repository.
+func isMercurialURL(url string) bool {
+	return strings.HasPrefix(url, "hg+") || strings.HasPrefix(url, "hg::")
+}
+
+// mercurialURL returns the URL that hg accesses the Mercurial repository with
+// the given remote URL at.
+func mercurialURL(url string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(url, "hg+"), "hg::")
+}
+
+// gitRemoteURL returns the URL to pass to git for the repo with the given
+// remote URL.
+func gitRemoteURL(url string) string {
+	if isMercurialURL(url) {
+		return "hg::" + mercurialURL(url)
+	}
+	return url
+}
+
+// isMercurialCloneable checks that the Mercurial repository at url exists.
+// It is much cheaper than git ls-remote, which makes git-remote-hg clone the
+// whole repository.
+func isMercurialCloneable(ctx context.Context, url string) error {
+	cmd := exec.CommandContext(ctx, "hg",
+		"--noninteractive",
+		"--config", "ui.ssh=ssh -o BatchMode=yes -o ConnectTimeout=30",
+		"identify", "--", mercurialURL(url))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s (output follows)\n\n%s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/mutablelimiter"
+)
+
+func TestGitRemoteURL(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/gorilla/mux":    "https://github.com/gorilla/mux",
+		"hg+https://hg.example.com/foo":     "hg::https://hg.example.com/foo",
+		"hg+ssh://hg@hg.example.com/foo":    "hg::ssh://hg@hg.example.com/foo",
+		"hg::https://hg.example.com/foo":    "hg::https://hg.example.com/foo",
+		"hg+/var/lib/mercurial/foo":         "hg::/var/lib/mercurial/foo",
+		"git@github.com:hg+/not-mercurial/": "git@github.com:hg+/not-mercurial/",
+	}
+	for url, want := range tests {
+		if got := gitRemoteURL(url); got != want {
+			t.Errorf("gitRemoteURL(%q): got %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestCloneRepo_mercurial(t *testing.T) {
+	for _, bin := range []string{"hg", "git-remote-hg"} {
+		if _, err := exec.LookPath(bin); err != nil {
+			t.Skipf("%s is not installed", bin)
+		}
+	}
+
+	remote, cleanup1 := tmpDir(t)
+	defer cleanup1()
+
+	dir := remote
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		c := exec.Command(name, arg...)
+		c.Dir = dir
+		c.Env = append(os.Environ(), "HGPLAIN=1", "HGUSER=a <a@a.com>")
+		b, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s %s failed: %s\n%s", name, strings.Join(arg, " "), err, b)
+		}
+		return string(b)
+	}
+
+	cmd("hg", "init", ".")
+	cmd("sh", "-c", "echo hello > hello.txt")
+	cmd("hg", "commit", "-A", "-m", "hello")
+
+	reposDir, cleanup2 := tmpDir(t)
+	defer cleanup2()
+
+	s := &Server{
+		ReposDir:         reposDir,
+		ctx:              context.Background(),
+		locker:           &RepositoryLocker{},
+		cloneLimiter:     mutablelimiter.New(1),
+		cloneableLimiter: mutablelimiter.New(1),
+	}
+	url := "hg+" + remote
+	if _, err := s.cloneRepo(context.Background(), "example.com/hg/foo", url, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir = filepath.Join(reposDir, "example.com/hg/foo")
+	if got, want := cmd("git", "log", "--format=%s", "HEAD"), "hello\n"; got != want {
+		t.Fatalf("got log %q, want %q", got, want)
+	}
+
+	// An update only converts the new changeset.
+	dir = remote
+	cmd("sh", "-c", "echo world >> hello.txt")
+	cmd("hg", "commit", "-m", "world")
+
+	dir = filepath.Join(reposDir, "example.com/hg/foo")
+	if err := s.doRepoUpdate2("example.com/hg/foo", url); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cmd("git", "log", "--format=%s", "HEAD"), "world\nhello\n"; got != want {
+		t.Errorf("got log %q, want %q", got, want)
+	}
+	if got, want := cmd("git", "show", "HEAD:hello.txt"), "hello\nworld\n"; got != want {
+		t.Errorf("got hello.txt %q, want %q", got, want)
+	}
+	if got, want := cmd("git", "config", "remote.origin.url"), "hg::"+remote+"\n"; got != want {
+		t.Errorf("got remote URL %q, want %q", got, want)
+	}
+}
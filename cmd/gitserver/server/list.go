@@ -68,6 +68,20 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 			repos = append(repos, rp...)
 		}
 
+	case query("perforce"):
+		p4port := q.Get("perforce")
+		for _, pconf := range conf.Get().Perforce {
+			if pconf.P4Port != p4port {
+				continue
+			}
+			depots, err := listPerforceDepots(ctx, pconf)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			repos = append(repos, depots...)
+		}
+
 	case query("cloned"):
 		err := filepath.Walk(s.ReposDir, func(path string, info os.FileInfo, err error) error {
 			if ctx.Err() != nil {
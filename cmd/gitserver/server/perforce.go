@@ -0,0 +1,227 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// Perforce depots are mirrored with git p4. Their remote URLs have the form
+// perforce://host:port//depot/path/ (see reposource.PerforceCloneURL), with
+// the scheme perforce+ssl for Perforce servers that use SSL. The URLs don't
+// contain credentials, because they are stored as the origin of the mirror.
+// The credentials are those of the Perforce connection in the site
+// configuration, and are passed to p4 in the environment.
+
+// isPerforceURL reports whether url is the remote URL of a Perforce depot.
+func isPerforceURL(url string) bool {
+	return strings.HasPrefix(url, "perforce://") || strings.HasPrefix(url, "perforce+ssl://")
+}
+
+// perforceDepot is a depot path on a Perforce server, and the credentials
+// to access it with.
+type perforceDepot struct {
+	port   string // P4PORT
+	user   string // P4USER
+	passwd string // P4PASSWD
+	path   string // e.g. "//depot/main/"
+}
+
+// parsePerforceURL parses the remote URL of a Perforce depot, and looks up
+// the credentials for its Perforce server in the site configuration.
+func parsePerforceURL(rawurl string) (*perforceDepot, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "perforce" && u.Scheme != "perforce+ssl" {
+		return nil, fmt.Errorf("not a Perforce URL (scheme %q)", u.Scheme)
+	}
+	if !strings.HasPrefix(u.Path, "//") || strings.ContainsAny(u.Path, "@# ") {
+		return nil, errors.New("invalid Perforce depot path")
+	}
+	d := &perforceDepot{port: u.Host, path: u.Path}
+	if u.Scheme == "perforce+ssl" {
+		d.port = "ssl:" + d.port
+	}
+	if !strings.HasSuffix(d.path, "/") {
+		d.path += "/"
+	}
+	pconf := perforceConnection(d.port)
+	if pconf == nil {
+		return nil, fmt.Errorf("no Perforce connection for %s in the site configuration", d.port)
+	}
+	d.user, d.passwd = pconf.P4User, pconf.P4Passwd
+	return d, nil
+}
+
+// perforceConnection returns the Perforce connection in the site
+// configuration for the server at port, or nil if there is none.
+func perforceConnection(port string) *schema.PerforceConnection {
+	for _, pconf := range conf.Get().Perforce {
+		if strings.EqualFold(pconf.P4Port, port) {
+			return pconf
+		}
+	}
+	return nil
+}
+
+// perforceEnv returns the environment for p4 (and git p4) commands that
+// access the Perforce server at port. Credentials are passed in the
+// environment so that they don't show up in process listings.
+func perforceEnv(port, user, passwd string) []string {
+	return append(os.Environ(), "P4PORT="+port, "P4USER="+user, "P4PASSWD="+passwd)
+}
+
+// p4Command returns a p4 command for the Perforce server at port.
+func p4Command(ctx context.Context, port, user, passwd string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "p4", args...)
+	cmd.Env = perforceEnv(port, user, passwd)
+	return cmd
+}
+
+// p4Trust trusts the fingerprint of the Perforce server at port if it uses
+// SSL. Otherwise p4 refuses to connect to it.
+func p4Trust(ctx context.Context, port, user, passwd string) error {
+	if !strings.HasPrefix(port, "ssl:") {
+		return nil
+	}
+	cmd := p4Command(ctx, port, user, passwd, "trust", "-y")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "p4 trust failed. Output: %s", string(out))
+	}
+	return nil
+}
+
+// isPerforceCloneable checks that the depot at url exists and has changes.
+func isPerforceCloneable(ctx context.Context, url string) error {
+	d, err := parsePerforceURL(url)
+	if err != nil {
+		return err
+	}
+	if err := p4Trust(ctx, d.port, d.user, d.passwd); err != nil {
+		return err
+	}
+	cmd := p4Command(ctx, d.port, d.user, d.passwd, "-ztag", "changes", "-m", "1", d.path+"...")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s (output follows)\n\n%s", err, out)
+	}
+	if !bytes.Contains(out, []byte("... change ")) {
+		return fmt.Errorf("no changes in Perforce depot %s", d.path)
+	}
+	return nil
+}
+
+// perforceCloneCommand returns the command that mirrors the depot at url to
+// the new bare repo at dir.
+func perforceCloneCommand(ctx context.Context, url, dir string) (*exec.Cmd, error) {
+	d, err := parsePerforceURL(url)
+	if err != nil {
+		return nil, err
+	}
+	if err := p4Trust(ctx, d.port, d.user, d.passwd); err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "git", "p4", "clone", "--bare", strings.TrimSuffix(d.path, "/")+"@all", dir)
+	cmd.Env = perforceEnv(d.port, d.user, d.passwd)
+	return cmd, nil
+}
+
+// syncPerforceDepot imports the changes that were submitted to the depot at
+// url since the last sync into the repo at dir.
+func (s *Server) syncPerforceDepot(ctx context.Context, dir, url string) error {
+	d, err := parsePerforceURL(url)
+	if err != nil {
+		return err
+	}
+	if err := p4Trust(ctx, d.port, d.user, d.passwd); err != nil {
+		return err
+	}
+	// git p4 sync reads the depot path from the commits it imported before.
+	cmd := exec.CommandContext(ctx, "git", "p4", "sync")
+	cmd.Dir = dir
+	cmd.Env = perforceEnv(d.port, d.user, d.passwd)
+	if output, err := s.runWithRemoteOpts(ctx, cmd, nil); err != nil {
+		return errors.Wrapf(err, "git p4 sync failed. Output: %s", newURLRedactor(url).redact(string(output)))
+	}
+	return setPerforceHEAD(ctx, dir)
+}
+
+// setPerforceHEAD points master, and HEAD, at the changes that git p4
+// imported into refs/remotes/p4/master.
+func setPerforceHEAD(ctx context.Context, dir string) error {
+	for _, args := range [][]string{
+		{"update-ref", "refs/heads/master", "refs/remotes/p4/master"},
+		{"symbolic-ref", "HEAD", "refs/heads/master"},
+	} {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return errors.Wrapf(err, "git %s failed. Output: %s", args[0], string(out))
+		}
+	}
+	return nil
+}
+
+// listPerforceDepots returns the paths of the local and stream depots on the
+// Perforce server of pconf, e.g. "//depot/".
+func listPerforceDepots(ctx context.Context, pconf *schema.PerforceConnection) ([]string, error) {
+	if err := p4Trust(ctx, pconf.P4Port, pconf.P4User, pconf.P4Passwd); err != nil {
+		return nil, err
+	}
+	cmd := p4Command(ctx, pconf.P4Port, pconf.P4User, pconf.P4Passwd, "-ztag", "depots")
+	out, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("listing Perforce depots failed: %s (stderr: %q)", err, ee.Stderr)
+		}
+		return nil, err
+	}
+	return parsePerforceDepots(out), nil
+}
+
+// parsePerforceDepots parses the output of p4 -ztag depots. Each depot is a
+// block of "... field value" lines.
+func parsePerforceDepots(out []byte) []string {
+	var (
+		depots     []string
+		name, typ  string
+		flushDepot = func() {
+			if name != "" && (typ == "local" || typ == "stream") {
+				depots = append(depots, "//"+name+"/")
+			}
+			name, typ = "", ""
+		}
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			flushDepot()
+			continue
+		}
+		fields := strings.SplitN(strings.TrimPrefix(line, "... "), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "name":
+			flushDepot()
+			name = fields[1]
+		case "type":
+			typ = fields[1]
+		}
+	}
+	flushDepot()
+	return depots
+}
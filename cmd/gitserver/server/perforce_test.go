@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// fakeP4 is a stand-in for the p4 CLI that knows about the depots //depot
+// (which has changes), //empty (which has none) and //spec.
+const fakeP4 = `#!/bin/sh
+if [ "$P4USER" != admin ] || [ "$P4PASSWD" != pa55 ]; then
+	echo "Perforce password (P4PASSWD) invalid or unset." >&2
+	exit 1
+fi
+case "$*" in
+"-ztag depots")
+	cat <<EOF
+... name depot
+... time 1331236473
+... type local
+... map depot/...
+... desc Default depot
+
+... name spec
+... time 1331236473
+... type spec
+... map spec/...
+... desc Spec depot
+
+... name streams
+... time 1331236473
+... type stream
+... map streams/...
+... desc Streams
+EOF
+	;;
+"-ztag changes -m 1 //depot/main/...")
+	echo "... change 42"
+	echo "... user admin"
+	;;
+"-ztag changes -m 1 //empty/...")
+	;;
+*)
+	echo "unexpected arguments: $*" >&2
+	exit 1
+	;;
+esac
+`
+
+func withFakeP4(t *testing.T) (cleanup func()) {
+	dir, err := ioutil.TempDir("", "fake-p4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "p4"), []byte(fakeP4), 0755); err != nil {
+		t.Fatal(err)
+	}
+	origPath := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+origPath)
+	return func() {
+		os.Setenv("PATH", origPath)
+		os.RemoveAll(dir)
+	}
+}
+
+// mockPerforceConf configures Perforce connections whose credentials are
+// looked up by parsePerforceURL.
+func mockPerforceConf() (cleanup func()) {
+	conf.Mock(&schema.SiteConfiguration{
+		Perforce: []*schema.PerforceConnection{
+			{P4Port: "perforce.example.com:1666", P4User: "admin", P4Passwd: "pa55"},
+			{P4Port: "ssl:perforce.example.com:1666", P4User: "admin", P4Passwd: "p@ss"},
+			{P4Port: "wrong.example.com:1666", P4User: "admin", P4Passwd: "wrong"},
+		},
+	})
+	return func() { conf.Mock(nil) }
+}
+
+func TestParsePerforceURL(t *testing.T) {
+	defer mockPerforceConf()()
+
+	tests := []struct {
+		url  string
+		want *perforceDepot
+	}{
+		{
+			url:  "perforce://perforce.example.com:1666//depot/main/",
+			want: &perforceDepot{port: "perforce.example.com:1666", user: "admin", passwd: "pa55", path: "//depot/main/"},
+		},
+		{
+			url:  "perforce+ssl://perforce.example.com:1666//depot",
+			want: &perforceDepot{port: "ssl:perforce.example.com:1666", user: "admin", passwd: "p@ss", path: "//depot/"},
+		},
+		{url: "https://github.com/gorilla/mux"},
+		{url: "perforce://perforce.example.com:1666/depot/main/"},
+		{url: "perforce://perforce.example.com:1666//depot/main/@all"},
+		{url: "perforce://unknown.example.com:1666//depot/main/"},
+	}
+	for _, test := range tests {
+		got, err := parsePerforceURL(test.url)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("%s: expected error, got %+v", test.url, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.url, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.url, got, test.want)
+		}
+	}
+}
+
+func TestListPerforceDepots(t *testing.T) {
+	defer withFakeP4(t)()
+
+	depots, err := listPerforceDepots(context.Background(), &schema.PerforceConnection{
+		P4Port:   "perforce.example.com:1666",
+		P4User:   "admin",
+		P4Passwd: "pa55",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"//depot/", "//streams/"}; !reflect.DeepEqual(depots, want) {
+		t.Errorf("got depots %q, want %q", depots, want)
+	}
+
+	_, err = listPerforceDepots(context.Background(), &schema.PerforceConnection{
+		P4Port:   "perforce.example.com:1666",
+		P4User:   "admin",
+		P4Passwd: "wrong",
+	})
+	if err == nil || !strings.Contains(err.Error(), "P4PASSWD") {
+		t.Errorf("got error %v, want authentication error", err)
+	}
+}
+
+func TestIsPerforceCloneable(t *testing.T) {
+	defer withFakeP4(t)()
+	defer mockPerforceConf()()
+
+	ctx := context.Background()
+	if err := isPerforceCloneable(ctx, "perforce://perforce.example.com:1666//depot/main/"); err != nil {
+		t.Errorf("expected //depot/main/ to be cloneable, got %s", err)
+	}
+	if err := isPerforceCloneable(ctx, "perforce://perforce.example.com:1666//empty/"); err == nil {
+		t.Error("expected //empty/ not to be cloneable")
+	}
+	if err := isPerforceCloneable(ctx, "perforce://wrong.example.com:1666//depot/main/"); err == nil {
+		t.Error("expected //depot/main/ not to be cloneable with the wrong password")
+	}
+}
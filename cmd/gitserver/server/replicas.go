@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// syncReplicas asks the replicas of repo to clone or update it from url with
+// the given clone options. It does nothing unless this gitserver is the
+// primary gitserver of repo.
+func (s *Server) syncReplicas(ctx context.Context, repo api.RepoName, url string, opts *protocol.CloneOptions) error {
+	if s.ReplicaAddrs == nil {
+		return nil
+	}
+	addrs := s.ReplicaAddrs(ctx, repo)
+	if len(addrs) < 2 || addrs[0] != s.Addr {
+		// Only the primary gitserver of a repository syncs its replicas.
+		return nil
+	}
+
+	var multi error
+	for _, addr := range addrs[1:] {
+		if err := enqueueReplicaUpdate(ctx, addr, repo, url, opts); err != nil {
+			multi = multierror.Append(multi, err)
+		}
+	}
+	return multi
+}
+
+// syncReplicasAsync is like syncReplicas, but runs in the background. It is
+// called after repo is cloned or updated, so that its replicas don't fall
+// behind until the next janitor run.
+func (s *Server) syncReplicasAsync(repo api.RepoName, url string, opts *protocol.CloneOptions) {
+	if s.ReplicaAddrs == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := s.serverContext()
+		defer cancel()
+		ctx, cancel = context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+		if err := s.syncReplicas(ctx, repo, url, opts); err != nil {
+			log15.Warn("Failed to sync replicas", "repo", repo, "error", err)
+		}
+	}()
+}
+
+// enqueueReplicaUpdate asks the gitserver at addr, which is a replica of repo,
+// to clone repo if it doesn't have it yet, or else to update it. opts are the
+// clone options of the primary's clone of repo.
+func enqueueReplicaUpdate(ctx context.Context, addr string, repo api.RepoName, url string, opts *protocol.CloneOptions) error {
+	body, err := json.Marshal(&protocol.RepoUpdateRequest{Repo: repo, URL: url, CloneOptions: opts})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", "http://"+addr+"/enqueue-repo-update", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("enqueue update of replica %s: http status %d: %s", addr, resp.StatusCode, string(b))
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
 	log15 "gopkg.in/inconshreveable/log15.v2"
@@ -58,6 +59,12 @@ func (s *Server) handleRepoInfo(w http.ResponseWriter, r *http.Request) {
 		} else {
 			resp.LastChanged = &lastChanged
 		}
+
+		if lastMaintained, err := getMaintenanceTime(dir); err != nil {
+			log15.Warn("error getting last maintenance time", "repo", req.Repo, "err", err)
+		} else if !lastMaintained.IsZero() {
+			resp.LastMaintained = &lastMaintained
+		}
 	}
 
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
@@ -81,6 +88,8 @@ func (s *Server) handleRepoDelete(w http.ResponseWriter, r *http.Request) {
 	log15.Info("deleted repository", "repo", req.Repo)
 }
 
+// deleteRepo deletes the clone of repo. Deleting a repository that is not
+// cloned is not an error, so that deletions can be retried.
 func (s *Server) deleteRepo(repo api.RepoName) error {
 	repo = protocol.NormalizeRepo(repo)
 	dir := filepath.Join(s.ReposDir, string(repo))
@@ -93,10 +102,70 @@ func (s *Server) deleteRepo(repo api.RepoName) error {
 	} else {
 		// Old style, ensure it actually is a git dir so we don't delete
 		// multiple repos. We do not need to change dir.
-		if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+		if _, err := os.Stat(filepath.Join(dir, "HEAD")); os.IsNotExist(err) {
+			return nil // not cloned, nothing to do
+		} else if err != nil {
 			return err
 		}
 	}
 
 	return s.removeRepoDirectory(dir)
 }
+
+func (s *Server) handleRepoRename(w http.ResponseWriter, r *http.Request) {
+	var req protocol.RepoRenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.renameRepo(req.From, req.To); err != nil {
+		log15.Error("failed to rename repository", "from", req.From, "to", req.To, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log15.Info("renamed repository", "from", req.From, "to", req.To)
+}
+
+// renameRepo moves the clone of a repository that was renamed on its code
+// host to the directory of its new name. If the repository is already cloned
+// under its new name, the clone under its old name is deleted instead.
+func (s *Server) renameRepo(from, to api.RepoName) error {
+	from, to = protocol.NormalizeRepo(from), protocol.NormalizeRepo(to)
+	fromDir := filepath.Join(s.ReposDir, string(from))
+	toDir := filepath.Join(s.ReposDir, string(to))
+
+	// Prevent the repository from being cloned or updated under either name
+	// while it is moved.
+	fromLock, ok := s.locker.TryAcquire(fromDir, "renaming repository")
+	if !ok {
+		return errors.Errorf("repository %s is busy", from)
+	}
+	defer fromLock.Release()
+	toLock, ok := s.locker.TryAcquire(toDir, "renaming repository")
+	if !ok {
+		return errors.Errorf("repository %s is busy", to)
+	}
+	defer toLock.Release()
+
+	// We only move the .git dir, since the directory of a repository can
+	// contain the directories of other repositories (e.g. github.com/foo/bar
+	// and github.com/foo/bar/baz).
+	fromGitDir := filepath.Join(fromDir, ".git")
+	if _, err := os.Stat(fromGitDir); os.IsNotExist(err) {
+		return nil // not cloned, nothing to do
+	} else if err != nil {
+		return err
+	}
+	if repoCloned(toDir) {
+		return s.removeRepoDirectory(fromGitDir)
+	}
+	if err := os.MkdirAll(toDir, os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Rename(fromGitDir, filepath.Join(toDir, ".git")); err != nil {
+		return err
+	}
+	s.removeEmptyParentDirs(fromGitDir)
+	return nil
+}
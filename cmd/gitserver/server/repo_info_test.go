@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -83,3 +85,58 @@ func TestServer_handleRepoInfo(t *testing.T) {
 		}
 	})
 }
+
+func TestRenameRepo(t *testing.T) {
+	root, cleanup := tmpDir(t)
+	defer cleanup()
+
+	mkFiles(t, root,
+		"github.com/foo/old/.git/HEAD",
+		"github.com/foo/nested/.git/HEAD",
+		"github.com/foo/nested/child/.git/HEAD",
+		"github.com/bar/dupe/.git/HEAD",
+		"github.com/bar/dupe-new/.git/HEAD",
+	)
+	s := &Server{ReposDir: root, locker: &RepositoryLocker{}}
+
+	for _, r := range []protocol.RepoRenameRequest{
+		{From: "github.com/foo/old", To: "github.com/baz/new"},
+		// The directories of other repositories inside the repository's directory stay.
+		{From: "github.com/foo/nested", To: "github.com/foo/nested-new"},
+		// A repository that is already cloned under its new name is deleted.
+		{From: "github.com/bar/dupe", To: "github.com/bar/dupe-new"},
+		// A repository that is not cloned is ignored.
+		{From: "github.com/foo/missing", To: "github.com/foo/missing-new"},
+	} {
+		if err := s.renameRepo(r.From, r.To); err != nil {
+			t.Fatalf("failed to rename %s to %s: %s", r.From, r.To, err)
+		}
+	}
+
+	assertPaths(t, root,
+		"github.com/baz/new/.git/HEAD",
+		"github.com/foo/nested-new/.git/HEAD",
+		"github.com/foo/nested/child/.git/HEAD",
+		"github.com/bar/dupe-new/.git/HEAD",
+		".tmp",
+	)
+
+	// Renames and deletions can be retried.
+	if err := s.renameRepo("github.com/foo/old", "github.com/baz/new"); err != nil {
+		t.Errorf("failed to retry rename: %s", err)
+	}
+	if err := s.deleteRepo("github.com/foo/missing"); err != nil {
+		t.Errorf("failed to delete repository that is not cloned: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "github.com/baz/new/.git/HEAD")); err != nil {
+		t.Errorf("renamed clone is gone after retrying the rename: %s", err)
+	}
+
+	// A repository that is being cloned (under either name) can't be renamed.
+	mkFiles(t, root, "github.com/foo/cloning/.git/HEAD")
+	lock, _ := s.locker.TryAcquire(filepath.Join(root, "github.com/foo/cloning-new"), "cloning")
+	defer lock.Release()
+	if err := s.renameRepo("github.com/foo/cloning", "github.com/foo/cloning-new"); err == nil {
+		t.Error("got no error renaming a repository that is being cloned under its new name")
+	}
+}
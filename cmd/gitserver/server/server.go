@@ -112,6 +112,16 @@ type Server struct {
 	// Janitor job runs.
 	DeleteStaleRepositories bool
 
+	// Addr is the address of this gitserver, as it appears in the list of
+	// gitserver addresses. It is only needed when repositories are
+	// replicated.
+	Addr string
+
+	// ReplicaAddrs returns the addresses of the gitservers that repo is
+	// cloned on, starting with its primary gitserver. If it is nil,
+	// repositories are not replicated.
+	ReplicaAddrs func(ctx context.Context, repo api.RepoName) []string
+
 	// skipCloneForTests is set by tests to avoid clones.
 	skipCloneForTests bool
 
@@ -227,11 +237,13 @@ func (s *Server) Handler() http.Handler {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/exec", s.handleExec)
+	mux.HandleFunc("/batch-exec", s.handleBatchExec)
 	mux.HandleFunc("/list", s.handleList)
 	mux.HandleFunc("/is-repo-cloneable", s.handleIsRepoCloneable)
 	mux.HandleFunc("/is-repo-cloned", s.handleIsRepoCloned)
 	mux.HandleFunc("/repo", s.handleRepoInfo)
 	mux.HandleFunc("/delete", s.handleRepoDelete)
+	mux.HandleFunc("/rename", s.handleRepoRename)
 	mux.HandleFunc("/enqueue-repo-update", s.handleEnqueueRepoUpdate)
 	mux.HandleFunc("/repo-update", s.handleRepoUpdate)
 	mux.HandleFunc("/upload-pack", s.handleUploadPack)
@@ -389,6 +401,12 @@ func (s *Server) handleEnqueueRepoUpdate(w http.ResponseWriter, r *http.Request)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if req.CloneOptions != nil {
+		if err := validateCloneOptions(req.CloneOptions); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 	var resp protocol.RepoUpdateResponse
 	req.Repo = protocol.NormalizeRepo(req.Repo)
 	dir := path.Join(s.ReposDir, string(req.Repo))
@@ -401,12 +419,18 @@ func (s *Server) handleEnqueueRepoUpdate(w http.ResponseWriter, r *http.Request)
 			defer cancel1()
 			ctx, cancel2 := context.WithTimeout(ctx, longGitCommandTimeout)
 			defer cancel2()
-			_, err := s.cloneRepo(ctx, req.Repo, req.URL, nil)
+			_, err := s.cloneRepo(ctx, req.Repo, req.URL, &cloneOptions{Repo: req.CloneOptions})
 			if err != nil {
 				log15.Warn("error cloning repo", "repo", req.Repo, "err", err)
 			}
 		}()
 	} else {
+		if req.CloneOptions != nil {
+			if err := updateCloneOptions(dir, req.CloneOptions); err != nil {
+				log15.Warn("error updating clone options", "repo", req.Repo, "err", err)
+			}
+		}
+
 		// Check the repo status before enqueuing
 		var statusErr error
 		lastFetched, err := repoLastFetched(dir)
@@ -448,6 +472,12 @@ func (s *Server) handleRepoUpdate(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if req.CloneOptions != nil {
+		if err := validateCloneOptions(req.CloneOptions); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 	var resp protocol.RepoUpdateResponse
 	req.Repo = protocol.NormalizeRepo(req.Repo)
 	dir := path.Join(s.ReposDir, string(req.Repo))
@@ -463,7 +493,7 @@ func (s *Server) handleRepoUpdate(w http.ResponseWriter, r *http.Request) {
 		// optimistically, we assume that our cloning attempt might
 		// succeed.
 		resp.CloneInProgress = true
-		_, err := s.cloneRepo(ctx, req.Repo, req.URL, nil)
+		_, err := s.cloneRepo(ctx, req.Repo, req.URL, &cloneOptions{Repo: req.CloneOptions})
 		if err != nil {
 			log15.Warn("error cloning repo", "repo", req.Repo, "err", err)
 			resp.Error = err.Error()
@@ -472,6 +502,12 @@ func (s *Server) handleRepoUpdate(w http.ResponseWriter, r *http.Request) {
 		resp.Cloned = true
 		var statusErr, updateErr error
 
+		if req.CloneOptions != nil {
+			if err := updateCloneOptions(dir, req.CloneOptions); err != nil {
+				log15.Warn("error updating clone options", "repo", req.Repo, "err", err)
+			}
+		}
+
 		if debounce(req.Repo, req.Since) {
 			updateErr = s.doRepoUpdate(ctx, req.Repo, req.URL)
 		}
@@ -610,35 +646,7 @@ func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
 	}
 
 	dir := path.Join(s.ReposDir, string(req.Repo))
-	cloneProgress, cloneInProgress := s.locker.Status(dir)
-	if strings.ToLower(string(req.Repo)) == "github.com/sourcegraphtest/alwayscloningtest" {
-		cloneInProgress = true
-		cloneProgress = "This will never finish cloning"
-	}
-	if cloneInProgress {
-		status = "clone-in-progress"
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
-			CloneInProgress: true,
-			CloneProgress:   cloneProgress,
-		})
-		return
-	}
-	if !repoCloned(dir) {
-		cloneProgress, err := s.cloneRepo(ctx, req.Repo, req.URL, nil)
-		if err != nil {
-			log15.Debug("error cloning repo", "repo", req.Repo, "err", err)
-			status = "repo-not-found"
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
-			return
-		}
-		status = "clone-in-progress"
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
-			CloneInProgress: true,
-			CloneProgress:   cloneProgress,
-		})
+	if status = s.writeRepoNotCloned(ctx, w, req.Repo, req.URL, dir); status != "" {
 		return
 	}
 
@@ -698,6 +706,43 @@ func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Exec-Stderr", string(stderr))
 }
 
+// writeRepoNotCloned responds with a 404 and a protocol.NotFoundPayload if the
+// repository in dir is not cloned yet, starting to clone it if possible. It
+// returns the status of the request for instrumentation ("clone-in-progress"
+// or "repo-not-found"), or "" if the repository is cloned (in which case it
+// writes nothing).
+func (s *Server) writeRepoNotCloned(ctx context.Context, w http.ResponseWriter, repo api.RepoName, url, dir string) (status string) {
+	cloneProgress, cloneInProgress := s.locker.Status(dir)
+	if strings.ToLower(string(repo)) == "github.com/sourcegraphtest/alwayscloningtest" {
+		cloneInProgress = true
+		cloneProgress = "This will never finish cloning"
+	}
+	if cloneInProgress {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
+			CloneInProgress: true,
+			CloneProgress:   cloneProgress,
+		})
+		return "clone-in-progress"
+	}
+	if !repoCloned(dir) {
+		cloneProgress, err := s.cloneRepo(ctx, repo, url, nil)
+		if err != nil {
+			log15.Debug("error cloning repo", "repo", repo, "err", err)
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
+			return "repo-not-found"
+		}
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
+			CloneInProgress: true,
+			CloneProgress:   cloneProgress,
+		})
+		return "clone-in-progress"
+	}
+	return ""
+}
+
 // setGitAttributes writes our global gitattributes to
 // gitDir/info/attributes. This will override .gitattributes inside of
 // repositories. It is used to unset attributes such as export-ignore.
@@ -730,6 +775,10 @@ type cloneOptions struct {
 
 	// Overwrite will overwrite the existing clone.
 	Overwrite bool
+
+	// Repo specifies how to clone and fetch the repo. If it is nil, the repo
+	// is cloned in full.
+	Repo *protocol.CloneOptions
 }
 
 // cloneRepo issues a git clone command for the given repo. It is
@@ -807,7 +856,31 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 		defer os.RemoveAll(tmpPath)
 		tmpPath = filepath.Join(tmpPath, ".git")
 
-		cmd := exec.CommandContext(ctx, "git", "clone", "--mirror", "--progress", url, tmpPath)
+		var repoOpts *protocol.CloneOptions
+		if opts != nil && !isFullClone(opts.Repo) {
+			repoOpts = opts.Repo
+		}
+		var cmd *exec.Cmd
+		if isPerforceURL(url) {
+			cmd, err = perforceCloneCommand(ctx, url, tmpPath)
+			if err != nil {
+				return err
+			}
+		} else if repoOpts == nil {
+			cmd = exec.CommandContext(ctx, "git", "clone", "--mirror", "--progress", gitRemoteURL(url), tmpPath)
+		} else {
+			// git clone can't restrict the refs of a mirror, so fetch into an
+			// empty repo instead.
+			if err := initClone(ctx, tmpPath, gitRemoteURL(url), repoOpts); err != nil {
+				return errors.Wrap(err, "failed to initialize clone")
+			}
+			remote, err := fetchRemote(ctx, tmpPath, url, repoOpts)
+			if err != nil {
+				return err
+			}
+			cmd = exec.CommandContext(ctx, "git", append([]string{"fetch", "--progress", remote}, fetchRefspecs(repoOpts)...)...)
+			cmd.Dir = tmpPath
+		}
 		log15.Info("cloning repo", "repo", repo, "tmp", tmpPath, "dst", dstPath)
 
 		pr, pw := io.Pipe()
@@ -818,6 +891,17 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 			return errors.Wrapf(err, "clone failed. Output: %s", string(output))
 		}
 
+		if isPerforceURL(url) {
+			if err := setPerforceHEAD(ctx, tmpPath); err != nil {
+				return err
+			}
+		} else if repoOpts != nil {
+			// git clone --mirror sets HEAD, but git fetch doesn't.
+			if err := s.setHEAD(ctx, repo, tmpPath, url); err != nil {
+				return err
+			}
+		}
+
 		// Update the last-changed stamp.
 		if err := setLastChanged(tmpPath); err != nil {
 			return errors.Wrapf(err, "failed to update last changed time")
@@ -845,6 +929,7 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 
 		log15.Info("repo cloned", "repo", repo)
 		repoClonedCounter.Inc()
+		s.syncReplicasAsync(repo, url, repoOpts)
 
 		return nil
 	}
@@ -969,6 +1054,12 @@ func (s *Server) isCloneable(ctx context.Context, url string) error {
 	if testRepoExists != nil {
 		return testRepoExists(ctx, url)
 	}
+	if isPerforceURL(url) {
+		return isPerforceCloneable(ctx, url)
+	}
+	if isMercurialURL(url) {
+		return isMercurialCloneable(ctx, url)
+	}
 
 	cmd := exec.CommandContext(ctx, "git", args...)
 	out, err := s.runWithRemoteOpts(ctx, cmd, nil)
@@ -1060,13 +1151,9 @@ func (s *Server) repoUpdateLoop() chan<- updateRepoRequest {
 
 var headBranchPattern = regexp.MustCompile(`HEAD branch: (.+?)\n`)
 
-func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, url string) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "Server.doRepoUpdate")
-	span.SetTag("repo", repo)
-	span.SetTag("url", url)
-	defer span.Finish()
-
-	s.repoUpdateLocksMu.Lock()
+// repoUpdateLocksLocked returns the update locks of repo, creating them if
+// necessary. The caller must hold s.repoUpdateLocksMu.
+func (s *Server) repoUpdateLocksLocked(repo api.RepoName) *locks {
 	l, ok := s.repoUpdateLocks[repo]
 	if !ok {
 		l = &locks{
@@ -1075,6 +1162,17 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, url string
 		}
 		s.repoUpdateLocks[repo] = l
 	}
+	return l
+}
+
+func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, url string) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Server.doRepoUpdate")
+	span.SetTag("repo", repo)
+	span.SetTag("url", url)
+	defer span.Finish()
+
+	s.repoUpdateLocksMu.Lock()
+	l := s.repoUpdateLocksLocked(repo)
 	once := l.once
 	mu := l.mu
 	s.repoUpdateLocksMu.Unlock()
@@ -1271,11 +1369,12 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 	if !urlIsGitRemote {
 		// Note: We do not use CommandContext since it is a fast operation.
 		var cmd *exec.Cmd
+		remoteURL := gitRemoteURL(url)
 		if current, _ := repoRemoteURL(ctx, dir); current == "" {
-			cmd = exec.Command("git", "remote", "add", "origin", url)
-		} else if current != url {
+			cmd = exec.Command("git", "remote", "add", "origin", remoteURL)
+		} else if current != remoteURL {
 			log15.Debug("repository remote URL changed", "repo", repo)
-			cmd = exec.Command("git", "remote", "set-url", "origin", "--", url)
+			cmd = exec.Command("git", "remote", "set-url", "origin", "--", remoteURL)
 		}
 		if cmd != nil {
 			cmd.Dir = dir
@@ -1285,7 +1384,29 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 		}
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "fetch", "--prune", url, "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*", "+refs/pull/*:refs/pull/*")
+	if isPerforceURL(url) {
+		defer s.cleanTmpFiles(dir)
+		if err := s.syncPerforceDepot(ctx, dir, url); err != nil {
+			log15.Error("Failed to update", "repo", repo, "error", err)
+			return errors.Wrap(err, "failed to update")
+		}
+		if err := setLastChanged(dir); err != nil {
+			log15.Warn("Failed to update last changed time", "repo", repo, "error", err)
+		}
+		s.syncReplicasAsync(repo, url, nil)
+		return nil
+	}
+
+	opts, err := readCloneOptions(dir)
+	if err != nil {
+		log15.Warn("Failed to read clone options, fetching all refs", "repo", repo, "error", err)
+	}
+	remote, err := fetchRemote(ctx, dir, url, opts)
+	if err != nil {
+		log15.Error("Failed to update", "repo", repo, "error", err)
+		return errors.Wrap(err, "failed to update")
+	}
+	cmd := exec.CommandContext(ctx, "git", append([]string{"fetch", "--prune", remote}, fetchRefspecs(opts)...)...)
 	cmd.Dir = dir
 
 	// drop temporary pack files after a fetch. this function won't
@@ -1304,11 +1425,25 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 		log15.Warn("Failed to update last changed time", "repo", repo, "error", err)
 	}
 
+	if err := s.setHEAD(ctx, repo, dir, url); err != nil {
+		return err
+	}
+	s.syncReplicasAsync(repo, url, opts)
+	return nil
+}
+
+// setHEAD sets HEAD of the repo at dir to the default branch of the remote
+// repository, or if that doesn't exist to the first branch.
+func (s *Server) setHEAD(ctx context.Context, repo api.RepoName, dir, url string) error {
 	headBranch := "master"
 
 	// try to fetch HEAD from origin
-	cmd = exec.CommandContext(ctx, "git", "remote", "show", url)
-	cmd.Dir = path.Join(s.ReposDir, string(repo))
+	remote := url
+	if isMercurialURL(url) {
+		remote = "origin"
+	}
+	cmd := exec.CommandContext(ctx, "git", "remote", "show", remote)
+	cmd.Dir = dir
 	output, err := s.runWithRemoteOpts(ctx, cmd, nil)
 	if err != nil {
 		log15.Error("Failed to fetch remote info", "repo", repo, "error", err, "output", string(output))
@@ -1324,11 +1459,11 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 
 	// check if branch pointed to by HEAD exists
 	cmd = exec.CommandContext(ctx, "git", "rev-parse", headBranch, "--")
-	cmd.Dir = path.Join(s.ReposDir, string(repo))
+	cmd.Dir = dir
 	if err := cmd.Run(); err != nil {
 		// branch does not exist, pick first branch
 		cmd := exec.CommandContext(ctx, "git", "branch")
-		cmd.Dir = path.Join(s.ReposDir, string(repo))
+		cmd.Dir = dir
 		list, err := cmd.Output()
 		if err != nil {
 			log15.Error("Failed to list branches", "repo", repo, "error", err, "output", string(output))
@@ -1343,7 +1478,7 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 
 	// set HEAD
 	cmd = exec.CommandContext(ctx, "git", "symbolic-ref", "HEAD", "refs/heads/"+headBranch)
-	cmd.Dir = path.Join(s.ReposDir, string(repo))
+	cmd.Dir = dir
 	if output, err := cmd.CombinedOutput(); err != nil {
 		log15.Error("Failed to set HEAD", "repo", repo, "error", err, "output", string(output))
 		return errors.Wrap(err, "Failed to set HEAD")
@@ -1367,9 +1502,44 @@ func (s *Server) ensureRevision(ctx context.Context, repo api.RepoName, url, rev
 	}
 	// Revision not found, update before returning.
 	s.doRepoUpdate(ctx, repo, url)
+	if commit := strings.TrimSuffix(rev, "^0"); commit != rev {
+		s.maybeFetchCommit(ctx, repo, url, commit, repoDir)
+	}
 	return true
 }
 
+// maybeFetchCommit fetches commit if it is still missing from a repo that
+// was cloned with clone options. Those repos may not fetch the refs that
+// the commit is on.
+func (s *Server) maybeFetchCommit(ctx context.Context, repo api.RepoName, url, commit, dir string) {
+	opts, err := readCloneOptions(dir)
+	if err != nil || opts == nil {
+		return
+	}
+	cmd := exec.Command("git", "rev-parse", commit+"^0", "--")
+	cmd.Dir = dir
+	if err := cmd.Run(); err == nil {
+		return
+	}
+
+	if url == "" {
+		if url, err = repoRemoteURL(ctx, dir); err != nil {
+			log15.Warn("Failed to determine Git remote URL", "repo", repo, "error", err)
+			return
+		}
+	}
+	remote, err := fetchRemote(ctx, dir, url, opts)
+	if err != nil {
+		log15.Warn("Failed to fetch commit", "repo", repo, "commit", commit, "error", err)
+		return
+	}
+	cmd = exec.CommandContext(ctx, "git", "fetch", remote, commit)
+	cmd.Dir = dir
+	if output, err := s.runWithRemoteOpts(ctx, cmd, nil); err != nil {
+		log15.Warn("Failed to fetch commit", "repo", repo, "commit", commit, "error", err, "output", string(output))
+	}
+}
+
 // quickRevParseHead best-effort mimics the execution of `git rev-parse HEAD`, but doesn't exec a child process.
 // It just reads the relevant files from the bare git repository directory.
 func quickRevParseHead(dir string) (string, error) {
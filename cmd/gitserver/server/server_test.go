@@ -11,11 +11,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/pkg/mutablelimiter"
 )
 
@@ -388,3 +390,111 @@ func TestCloneRepo(t *testing.T) {
 		t.Fatal("failed to clone")
 	}
 }
+
+func TestCloneRepo_cloneOptions(t *testing.T) {
+	remote, cleanup1 := tmpDir(t)
+	defer cleanup1()
+
+	repo := remote
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		c := exec.Command(name, arg...)
+		c.Dir = repo
+		c.Env = []string{
+			"GIT_COMMITTER_NAME=a",
+			"GIT_COMMITTER_EMAIL=a@a.com",
+			"GIT_AUTHOR_NAME=a",
+			"GIT_AUTHOR_EMAIL=a@a.com",
+		}
+		b, err := c.Output()
+		if err != nil {
+			t.Fatalf("%s %s failed: %s", name, strings.Join(arg, " "), err)
+		}
+		return string(b)
+	}
+
+	// Setup a repo with branches and a pull request ref.
+	cmd("git", "init", ".")
+	cmd("git", "config", "uploadpack.allowFilter", "true")
+	cmd("sh", "-c", "echo hello world > hello.txt")
+	cmd("git", "add", "hello.txt")
+	cmd("git", "commit", "-m", "hello")
+	cmd("git", "branch", "archive/old")
+	cmd("git", "update-ref", "refs/pull/1/head", "HEAD")
+	wantCommit := cmd("git", "rev-parse", "HEAD")
+	wantRefs := cmd("git", "symbolic-ref", "HEAD")
+
+	reposDir, cleanup2 := tmpDir(t)
+	defer cleanup2()
+
+	s := &Server{
+		ReposDir:         reposDir,
+		ctx:              context.Background(),
+		locker:           &RepositoryLocker{},
+		cloneLimiter:     mutablelimiter.New(1),
+		cloneableLimiter: mutablelimiter.New(1),
+	}
+	opts := &protocol.CloneOptions{
+		Filter:      "blob:none",
+		Refs:        []string{"refs/heads/*"},
+		ExcludeRefs: []string{"refs/heads/archive/*"},
+	}
+	_, err := s.cloneRepo(context.Background(), "example.com/foo/bar", "file://"+remote, &cloneOptions{Block: true, Repo: opts})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(s.ReposDir, "example.com/foo/bar")
+	repo = dst
+	if gotCommit := cmd("git", "rev-parse", "HEAD"); gotCommit != wantCommit {
+		t.Fatalf("got HEAD %s, want %s", gotCommit, wantCommit)
+	}
+	if refs := cmd("git", "for-each-ref", "--format=%(refname)"); refs != wantRefs {
+		t.Errorf("got refs %q, want %q", refs, wantRefs)
+	}
+	// The blob is fetched lazily.
+	if got := cmd("git", "show", "HEAD:hello.txt"); got != "hello world\n" {
+		t.Errorf("got hello.txt %q", got)
+	}
+
+	gotOpts, err := readCloneOptions(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotOpts, opts) {
+		t.Errorf("got clone options %+v, want %+v", gotOpts, opts)
+	}
+}
+
+func TestParseGitVersion(t *testing.T) {
+	for out, want := range map[string][2]int{
+		"git version 2.29.2\n":                 {2, 29},
+		"git version 2.17.1.windows.2\n":       {2, 17},
+		"git version 2.20.1 (Apple Git-117)\n": {2, 20},
+		"git version 10.0\n":                   {10, 0},
+	} {
+		major, minor, ok := parseGitVersion(out)
+		if !ok || major != want[0] || minor != want[1] {
+			t.Errorf("parseGitVersion(%q) = %d, %d, %v, want %d, %d", out, major, minor, ok, want[0], want[1])
+		}
+	}
+	if _, _, ok := parseGitVersion("not git"); ok {
+		t.Error("expected unparsable version")
+	}
+}
+
+func TestValidateCloneOptions(t *testing.T) {
+	for _, opts := range []*protocol.CloneOptions{
+		{Filter: "--upload-pack=evil"},
+		{Refs: []string{"--upload-pack=evil"}},
+		{Refs: []string{"refs/heads/*:refs/remotes/*"}},
+		{ExcludeRefs: []string{"^refs/heads/*"}},
+	} {
+		if err := validateCloneOptions(opts); err == nil {
+			t.Errorf("expected error for %+v", opts)
+		}
+	}
+	if err := validateCloneOptions(&protocol.CloneOptions{Filter: "blob:limit=1m", Refs: []string{"refs/heads/*"}}); err != nil {
+		t.Error(err)
+	}
+}
@@ -31,6 +31,10 @@ const gqlSearchQuery = `query Search(
 			limitHit
 			cloning { name }
 			timedout { name }
+			aggregates {
+				value
+				count
+			}
 			results {
 				__typename
 				... on FileMatch {
@@ -115,6 +119,7 @@ type gqlSearchResponse struct {
 				ApproximateResultCount string
 				Cloning                []*api.Repo
 				Timedout               []*api.Repo
+				Aggregates             []*gqlSearchAggregate
 				Results                []interface{}
 			}
 		}
@@ -122,6 +127,13 @@ type gqlSearchResponse struct {
 	Errors []interface{}
 }
 
+// gqlSearchAggregate is a group of search results, for saved queries with a
+// countby: keyword.
+type gqlSearchAggregate struct {
+	Value string
+	Count int
+}
+
 func search(ctx context.Context, query string) (*gqlSearchResponse, error) {
 	var buf bytes.Buffer
 	err := json.NewEncoder(&buf).Encode(graphQLQuery{
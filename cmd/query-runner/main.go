@@ -310,6 +310,20 @@ const (
 	utmSourceSlack = "saved-search-slack"
 )
 
+// maxNotifyAggregates is the maximum number of aggregates (for saved queries
+// with a countby: keyword) included in a notification.
+const maxNotifyAggregates = 10
+
+// aggregates returns the aggregates to include in the notification, with the
+// largest counts first.
+func (n *notifier) aggregates() []*gqlSearchAggregate {
+	aggregates := n.results.Data.Search.Results.Aggregates
+	if len(aggregates) > maxNotifyAggregates {
+		aggregates = aggregates[:maxNotifyAggregates]
+	}
+	return aggregates
+}
+
 func searchURL(query, utmSource string) string {
 	if externalURL == nil {
 		// Determine the external URL.
@@ -22,6 +22,9 @@ func (n *notifier) slackNotify(ctx context.Context) {
 		searchURL(n.newQuery, utmSourceSlack),
 		n.query.Description,
 	)
+	for _, a := range n.aggregates() {
+		text += fmt.Sprintf("\n• `%s`: %d", a.Value, a.Count)
+	}
 	for _, recipient := range n.recipients {
 		if err := slackNotify(ctx, recipient, text); err != nil {
 			log15.Error("Failed to post Slack notification message.", "recipient", recipient, "text", text, "error", err)
@@ -36,12 +36,16 @@ func main() {
 		Name: "Repo Updater State",
 		Path: "/repo-updater-state",
 		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var snapshot interface{}
 			if conf.UpdateScheduler2Enabled() {
-				http.Error(w, "The new update scheduler exposes information in the mirroring tab of the repository settings page.", http.StatusBadRequest)
-				return
+				status := repos.Scheduler.Status()
+				repos.Syncer.AddToStatus(status)
+				snapshot = status
+			} else {
+				snapshot = repos.QueueSnapshot()
 			}
 
-			d, err := json.MarshalIndent(repos.QueueSnapshot(), "", "  ")
+			d, err := json.MarshalIndent(snapshot, "", "  ")
 			if err != nil {
 				http.Error(w, "failed to marshal snapshot: "+err.Error(), http.StatusInternalServerError)
 				return
@@ -78,6 +82,9 @@ func main() {
 	// GitLab Repository syncing thread
 	go repos.RunGitLabRepositorySyncWorker(ctx)
 
+	// Gitea Repository syncing thread
+	go repos.RunGiteaRepositorySyncWorker(ctx)
+
 	// AWS CodeCommit repository syncing thread
 	go repos.RunAWSCodeCommitRepositorySyncWorker(ctx)
 
@@ -90,5 +97,8 @@ func main() {
 	// Bitbucket Server syncing thread
 	go repos.RunBitbucketServerRepositorySyncWorker(ctx)
 
+	// Perforce syncing thread
+	go repos.RunPerforceRepositorySyncWorker(ctx)
+
 	select {}
 }
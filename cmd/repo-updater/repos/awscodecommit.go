@@ -68,27 +68,8 @@ func GetAWSCodeCommitRepository(ctx context.Context, args protocol.RepoLookupArg
 			var serviceID string
 			serviceID, err = conn.getServiceID()
 			if serviceID != "" && args.ExternalRepo.ServiceID == serviceID {
-				ccrepo, err := conn.client.GetRepository(ctx, args.ExternalRepo.ID)
-				if ccrepo != nil {
-					remoteURL, err := conn.authenticatedRemoteURL(ccrepo)
-					if err != nil {
-						return nil, true, errors.Wrap(err, "authenticatedRemoteURL")
-					}
-					webURL := fmt.Sprintf("https://%s.console.aws.amazon.com/codecommit/home#/repository/%s", conn.awsRegion.ID(), ccrepo.Name)
-					repo = &protocol.RepoInfo{
-						Name:         awsCodeCommitRepositoryToRepoPath(conn, ccrepo),
-						ExternalRepo: awscodecommit.ExternalRepoSpec(ccrepo, serviceID),
-						Description:  ccrepo.Description,
-						VCS:          protocol.VCSInfo{URL: remoteURL},
-						Links: &protocol.RepoLinks{
-							Root:   webURL,
-							Tree:   webURL + "/browse/{rev}/--/{path}",
-							Blob:   webURL + "/browse/{rev}/--/{path}",
-							Commit: webURL + "/commit/{commit}",
-						},
-					}
-				}
-				return repo, true, errors.Wrap(err, "GetRepository")
+				repo, err := conn.GetRepo(ctx, args)
+				return repo, true, err
 			}
 		}
 		return nil, true, errors.Wrap(err, "getServiceID")
@@ -100,45 +81,50 @@ func GetAWSCodeCommitRepository(ctx context.Context, args protocol.RepoLookupArg
 	return nil, false, nil
 }
 
-var awsCodeCommitRepositorySyncWorker = &worker{
-	work: func(ctx context.Context, shutdown chan struct{}) {
-		awsCodeCommitConnections := awsCodeCommitConnections.Get().([]*awsCodeCommitConnection)
-		if len(awsCodeCommitConnections) == 0 {
-			return
-		}
-		for _, c := range awsCodeCommitConnections {
-			go func(c *awsCodeCommitConnection) {
-				// Hit the AWS API to determine our account ID (which is a fixed value but not derivable
-				// from the values in the Sourcegraph site config). Be robust to the API being
-				// unreachable when we start up.
-				const retryInterval = 20 * time.Second
-				for {
-					_, err := c.tryPopulateAWSAccountID()
-					if err == nil {
-						break
-					}
-					log15.Error("Unable to reach AWS CodeCommit API to determine AWS account ID.", "region", c.config.Region, "error", err, "retryInterval", retryInterval)
-					select {
-					case <-shutdown:
-						return
-					case <-time.After(retryInterval):
-					}
-				}
-
-				for {
-					updateAWSCodeCommitRepositories(ctx, c)
-					awsCodeCommitUpdateTime.WithLabelValues(c.awsAccountID).Set(float64(time.Now().Unix()))
-					select {
-					case <-shutdown:
-						return
-					case <-time.After(getUpdateInterval()):
-					}
-				}
-			}(c)
-		}
-	},
+// GetRepo implements Source. Repositories can only be looked up by their external repository spec.
+func (c *awsCodeCommitConnection) GetRepo(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.RepoInfo, error) {
+	if args.ExternalRepo == nil || args.ExternalRepo.ServiceType != awscodecommit.ServiceType {
+		return nil, fmt.Errorf("unable to look up AWS CodeCommit repository (%+v)", args)
+	}
+	serviceID, err := c.getServiceID()
+	if err != nil {
+		return nil, errors.Wrap(err, "getServiceID")
+	}
+	ccrepo, err := c.client.GetRepository(ctx, args.ExternalRepo.ID)
+	if err != nil {
+		return nil, err
+	}
+	if c.exclude.excludes(ccrepo.Name, false, false) {
+		return nil, errExcluded(awsCodeCommitRepositoryToRepoPath(c, ccrepo))
+	}
+	remoteURL, err := c.authenticatedRemoteURL(ccrepo)
+	if err != nil {
+		return nil, errors.Wrap(err, "authenticatedRemoteURL")
+	}
+	webURL := fmt.Sprintf("https://%s.console.aws.amazon.com/codecommit/home#/repository/%s", c.awsRegion.ID(), ccrepo.Name)
+	return &protocol.RepoInfo{
+		Name:         awsCodeCommitRepositoryToRepoPath(c, ccrepo),
+		ExternalRepo: awscodecommit.ExternalRepoSpec(ccrepo, serviceID),
+		Description:  ccrepo.Description,
+		VCS:          protocol.VCSInfo{URL: remoteURL},
+		Links: &protocol.RepoLinks{
+			Root:   webURL,
+			Tree:   webURL + "/browse/{rev}/--/{path}",
+			Blob:   webURL + "/browse/{rev}/--/{path}",
+			Commit: webURL + "/commit/{commit}",
+		},
+	}, nil
 }
 
+var awsCodeCommitRepositorySyncWorker = newSyncWorker(func() []Source {
+	conns := awsCodeCommitConnections.Get().([]*awsCodeCommitConnection)
+	sources := make([]Source, len(conns))
+	for i, c := range conns {
+		sources[i] = c
+	}
+	return sources
+})
+
 // RunAWSCodeCommitRepositorySyncWorker runs the worker that syncs repositories from the configured AWSCodeCommit and AWSCodeCommit
 // Enterprise instances to Sourcegraph.
 func RunAWSCodeCommitRepositorySyncWorker(ctx context.Context) {
@@ -149,30 +135,42 @@ func awsCodeCommitRepositoryToRepoPath(conn *awsCodeCommitConnection, repo *awsc
 	return reposource.AWSRepoName(conn.config.RepositoryPathPattern, repo.Name)
 }
 
-// updateAWSCodeCommitRepositories ensures that all provided repositories have been added and updated on Sourcegraph.
-func updateAWSCodeCommitRepositories(ctx context.Context, conn *awsCodeCommitConnection) {
-	repos := conn.listAllRepositories(ctx)
+// Name implements Source.
+func (c *awsCodeCommitConnection) Name() string {
+	return fmt.Sprintf("aws:%s", c.config.AccessKeyID)
+}
+
+// ListRepos implements Source.
+func (c *awsCodeCommitConnection) ListRepos(ctx context.Context) ([]repoCreateOrUpdateRequest, error) {
+	// Hit the AWS API to determine our account ID (which is a fixed value but not derivable from
+	// the values in the Sourcegraph site config). If the API is unreachable, the next sync tries
+	// again.
+	if _, err := c.tryPopulateAWSAccountID(); err != nil {
+		return nil, errors.Wrap(err, "unable to reach AWS CodeCommit API to determine AWS account ID")
+	}
 
-	repoChan := make(chan repoCreateOrUpdateRequest)
-	defer close(repoChan)
-	go createEnableUpdateRepos(ctx, fmt.Sprintf("aws:%s", conn.config.AccessKeyID), repoChan)
-	for repo := range repos {
-		// log15.Debug("awscodecommit sync: create/enable/update repo", "repo", repo.Name)
-		remoteURL, err := conn.authenticatedRemoteURL(repo)
+	var errs listErrors
+	var repos []repoCreateOrUpdateRequest
+	for repo := range c.listAllRepositories(ctx, &errs) {
+		if c.exclude.excludes(repo.Name, false, false) {
+			continue
+		}
+		remoteURL, err := c.authenticatedRemoteURL(repo)
 		if err != nil {
-			log15.Error("Error generating remote URL for AWS CodeCommit repository. Skipping.", "repo", repo.ARN, "error", err)
+			errs.add(errors.Wrapf(err, "generating remote URL for AWS CodeCommit repository %s", repo.ARN))
 			continue
 		}
-		repoChan <- repoCreateOrUpdateRequest{
+		repos = append(repos, repoCreateOrUpdateRequest{
 			RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
-				RepoName:     awsCodeCommitRepositoryToRepoPath(conn, repo),
-				ExternalRepo: awscodecommit.ExternalRepoSpec(repo, awscodecommit.ServiceID(conn.awsPartition, conn.awsRegion, repo.AccountID)),
+				RepoName:     awsCodeCommitRepositoryToRepoPath(c, repo),
+				ExternalRepo: awscodecommit.ExternalRepoSpec(repo, awscodecommit.ServiceID(c.awsPartition, c.awsRegion, repo.AccountID)),
 				Description:  repo.Description,
-				Enabled:      conn.config.InitialRepositoryEnablement,
+				Enabled:      c.config.InitialRepositoryEnablement,
 			},
 			URL: remoteURL,
-		}
+		})
 	}
+	return repos, errs.errorOrNil()
 }
 
 func newAWSCodeCommitConnection(config *schema.AWSCodeCommitConnection) (*awsCodeCommitConnection, error) {
@@ -185,9 +183,14 @@ func newAWSCodeCommitConnection(config *schema.AWSCodeCommitConnection) (*awsCod
 			Source:          "sourcegraph-site-configuration",
 		},
 	}
+	exclude, err := newExcludeList(config.Exclude)
+	if err != nil {
+		return nil, err
+	}
 	conn := &awsCodeCommitConnection{
 		config:    config,
 		awsConfig: awsConfig,
+		exclude:   exclude,
 	}
 	conn.client = awscodecommit.NewClient(conn.awsConfig)
 
@@ -209,6 +212,7 @@ type awsCodeCommitConnection struct {
 	awsPartition endpoints.Partition // "aws", "aws-cn", "aws-us-gov"
 	awsRegion    endpoints.Region
 	client       *awscodecommit.Client
+	exclude      excludeList
 
 	mu           sync.Mutex
 	awsAccountID string
@@ -307,7 +311,9 @@ func makeSHA256(data []byte) []byte {
 	return hash.Sum(nil)
 }
 
-func (c *awsCodeCommitConnection) listAllRepositories(ctx context.Context) <-chan *awscodecommit.Repository {
+// listAllRepositories lists the repositories of the connection. Errors are added to errs, and the
+// repositories that could be listed are still sent.
+func (c *awsCodeCommitConnection) listAllRepositories(ctx context.Context, errs *listErrors) <-chan *awscodecommit.Repository {
 	// The document limit per page is here:
 	// https://docs.aws.amazon.com/AWSJavaSDK/latest/javadoc/com/amazonaws/services/codecommit/model/MaximumRepositoryNamesExceededException.html
 	const maxItems = 25
@@ -318,7 +324,7 @@ func (c *awsCodeCommitConnection) listAllRepositories(ctx context.Context) <-cha
 		for {
 			repos, token, err := c.client.ListRepositories(ctx, maxItems, nextToken)
 			if err != nil {
-				log15.Error("Error listing AWS CodeCommit repositories", "error", err)
+				errs.add(errors.Wrap(err, "listing AWS CodeCommit repositories"))
 				return
 			}
 			for _, r := range repos {
@@ -16,7 +16,6 @@ import (
 	"github.com/sourcegraph/sourcegraph/pkg/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
 	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
-	"github.com/sourcegraph/sourcegraph/pkg/vcs"
 	"github.com/sourcegraph/sourcegraph/schema"
 	"golang.org/x/time/rate"
 	log15 "gopkg.in/inconshreveable/log15.v2"
@@ -153,22 +152,27 @@ func GetBitbucketServerRepository(ctx context.Context, args protocol.RepoLookupA
 	if conn == nil {
 		return nil, false, nil // refers to a non-BitbucketServer repo
 	}
+	repo, err = conn.GetRepo(ctx, args)
+	return repo, true, err
+}
 
+// GetRepo implements Source.
+func (c *bitbucketServerConnection) GetRepo(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.RepoInfo, error) {
 	if args.ExternalRepo != nil && args.ExternalRepo.ServiceType == bitbucketserver.ServiceType {
 		// Look up by external repository spec. Expect {projectKey}/{repoSlug}
 		i := strings.Index(args.ExternalRepo.ID, "/")
 		if i < 0 || i == len(args.ExternalRepo.ID)-1 {
-			return nil, true, errors.Errorf("malformed bitbucket server ID: %q", args.ExternalRepo.ID)
+			return nil, errors.Errorf("malformed bitbucket server ID: %q", args.ExternalRepo.ID)
 		}
 		projectKey, repoSlug := args.ExternalRepo.ID[:i], args.ExternalRepo.ID[i+1:]
-		repo, err := conn.client.Repo(ctx, projectKey, repoSlug)
+		repo, err := c.client.Repo(ctx, projectKey, repoSlug)
 		if err != nil {
-			return nil, true, err
+			return nil, err
 		}
-		if conn.config.ExcludePersonalRepositories && repo.IsPersonalRepository() {
-			return nil, true, &vcs.RepoNotExistError{Repo: api.RepoName(repoSlug)}
+		if c.excludes(repo) {
+			return nil, errExcluded(api.RepoName(repoSlug))
 		}
-		return bitbucketServerRepoInfo(conn.config, repo), true, nil
+		return bitbucketServerRepoInfo(c.config, repo), nil
 	}
 
 	if args.Repo != "" {
@@ -176,53 +180,30 @@ func GetBitbucketServerRepository(ctx context.Context, args protocol.RepoLookupA
 		// TODO shouldn't we use RepositoryPathPattern?
 		match := bitbucketServerRepoInfoSuffix.FindStringSubmatch(string(args.Repo))
 		if len(match) == 0 {
-			return nil, true, errors.Errorf("malformed bitbucket server repo URL: %q", args.Repo)
+			return nil, errors.Errorf("malformed bitbucket server repo URL: %q", args.Repo)
 		}
 		projectKey, repoSlug := match[0], match[1]
-		repo, err := conn.client.Repo(ctx, projectKey, repoSlug)
+		repo, err := c.client.Repo(ctx, projectKey, repoSlug)
 		if err != nil {
-			return nil, true, err
+			return nil, err
 		}
-		if conn.config.ExcludePersonalRepositories && repo.IsPersonalRepository() {
-			return nil, true, &vcs.RepoNotExistError{Repo: args.Repo}
+		if c.excludes(repo) {
+			return nil, errExcluded(args.Repo)
 		}
-		return bitbucketServerRepoInfo(conn.config, repo), true, nil
+		return bitbucketServerRepoInfo(c.config, repo), nil
 	}
 
-	return nil, true, fmt.Errorf("unable to look up Bitbucket Server repository (%+v)", args)
+	return nil, fmt.Errorf("unable to look up Bitbucket Server repository (%+v)", args)
 }
 
-var bitbucketServerWorker = &worker{
-	work: func(ctx context.Context, shutdown chan struct{}) {
-		for _, c := range bitbucketServerConnections.Get().([]*bitbucketServerConnection) {
-			go func(c *bitbucketServerConnection) {
-				for {
-					reservationTime := time.Now()
-					r := c.client.RateLimit.ReserveN(reservationTime, rateLimitReservationSize)
-					if !r.OK() {
-						log15.Error("Bitbucket worker cannot reserve requests. Is the maximum burst size lower than the reservation size?", "reservation_size", rateLimitReservationSize, "max_burst_size", rateLimitMaxBurstRequests)
-					}
-					delay := r.Delay()
-					// Since we're not actually planning to use the reservation, cancel it now.
-					// We only wanted to know the delay / availability of the reservation.
-					r.CancelAt(reservationTime)
-					if delay > time.Second {
-						log15.Warn("Bitbucket self-enforced API rate limit is almost exhausted. Waiting before doing more work", "delay", r.Delay())
-					}
-					time.Sleep(delay)
-
-					updateBitbucketServerRepos(ctx, c)
-					bitbucketServerUpdateTime.WithLabelValues(c.config.Url).Set(float64(time.Now().Unix()))
-					select {
-					case <-shutdown:
-						return
-					case <-time.After(getUpdateInterval()):
-					}
-				}
-			}(c)
-		}
-	},
-}
+var bitbucketServerWorker = newSyncWorker(func() []Source {
+	conns := bitbucketServerConnections.Get().([]*bitbucketServerConnection)
+	sources := make([]Source, len(conns))
+	for i, c := range conns {
+		sources[i] = c
+	}
+	return sources
+})
 
 // RunBitbucketServerRepositorySyncWorker runs the worker that syncs projects from configured BitbucketServer instances to
 // Sourcegraph.
@@ -230,36 +211,56 @@ func RunBitbucketServerRepositorySyncWorker(ctx context.Context) {
 	bitbucketServerWorker.start(ctx)
 }
 
-// updateBitbucketServerRepos ensures that all provided repositories exist in the repository table.
-func updateBitbucketServerRepos(ctx context.Context, conn *bitbucketServerConnection) {
-	repoChan := make(chan repoCreateOrUpdateRequest)
-	defer close(repoChan)
-	sourceID := conn.config.Token
+// Name implements Source.
+func (c *bitbucketServerConnection) Name() string {
+	sourceID := c.config.Token
 	if sourceID == "" {
-		sourceID = conn.config.Username
+		sourceID = c.config.Username
 	}
-	go createEnableUpdateRepos(ctx, fmt.Sprintf("bitbucket:%s", sourceID), repoChan)
-	for r := range conn.listAllRepos(ctx) {
-		if r.State != "AVAILABLE" {
+	return connectionSource("bitbucket", c.config.Url, sourceID)
+}
+
+// ListRepos implements Source.
+func (c *bitbucketServerConnection) ListRepos(ctx context.Context) ([]repoCreateOrUpdateRequest, error) {
+	reservationTime := time.Now()
+	r := c.client.RateLimit.ReserveN(reservationTime, rateLimitReservationSize)
+	if !r.OK() {
+		log15.Error("Bitbucket worker cannot reserve requests. Is the maximum burst size lower than the reservation size?", "reservation_size", rateLimitReservationSize, "max_burst_size", rateLimitMaxBurstRequests)
+	}
+	delay := r.Delay()
+	// Since we're not actually planning to use the reservation, cancel it now.
+	// We only wanted to know the delay / availability of the reservation.
+	r.CancelAt(reservationTime)
+	if delay > time.Second {
+		log15.Warn("Bitbucket self-enforced API rate limit is almost exhausted. Waiting before doing more work", "delay", r.Delay())
+	}
+	time.Sleep(delay)
+
+	var errs listErrors
+	var repos []repoCreateOrUpdateRequest
+	for repo := range c.listAllRepos(ctx, &errs) {
+		if repo.State != "AVAILABLE" {
 			continue
 		}
 
-		ri := bitbucketServerRepoInfo(conn.config, r)
+		ri := bitbucketServerRepoInfo(c.config, repo)
 		if ri.VCS.URL == "" {
 			continue
 		}
 
-		repoChan <- repoCreateOrUpdateRequest{
+		repos = append(repos, repoCreateOrUpdateRequest{
 			RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
 				RepoName:     ri.Name,
 				ExternalRepo: ri.ExternalRepo,
 				Description:  ri.Description,
 				Fork:         ri.Fork,
-				Enabled:      conn.config.InitialRepositoryEnablement,
+				Enabled:      c.config.InitialRepositoryEnablement,
 			},
-			URL: ri.VCS.URL,
-		}
+			URL:          ri.VCS.URL,
+			CloneOptions: c.cloneOptions.get(ri.Name),
+		})
 	}
+	return repos, errs.errorOrNil()
 }
 
 // These fields define the self-imposed Bitbucket rate limit (since Bitbucket Server does
@@ -300,6 +301,15 @@ func newBitbucketServerConnection(config *schema.BitbucketServerConnection) (*bi
 		return nil, err
 	}
 
+	exclude, err := newExcludeList(config.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	cloneOptions, err := newCloneOptionsList(config.GitCloneOptions)
+	if err != nil {
+		return nil, err
+	}
+
 	return &bitbucketServerConnection{
 		config: config,
 		client: &bitbucketserver.Client{
@@ -312,15 +322,35 @@ func newBitbucketServerConnection(config *schema.BitbucketServerConnection) (*bi
 			},
 			RateLimit: rate.NewLimiter(rateLimitRequestsPerSecond, rateLimitMaxBurstRequests),
 		},
+		exclude:      exclude,
+		cloneOptions: cloneOptions,
 	}, nil
 }
 
 type bitbucketServerConnection struct {
-	config *schema.BitbucketServerConnection
-	client *bitbucketserver.Client
+	config       *schema.BitbucketServerConnection
+	client       *bitbucketserver.Client
+	exclude      excludeList
+	cloneOptions cloneOptionsList
+}
+
+// excludes reports whether the repository is excluded by the connection's exclude list or, if
+// excludePersonalRepositories is set, because it is a personal repository. Bitbucket Server
+// repositories can't be archived.
+func (c *bitbucketServerConnection) excludes(repo *bitbucketserver.Repo) bool {
+	if c.config.ExcludePersonalRepositories && repo.IsPersonalRepository() {
+		return true
+	}
+	name := repo.Slug
+	if repo.Project != nil {
+		name = repo.Project.Key + "/" + repo.Slug
+	}
+	return c.exclude.excludes(name, repo.Origin != nil, false)
 }
 
-func (c *bitbucketServerConnection) listAllRepos(ctx context.Context) <-chan *bitbucketserver.Repo {
+// listAllRepos lists the repositories of the connection. Errors are added to errs, and the
+// repositories that could be listed are still sent.
+func (c *bitbucketServerConnection) listAllRepos(ctx context.Context, errs *listErrors) <-chan *bitbucketserver.Repo {
 	perPage := 100
 	ch := make(chan *bitbucketserver.Repo, perPage)
 	go func() {
@@ -329,11 +359,11 @@ func (c *bitbucketServerConnection) listAllRepos(ctx context.Context) <-chan *bi
 		// First we list one page of recent repos, so that we clone them first
 		repos, _, err := c.client.RecentRepos(ctx, &bitbucketserver.PageToken{Limit: perPage})
 		if err != nil {
-			log15.Warn("failed to list recent repos for Bitbucket Server", "url", c.client.URL, "error", err)
+			errs.add(errors.Wrap(err, "listing recent Bitbucket Server repositories"))
 		}
 		recent := map[int]bool{}
 		for _, r := range repos {
-			if c.config.ExcludePersonalRepositories && r.IsPersonalRepository() {
+			if c.excludes(r) {
 				continue
 			}
 			recent[r.ID] = true
@@ -346,11 +376,11 @@ func (c *bitbucketServerConnection) listAllRepos(ctx context.Context) <-chan *bi
 		for page.HasMore() {
 			repos, page, err = c.client.Repos(ctx, page)
 			if err != nil {
-				log15.Error("failed when listing Bitbucket Server repos", "url", c.client.URL, "error", err)
+				errs.add(errors.Wrap(err, "listing Bitbucket Server repositories"))
 				return
 			}
 			for _, r := range repos {
-				if c.config.ExcludePersonalRepositories && r.IsPersonalRepository() {
+				if c.excludes(r) {
 					continue
 				}
 				if !recent[r.ID] {
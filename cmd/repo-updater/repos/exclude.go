@@ -0,0 +1,86 @@
+package repos
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// An excludeList is the "exclude" configuration of a code host connection. The repositories that
+// it excludes are neither listed nor found by lookups, as if they did not exist on the code host.
+type excludeList []excludeRule
+
+// An excludeRule excludes the repositories that match all of its set fields.
+type excludeRule struct {
+	name     string         // lowercase name on the code host
+	pattern  *regexp.Regexp // matches the name on the code host (case-insensitively)
+	forks    bool           // only match forks
+	archived bool           // only match archived repositories
+}
+
+// newExcludeList returns the exclude list of the given configuration. Rules without any condition
+// are ignored, so that they don't exclude all repositories.
+func newExcludeList(config []*schema.ExcludedRepository) (excludeList, error) {
+	var l excludeList
+	for _, c := range config {
+		r := excludeRule{
+			name:     strings.ToLower(c.Name),
+			forks:    c.Forks,
+			archived: c.Archived,
+		}
+		if c.Pattern != "" {
+			var err error
+			// Names on most code hosts are case-insensitive, and lookups by name may use a
+			// different case than the code host.
+			if r.pattern, err = regexp.Compile("(?i)" + c.Pattern); err != nil {
+				return nil, errors.Wrapf(err, "invalid exclude pattern %q", c.Pattern)
+			}
+		}
+		if r.name == "" && r.pattern == nil && !r.forks && !r.archived {
+			continue
+		}
+		l = append(l, r)
+	}
+	return l, nil
+}
+
+// excludes reports whether the repository with the given name on the code host (such as
+// "owner/name" on GitHub) is excluded. Code hosts that don't have forks or archived repositories
+// pass false for fork or archived.
+func (l excludeList) excludes(name string, fork, archived bool) bool {
+	for _, r := range l {
+		if r.matches(name, fork, archived) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r excludeRule) matches(name string, fork, archived bool) bool {
+	return (r.name == "" || r.name == strings.ToLower(name)) &&
+		(r.pattern == nil || r.pattern.MatchString(name)) &&
+		(!r.forks || fork) &&
+		(!r.archived || archived)
+}
+
+// errExcluded returns the error that GetRepo returns for an excluded repository.
+func errExcluded(repo api.RepoName) error {
+	return &excludedError{repo: repo}
+}
+
+// An excludedError is returned by GetRepo for an excluded repository. Lookups treat it as "not
+// found", so that the repository is not added, but the syncer doesn't mark the repository as
+// deleted: it still exists on the code host, and it is unscheduled because it is no longer listed.
+type excludedError struct {
+	repo api.RepoName
+}
+
+func (e *excludedError) Error() string {
+	return fmt.Sprintf("repository %s is excluded by its code host connection", e.repo)
+}
+
+func (e *excludedError) NotFound() bool { return true }
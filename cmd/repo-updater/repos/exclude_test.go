@@ -0,0 +1,45 @@
+package repos
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestExcludeList(t *testing.T) {
+	l, err := newExcludeList([]*schema.ExcludedRepository{
+		{Name: "Foo/Generated"},
+		{Pattern: `^bar/archive-`},
+		{Forks: true},
+		{Pattern: `^baz/`, Archived: true},
+		{}, // ignored, doesn't exclude everything
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name           string
+		fork, archived bool
+		want           bool
+	}{
+		{name: "foo/generated", want: true},
+		{name: "foo/generated-2", want: false},
+		{name: "bar/archive-2018", want: true},
+		{name: "Bar/Archive-2018", want: true},
+		{name: "foo/bar/archive-2018", want: false},
+		{name: "qux/qux", fork: true, want: true},
+		{name: "qux/qux", want: false},
+		{name: "baz/old", archived: true, want: true},
+		{name: "baz/new", want: false},
+		{name: "qux/old", archived: true, want: false},
+	}
+	for _, c := range cases {
+		if got := l.excludes(c.name, c.fork, c.archived); got != c.want {
+			t.Errorf("excludes(%q, %v, %v): got %v want %v", c.name, c.fork, c.archived, got, c.want)
+		}
+	}
+
+	if _, err := newExcludeList([]*schema.ExcludedRepository{{Pattern: "["}}); err == nil {
+		t.Error("got no error for invalid pattern")
+	}
+}
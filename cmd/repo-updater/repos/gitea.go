@@ -0,0 +1,260 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/atomicvalue"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+var giteaConnections = atomicvalue.New()
+
+func init() {
+	conf.Watch(func() {
+		giteaConnections.Set(func() interface{} {
+			var conns []*giteaConnection
+			for _, c := range conf.Get().Gitea {
+				conn, err := newGiteaConnection(c)
+				if err != nil {
+					log15.Error("Error processing configured Gitea connection. Skipping it.", "url", c.Url, "error", err)
+					continue
+				}
+				conns = append(conns, conn)
+			}
+			return conns
+		})
+		giteaWorker.restart()
+	})
+}
+
+// getGiteaConnection returns the Gitea connection (config + API client) that is responsible for
+// the repository specified by the args.
+func getGiteaConnection(args protocol.RepoLookupArgs) (*giteaConnection, error) {
+	conns := giteaConnections.Get().([]*giteaConnection)
+	if args.ExternalRepo != nil && args.ExternalRepo.ServiceType == gitea.ServiceType {
+		// Look up by external repository spec.
+		for _, conn := range conns {
+			if args.ExternalRepo.ServiceID == conn.client.URL.String() {
+				return conn, nil
+			}
+		}
+		return nil, errors.Errorf("no configured Gitea connection with URL: %q", args.ExternalRepo.ServiceID)
+	}
+
+	if args.Repo != "" {
+		// Look up by repository name.
+		repo := strings.ToLower(string(args.Repo))
+		for _, conn := range conns {
+			if strings.HasPrefix(repo, conn.client.URL.Hostname()+"/") {
+				return conn, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// GetGiteaRepository queries a configured Gitea connection endpoint for information about the
+// specified repository.
+//
+// If args.Repo refers to a repository that is not known to be on a configured Gitea connection's
+// host, it returns authoritative == false.
+func GetGiteaRepository(ctx context.Context, args protocol.RepoLookupArgs) (repo *protocol.RepoInfo, authoritative bool, err error) {
+	conn, err := getGiteaConnection(args)
+	if err != nil {
+		return nil, true, err // refers to a Gitea repo but the host is not configured
+	}
+	if conn == nil {
+		return nil, false, nil // refers to a non-Gitea repo
+	}
+	repo, err = conn.GetRepo(ctx, args)
+	return repo, true, err
+}
+
+// GetRepo implements Source.
+func (c *giteaConnection) GetRepo(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.RepoInfo, error) {
+	if args.ExternalRepo != nil && args.ExternalRepo.ServiceType == gitea.ServiceType {
+		// Look up by external repository spec.
+		id, err := strconv.ParseInt(args.ExternalRepo.ID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		r, err := c.client.RepoByID(ctx, id)
+		if err == nil {
+			if c.excludes(r) {
+				return nil, errExcluded(c.repoName(r))
+			}
+			return c.repoInfo(r), nil
+		}
+		// Gogs can't look up repositories by ID, so fall back to the name.
+		if !gitea.IsNotFound(err) || args.Repo == "" {
+			return nil, err
+		}
+	}
+
+	if args.Repo != "" {
+		// Look up by repository name. Expect {host}/{owner}/{name}.
+		nameWithOwner := strings.TrimPrefix(string(args.Repo), c.client.URL.Hostname()+"/")
+		i := strings.Index(nameWithOwner, "/")
+		if i < 0 || i == len(nameWithOwner)-1 {
+			return nil, errors.Errorf("malformed Gitea repo name: %q", args.Repo)
+		}
+		r, err := c.client.Repo(ctx, nameWithOwner[:i], nameWithOwner[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		if c.excludes(r) {
+			return nil, errExcluded(args.Repo)
+		}
+		return c.repoInfo(r), nil
+	}
+
+	return nil, fmt.Errorf("unable to look up Gitea repository (%+v)", args)
+}
+
+var giteaWorker = newSyncWorker(func() []Source {
+	conns := giteaConnections.Get().([]*giteaConnection)
+	sources := make([]Source, len(conns))
+	for i, c := range conns {
+		sources[i] = c
+	}
+	return sources
+})
+
+// RunGiteaRepositorySyncWorker runs the worker that syncs repositories from configured Gitea
+// instances to Sourcegraph.
+func RunGiteaRepositorySyncWorker(ctx context.Context) {
+	giteaWorker.start(ctx)
+}
+
+// Name implements Source.
+func (c *giteaConnection) Name() string {
+	return connectionSource("gitea", c.config.Url, c.config.Token)
+}
+
+// ListRepos implements Source.
+func (c *giteaConnection) ListRepos(ctx context.Context) ([]repoCreateOrUpdateRequest, error) {
+	const perPage = 50 // Gitea's default MAX_RESPONSE_ITEMS
+	list, err := c.client.ListUserRepos(ctx, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make([]repoCreateOrUpdateRequest, 0, len(list))
+	for _, r := range list {
+		if c.excludes(r) {
+			continue
+		}
+		ri := c.repoInfo(r)
+		repos = append(repos, repoCreateOrUpdateRequest{
+			RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
+				RepoName:     ri.Name,
+				ExternalRepo: ri.ExternalRepo,
+				Description:  ri.Description,
+				Fork:         ri.Fork,
+				Archived:     ri.Archived,
+				Enabled:      c.config.InitialRepositoryEnablement,
+			},
+			URL:          ri.VCS.URL,
+			CloneOptions: c.cloneOptions.get(ri.Name),
+		})
+	}
+	return repos, nil
+}
+
+func newGiteaConnection(config *schema.GiteaConnection) (*giteaConnection, error) {
+	baseURL, err := url.Parse(config.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = NormalizeBaseURL(baseURL)
+
+	transport, err := cachedTransportWithCertTrusted(config.Certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude, err := newExcludeList(config.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	cloneOptions, err := newCloneOptionsList(config.GitCloneOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &giteaConnection{
+		config: config,
+		client: &gitea.Client{
+			URL:   baseURL,
+			Token: config.Token,
+			HTTPClient: &http.Client{
+				Transport: gitea.WithRequestCounter(transport),
+			},
+		},
+		exclude:      exclude,
+		cloneOptions: cloneOptions,
+	}, nil
+}
+
+type giteaConnection struct {
+	config       *schema.GiteaConnection
+	client       *gitea.Client
+	exclude      excludeList
+	cloneOptions cloneOptionsList
+}
+
+// excludes reports whether the repository is excluded by the connection's exclude list.
+func (c *giteaConnection) excludes(r *gitea.Repo) bool {
+	return c.exclude.excludes(r.FullName, r.Fork, r.Archived)
+}
+
+func (c *giteaConnection) repoName(r *gitea.Repo) api.RepoName {
+	return reposource.GiteaRepoName(c.config.RepositoryPathPattern, c.client.URL.Hostname(), r.FullName)
+}
+
+func (c *giteaConnection) repoInfo(r *gitea.Repo) *protocol.RepoInfo {
+	return &protocol.RepoInfo{
+		Name:         c.repoName(r),
+		ExternalRepo: gitea.ExternalRepoSpec(r, *c.client.URL),
+		Description:  r.Description,
+		Fork:         r.Fork,
+		Archived:     r.Archived,
+		VCS: protocol.VCSInfo{
+			URL: c.authenticatedRemoteURL(r),
+		},
+		Links: &protocol.RepoLinks{
+			Root: r.HTMLURL,
+			// Gitea resolves {rev} to a branch, tag or commit.
+			Tree:   r.HTMLURL + "/src/{rev}/{path}",
+			Blob:   r.HTMLURL + "/src/{rev}/{path}",
+			Commit: r.HTMLURL + "/commit/{commit}",
+		},
+	}
+}
+
+// authenticatedRemoteURL returns the Gitea repository's Git remote URL with the configured Gitea
+// access token inserted in the URL userinfo, for private repositories.
+func (c *giteaConnection) authenticatedRemoteURL(r *gitea.Repo) string {
+	if c.config.GitURLType == "ssh" {
+		return r.SSHURL // SSH authentication must be provided out-of-band
+	}
+	if c.config.Token == "" || !r.Private {
+		return r.CloneURL
+	}
+	// Gitea and Gogs accept an access token as the username, with an empty
+	// password or "x-oauth-basic".
+	return setUserinfoBestEffort(r.CloneURL, c.config.Token, "x-oauth-basic")
+}
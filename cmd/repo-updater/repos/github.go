@@ -119,25 +119,6 @@ func GetGitHubRepository(ctx context.Context, args protocol.RepoLookupArgs) (rep
 		return GetGitHubRepositoryMock(args)
 	}
 
-	ghrepoToRepoInfo := func(ghrepo *github.Repository, conn *githubConnection) *protocol.RepoInfo {
-		return &protocol.RepoInfo{
-			Name:         githubRepositoryToRepoPath(conn, ghrepo),
-			ExternalRepo: github.ExternalRepoSpec(ghrepo, *conn.baseURL),
-			Description:  ghrepo.Description,
-			Fork:         ghrepo.IsFork,
-			Archived:     ghrepo.IsArchived,
-			Links: &protocol.RepoLinks{
-				Root:   ghrepo.URL,
-				Tree:   ghrepo.URL + "/tree/{rev}/{path}",
-				Blob:   ghrepo.URL + "/blob/{rev}/{path}",
-				Commit: ghrepo.URL + "/commit/{commit}",
-			},
-			VCS: protocol.VCSInfo{
-				URL: conn.authenticatedRemoteURL(ghrepo),
-			},
-		}
-	}
-
 	conn, err := getGitHubConnection(args)
 	if err != nil {
 		return nil, true, err // refers to a GitHub repo but the host is not configured
@@ -145,7 +126,12 @@ func GetGitHubRepository(ctx context.Context, args protocol.RepoLookupArgs) (rep
 	if conn == nil {
 		return nil, false, nil // refers to a non-GitHub repo
 	}
+	repo, err = conn.GetRepo(ctx, args)
+	return repo, true, err
+}
 
+// GetRepo implements Source.
+func (c *githubConnection) GetRepo(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.RepoInfo, error) {
 	// Support bypassing GitHub API, for rate limit evasion.
 	var bypassReason string
 	bypass := bypassGitHubAPI
@@ -153,7 +139,7 @@ func GetGitHubRepository(ctx context.Context, args protocol.RepoLookupArgs) (rep
 		bypassReason = "manual bypass env var BYPASS_GITHUB_API=1 is set"
 	}
 	if !bypass && minGitHubAPIRateLimit > 0 {
-		remaining, reset, known := conn.client.RateLimit.Get()
+		remaining, reset, known := c.client.RateLimit.Get()
 		// If we're below the min rate limit, bypass the GitHub API. But if the rate limit has reset, then we need
 		// to perform an API request to check the new rate limit. (Give 30s of buffer for clock unsync.)
 		if known && remaining < minGitHubAPIRateLimit && reset > -30*time.Second {
@@ -162,9 +148,9 @@ func GetGitHubRepository(ctx context.Context, args protocol.RepoLookupArgs) (rep
 		}
 	}
 	if bypass {
-		remaining, reset, known := conn.client.RateLimit.Get()
+		remaining, reset, known := c.client.RateLimit.Get()
 
-		logArgs := []interface{}{"reason", bypassReason, "repo", args.Repo, "baseURL", conn.config.Url}
+		logArgs := []interface{}{"reason", bypassReason, "repo", args.Repo, "baseURL", c.config.Url}
 		if known {
 			logArgs = append(logArgs, "rateLimitRemaining", remaining, "rateLimitReset", reset)
 		} else {
@@ -173,15 +159,20 @@ func GetGitHubRepository(ctx context.Context, args protocol.RepoLookupArgs) (rep
 
 		// For public repositories, we can bypass the GitHub API and still get almost everything we
 		// need (except for the repository's ID, description, and fork status).
-		isPublicRepo := args.Repo != "" && conn.config.Token == ""
+		isPublicRepo := args.Repo != "" && c.config.Token == ""
 		if isPublicRepo {
+			nameWithOwner := strings.TrimPrefix(strings.ToLower(string(args.Repo)), c.originalHostname+"/")
+			if c.exclude.excludes(nameWithOwner, false, false) {
+				return nil, errExcluded(args.Repo)
+			}
+
 			log15.Debug("Bypassing GitHub API when getting public repository. Some repository metadata fields will be blank.", logArgs...)
 
 			// It's important to still check cloneability, so we don't add a bunch of junk GitHub repos that don't
 			// exist (like github.com/settings/profile) or that are private and not on Sourcegraph.com.
 			remoteURL := "https://" + string(args.Repo)
 			if err := gitserver.DefaultClient.IsRepoCloneable(ctx, gitserver.Repo{Name: args.Repo, URL: remoteURL}); err != nil {
-				return nil, true, errors.Wrap(github.ErrNotFound, fmt.Sprintf("IsRepoCloneable: %s", err))
+				return nil, &notCloneableError{repo: args.Repo, err: err}
 			}
 
 			return &protocol.RepoInfo{
@@ -197,70 +188,97 @@ func GetGitHubRepository(ctx context.Context, args protocol.RepoLookupArgs) (rep
 					Commit: remoteURL + "/commit/{commit}",
 				},
 				VCS: protocol.VCSInfo{URL: remoteURL},
-			}, true, nil
+			}, nil
 		}
 
 		log15.Warn("Unable to get repository metadata from GitHub API for a (possibly) private repository.", logArgs...)
-		return nil, true, ErrGitHubAPITemporarilyUnavailable
+		return nil, ErrGitHubAPITemporarilyUnavailable
 	}
 
 	log15.Debug("GetGitHubRepository", "repo", args.Repo, "externalRepo", args.ExternalRepo)
 
-	canUseGraphQLAPI := conn.config.Token != "" // GraphQL API requires authentication
+	canUseGraphQLAPI := c.config.Token != "" // GraphQL API requires authentication
 	if canUseGraphQLAPI && args.ExternalRepo != nil && args.ExternalRepo.ServiceType == github.ServiceType {
 		// Look up by external repository spec.
-		ghrepo, err := conn.client.GetRepositoryByNodeID(ctx, args.ExternalRepo.ID)
-		if ghrepo != nil {
-			repo = ghrepoToRepoInfo(ghrepo, conn)
+		ghrepo, err := c.client.GetRepositoryByNodeID(ctx, args.ExternalRepo.ID)
+		if err != nil {
+			return nil, err
 		}
-		return repo, true, err
+		if c.excludes(ghrepo) {
+			return nil, errExcluded(githubRepositoryToRepoPath(c, ghrepo))
+		}
+		return c.repoInfo(ghrepo), nil
 	}
 
 	if args.Repo != "" {
 		// Look up by repository name.
-		nameWithOwner := strings.TrimPrefix(strings.ToLower(string(args.Repo)), conn.originalHostname+"/")
+		nameWithOwner := strings.TrimPrefix(strings.ToLower(string(args.Repo)), c.originalHostname+"/")
 		owner, repoName, err := github.SplitRepositoryNameWithOwner(nameWithOwner)
 		if err != nil {
-			return nil, true, err
+			return nil, err
 		}
 
-		ghrepo, err := conn.client.GetRepository(ctx, owner, repoName)
-		if ghrepo != nil {
-			repo = ghrepoToRepoInfo(ghrepo, conn)
+		ghrepo, err := c.client.GetRepository(ctx, owner, repoName)
+		if err != nil {
+			return nil, err
 		}
-		return repo, true, err
+		if c.excludes(ghrepo) {
+			return nil, errExcluded(args.Repo)
+		}
+		return c.repoInfo(ghrepo), nil
 	}
 
-	return nil, true, fmt.Errorf("unable to look up GitHub repository (%+v)", args)
+	return nil, fmt.Errorf("unable to look up GitHub repository (%+v)", args)
 }
 
-var gitHubRepositorySyncWorker = &worker{
-	work: func(ctx context.Context, shutdown chan struct{}) {
-		githubConnections := githubConnections.Get().([]*githubConnection)
-		if len(githubConnections) == 0 {
-			return
-		}
-		for _, c := range githubConnections {
-			go func(c *githubConnection) {
-				for {
-					if rateLimitRemaining, rateLimitReset, ok := c.client.RateLimit.Get(); ok && rateLimitRemaining < 200 {
-						wait := rateLimitReset + 10*time.Second
-						log15.Warn("GitHub API rate limit is almost exhausted. Waiting until rate limit is reset.", "wait", rateLimitReset, "rateLimitRemaining", rateLimitRemaining)
-						time.Sleep(wait)
-					}
-					updateGitHubRepositories(ctx, c)
-					githubUpdateTime.WithLabelValues(c.baseURL.String()).Set(float64(time.Now().Unix()))
-					select {
-					case <-shutdown:
-						return
-					case <-time.After(getUpdateInterval()):
-					}
-				}
-			}(c)
-		}
-	},
+// A notCloneableError is returned by GetRepo for a public repository that can't be cloned while the
+// GitHub API is bypassed. Lookups treat it as "not found", but the syncer doesn't mark the repository
+// as deleted: the clone may have failed for other reasons (such as gitserver being unable to reach
+// GitHub), so it is not an authoritative answer from GitHub.
+type notCloneableError struct {
+	repo api.RepoName
+	err  error
 }
 
+func (e *notCloneableError) Error() string {
+	return fmt.Sprintf("GitHub repository %s is not cloneable: %s", e.repo, e.err)
+}
+
+func (e *notCloneableError) NotFound() bool { return true }
+
+// excludes reports whether the repository is excluded by the connection's exclude list.
+func (c *githubConnection) excludes(repo *github.Repository) bool {
+	return c.exclude.excludes(repo.NameWithOwner, repo.IsFork, repo.IsArchived)
+}
+
+func (c *githubConnection) repoInfo(ghrepo *github.Repository) *protocol.RepoInfo {
+	return &protocol.RepoInfo{
+		Name:         githubRepositoryToRepoPath(c, ghrepo),
+		ExternalRepo: github.ExternalRepoSpec(ghrepo, *c.baseURL),
+		Description:  ghrepo.Description,
+		Fork:         ghrepo.IsFork,
+		Archived:     ghrepo.IsArchived,
+		Links: &protocol.RepoLinks{
+			Root:   ghrepo.URL,
+			Tree:   ghrepo.URL + "/tree/{rev}/{path}",
+			Blob:   ghrepo.URL + "/blob/{rev}/{path}",
+			Commit: ghrepo.URL + "/commit/{commit}",
+		},
+		VCS: protocol.VCSInfo{
+			URL: c.authenticatedRemoteURL(ghrepo),
+		},
+	}
+}
+
+var gitHubRepositorySyncWorker = newSyncWorker(func() []Source {
+	conns := githubConnections.Get().([]*githubConnection)
+	sources := make([]Source, len(conns))
+	for i, c := range conns {
+		sources[i] = c
+	}
+	return sources
+})
+
 // RunGitHubRepositorySyncWorker runs the worker that syncs repositories from the configured GitHub and GitHub
 // Enterprise instances to Sourcegraph.
 func RunGitHubRepositorySyncWorker(ctx context.Context) {
@@ -271,27 +289,40 @@ func githubRepositoryToRepoPath(conn *githubConnection, repo *github.Repository)
 	return reposource.GitHubRepoName(conn.config.RepositoryPathPattern, conn.originalHostname, repo.NameWithOwner)
 }
 
-// updateGitHubRepositories ensures that all provided repositories have been added and updated on Sourcegraph.
-func updateGitHubRepositories(ctx context.Context, conn *githubConnection) {
-	repos := conn.listAllRepositories(ctx)
+// Name implements Source.
+func (c *githubConnection) Name() string {
+	return connectionSource("github", c.config.Url, c.config.Token)
+}
 
-	repoChan := make(chan repoCreateOrUpdateRequest)
-	defer close(repoChan)
-	go createEnableUpdateRepos(ctx, fmt.Sprintf("github:%s", conn.config.Token), repoChan)
-	for repo := range repos {
-		// log15.Debug("github sync: create/enable/update repo", "repo", repo.NameWithOwner)
-		repoChan <- repoCreateOrUpdateRequest{
+// ListRepos implements Source.
+func (c *githubConnection) ListRepos(ctx context.Context) ([]repoCreateOrUpdateRequest, error) {
+	if rateLimitRemaining, rateLimitReset, ok := c.client.RateLimit.Get(); ok && rateLimitRemaining < 200 {
+		wait := rateLimitReset + 10*time.Second
+		log15.Warn("GitHub API rate limit is almost exhausted. Waiting until rate limit is reset.", "wait", rateLimitReset, "rateLimitRemaining", rateLimitRemaining)
+		time.Sleep(wait)
+	}
+
+	var errs listErrors
+	var repos []repoCreateOrUpdateRequest
+	for repo := range c.listAllRepositories(ctx, &errs) {
+		if c.excludes(repo) {
+			continue
+		}
+		name := githubRepositoryToRepoPath(c, repo)
+		repos = append(repos, repoCreateOrUpdateRequest{
 			RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
-				RepoName:     githubRepositoryToRepoPath(conn, repo),
-				ExternalRepo: github.ExternalRepoSpec(repo, *conn.baseURL),
+				RepoName:     name,
+				ExternalRepo: github.ExternalRepoSpec(repo, *c.baseURL),
 				Description:  repo.Description,
 				Fork:         repo.IsFork,
 				Archived:     repo.IsArchived,
-				Enabled:      conn.config.InitialRepositoryEnablement,
+				Enabled:      c.config.InitialRepositoryEnablement,
 			},
-			URL: conn.authenticatedRemoteURL(repo),
-		}
+			URL:          c.authenticatedRemoteURL(repo),
+			CloneOptions: c.cloneOptions.get(name),
+		})
 	}
+	return repos, errs.errorOrNil()
 }
 
 func newGitHubConnection(config *schema.GitHubConnection) (*githubConnection, error) {
@@ -302,6 +333,15 @@ func newGitHubConnection(config *schema.GitHubConnection) (*githubConnection, er
 	baseURL = NormalizeBaseURL(baseURL)
 	originalHostname := baseURL.Hostname()
 
+	exclude, err := newExcludeList(config.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	cloneOptions, err := newCloneOptionsList(config.GitCloneOptions)
+	if err != nil {
+		return nil, err
+	}
+
 	// GitHub.com's API is hosted on api.github.com.
 	apiURL := *baseURL
 	githubDotCom := false
@@ -332,6 +372,8 @@ func newGitHubConnection(config *schema.GitHubConnection) (*githubConnection, er
 		client:           github.NewClient(&apiURL, config.Token, transport, repoCache),
 		searchClient:     github.NewClient(&apiURL, config.Token, transport, repoCache),
 		originalHostname: originalHostname,
+		exclude:          exclude,
+		cloneOptions:     cloneOptions,
 	}, nil
 }
 
@@ -347,6 +389,9 @@ type githubConnection struct {
 	// originalHostname is the hostname of config.Url (differs from client APIURL, whose host is api.github.com
 	// for an originalHostname of github.com).
 	originalHostname string
+
+	exclude      excludeList
+	cloneOptions cloneOptionsList
 }
 
 // authenticatedRemoteURL returns the repository's Git remote URL with the configured
@@ -369,7 +414,9 @@ func (c *githubConnection) authenticatedRemoteURL(repo *github.Repository) strin
 	return u.String()
 }
 
-func (c *githubConnection) listAllRepositories(ctx context.Context) <-chan *github.Repository {
+// listAllRepositories lists the repositories of the connection. Errors are added to errs, and
+// the repositories that could be listed are still sent.
+func (c *githubConnection) listAllRepositories(ctx context.Context, errs *listErrors) <-chan *github.Repository {
 	const first = 100 // max GitHub API "first" parameter
 	ch := make(chan *github.Repository, first)
 
@@ -398,7 +445,7 @@ func (c *githubConnection) listAllRepositories(ctx context.Context) <-chan *gith
 				for {
 					repos, err := c.client.ListPublicRepositories(ctx, sinceRepoID)
 					if err != nil {
-						log15.Error("Error listing public repositories", "sinceRepoID", sinceRepoID, "error", err)
+						errs.add(errors.Wrapf(err, "listing public GitHub repositories since ID %d", sinceRepoID))
 						return
 					}
 					if len(repos) == 0 {
@@ -420,7 +467,7 @@ func (c *githubConnection) listAllRepositories(ctx context.Context) <-chan *gith
 					var err error
 					repos, hasNextPage, rateLimitCost, err = c.client.ListViewerRepositories(ctx, page)
 					if err != nil {
-						log15.Error("Error listing viewer's affiliated GitHub repositories", "page", page, "error", err)
+						errs.add(errors.Wrapf(err, "listing affiliated GitHub repositories (page %d)", page))
 						break
 					}
 					rateLimitRemaining, rateLimitReset, _ := c.client.RateLimit.Get()
@@ -451,7 +498,7 @@ func (c *githubConnection) listAllRepositories(ctx context.Context) <-chan *gith
 					var err error
 					repos, hasNextPage, rateLimitCost, err = c.searchClient.ListRepositoriesForSearch(ctx, repositoryQuery, page)
 					if err != nil {
-						log15.Error("Error listing GitHub repositories for search", "searchString", repositoryQuery, "page", page, "error", err)
+						errs.add(errors.Wrapf(err, "listing GitHub repositories for search %q (page %d)", repositoryQuery, page))
 						break
 					}
 					rateLimitRemaining, rateLimitReset, _ := c.searchClient.RateLimit.Get()
@@ -473,12 +520,12 @@ func (c *githubConnection) listAllRepositories(ctx context.Context) <-chan *gith
 		for _, nameWithOwner := range c.config.Repos {
 			owner, name, err := github.SplitRepositoryNameWithOwner(nameWithOwner)
 			if err != nil {
-				log15.Error("Invalid GitHub repository", "nameWithOwner", nameWithOwner)
+				errs.add(err)
 				continue
 			}
 			repo, err := c.client.GetRepository(ctx, owner, name)
 			if err != nil {
-				log15.Error("Error getting GitHub repository", "nameWithOwner", nameWithOwner, "error", err)
+				errs.add(errors.Wrapf(err, "getting GitHub repository %q", nameWithOwner))
 				continue
 			}
 			log15.Debug("github sync: GetRepository", "repo", repo.NameWithOwner)
@@ -99,25 +99,6 @@ func GetGitLabRepository(ctx context.Context, args protocol.RepoLookupArgs) (rep
 		return GetGitLabRepositoryMock(args)
 	}
 
-	ghrepoToRepoInfo := func(proj *gitlab.Project, conn *gitlabConnection) *protocol.RepoInfo {
-		return &protocol.RepoInfo{
-			Name:         gitlabProjectToRepoPath(conn, proj),
-			ExternalRepo: gitlab.ExternalRepoSpec(proj, *conn.baseURL),
-			Description:  proj.Description,
-			Fork:         proj.ForkedFromProject != nil,
-			Archived:     proj.Archived,
-			VCS: protocol.VCSInfo{
-				URL: conn.authenticatedRemoteURL(proj),
-			},
-			Links: &protocol.RepoLinks{
-				Root:   proj.WebURL,
-				Tree:   proj.WebURL + "/tree/{rev}/{path}",
-				Blob:   proj.WebURL + "/blob/{rev}/{path}",
-				Commit: proj.WebURL + "/commit/{commit}",
-			},
-		}
-	}
-
 	conn, err := getGitLabConnection(args)
 	if err != nil {
 		return nil, true, err // refers to a GitLab repo but the host is not configured
@@ -125,60 +106,77 @@ func GetGitLabRepository(ctx context.Context, args protocol.RepoLookupArgs) (rep
 	if conn == nil {
 		return nil, false, nil // refers to a non-GitLab repo
 	}
+	repo, err = conn.GetRepo(ctx, args)
+	return repo, true, err
+}
 
+// GetRepo implements Source.
+func (c *gitlabConnection) GetRepo(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.RepoInfo, error) {
 	if args.ExternalRepo != nil && args.ExternalRepo.ServiceType == gitlab.ServiceType {
 		// Look up by external repository spec.
 		id, err := strconv.Atoi(args.ExternalRepo.ID)
 		if err != nil {
-			return nil, true, err
+			return nil, err
 		}
-		proj, err := conn.client.GetProject(ctx, id, "")
-		if proj != nil {
-			repo = ghrepoToRepoInfo(proj, conn)
+		proj, err := c.client.GetProject(ctx, id, "")
+		if err != nil {
+			return nil, err
 		}
-		return repo, true, err
+		if c.excludes(proj) {
+			return nil, errExcluded(gitlabProjectToRepoPath(c, proj))
+		}
+		return c.repoInfo(proj), nil
 	}
 
 	if args.Repo != "" {
 		// Look up by repository name.
-		pathWithNamespace := strings.TrimPrefix(strings.ToLower(string(args.Repo)), conn.baseURL.Hostname()+"/")
-		proj, err := conn.client.GetProject(ctx, 0, pathWithNamespace)
-		if proj != nil {
-			repo = ghrepoToRepoInfo(proj, conn)
+		pathWithNamespace := strings.TrimPrefix(strings.ToLower(string(args.Repo)), c.baseURL.Hostname()+"/")
+		proj, err := c.client.GetProject(ctx, 0, pathWithNamespace)
+		if err != nil {
+			return nil, err
+		}
+		if c.excludes(proj) {
+			return nil, errExcluded(args.Repo)
 		}
-		return repo, true, err
+		return c.repoInfo(proj), nil
 	}
 
-	return nil, true, fmt.Errorf("unable to look up GitLab repository (%+v)", args)
+	return nil, fmt.Errorf("unable to look up GitLab repository (%+v)", args)
 }
 
-var gitLabRepositorySyncWorker = &worker{
-	work: func(ctx context.Context, shutdown chan struct{}) {
-		gitlabConnections := gitlabConnections.Get().([]*gitlabConnection)
-		if len(gitlabConnections) == 0 {
-			return
-		}
-		for _, c := range gitlabConnections {
-			go func(c *gitlabConnection) {
-				for {
-					if rateLimitRemaining, rateLimitReset, ok := c.client.RateLimit.Get(); ok && rateLimitRemaining < 50 {
-						wait := rateLimitReset + 10*time.Second
-						log15.Warn("GitLab API rate limit is almost exhausted. Waiting until rate limit is reset.", "wait", rateLimitReset, "rateLimitRemaining", rateLimitRemaining)
-						time.Sleep(wait)
-					}
-					updateGitLabProjects(ctx, c)
-					gitlabUpdateTime.WithLabelValues(c.baseURL.String()).Set(float64(time.Now().Unix()))
-					select {
-					case <-shutdown:
-						return
-					case <-time.After(getUpdateInterval()):
-					}
-				}
-			}(c)
-		}
-	},
+// excludes reports whether the project is excluded by the connection's exclude list.
+func (c *gitlabConnection) excludes(proj *gitlab.Project) bool {
+	return c.exclude.excludes(proj.PathWithNamespace, proj.ForkedFromProject != nil, proj.Archived)
+}
+
+func (c *gitlabConnection) repoInfo(proj *gitlab.Project) *protocol.RepoInfo {
+	return &protocol.RepoInfo{
+		Name:         gitlabProjectToRepoPath(c, proj),
+		ExternalRepo: gitlab.ExternalRepoSpec(proj, *c.baseURL),
+		Description:  proj.Description,
+		Fork:         proj.ForkedFromProject != nil,
+		Archived:     proj.Archived,
+		VCS: protocol.VCSInfo{
+			URL: c.authenticatedRemoteURL(proj),
+		},
+		Links: &protocol.RepoLinks{
+			Root:   proj.WebURL,
+			Tree:   proj.WebURL + "/tree/{rev}/{path}",
+			Blob:   proj.WebURL + "/blob/{rev}/{path}",
+			Commit: proj.WebURL + "/commit/{commit}",
+		},
+	}
 }
 
+var gitLabRepositorySyncWorker = newSyncWorker(func() []Source {
+	conns := gitlabConnections.Get().([]*gitlabConnection)
+	sources := make([]Source, len(conns))
+	for i, c := range conns {
+		sources[i] = c
+	}
+	return sources
+})
+
 // RunGitLabRepositorySyncWorker runs the worker that syncs projects from configured GitLab instances to
 // Sourcegraph.
 func RunGitLabRepositorySyncWorker(ctx context.Context) {
@@ -189,26 +187,40 @@ func gitlabProjectToRepoPath(conn *gitlabConnection, proj *gitlab.Project) api.R
 	return reposource.GitLabRepoName(conn.config.RepositoryPathPattern, conn.baseURL.Hostname(), proj.PathWithNamespace)
 }
 
-// updateGitLabProjects ensures that all provided repositories exist in the repository table.
-func updateGitLabProjects(ctx context.Context, conn *gitlabConnection) {
-	projs := conn.listAllProjects(ctx)
+// Name implements Source.
+func (c *gitlabConnection) Name() string {
+	return connectionSource("gitlab", c.config.Url, c.config.Token)
+}
 
-	repoChan := make(chan repoCreateOrUpdateRequest)
-	defer close(repoChan)
-	go createEnableUpdateRepos(ctx, fmt.Sprintf("gitlab:%s", conn.config.Token), repoChan)
-	for proj := range projs {
-		repoChan <- repoCreateOrUpdateRequest{
+// ListRepos implements Source.
+func (c *gitlabConnection) ListRepos(ctx context.Context) ([]repoCreateOrUpdateRequest, error) {
+	if rateLimitRemaining, rateLimitReset, ok := c.client.RateLimit.Get(); ok && rateLimitRemaining < 50 {
+		wait := rateLimitReset + 10*time.Second
+		log15.Warn("GitLab API rate limit is almost exhausted. Waiting until rate limit is reset.", "wait", rateLimitReset, "rateLimitRemaining", rateLimitRemaining)
+		time.Sleep(wait)
+	}
+
+	var errs listErrors
+	var repos []repoCreateOrUpdateRequest
+	for proj := range c.listAllProjects(ctx, &errs) {
+		if c.excludes(proj) {
+			continue
+		}
+		name := gitlabProjectToRepoPath(c, proj)
+		repos = append(repos, repoCreateOrUpdateRequest{
 			RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
-				RepoName:     gitlabProjectToRepoPath(conn, proj),
-				ExternalRepo: gitlab.ExternalRepoSpec(proj, *conn.baseURL),
+				RepoName:     name,
+				ExternalRepo: gitlab.ExternalRepoSpec(proj, *c.baseURL),
 				Description:  proj.Description,
 				Fork:         proj.ForkedFromProject != nil,
 				Archived:     proj.Archived,
-				Enabled:      conn.config.InitialRepositoryEnablement,
+				Enabled:      c.config.InitialRepositoryEnablement,
 			},
-			URL: conn.authenticatedRemoteURL(proj),
-		}
+			URL:          c.authenticatedRemoteURL(proj),
+			CloneOptions: c.cloneOptions.get(name),
+		})
 	}
+	return repos, errs.errorOrNil()
 }
 
 func newGitLabConnection(config *schema.GitLabConnection) (*gitlabConnection, error) {
@@ -223,17 +235,30 @@ func newGitLabConnection(config *schema.GitLabConnection) (*gitlabConnection, er
 		return nil, err
 	}
 
+	exclude, err := newExcludeList(config.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	cloneOptions, err := newCloneOptionsList(config.GitCloneOptions)
+	if err != nil {
+		return nil, err
+	}
+
 	return &gitlabConnection{
-		config:  config,
-		baseURL: baseURL,
-		client:  gitlab.NewClient(baseURL, config.Token, transport),
+		config:       config,
+		baseURL:      baseURL,
+		client:       gitlab.NewClient(baseURL, config.Token, transport),
+		exclude:      exclude,
+		cloneOptions: cloneOptions,
 	}, nil
 }
 
 type gitlabConnection struct {
-	config  *schema.GitLabConnection
-	baseURL *url.URL // URL with path /api/v4 (no trailing slash)
-	client  *gitlab.Client
+	config       *schema.GitLabConnection
+	baseURL      *url.URL // URL with path /api/v4 (no trailing slash)
+	client       *gitlab.Client
+	exclude      excludeList
+	cloneOptions cloneOptionsList
 }
 
 // authenticatedRemoteURL returns the GitLab projects's Git remote URL with the configured GitLab personal access
@@ -255,7 +280,9 @@ func (c *gitlabConnection) authenticatedRemoteURL(proj *gitlab.Project) string {
 	return u.String()
 }
 
-func (c *gitlabConnection) listAllProjects(ctx context.Context) <-chan *gitlab.Project {
+// listAllProjects lists the projects of the connection. Errors are added to errs, and the
+// projects that could be listed are still sent.
+func (c *gitlabConnection) listAllProjects(ctx context.Context, errs *listErrors) <-chan *gitlab.Project {
 	if len(c.config.ProjectQuery) == 0 {
 		c.config.ProjectQuery = []string{"?membership=true"}
 	}
@@ -282,7 +309,7 @@ func (c *gitlabConnection) listAllProjects(ctx context.Context) <-chan *gitlab.P
 			}
 			q, err := normalizeQuery(projectQuery)
 			if err != nil {
-				log15.Error("Skipping invalid GitLab projectQuery", "projectQuery", projectQuery, "error", err)
+				errs.add(errors.Wrapf(err, "invalid GitLab projectQuery %q", projectQuery))
 				continue
 			}
 			q.Set("per_page", strconv.Itoa(perPage))
@@ -291,7 +318,7 @@ func (c *gitlabConnection) listAllProjects(ctx context.Context) <-chan *gitlab.P
 			for {
 				projects, nextPageURL, err := c.client.ListProjects(ctx, url)
 				if err != nil {
-					log15.Error("Error listing GitLab projects", "url", url, "error", err)
+					errs.add(errors.Wrapf(err, "listing GitLab projects (%s)", url))
 					continue projectsQueries
 				}
 				for _, p := range projects {
@@ -10,6 +10,7 @@ import (
 	log15 "gopkg.in/inconshreveable/log15.v2"
 
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/atomicvalue"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
 	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
@@ -21,27 +22,41 @@ var (
 	phabTaskMu      sync.Mutex
 )
 
+var gitoliteConnections = atomicvalue.New()
+
+func init() {
+	conf.Watch(func() {
+		gitoliteConnections.Set(func() interface{} {
+			var conns []*gitoliteConnection
+			for _, c := range conf.Get().Gitolite {
+				conn, err := newGitoliteConnection(c)
+				if err != nil {
+					log15.Error("Error processing configured Gitolite connection. Skipping it.", "prefix", c.Prefix, "error", err)
+					continue
+				}
+				conns = append(conns, conn)
+			}
+			return conns
+		})
+		gitoliteWorker.restart()
+	})
+}
+
+var gitoliteWorker = newSyncWorker(func() []Source {
+	conns := gitoliteConnections.Get().([]*gitoliteConnection)
+	sources := make([]Source, len(conns))
+	for i, c := range conns {
+		sources[i] = c
+	}
+	return sources
+})
+
 // RunGitoliteRepositorySyncWorker runs the worker that syncs repositories from gitolite hosts to Sourcegraph
 //
-// If there is a PhabricatorMetadataCommand set, every ten loops we will try to run that for every
+// If there is a PhabricatorMetadataCommand set, every ten syncs we will try to run that for every
 // repo also.
 func RunGitoliteRepositorySyncWorker(ctx context.Context) {
-	phabricatorMetadataCounter := 0
-	for {
-		log15.Debug("RunGitoliteRepositorySyncWorker:GitoliteUpdateRepos")
-		for _, gconf := range conf.Get().Gitolite {
-			if err := gitoliteUpdateRepos(ctx, gconf, (phabricatorMetadataCounter%10) == 0); err != nil {
-				log15.Error("error updating Gitolite repositories", "err", err, "prefix", gconf.Prefix)
-			} else {
-				log15.Debug("updated Gitolite repositories", "prefix", gconf.Prefix)
-			}
-		}
-		// If you are looking at this line of code because this ran long enough that
-		// an int wrapped, I'm sorry.
-		phabricatorMetadataCounter++
-		gitoliteUpdateTime.Set(float64(time.Now().Unix()))
-		time.Sleep(getUpdateInterval())
-	}
+	gitoliteWorker.start(ctx)
 }
 
 // GetGitoliteRepository returns dummy repo info about the repository, since we don't have the SSH keys in repo-updater
@@ -49,17 +64,87 @@ func RunGitoliteRepositorySyncWorker(ctx context.Context) {
 // existence). We return a dummy response, because if we don't, callers will interpret the response as "repository not
 // found".
 func GetGitoliteRepository(ctx context.Context, args protocol.RepoLookupArgs) (repo *protocol.RepoInfo, authoritative bool, err error) {
-	for _, c := range conf.Get().Gitolite {
-		if strings.HasPrefix(string(args.Repo), c.Prefix) {
-			return &protocol.RepoInfo{
-				Name:         args.Repo,
-				ExternalRepo: args.ExternalRepo,
-			}, true, nil
+	for _, conn := range gitoliteConnections.Get().([]*gitoliteConnection) {
+		if strings.HasPrefix(string(args.Repo), conn.config.Prefix) {
+			repo, err := conn.GetRepo(ctx, args)
+			return repo, true, err
 		}
 	}
 	return nil, false, nil // not found
 }
 
+type gitoliteConnection struct {
+	config  *schema.GitoliteConnection
+	exclude excludeList
+
+	mu    sync.Mutex
+	syncs int // number of calls to ListRepos, to update Phabricator metadata every ten syncs
+}
+
+func newGitoliteConnection(config *schema.GitoliteConnection) (*gitoliteConnection, error) {
+	exclude, err := newExcludeList(config.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &gitoliteConnection{config: config, exclude: exclude}, nil
+}
+
+// Name implements Source.
+func (c *gitoliteConnection) Name() string {
+	return fmt.Sprintf("gitolite:%s", c.config.Prefix)
+}
+
+// ListRepos implements Source.
+func (c *gitoliteConnection) ListRepos(ctx context.Context) ([]repoCreateOrUpdateRequest, error) {
+	// Get list of Gitolite repositories for this connection.
+	rlist, err := gitserver.DefaultClient.ListGitolite(ctx, c.config.Host)
+	if err != nil {
+		return nil, err
+	}
+	included := rlist[:0]
+	for _, entry := range rlist {
+		if !c.exclude.excludes(strings.TrimPrefix(entry, c.config.Prefix), false, false) {
+			included = append(included, entry)
+		}
+	}
+	rlist = included
+
+	c.mu.Lock()
+	doPhabricator := c.syncs%10 == 0
+	c.syncs++
+	c.mu.Unlock()
+	if doPhabricator && c.config.PhabricatorMetadataCommand != "" {
+		go tryUpdateGitolitePhabricatorMetadata(ctx, c.config, rlist)
+	}
+
+	repos := make([]repoCreateOrUpdateRequest, 0, len(rlist))
+	for _, entry := range rlist {
+		// We don't have descriptions available for these. The old code didn't do that either.
+		url := strings.Replace(entry, c.config.Prefix, c.config.Host+":", 1)
+		repos = append(repos, repoCreateOrUpdateRequest{
+			RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
+				RepoName: api.RepoName(entry),
+				Enabled:  true,
+			},
+			URL: url,
+		})
+	}
+	gitoliteUpdateTime.Set(float64(time.Now().Unix()))
+	return repos, nil
+}
+
+// GetRepo implements Source. Gitolite repositories are not looked up on the Gitolite host (see
+// GetGitoliteRepository), so it only reports whether the repository is excluded.
+func (c *gitoliteConnection) GetRepo(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.RepoInfo, error) {
+	if c.exclude.excludes(strings.TrimPrefix(string(args.Repo), c.config.Prefix), false, false) {
+		return nil, errExcluded(args.Repo)
+	}
+	return &protocol.RepoInfo{
+		Name:         args.Repo,
+		ExternalRepo: args.ExternalRepo,
+	}, nil
+}
+
 // tryUpdateGitolitePhabricatorMetadata attempts to update Phabricator metadata for a Gitolite-sourced repository, if it
 // is appropriate to do so.
 func tryUpdateGitolitePhabricatorMetadata(ctx context.Context, gconf *schema.GitoliteConnection, repos []string) {
@@ -92,32 +177,3 @@ func tryUpdateGitolitePhabricatorMetadata(ctx context.Context, gconf *schema.Git
 	phabTaskMu.Unlock()
 	log15.Info("updated gitolite/phabricator metadata for repos", "repos", len(repos))
 }
-
-// gitoliteUpdateRepos updates the repos associated with a specific
-// Gitolite connection.
-func gitoliteUpdateRepos(ctx context.Context, gconf *schema.GitoliteConnection, doPhabricator bool) error {
-	// Get list of Gitolite repositories for this connection.
-	rlist, err := gitserver.DefaultClient.ListGitolite(ctx, gconf.Host)
-	if err != nil {
-		return err
-	}
-
-	repoChan := make(chan repoCreateOrUpdateRequest)
-	defer close(repoChan)
-	go createEnableUpdateRepos(ctx, fmt.Sprintf("gitolite:%s", gconf.Prefix), repoChan)
-	if doPhabricator && gconf.PhabricatorMetadataCommand != "" {
-		go tryUpdateGitolitePhabricatorMetadata(ctx, gconf, rlist)
-	}
-	for _, entry := range rlist {
-		// We don't have descriptions available for these. The old code didn't do that either.
-		url := strings.Replace(entry, gconf.Prefix, gconf.Host+":", 1)
-		repoChan <- repoCreateOrUpdateRequest{
-			RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
-				RepoName: api.RepoName(entry),
-				Enabled:  true,
-			},
-			URL: url,
-		}
-	}
-	return nil
-}
@@ -3,35 +3,29 @@ package repos
 import "github.com/prometheus/client_golang/prometheus"
 
 var (
-	githubUpdateTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	sourceSyncTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "src",
 		Subsystem: "repoupdater",
-		Name:      "time_last_github_sync",
-		Help:      "The last time a comprehensive GitHub sync finished",
-	}, []string{"id"})
-	gitlabUpdateTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "time_last_source_sync",
+		Help:      "The last time a comprehensive sync of a code host connection finished without errors",
+	}, []string{"source"})
+	sourceSyncErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: "src",
 		Subsystem: "repoupdater",
-		Name:      "time_last_gitlab_sync",
-		Help:      "The last time a comprehensive GitLab sync finished",
-	}, []string{"id"})
-	awsCodeCommitUpdateTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: "src",
-		Subsystem: "repoupdater",
-		Name:      "time_last_awscodecommit_sync",
-		Help:      "The last time a comprehensive AWS Code Commit sync finished",
-	}, []string{"id"})
+		Name:      "source_sync_errors",
+		Help:      "Incremented each time a sync of a code host connection fails to list its repositories.",
+	}, []string{"source"})
 	phabricatorUpdateTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "src",
 		Subsystem: "repoupdater",
 		Name:      "time_last_phabricator_sync",
 		Help:      "The last time a comprehensive Phabricator sync finished",
 	}, []string{"id"})
-	bitbucketServerUpdateTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	perforceUpdateTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "src",
 		Subsystem: "repoupdater",
-		Name:      "time_last_bitbucketserver_sync",
-		Help:      "The last time a comprehensive Bitbucket Server sync finished",
+		Name:      "time_last_perforce_sync",
+		Help:      "The last time a comprehensive Perforce sync finished",
 	}, []string{"id"})
 
 	// gitoliteUpdateTime is an ugly duckling as repo-updater just
@@ -111,11 +105,10 @@ var (
 )
 
 func init() {
-	prometheus.MustRegister(githubUpdateTime)
-	prometheus.MustRegister(gitlabUpdateTime)
-	prometheus.MustRegister(awsCodeCommitUpdateTime)
+	prometheus.MustRegister(sourceSyncTime)
+	prometheus.MustRegister(sourceSyncErrors)
 	prometheus.MustRegister(phabricatorUpdateTime)
-	prometheus.MustRegister(bitbucketServerUpdateTime)
+	prometheus.MustRegister(perforceUpdateTime)
 	prometheus.MustRegister(gitoliteUpdateTime)
 	prometheus.MustRegister(repoListUpdateTime)
 	prometheus.MustRegister(purgeSuccess)
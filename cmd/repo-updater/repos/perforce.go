@@ -0,0 +1,175 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log15 "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/atomicvalue"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+var (
+	// perforceRepos are the repositories of the depots found by the last sync
+	// of each Perforce connection, keyed by P4PORT.
+	perforceReposMu sync.Mutex
+	perforceRepos   = map[string]map[api.RepoName]*protocol.RepoInfo{}
+)
+
+var perforceConnections = atomicvalue.New()
+
+func init() {
+	conf.Watch(func() {
+		perforceConnections.Set(func() interface{} {
+			conns := make([]*perforceConnection, 0, len(conf.Get().Perforce))
+			configured := map[string]bool{}
+			for _, c := range conf.Get().Perforce {
+				configured[c.P4Port] = true
+				conn, err := newPerforceConnection(c)
+				if err != nil {
+					log15.Error("Error processing configured Perforce connection. Skipping it.", "p4.port", c.P4Port, "error", err)
+					continue
+				}
+				conns = append(conns, conn)
+			}
+
+			// Forget the depots of Perforce connections that were removed.
+			perforceReposMu.Lock()
+			for p4port := range perforceRepos {
+				if !configured[p4port] {
+					delete(perforceRepos, p4port)
+				}
+			}
+			perforceReposMu.Unlock()
+
+			return conns
+		})
+		perforceWorker.restart()
+	})
+}
+
+var perforceWorker = newSyncWorker(func() []Source {
+	conns := perforceConnections.Get().([]*perforceConnection)
+	sources := make([]Source, len(conns))
+	for i, c := range conns {
+		sources[i] = c
+	}
+	return sources
+})
+
+// RunPerforceRepositorySyncWorker runs the worker that syncs depots from
+// Perforce servers to Sourcegraph.
+func RunPerforceRepositorySyncWorker(ctx context.Context) {
+	perforceWorker.start(ctx)
+}
+
+// GetPerforceRepository returns information about the repository of a
+// Perforce depot that was found by the last sync.
+func GetPerforceRepository(ctx context.Context, args protocol.RepoLookupArgs) (repo *protocol.RepoInfo, authoritative bool, err error) {
+	perforceReposMu.Lock()
+	defer perforceReposMu.Unlock()
+	for _, repos := range perforceRepos {
+		if repo := repos[args.Repo]; repo != nil {
+			return repo, true, nil
+		}
+	}
+	return nil, false, nil // not found
+}
+
+type perforceConnection struct {
+	config  *schema.PerforceConnection
+	exclude excludeList
+}
+
+func newPerforceConnection(config *schema.PerforceConnection) (*perforceConnection, error) {
+	exclude, err := newExcludeList(config.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &perforceConnection{config: config, exclude: exclude}, nil
+}
+
+// Name implements Source.
+func (c *perforceConnection) Name() string {
+	return fmt.Sprintf("perforce:%s", c.config.P4Port)
+}
+
+// ListRepos implements Source.
+func (c *perforceConnection) ListRepos(ctx context.Context) ([]repoCreateOrUpdateRequest, error) {
+	depots, err := perforceDepots(ctx, c.config)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make(map[api.RepoName]*protocol.RepoInfo, len(depots))
+	for _, depot := range depots {
+		if c.exclude.excludes(strings.Trim(depot, "/"), false, false) {
+			continue
+		}
+		depot = "//" + strings.Trim(depot, "/") + "/"
+		repo := &protocol.RepoInfo{
+			Name: reposource.PerforceRepoName(c.config.RepositoryPathPattern, c.config.P4Port, depot),
+			ExternalRepo: &api.ExternalRepoSpec{
+				ID:          depot,
+				ServiceType: "perforce",
+				ServiceID:   c.config.P4Port,
+			},
+			VCS: protocol.VCSInfo{URL: reposource.PerforceCloneURL(c.config, depot)},
+		}
+		repos[repo.Name] = repo
+	}
+	perforceReposMu.Lock()
+	perforceRepos[c.config.P4Port] = repos
+	perforceReposMu.Unlock()
+
+	reqs := make([]repoCreateOrUpdateRequest, 0, len(repos))
+	for _, repo := range repos {
+		reqs = append(reqs, repoCreateOrUpdateRequest{
+			RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
+				RepoName:     repo.Name,
+				ExternalRepo: repo.ExternalRepo,
+				Enabled:      true,
+			},
+			URL: repo.VCS.URL,
+		})
+	}
+	perforceUpdateTime.WithLabelValues(c.config.P4Port).Set(float64(time.Now().Unix()))
+	return reqs, nil
+}
+
+// GetRepo implements Source. Depots are only known from the last sync of the connection, so a
+// depot that was not found by it is not reported as nonexistent (which would mark its repository
+// as deleted).
+func (c *perforceConnection) GetRepo(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.RepoInfo, error) {
+	if args.ExternalRepo != nil && args.ExternalRepo.ServiceType == "perforce" && c.exclude.excludes(strings.Trim(args.ExternalRepo.ID, "/"), false, false) {
+		return nil, errExcluded(args.Repo)
+	}
+
+	perforceReposMu.Lock()
+	defer perforceReposMu.Unlock()
+	for _, repo := range perforceRepos[c.config.P4Port] {
+		if repo.Name == args.Repo || (args.ExternalRepo != nil && *repo.ExternalRepo == *args.ExternalRepo) {
+			return repo, nil
+		}
+	}
+	return nil, fmt.Errorf("perforce depot of repository %s is unknown to connection %s", args.Repo, c.config.P4Port)
+}
+
+// perforceDepots returns the paths of the depots to mirror for a Perforce
+// connection.
+func perforceDepots(ctx context.Context, pconf *schema.PerforceConnection) ([]string, error) {
+	if len(pconf.Depots) > 0 {
+		return pconf.Depots, nil
+	}
+	// The p4 CLI is installed on gitserver, which mirrors the depots.
+	return gitserver.DefaultClient.ListPerforceDepots(ctx, pconf.P4Port)
+}
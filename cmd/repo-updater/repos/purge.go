@@ -15,7 +15,9 @@ import (
 )
 
 // RunRepositoryPurgeWorker is a worker which deletes repos which are present
-// on gitserver, but not enabled/present in our repos table.
+// on gitserver, but not enabled/present in our repos table. This includes
+// repos that were deleted on their code host (which are not listed as
+// enabled) and the old names of renamed repos whose clones couldn't be moved.
 func RunRepositoryPurgeWorker(ctx context.Context) {
 	log := log15.Root().New("worker", "repo-purge")
 
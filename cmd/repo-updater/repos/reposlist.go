@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
@@ -62,6 +63,10 @@ type repoData struct {
 	// URL is the git origin URL.
 	URL string
 
+	// CloneOptions are passed to gitserver with update requests. If nil,
+	// gitserver keeps the clone options it has.
+	CloneOptions *gitserverprotocol.CloneOptions
+
 	// Due is the next time this repo should be updated.
 	Due time.Time
 
@@ -185,9 +190,10 @@ type repoList struct {
 // a configuration source, such as information retrieved from GitHub for a
 // given GitHubConnection.
 type configuredRepo struct {
-	url     string
-	name    api.RepoName // only set and read by new scheduler. TODO(nick): Remove this comment when updateScheduler2 feature flag is disabled
-	enabled bool
+	url          string
+	name         api.RepoName // only set and read by new scheduler. TODO(nick): Remove this comment when updateScheduler2 feature flag is disabled
+	enabled      bool
+	cloneOptions *gitserverprotocol.CloneOptions // nil if the source doesn't configure clone options
 }
 
 // a sourceRepoList represents the set of repositories associated with a
@@ -429,6 +435,7 @@ func (r *repoList) doUpdate(ctx context.Context, repo *repoData) {
 	r.mu.Lock()
 	repoName := api.RepoName(repo.Name)
 	url := repo.URL
+	cloneOptions := repo.CloneOptions
 	interval := repo.UpdateInterval
 	manual := repo.UpdateSoon
 	autoUpdatesDisabled := r.autoUpdatesDisabled
@@ -456,7 +463,7 @@ func (r *repoList) doUpdate(ctx context.Context, repo *repoData) {
 		if manual {
 			interval = 5 * time.Second
 		}
-		resp, err = gitserver.DefaultClient.RequestRepoUpdate(ctx, gitserver.Repo{Name: repoName, URL: url}, interval)
+		resp, err = gitserver.DefaultClient.RequestRepoUpdate(ctx, gitserver.Repo{Name: repoName, URL: url, CloneOptions: cloneOptions}, interval)
 		if err != nil {
 			log15.Warn("error requesting repo update", "repo", repoName, "err", err)
 			return
@@ -700,7 +707,7 @@ func (r *repoList) updateSource(source string, newList sourceRepoList) (enqueued
 		switch {
 		case !ok:
 			log15.Info("adding repo", "source", source, "name", name, "url", value.url)
-		case value.url != old.url, value.enabled != old.enabled:
+		case value.url != old.url, value.enabled != old.enabled, !reflect.DeepEqual(value.cloneOptions, old.cloneOptions):
 			log15.Debug("updating repo", "source", source, "name", name, "url", value.url)
 		default:
 			// No change in whether or not it's enabled, no change in URL, we
@@ -711,6 +718,7 @@ func (r *repoList) updateSource(source string, newList sourceRepoList) (enqueued
 		if value.enabled {
 			enqueued++
 			r.queue(name, value.url)
+			r.repos[name].CloneOptions = value.cloneOptions
 		} else {
 			dequeued++
 			r.dequeue(name, value.url)
@@ -788,7 +796,7 @@ func updateConfig(ctx context.Context, configs []*schema.Repository) (sourceRepo
 		if cfg.Type == "" {
 			cfg.Type = "git"
 		}
-		if cfg.Type != "git" {
+		if cfg.Type != "git" && cfg.Type != "hg" {
 			continue
 		}
 		if cfg.Path == "" {
@@ -804,16 +812,25 @@ func updateConfig(ctx context.Context, configs []*schema.Repository) (sourceRepo
 		if newScheduler {
 			newMap[api.RepoName(cfg.Path)] = &configuredRepo{
 				name:    api.RepoName(cfg.Path),
-				url:     cfg.Url,
+				url:     reposListCloneURL(cfg),
 				enabled: newRepo.Enabled,
 			}
 			continue
 		}
-		newList[cfg.Path] = configuredRepo{url: cfg.Url, enabled: newRepo.Enabled}
+		newList[cfg.Path] = configuredRepo{url: reposListCloneURL(cfg), enabled: newRepo.Enabled}
 	}
 	return newList, newMap
 }
 
+// reposListCloneURL returns the URL that gitserver clones the repos.list
+// entry from. Gitserver mirrors Mercurial repositories from "hg+" URLs.
+func reposListCloneURL(repo *schema.Repository) string {
+	if repo.Type == "hg" && !strings.HasPrefix(repo.Url, "hg+") && !strings.HasPrefix(repo.Url, "hg::") {
+		return "hg+" + repo.Url
+	}
+	return repo.Url
+}
+
 // Snapshot represents the state of the various queues repo-updater
 // maintains. The fields are ordered by priority.
 type Snapshot struct {
@@ -901,7 +918,7 @@ func GetExplicitlyConfiguredRepository(ctx context.Context, args protocol.RepoLo
 			repoInfo := &protocol.RepoInfo{
 				Name:         api.RepoName(repo.Path),
 				ExternalRepo: nil,
-				VCS:          protocol.VCSInfo{URL: repo.Url},
+				VCS:          protocol.VCSInfo{URL: reposListCloneURL(repo)},
 			}
 			if repo.Links != nil {
 				repoInfo.Links = &protocol.RepoLinks{
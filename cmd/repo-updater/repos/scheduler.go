@@ -3,14 +3,17 @@ package repos
 import (
 	"container/heap"
 	"context"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
 	gitserverprotocol "github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/pkg/mutablelimiter"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
 	log15 "gopkg.in/inconshreveable/log15.v2"
 )
 
@@ -43,6 +46,9 @@ type updateScheduler struct {
 	// so we can compute which repos have been added/removed/enabled/disabled.
 	sourceRepos map[string]sourceRepoMap
 
+	// pausedSources is the set of sources whose repos are not in the schedule.
+	pausedSources map[string]bool
+
 	updateQueue *updateQueue
 	schedule    *schedule
 }
@@ -58,7 +64,8 @@ const notifyChanBuffer = 1
 // newUpdateScheduler returns a new scheduler.
 func newUpdateScheduler() *updateScheduler {
 	return &updateScheduler{
-		sourceRepos: make(map[string]sourceRepoMap),
+		sourceRepos:   make(map[string]sourceRepoMap),
+		pausedSources: make(map[string]bool),
 		updateQueue: &updateQueue{
 			index:         make(map[api.RepoName]*repoUpdate),
 			notifyEnqueue: make(chan struct{}, notifyChanBuffer),
@@ -139,6 +146,13 @@ func (s *updateScheduler) runUpdateLoop(ctx context.Context) {
 				if err != nil {
 					log15.Warn("error requesting repo update", "uri", repo.name, "err", err)
 				}
+				var lastError string
+				if err != nil {
+					lastError = err.Error()
+				} else if resp != nil {
+					lastError = resp.Error
+				}
+				s.schedule.setLastError(repo, lastError)
 				if resp != nil && resp.LastFetched != nil && resp.LastChanged != nil {
 					// This is the heuristic that is described in the updateScheduler documentation.
 					// Update that documentation if you update this logic.
@@ -152,7 +166,7 @@ func (s *updateScheduler) runUpdateLoop(ctx context.Context) {
 
 // requestRepoUpdate sends a request to gitserver to request an update.
 var requestRepoUpdate = func(ctx context.Context, repo *configuredRepo, since time.Duration) (*gitserverprotocol.RepoUpdateResponse, error) {
-	return gitserver.DefaultClient.RequestRepoUpdate(ctx, gitserver.Repo{Name: repo.name, URL: repo.url}, since)
+	return gitserver.DefaultClient.RequestRepoUpdate(ctx, gitserver.Repo{Name: repo.name, URL: repo.url, CloneOptions: repo.cloneOptions}, since)
 }
 
 // configuredLimiter returns a mutable limiter that is
@@ -179,6 +193,11 @@ func (s *updateScheduler) updateSource(source string, newList sourceRepoMap) {
 	if s.sourceRepos[source] == nil {
 		s.sourceRepos[source] = sourceRepoMap{}
 	}
+	if s.pausedSources[source] {
+		// The repos are added to the schedule when the source is resumed.
+		s.sourceRepos[source] = newList
+		return
+	}
 
 	// Remove repos that don't exist in the new list or are disabled in the new list.
 	oldList := s.sourceRepos[source]
@@ -211,12 +230,123 @@ func (s *updateScheduler) updateSource(source string, newList sourceRepoMap) {
 
 // UpdateOnce causes a single update of the given repository.
 // It neither adds nor removes the repo from the schedule.
+//
+// If the repo is known to a source, its clone options are used for the update,
+// and its URL is used if url is empty.
 func (s *updateScheduler) UpdateOnce(name api.RepoName, url string) {
+	s.enqueueOnce(name, url, priorityHigh)
+}
+
+// Bump moves the given repository to the front of the update queue,
+// ahead of repos enqueued by UpdateOnce.
+func (s *updateScheduler) Bump(name api.RepoName) {
+	s.enqueueOnce(name, "", priorityBump)
+}
+
+func (s *updateScheduler) enqueueOnce(name api.RepoName, url string, p priority) {
 	repo := &configuredRepo{
 		name: name,
 		url:  url,
 	}
-	s.updateQueue.enqueue(repo, priorityHigh)
+	s.mu.Lock()
+	for _, repos := range s.sourceRepos {
+		if known, ok := repos[name]; ok {
+			repo.cloneOptions = known.cloneOptions
+			if repo.url == "" {
+				repo.url = known.url
+			}
+			break
+		}
+	}
+	s.mu.Unlock()
+	s.updateQueue.enqueue(repo, p)
+}
+
+// SetSourcePaused pauses or resumes scheduled updates of the repos of the given source.
+// The repos of a paused source are removed from the schedule (and from the update queue,
+// unless they are already updating) until the source is resumed. UpdateOnce still updates them.
+func (s *updateScheduler) SetSourcePaused(source string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repos, ok := s.sourceRepos[source]
+	if !ok {
+		return errors.Errorf("unknown source %q", source)
+	}
+	if s.pausedSources[source] == paused {
+		return nil
+	}
+	if paused {
+		s.pausedSources[source] = true
+	} else {
+		delete(s.pausedSources, source)
+	}
+
+	for _, repo := range repos {
+		if !repo.enabled {
+			continue
+		}
+		if paused {
+			s.schedule.remove(repo)
+			s.updateQueue.remove(repo, false)
+		} else {
+			s.schedule.add(repo)
+		}
+	}
+	return nil
+}
+
+// Status returns the repos in the schedule or in the update queue and the sources of the scheduler.
+// Repos are ordered by their position in the update queue, followed by repos that are updating and
+// then the rest of the schedule in the order that repos are due.
+func (s *updateScheduler) Status() *protocol.SchedulerStatus {
+	var status protocol.SchedulerStatus
+
+	s.mu.Lock()
+	for source, repos := range s.sourceRepos {
+		status.Sources = append(status.Sources, &protocol.SchedulerSource{
+			Name:   source,
+			Repos:  len(repos),
+			Paused: s.pausedSources[source],
+		})
+	}
+	s.mu.Unlock()
+	sort.Slice(status.Sources, func(i, j int) bool { return status.Sources[i].Name < status.Sources[j].Name })
+
+	repos := make(map[api.RepoName]*protocol.ScheduledRepo)
+	repo := func(name api.RepoName) *protocol.ScheduledRepo {
+		r := repos[name]
+		if r == nil {
+			r = &protocol.ScheduledRepo{Name: name}
+			repos[name] = r
+			status.Repos = append(status.Repos, r)
+		}
+		return r
+	}
+
+	s.updateQueue.mu.Lock()
+	queue := append([]*repoUpdate(nil), s.updateQueue.heap...)
+	sort.Slice(queue, func(i, j int) bool { return queue[i].less(queue[j]) })
+	for i, update := range queue {
+		r := repo(update.repo.name)
+		r.Updating = update.updating
+		if !update.updating {
+			r.QueuePosition = i + 1
+		}
+	}
+	s.updateQueue.mu.Unlock()
+
+	s.schedule.mu.Lock()
+	schedule := append([]*scheduledRepoUpdate(nil), s.schedule.heap...)
+	sort.Slice(schedule, func(i, j int) bool { return schedule[i].due.Before(schedule[j].due) })
+	for _, update := range schedule {
+		r := repo(update.repo.name)
+		r.Due = update.due
+		r.Interval = update.interval
+		r.LastError = update.lastError
+	}
+	s.schedule.mu.Unlock()
+
+	return &status
 }
 
 // updateQueue is a priority queue of repos to update.
@@ -240,6 +370,7 @@ type priority int
 const (
 	priorityLow priority = iota
 	priorityHigh
+	priorityBump
 )
 
 // repoUpdate is a repository that has been queued for an update.
@@ -328,8 +459,11 @@ func (q *updateQueue) acquireNext() *configuredRepo {
 
 func (q *updateQueue) Len() int { return len(q.heap) }
 func (q *updateQueue) Less(i, j int) bool {
-	qi := q.heap[i]
-	qj := q.heap[j]
+	return q.heap[i].less(q.heap[j])
+}
+
+// less reports whether qi is updated before qj.
+func (qi *repoUpdate) less(qj *repoUpdate) bool {
 	if qi.updating != qj.updating {
 		// Repos that are already updating are sorted last.
 		return qj.updating
@@ -377,10 +511,11 @@ type schedule struct {
 
 // scheduledRepoUpdate is the update schedule for a single repo.
 type scheduledRepoUpdate struct {
-	repo     *configuredRepo // the repo to update
-	interval time.Duration   // how regularly the repo is updated
-	due      time.Time       // the next time that the repo will be enqueued for a update
-	index    int             // the index in the heap
+	repo      *configuredRepo // the repo to update
+	interval  time.Duration   // how regularly the repo is updated
+	due       time.Time       // the next time that the repo will be enqueued for a update
+	lastError string          // the error of the last update, if any
+	index     int             // the index in the heap
 }
 
 // add adds a repo to the schedule.
@@ -429,6 +564,16 @@ func (s *schedule) updateInterval(repo *configuredRepo, interval time.Duration)
 	s.mu.Unlock()
 }
 
+// setLastError records the error of the last update of a repo in the schedule.
+// It does nothing if the repo is not in the schedule.
+func (s *schedule) setLastError(repo *configuredRepo, lastError string) {
+	s.mu.Lock()
+	if update := s.index[repo.name]; update != nil {
+		update.lastError = lastError
+	}
+	s.mu.Unlock()
+}
+
 // remove removes a repo from the schedule.
 func (s *schedule) remove(repo *configuredRepo) {
 	s.mu.Lock()
@@ -11,6 +11,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	gitserverprotocol "github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/pkg/mutablelimiter"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
 )
 
 var defaultTime = time.Date(2000, 1, 1, 1, 1, 1, 1, time.UTC)
@@ -1587,4 +1588,141 @@ func TestUpdateScheduler_updateSource(t *testing.T) {
 	}
 }
 
+func TestUpdateScheduler_UpdateOnce(t *testing.T) {
+	cloneOptions := &gitserverprotocol.CloneOptions{Filter: "blob:none"}
+	known := &configuredRepo{name: "a", url: "a.com", enabled: true, cloneOptions: cloneOptions}
+
+	tests := []struct {
+		name         string
+		initialQueue []*repoUpdate
+		repo         api.RepoName
+		url          string
+		finalQueue   []*repoUpdate
+	}{
+		{
+			name:       "unknown repo",
+			repo:       "b",
+			url:        "b.com",
+			finalQueue: []*repoUpdate{{repo: &configuredRepo{name: "b", url: "b.com"}, priority: priorityHigh, seq: 1}},
+		},
+		{
+			name:       "known repo uses configured clone options",
+			repo:       "a",
+			url:        "aa.com",
+			finalQueue: []*repoUpdate{{repo: &configuredRepo{name: "a", url: "aa.com", cloneOptions: cloneOptions}, priority: priorityHigh, seq: 1}},
+		},
+		{
+			name:       "known repo without url",
+			repo:       "a",
+			finalQueue: []*repoUpdate{{repo: &configuredRepo{name: "a", url: "a.com", cloneOptions: cloneOptions}, priority: priorityHigh, seq: 1}},
+		},
+		{
+			name:         "bumps priority of queued repo",
+			initialQueue: []*repoUpdate{{repo: known, priority: priorityLow, seq: 1}},
+			repo:         "a",
+			url:          "a.com",
+			finalQueue:   []*repoUpdate{{repo: known, priority: priorityHigh, seq: 2}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, stop := startRecording()
+			defer stop()
+
+			s := newUpdateScheduler()
+			s.sourceRepos["a"] = sourceRepoMap{"a": known}
+			setupInitialQueue(s, test.initialQueue)
+
+			s.UpdateOnce(test.repo, test.url)
+
+			verifyQueue(t, s, test.finalQueue)
+		})
+	}
+}
+
+func TestUpdateScheduler_SetSourcePaused(t *testing.T) {
+	a := &configuredRepo{name: "a", url: "a.com", enabled: true}
+	b := &configuredRepo{name: "b", url: "b.com", enabled: true}
+	c := &configuredRepo{name: "c", url: "c.com", enabled: false}
+
+	_, stop := startRecording()
+	defer stop()
+
+	s := newUpdateScheduler()
+	s.updateSource("s", sourceRepoMap{"a": a, "c": c})
+	s.updateSource("t", sourceRepoMap{"b": b})
+
+	if err := s.SetSourcePaused("unknown", true); err == nil {
+		t.Error("expected error pausing unknown source")
+	}
+
+	if err := s.SetSourcePaused("s", true); err != nil {
+		t.Fatal(err)
+	}
+	if s.schedule.index["a"] != nil || s.updateQueue.index["a"] != nil {
+		t.Error("expected paused repo a to be removed from the schedule and queue")
+	}
+	if s.schedule.index["b"] == nil {
+		t.Error("expected repo b of another source to remain in the schedule")
+	}
+
+	// Updates of a paused source don't schedule its repos.
+	d := &configuredRepo{name: "d", url: "d.com", enabled: true}
+	s.updateSource("s", sourceRepoMap{"a": a, "d": d})
+	if s.schedule.index["d"] != nil {
+		t.Error("expected new repo d of paused source not to be scheduled")
+	}
+
+	if err := s.SetSourcePaused("s", false); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []api.RepoName{"a", "b", "d"} {
+		if s.schedule.index[name] == nil {
+			t.Errorf("expected repo %s to be scheduled after resuming", name)
+		}
+	}
+}
+
+func TestUpdateScheduler_Status(t *testing.T) {
+	a := &configuredRepo{name: "a", url: "a.com", enabled: true}
+	b := &configuredRepo{name: "b", url: "b.com", enabled: true}
+	c := &configuredRepo{name: "c", url: "c.com", enabled: true}
+	d := &configuredRepo{name: "d", url: "d.com", enabled: true}
+
+	_, stop := startRecording()
+	defer stop()
+
+	s := newUpdateScheduler()
+	s.sourceRepos["s"] = sourceRepoMap{"a": a, "b": b, "c": c}
+	s.pausedSources["t"] = true
+	s.sourceRepos["t"] = sourceRepoMap{"d": d}
+	setupInitialSchedule(s, []*scheduledRepoUpdate{
+		{repo: a, interval: time.Hour, due: defaultTime.Add(time.Hour), lastError: "boom"},
+		{repo: b, interval: time.Minute, due: defaultTime.Add(time.Minute)},
+		{repo: c, interval: minDelay, due: defaultTime.Add(minDelay)},
+	})
+	setupInitialQueue(s, []*repoUpdate{
+		{repo: c, updating: true},
+		{repo: a, priority: priorityLow},
+	})
+	s.Bump("e")
+
+	expected := &protocol.SchedulerStatus{
+		Repos: []*protocol.ScheduledRepo{
+			{Name: "e", QueuePosition: 1},
+			{Name: "a", QueuePosition: 2, Due: defaultTime.Add(time.Hour), Interval: time.Hour, LastError: "boom"},
+			{Name: "c", Updating: true, Due: defaultTime.Add(minDelay), Interval: minDelay},
+			{Name: "b", Due: defaultTime.Add(time.Minute), Interval: time.Minute},
+		},
+		Sources: []*protocol.SchedulerSource{
+			{Name: "s", Repos: 3},
+			{Name: "t", Repos: 1, Paused: true},
+		},
+	}
+	if status := s.Status(); !reflect.DeepEqual(expected, status) {
+		t.Fatalf("\nexpected status\n%s\ngot\n%s", spew.Sdump(expected), spew.Sdump(status))
+	}
+}
+
 // TODO: update enabled state and url once in the queue?
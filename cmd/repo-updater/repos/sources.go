@@ -0,0 +1,66 @@
+package repos
+
+import (
+	"context"
+	"sync"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/awscodecommit"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+)
+
+// A Source is a code host connection in site configuration. The repositories of all sources are
+// synced by the same Syncer, so that they are added, renamed and deleted the same way regardless of
+// the code host. Only repositories with external repository specs (api.ExternalRepoSpec) can be
+// detected as renamed or deleted.
+type Source interface {
+	// Name identifies the connection. It is the source under which the connection's repositories
+	// are scheduled for updates. Site admins see it, so it must not contain secrets.
+	Name() string
+
+	// ListRepos returns the repositories of the connection, with the Git remote URLs that
+	// gitserver should clone them from (including credentials, if needed). If not all
+	// repositories could be listed, it returns the ones that could be and an error.
+	ListRepos(ctx context.Context) ([]repoCreateOrUpdateRequest, error)
+
+	// GetRepo looks up a repository of the connection by args.ExternalRepo or, if the external
+	// repository spec is not set (or not usable), by args.Repo. The repository's VCS.URL is the Git
+	// remote URL that gitserver should clone it from.
+	GetRepo(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.RepoInfo, error)
+}
+
+// listErrors collects the errors that occur while listing the repositories of a connection,
+// which may be listed by several goroutines.
+type listErrors struct {
+	mu  sync.Mutex
+	err *multierror.Error
+}
+
+func (e *listErrors) add(err error) {
+	e.mu.Lock()
+	e.err = multierror.Append(e.err, err)
+	e.mu.Unlock()
+}
+
+// errorOrNil returns an error that describes all collected errors, or nil if there were none.
+func (e *listErrors) errorOrNil() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err.ErrorOrNil()
+}
+
+// isRepoNotFound reports whether err, returned by (Source).GetRepo, means that the code host
+// reported that the repository does not exist (or is not visible to the connection's credentials).
+// Errors of repositories that are only not found by Sourcegraph (because they are excluded, or
+// couldn't be checked) are not.
+func isRepoNotFound(err error) bool {
+	switch errors.Cause(err).(type) {
+	case *excludedError, *notCloneableError:
+		return false
+	}
+	return github.IsNotFound(err) || gitlab.IsNotFound(err) || awscodecommit.IsNotFound(err) || errcode.IsNotFound(err)
+}
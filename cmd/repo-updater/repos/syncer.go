@@ -0,0 +1,269 @@
+package repos
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// Syncer syncs the repositories of all sources.
+var Syncer = newSyncer()
+
+// syncer syncs the repositories of sources (code host connections) to the repository table and the
+// repository update scheduler, and keeps the result of each source's last sync.
+//
+// A sync lists the repositories of a source and diffs them against the repositories in the
+// database with the same external service (by their external repository IDs), which tells which
+// repositories were added and which were renamed on the code host. Repositories that a source
+// listed in its previous sync but doesn't list anymore are looked up on the code host, which tells
+// which were deleted. Renamed repositories are renamed in the database when they are updated (by
+// their external repository spec), and deleted repositories are marked as deleted.
+type syncer struct {
+	mu sync.Mutex
+
+	// listed is the set of repositories that each source listed in its last sync that listed all
+	// of its repositories without an error.
+	listed map[string]map[api.ExternalRepoSpec]api.RepoName
+
+	// results is the result of the last sync of each source.
+	results map[string]*SyncResult
+}
+
+// SyncResult is the result of syncing the repositories of a source.
+type SyncResult struct {
+	Source string
+	Time   time.Time // when the sync finished
+
+	Repos   int            // number of repositories listed
+	Added   []api.RepoName // repositories that were not yet in the database
+	Renamed []RepoRename   // repositories whose name on the code host changed
+	Deleted []api.RepoName // repositories that no longer exist on the code host
+
+	// Err is the error that occurred while listing the repositories, if any. Repositories are not
+	// checked for deletion when listing them failed.
+	Err error
+}
+
+// RepoRename is a repository that was renamed on its code host.
+type RepoRename struct {
+	From, To api.RepoName
+}
+
+func newSyncer() *syncer {
+	return &syncer{
+		listed:  make(map[string]map[api.ExternalRepoSpec]api.RepoName),
+		results: make(map[string]*SyncResult),
+	}
+}
+
+var (
+	// listExternalServiceRepos lists the repositories in the database with the given external
+	// service. It is mocked by tests.
+	listExternalServiceRepos = func(ctx context.Context, serviceType, serviceID string) ([]*api.Repo, error) {
+		return api.InternalClient.ReposListByExternalService(ctx, serviceType, serviceID)
+	}
+
+	// updateSourceRepos creates or updates the repositories of a source and replaces the
+	// source's repositories in the update scheduler. It is mocked by tests.
+	updateSourceRepos = func(ctx context.Context, source string, repos []repoCreateOrUpdateRequest) {
+		repoChan := make(chan repoCreateOrUpdateRequest)
+		done := make(chan struct{})
+		go func() {
+			createEnableUpdateRepos(ctx, source, repoChan)
+			close(done)
+		}()
+		for _, repo := range repos {
+			repoChan <- repo
+		}
+		close(repoChan)
+		<-done
+	}
+
+	// markRepoDeleted marks a repository that was deleted on its code host as deleted in the
+	// database. It is mocked by tests.
+	markRepoDeleted = func(ctx context.Context, repo api.RepoName) error {
+		return api.InternalClient.ReposMarkDeleted(ctx, repo)
+	}
+)
+
+// Sync syncs the repositories of src and records (and returns) the result.
+func (s *syncer) Sync(ctx context.Context, src Source) *SyncResult {
+	name := src.Name()
+	result := &SyncResult{Source: name}
+
+	repos, err := src.ListRepos(ctx)
+	result.Repos = len(repos)
+	result.Err = err
+
+	listed := make(map[api.ExternalRepoSpec]api.RepoName, len(repos))
+	for _, repo := range repos {
+		if repo.ExternalRepo != nil {
+			listed[*repo.ExternalRepo] = repo.RepoName
+		}
+	}
+
+	if err := s.diffWithDB(ctx, repos, result); err != nil {
+		log15.Warn("Unable to diff repositories of source with the database.", "source", name, "error", err)
+	}
+
+	s.mu.Lock()
+	previous, ok := s.listed[name]
+	if result.Err == nil {
+		s.listed[name] = listed
+	}
+	s.mu.Unlock()
+	if ok && result.Err == nil {
+		result.Deleted = deletedRepos(ctx, src, previous, listed)
+		for _, repo := range result.Deleted {
+			if err := markRepoDeleted(ctx, repo); err != nil {
+				log15.Warn("Unable to mark repository as deleted.", "source", name, "repo", repo, "error", err)
+			}
+		}
+	}
+
+	if result.Err == nil || len(repos) > 0 {
+		// Don't unschedule all of the source's repositories when the code host is unreachable.
+		updateSourceRepos(ctx, name, repos)
+	}
+	result.Time = timeNow()
+
+	s.mu.Lock()
+	s.results[name] = result
+	s.mu.Unlock()
+
+	if result.Err != nil {
+		log15.Error("Error listing repositories of source.", "source", name, "error", result.Err)
+		sourceSyncErrors.WithLabelValues(name).Inc()
+	} else {
+		sourceSyncTime.WithLabelValues(name).Set(float64(result.Time.Unix()))
+	}
+	for _, r := range result.Renamed {
+		log15.Info("Repository was renamed on its code host.", "source", name, "from", r.From, "to", r.To)
+	}
+	for _, r := range result.Deleted {
+		log15.Info("Repository was deleted on its code host.", "source", name, "repo", r)
+	}
+	return result
+}
+
+// diffWithDB sets the added and renamed repositories of result by looking up the listed repos in
+// the database by their external repository specs.
+func (s *syncer) diffWithDB(ctx context.Context, repos []repoCreateOrUpdateRequest, result *SyncResult) error {
+	type service struct{ serviceType, serviceID string }
+	dbNames := make(map[service]map[string][]api.RepoName) // external ID -> names
+	for _, repo := range repos {
+		spec := repo.ExternalRepo
+		if spec == nil {
+			continue
+		}
+		svc := service{spec.ServiceType, spec.ServiceID}
+		names, ok := dbNames[svc]
+		if !ok {
+			dbRepos, err := listExternalServiceRepos(ctx, svc.serviceType, svc.serviceID)
+			if err != nil {
+				return err
+			}
+			names = make(map[string][]api.RepoName, len(dbRepos))
+			for _, r := range dbRepos {
+				names[r.ExternalRepo.ID] = append(names[r.ExternalRepo.ID], r.Name)
+			}
+			dbNames[svc] = names
+		}
+
+		switch existing := names[spec.ID]; {
+		case len(existing) == 0:
+			result.Added = append(result.Added, repo.RepoName)
+		case !containsRepoName(existing, repo.RepoName):
+			result.Renamed = append(result.Renamed, RepoRename{From: existing[0], To: repo.RepoName})
+		}
+	}
+	return nil
+}
+
+// deletedRepos returns the repositories that were previously listed by src but are no longer
+// listed and no longer exist on the code host. Repositories that still exist (e.g., because the
+// connection's repository query changed) are not deleted.
+func deletedRepos(ctx context.Context, src Source, previous, listed map[api.ExternalRepoSpec]api.RepoName) []api.RepoName {
+	var deleted []api.RepoName
+	for spec, name := range previous {
+		if _, ok := listed[spec]; ok {
+			continue
+		}
+		spec := spec
+		_, err := src.GetRepo(ctx, protocol.RepoLookupArgs{Repo: name, ExternalRepo: &spec})
+		if err != nil && isRepoNotFound(err) {
+			deleted = append(deleted, name)
+		}
+	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i] < deleted[j] })
+	return deleted
+}
+
+func containsRepoName(names []api.RepoName, name api.RepoName) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Results returns the result of the last sync of each source, ordered by source name.
+func (s *syncer) Results() []*SyncResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	results := make([]*SyncResult, 0, len(s.results))
+	for _, r := range s.results {
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Source < results[j].Source })
+	return results
+}
+
+// AddToStatus sets the last sync time and error of the sources in the scheduler status from the
+// results of their last syncs. Sources that have been synced but are not in the status (e.g.,
+// because they have no repositories) are added to it.
+func (s *syncer) AddToStatus(status *protocol.SchedulerStatus) {
+	byName := make(map[string]*protocol.SchedulerSource, len(status.Sources))
+	for _, src := range status.Sources {
+		byName[src.Name] = src
+	}
+	for _, result := range s.Results() {
+		src, ok := byName[result.Source]
+		if !ok {
+			src = &protocol.SchedulerSource{Name: result.Source}
+			status.Sources = append(status.Sources, src)
+		}
+		src.LastSync = result.Time
+		if result.Err != nil {
+			src.LastSyncError = result.Err.Error()
+		}
+	}
+	sort.Slice(status.Sources, func(i, j int) bool { return status.Sources[i].Name < status.Sources[j].Name })
+}
+
+// newSyncWorker returns a worker that syncs each of the sources returned by sources (typically,
+// the connections of one kind of code host) once per update interval.
+func newSyncWorker(sources func() []Source) *worker {
+	return &worker{
+		work: func(ctx context.Context, shutdown chan struct{}) {
+			for _, src := range sources() {
+				go func(src Source) {
+					for {
+						Syncer.Sync(ctx, src)
+						select {
+						case <-shutdown:
+							return
+						case <-time.After(getUpdateInterval()):
+						}
+					}
+				}(src)
+			}
+		},
+	}
+}
@@ -0,0 +1,166 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+)
+
+type fakeSource struct {
+	name   string
+	repos  []repoCreateOrUpdateRequest
+	err    error
+	exists map[api.RepoName]bool  // repositories that GetRepo finds
+	errs   map[api.RepoName]error // errors that GetRepo returns instead of "not found"
+}
+
+func (s *fakeSource) Name() string { return s.name }
+
+func (s *fakeSource) ListRepos(ctx context.Context) ([]repoCreateOrUpdateRequest, error) {
+	return s.repos, s.err
+}
+
+func (s *fakeSource) GetRepo(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.RepoInfo, error) {
+	if err := s.errs[args.Repo]; err != nil {
+		return nil, err
+	}
+	if !s.exists[args.Repo] {
+		return nil, &errcode.Mock{Message: "not found", IsNotFound: true}
+	}
+	return &protocol.RepoInfo{Name: args.Repo, ExternalRepo: args.ExternalRepo}, nil
+}
+
+func sourceRepo(name api.RepoName, id string) repoCreateOrUpdateRequest {
+	return repoCreateOrUpdateRequest{
+		RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
+			RepoName:     name,
+			ExternalRepo: &api.ExternalRepoSpec{ID: id, ServiceType: "github", ServiceID: "https://github.com/"},
+		},
+		URL: "https://" + string(name),
+	}
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	dbRepos := []*api.Repo{
+		{Name: "github.com/a/a", ExternalRepo: &api.ExternalRepoSpec{ID: "1", ServiceType: "github", ServiceID: "https://github.com/"}},
+		{Name: "github.com/a/old", ExternalRepo: &api.ExternalRepoSpec{ID: "2", ServiceType: "github", ServiceID: "https://github.com/"}},
+		{Name: "github.com/a/gone", ExternalRepo: &api.ExternalRepoSpec{ID: "3", ServiceType: "github", ServiceID: "https://github.com/"}},
+		{Name: "github.com/a/unlisted", ExternalRepo: &api.ExternalRepoSpec{ID: "4", ServiceType: "github", ServiceID: "https://github.com/"}},
+	}
+	origList, origUpdate, origMarkDeleted := listExternalServiceRepos, updateSourceRepos, markRepoDeleted
+	defer func() {
+		listExternalServiceRepos, updateSourceRepos, markRepoDeleted = origList, origUpdate, origMarkDeleted
+	}()
+	listExternalServiceRepos = func(ctx context.Context, serviceType, serviceID string) ([]*api.Repo, error) {
+		if serviceType != "github" || serviceID != "https://github.com/" {
+			t.Errorf("got external service %s %s, want github https://github.com/", serviceType, serviceID)
+		}
+		return dbRepos, nil
+	}
+	var updated map[string][]api.RepoName
+	updateSourceRepos = func(ctx context.Context, source string, repos []repoCreateOrUpdateRequest) {
+		var names []api.RepoName
+		for _, repo := range repos {
+			names = append(names, repo.RepoName)
+		}
+		updated[source] = names
+	}
+	var markedDeleted []api.RepoName
+	markRepoDeleted = func(ctx context.Context, repo api.RepoName) error {
+		markedDeleted = append(markedDeleted, repo)
+		return nil
+	}
+
+	s := newSyncer()
+	src := &fakeSource{
+		name: "github:https://github.com#1a2b3c4d",
+		repos: []repoCreateOrUpdateRequest{
+			sourceRepo("github.com/a/a", "1"),
+			sourceRepo("github.com/a/old", "2"),
+			sourceRepo("github.com/a/gone", "3"),
+			sourceRepo("github.com/a/unlisted", "4"),
+		},
+		exists: map[api.RepoName]bool{"github.com/a/unlisted": true},
+	}
+
+	// The first sync only finds repositories that are not yet in the database.
+	updated = map[string][]api.RepoName{}
+	result := s.Sync(context.Background(), src)
+	if result.Repos != 4 || result.Added != nil || result.Renamed != nil || result.Deleted != nil || result.Err != nil {
+		t.Errorf("unexpected result of first sync: %+v", result)
+	}
+	if got, want := updated[src.name], []api.RepoName{"github.com/a/a", "github.com/a/old", "github.com/a/gone", "github.com/a/unlisted"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got updated repos %v, want %v", got, want)
+	}
+
+	// A renamed repository keeps its external ID, a deleted one is not listed anymore and can't be
+	// found, and one that is only no longer listed (e.g., because the repository query changed)
+	// still exists.
+	src.repos = []repoCreateOrUpdateRequest{
+		sourceRepo("github.com/a/a", "1"),
+		sourceRepo("github.com/a/new", "2"),
+		sourceRepo("github.com/a/added", "5"),
+	}
+	updated = map[string][]api.RepoName{}
+	result = s.Sync(context.Background(), src)
+	if want := []api.RepoName{"github.com/a/added"}; !reflect.DeepEqual(result.Added, want) {
+		t.Errorf("got added %v, want %v", result.Added, want)
+	}
+	if want := []RepoRename{{From: "github.com/a/old", To: "github.com/a/new"}}; !reflect.DeepEqual(result.Renamed, want) {
+		t.Errorf("got renamed %v, want %v", result.Renamed, want)
+	}
+	if want := []api.RepoName{"github.com/a/gone"}; !reflect.DeepEqual(result.Deleted, want) {
+		t.Errorf("got deleted %v, want %v", result.Deleted, want)
+	}
+	if want := []api.RepoName{"github.com/a/gone"}; !reflect.DeepEqual(markedDeleted, want) {
+		t.Errorf("got repos marked as deleted %v, want %v", markedDeleted, want)
+	}
+
+	// When listing fails, repositories are not checked for deletion, and the source's repositories
+	// stay scheduled if none could be listed.
+	src.repos, src.err = nil, errors.New("rate limited")
+	updated = map[string][]api.RepoName{}
+	result = s.Sync(context.Background(), src)
+	if result.Err == nil || result.Deleted != nil {
+		t.Errorf("unexpected result of failed sync: %+v", result)
+	}
+	if _, ok := updated[src.name]; ok {
+		t.Error("source's repositories were updated although none could be listed")
+	}
+
+	status := &protocol.SchedulerStatus{}
+	s.AddToStatus(status)
+	if len(status.Sources) != 1 || status.Sources[0].Name != src.name || status.Sources[0].LastSyncError != "rate limited" || status.Sources[0].LastSync.IsZero() {
+		t.Errorf("unexpected sources in status: %+v", status.Sources)
+	}
+}
+
+func TestDeletedRepos(t *testing.T) {
+	src := &fakeSource{
+		errs: map[api.RepoName]error{
+			"github.com/a/excluded":     errExcluded("github.com/a/excluded"),
+			"github.com/a/notcloneable": &notCloneableError{repo: "github.com/a/notcloneable", err: errors.New("timeout")},
+			"github.com/a/unavailable":  errors.New("rate limited"),
+		},
+	}
+	previous := map[api.ExternalRepoSpec]api.RepoName{}
+	for _, repo := range []repoCreateOrUpdateRequest{
+		sourceRepo("github.com/a/gone", "1"),
+		sourceRepo("github.com/a/excluded", "2"),
+		sourceRepo("github.com/a/notcloneable", "3"),
+		sourceRepo("github.com/a/unavailable", "4"),
+	} {
+		previous[*repo.ExternalRepo] = repo.RepoName
+	}
+
+	// Only repositories that the code host reports as nonexistent are deleted.
+	deleted := deletedRepos(context.Background(), src, previous, nil)
+	if want := []api.RepoName{"github.com/a/gone"}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("got deleted %v, want %v", deleted, want)
+	}
+}
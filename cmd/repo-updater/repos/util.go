@@ -2,18 +2,23 @@ package repos
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/gregjones/httpcache"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/pkg/httputil"
+	"github.com/sourcegraph/sourcegraph/schema"
 	log15 "gopkg.in/inconshreveable/log15.v2"
 )
 
@@ -57,7 +62,59 @@ func cachedTransportWithCertTrusted(cert string) (http.RoundTripper, error) {
 // plus a specific URL we'd like to use for it.
 type repoCreateOrUpdateRequest struct {
 	api.RepoCreateOrUpdateRequest
-	URL string // the repository's Git remote URL
+	URL          string                          // the repository's Git remote URL
+	CloneOptions *gitserverprotocol.CloneOptions // how gitserver clones the repository (nil means unchanged)
+}
+
+// A cloneOptionsList is the "gitCloneOptions" configuration of a code host
+// connection, with its repos patterns compiled.
+type cloneOptionsList []cloneOptionsRule
+
+type cloneOptionsRule struct {
+	repos *regexp.Regexp
+	opts  gitserverprotocol.CloneOptions
+}
+
+// newCloneOptionsList returns the clone options list of the given
+// configuration.
+func newCloneOptionsList(configs []*schema.GitCloneOptions) (cloneOptionsList, error) {
+	l := make(cloneOptionsList, 0, len(configs))
+	for _, c := range configs {
+		repos, err := regexp.Compile(c.Repos)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid gitCloneOptions repos pattern %q", c.Repos)
+		}
+		l = append(l, cloneOptionsRule{
+			repos: repos,
+			opts: gitserverprotocol.CloneOptions{
+				Filter:      c.Filter,
+				Refs:        c.Refs,
+				ExcludeRefs: c.ExcludeRefs,
+			},
+		})
+	}
+	return l, nil
+}
+
+// get returns the clone options of the first rule whose repos pattern matches
+// name. If none match, it returns empty options, so that gitserver clones the
+// repository in full.
+func (l cloneOptionsList) get(name api.RepoName) *gitserverprotocol.CloneOptions {
+	for _, r := range l {
+		if r.repos.MatchString(string(name)) {
+			opts := r.opts
+			return &opts
+		}
+	}
+	return &gitserverprotocol.CloneOptions{}
+}
+
+// connectionSource returns the createEnableUpdateRepos source of a code host
+// connection whose repositories depend on a secret (such as an access token).
+// Source names are shown to site admins, so the secret is hashed.
+func connectionSource(kind, url, secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return fmt.Sprintf("%s:%s#%x", kind, url, h[:4])
 }
 
 // createEnableUpdateRepos receives requests on the provided channel. The
@@ -88,14 +145,15 @@ func createEnableUpdateRepos(ctx context.Context, source string, repoChan <-chan
 
 		if newScheduler {
 			newMap[createdRepo.Name] = &configuredRepo{
-				name:    createdRepo.Name,
-				url:     op.URL,
-				enabled: createdRepo.Enabled,
+				name:         createdRepo.Name,
+				url:          op.URL,
+				enabled:      createdRepo.Enabled,
+				cloneOptions: op.CloneOptions,
 			}
 			return
 		}
 
-		newList[string(createdRepo.Name)] = configuredRepo{url: op.URL, enabled: createdRepo.Enabled}
+		newList[string(createdRepo.Name)] = configuredRepo{url: op.URL, enabled: createdRepo.Enabled, cloneOptions: op.CloneOptions}
 	}
 	for repo := range repoChan {
 		do(repo)
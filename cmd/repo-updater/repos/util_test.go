@@ -1,6 +1,13 @@
 package repos
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
 
 func TestSetUserinfoBestEffort(t *testing.T) {
 	cases := []struct {
@@ -41,3 +48,31 @@ func TestSetUserinfoBestEffort(t *testing.T) {
 		}
 	}
 }
+
+func TestCloneOptionsList(t *testing.T) {
+	l, err := newCloneOptionsList([]*schema.GitCloneOptions{
+		{Repos: `^github\.com/foo/monorepo$`, Filter: "blob:none", ExcludeRefs: []string{"refs/pull/*"}},
+		{Repos: `^github\.com/foo/`, Refs: []string{"refs/heads/*"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name api.RepoName
+		want *gitserverprotocol.CloneOptions
+	}{
+		{"github.com/foo/monorepo", &gitserverprotocol.CloneOptions{Filter: "blob:none", ExcludeRefs: []string{"refs/pull/*"}}},
+		{"github.com/foo/bar", &gitserverprotocol.CloneOptions{Refs: []string{"refs/heads/*"}}},
+		{"github.com/baz/bar", &gitserverprotocol.CloneOptions{}},
+	}
+	for _, c := range cases {
+		got := l.get(c.name)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("get(%q): got %+v want %+v", c.name, got, c.want)
+		}
+	}
+
+	if _, err := newCloneOptionsList([]*schema.GitCloneOptions{{Repos: "[", Filter: "blob:none"}}); err == nil {
+		t.Error("expected error for invalid repos pattern")
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc/github"
@@ -27,6 +28,9 @@ func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/repo-lookup", s.handleRepoLookup)
 	mux.HandleFunc("/enqueue-repo-update", s.handleEnqueueRepoUpdate)
+	mux.HandleFunc("/scheduler-status", s.handleSchedulerStatus)
+	mux.HandleFunc("/scheduler-bump-repo", s.handleSchedulerBumpRepo)
+	mux.HandleFunc("/scheduler-pause-source", s.handleSchedulerPauseSource)
 	return mux
 }
 
@@ -63,9 +67,59 @@ func (s *Server) handleEnqueueRepoUpdate(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if conf.UpdateScheduler2Enabled() {
+		repos.Scheduler.UpdateOnce(req.Repo, req.URL)
+		return
+	}
 	repos.UpdateOnce(r.Context(), req.Repo, req.URL)
 }
 
+var errSchedulerDisabled = errors.New("the update scheduler is not enabled (set experimentalFeatures.updateScheduler2 to \"enabled\" in site configuration)")
+
+func (s *Server) handleSchedulerStatus(w http.ResponseWriter, r *http.Request) {
+	if !conf.UpdateScheduler2Enabled() {
+		http.Error(w, errSchedulerDisabled.Error(), http.StatusNotFound)
+		return
+	}
+
+	status := repos.Scheduler.Status()
+	repos.Syncer.AddToStatus(status)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleSchedulerBumpRepo(w http.ResponseWriter, r *http.Request) {
+	if !conf.UpdateScheduler2Enabled() {
+		http.Error(w, errSchedulerDisabled.Error(), http.StatusNotFound)
+		return
+	}
+
+	var req protocol.RepoUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	repos.Scheduler.Bump(req.Repo)
+}
+
+func (s *Server) handleSchedulerPauseSource(w http.ResponseWriter, r *http.Request) {
+	if !conf.UpdateScheduler2Enabled() {
+		http.Error(w, errSchedulerDisabled.Error(), http.StatusNotFound)
+		return
+	}
+
+	var req protocol.SchedulerPauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := repos.Scheduler.SetSourcePaused(req.Source, req.Paused); err != nil {
+		// Not http.StatusNotFound, which means that the scheduler is disabled.
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
 var mockRepoLookup func(protocol.RepoLookupArgs) (*protocol.RepoLookupResult, error)
 
 func repoLookup(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.RepoLookupResult, error) {
@@ -87,6 +141,9 @@ func repoLookup(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.Re
 	if !authoritative {
 		repo, authoritative, err = repos.GetBitbucketServerRepository(ctx, args)
 	}
+	if !authoritative {
+		repo, authoritative, err = repos.GetGiteaRepository(ctx, args)
+	}
 	if !authoritative {
 		repo, authoritative, err = repos.GetAWSCodeCommitRepository(ctx, args)
 	}
@@ -96,6 +153,9 @@ func repoLookup(ctx context.Context, args protocol.RepoLookupArgs) (*protocol.Re
 	if !authoritative {
 		repo, authoritative, err = repos.GetGitoliteRepository(ctx, args)
 	}
+	if !authoritative {
+		repo, authoritative, err = repos.GetPerforceRepository(ctx, args)
+	}
 	if authoritative {
 		if isNotFound(err) {
 			result.ErrorNotFound = true
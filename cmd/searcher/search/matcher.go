@@ -86,6 +86,10 @@ type readerGrep struct {
 	// re. It is the output of the longestLiteral function. It is only set if
 	// the regex has an empty LiteralPrefix.
 	literalSubstring []byte
+
+	// structural is the structural pattern to match instead of re, or nil
+	// if the pattern is not structural.
+	structural *structuralPattern
 }
 
 // compile returns a readerGrep for matching p.
@@ -93,8 +97,16 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 	var (
 		re               *regexp.Regexp
 		literalSubstring []byte
+		structural       *structuralPattern
 	)
-	if p.Pattern != "" {
+	if p.IsStructuralPat && p.Pattern != "" {
+		var err error
+		structural, err = compileStructural(p.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		literalSubstring = structural.literal
+	} else if p.Pattern != "" {
 		expr := p.Pattern
 		if !p.IsRegExp {
 			expr = regexp.QuoteMeta(expr)
@@ -148,9 +160,10 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 
 	return &readerGrep{
 		re:               re,
-		ignoreCase:       !p.IsCaseSensitive,
+		ignoreCase:       !p.IsCaseSensitive && structural == nil, // structural patterns are case sensitive
 		matchPath:        matchPath,
 		literalSubstring: literalSubstring,
+		structural:       structural,
 	}, nil
 }
 
@@ -166,12 +179,16 @@ func (rg *readerGrep) Copy() *readerGrep {
 		ignoreCase:       rg.ignoreCase,
 		matchPath:        rg.matchPath.Copy(),
 		literalSubstring: rg.literalSubstring,
+		structural:       rg.structural,
 	}
 }
 
 // matchString returns whether rg's regexp pattern matches s. It is intended to be
-// used to match file paths.
+// used to match file paths. Structural patterns never match file paths.
 func (rg *readerGrep) matchString(s string) bool {
+	if rg.structural != nil {
+		return false
+	}
 	if rg.re == nil {
 		return true
 	}
@@ -214,6 +231,10 @@ func (rg *readerGrep) Find(zf *zipFile, f *srcFile) (matches []protocol.LineMatc
 	if !bytes.Contains(fileMatchBuf, rg.literalSubstring) {
 		return nil, false, nil
 	}
+	if rg.structural != nil {
+		matches, limitHit = findStructural(rg.structural, fileBuf)
+		return matches, limitHit, nil
+	}
 	first := rg.re.FindIndex(fileMatchBuf)
 	if first == nil {
 		return nil, false, nil
@@ -288,13 +309,18 @@ func (rg *readerGrep) FindZip(zf *zipFile, f *srcFile) (protocol.FileMatch, erro
 	}, err
 }
 
-// concurrentFind searches files in zr looking for matches using rg.
-func concurrentFind(ctx context.Context, rg *readerGrep, zf *zipFile, fileMatchLimit int, patternMatchesContent, patternMatchesPaths bool) (fm []protocol.FileMatch, limitHit bool, err error) {
+// concurrentFind searches files in zr looking for matches using rg. If
+// onMatch is non-nil, it is called with each match as soon as it is found.
+// onMatch may be called concurrently.
+func concurrentFind(ctx context.Context, rg *readerGrep, zf *zipFile, fileMatchLimit int, patternMatchesContent, patternMatchesPaths bool, onMatch func(protocol.FileMatch)) (fm []protocol.FileMatch, limitHit bool, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "ConcurrentFind")
 	ext.Component.Set(span, "matcher")
 	if rg.re != nil {
 		span.SetTag("re", rg.re.String())
 	}
+	if rg.structural != nil {
+		span.SetTag("structural", true)
+	}
 	span.SetTag("path", rg.matchPath.String())
 	defer func() {
 		if err != nil {
@@ -331,13 +357,17 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *zipFile, fileMatchL
 		matches   = []protocol.FileMatch{}
 	)
 
-	if patternMatchesPaths && (!patternMatchesContent || rg.re == nil) {
+	if patternMatchesPaths && (!patternMatchesContent || rg.re == nil && rg.structural == nil) {
 		// Fast path for only matching file paths (or with a nil pattern, which matches all files,
 		// so is effectively matching only on file paths).
 		for _, f := range files {
 			if rg.matchPath.MatchPath(f.Name) && rg.matchString(f.Name) {
 				if len(matches) < fileMatchLimit {
-					matches = append(matches, protocol.FileMatch{Path: f.Name})
+					fm := protocol.FileMatch{Path: f.Name}
+					matches = append(matches, fm)
+					if onMatch != nil {
+						onMatch(fm)
+					}
 				} else {
 					limitHit = true
 					break
@@ -407,13 +437,20 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *zipFile, fileMatchL
 				}
 				if match {
 					matchesmu.Lock()
-					if len(matches) < fileMatchLimit {
+					added := len(matches) < fileMatchLimit
+					if added {
 						matches = append(matches, fm)
 					} else {
 						limitHit = true
 						cancel()
 					}
 					matchesmu.Unlock()
+
+					// Send the match after releasing matchesmu, so that a slow
+					// client does not block the other workers.
+					if added && onMatch != nil {
+						onMatch(fm)
+					}
 				}
 			}
 		}(rg.Copy())
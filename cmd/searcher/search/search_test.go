@@ -125,6 +125,14 @@ main.go:6:	fmt.Println("Hello world")
 main.go:6:	fmt.Println("Hello world")
 `},
 
+		{protocol.PatternInfo{Pattern: "fmt.Println(:[x])", IsStructuralPat: true}, `
+main.go:6:	fmt.Println("Hello world")
+`},
+		{protocol.PatternInfo{Pattern: "func :[[f]](:[_]) {", IsStructuralPat: true}, `
+main.go:5:func main() {
+`},
+		{protocol.PatternInfo{Pattern: "FMT.Println(:[x])", IsStructuralPat: true}, ""},
+
 		{protocol.PatternInfo{Pattern: "doesnotmatch"}, ""},
 		{protocol.PatternInfo{Pattern: "", IsRegExp: false, IncludePatterns: []string{"\\.png"}, PathPatternsAreRegExps: true, PatternMatchesPath: true}, `
 milton.png
@@ -292,6 +300,49 @@ func TestSearch_badrequest(t *testing.T) {
 	}
 }
 
+func TestSearch_stream(t *testing.T) {
+	files := map[string]string{
+		"README.md": "# Hello World\n\nHello world example in go",
+		"main.go":   "package main\n\nfunc main() {}\n",
+	}
+
+	store, cleanup, err := newStore(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	ts := httptest.NewServer(&search.Service{Store: store})
+	defer ts.Close()
+
+	req := protocol.Request{
+		Repo:   "foo",
+		URL:    "u",
+		Commit: "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+		PatternInfo: protocol.PatternInfo{
+			Pattern:               "world",
+			PatternMatchesContent: true,
+		},
+		FetchTimeout: "500ms",
+		Stream:       true,
+	}
+	m, err := doSearch(ts.URL, &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Sort(sortByPath(m))
+	want := "README.md:1:# Hello World\nREADME.md:3:Hello world example in go\n"
+	if got := toString(m); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Errors before the first match are still reported with a status code.
+	req.Pattern = `\F`
+	req.IsRegExp = true
+	if _, err := doSearch(ts.URL, &req); err == nil || !strings.HasPrefix(err.Error(), "non-200 response: code=400 ") {
+		t.Errorf("expected HTTP 400 response, got %v", err)
+	}
+}
+
 func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 	form := url.Values{
 		"Repo":            []string{string(p.Repo)},
@@ -323,11 +374,35 @@ func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 	if p.PatternMatchesPath {
 		form.Set("PatternMatchesPath", "true")
 	}
+	if p.Stream {
+		form.Set("Stream", "true")
+	}
 	resp, err := http.PostForm(u, form)
 	if err != nil {
 		return nil, err
 	}
 
+	if p.Stream && resp.StatusCode == 200 {
+		if ct := resp.Header.Get("Content-Type"); ct != protocol.StreamContentType {
+			return nil, fmt.Errorf("got Content-Type %q, want %q", ct, protocol.StreamContentType)
+		}
+		var matches []protocol.FileMatch
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var event protocol.StreamEvent
+			if err := dec.Decode(&event); err != nil {
+				return nil, fmt.Errorf("reading stream (no trailer): %s", err)
+			}
+			if event.Trailer != nil {
+				if event.Trailer.Error != "" {
+					return nil, errors.New(event.Trailer.Error)
+				}
+				return matches, nil
+			}
+			matches = append(matches, *event.Match)
+		}
+	}
+
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,90 @@
+package search
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/sourcegraph/sourcegraph/pkg/searcher/protocol"
+)
+
+// streamWriter writes a streaming response (see protocol.StreamEvent). The
+// response header is only written with the first event, so that errors which
+// occur before any match is found can still be sent with an error status.
+type streamWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher // nil if w does not support flushing
+
+	mu  sync.Mutex
+	enc *json.Encoder // nil until the header is written
+	err error         // the first error writing to w
+}
+
+func newStreamWriter(w http.ResponseWriter) *streamWriter {
+	return &streamWriter{w: w, flusher: getFlusher(w)}
+}
+
+// getFlusher returns w as an http.Flusher, or nil if it does not support
+// flushing. Like hackilyGetHTTPFlusher in cmd/gitserver/server, it handles the
+// nethttp.statusCodeTracker wrapper (added by nethttp.Middleware), which
+// hides the http.Flusher implementation of the underlying ResponseWriter.
+func getFlusher(w http.ResponseWriter) http.Flusher {
+	if f, ok := w.(http.Flusher); ok {
+		return f
+	}
+	if reflect.TypeOf(w).String() == "*nethttp.statusCodeTracker" {
+		v := reflect.ValueOf(w).Elem()
+		if v.Kind() == reflect.Struct {
+			if rwv := v.FieldByName("ResponseWriter"); rwv.IsValid() {
+				if f, ok := rwv.Interface().(http.Flusher); ok {
+					return f
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// started returns true if the response header has been written.
+func (sw *streamWriter) started() bool {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.enc != nil
+}
+
+// sendMatch sends a file match. Errors are returned by sendTrailer, since
+// there is nothing the search can do about them.
+func (sw *streamWriter) sendMatch(fm protocol.FileMatch) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.send(&protocol.StreamEvent{Match: &fm})
+}
+
+// sendTrailer sends the trailer, which ends the response. It returns the
+// first error that occurred writing the response.
+func (sw *streamWriter) sendTrailer(trailer protocol.StreamTrailer) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.send(&protocol.StreamEvent{Trailer: &trailer})
+	return sw.err
+}
+
+// send assumes the caller holds sw.mu.
+func (sw *streamWriter) send(event *protocol.StreamEvent) {
+	if sw.err != nil {
+		return
+	}
+	if sw.enc == nil {
+		sw.w.Header().Set("Content-Type", protocol.StreamContentType)
+		sw.w.WriteHeader(http.StatusOK)
+		sw.enc = json.NewEncoder(sw.w)
+	}
+	// Encode writes a newline after each value, which separates the events.
+	if sw.err = sw.enc.Encode(event); sw.err != nil {
+		return
+	}
+	if sw.flusher != nil {
+		sw.flusher.Flush()
+	}
+}
@@ -0,0 +1,405 @@
+package search
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/sourcegraph/sourcegraph/pkg/searcher/protocol"
+)
+
+// maxStructuralSteps limits the work done to match a structural pattern
+// against a single file, because patterns with several holes can backtrack a
+// lot. Files that exceed it are reported with LimitHit.
+const maxStructuralSteps = 1 << 22
+
+// structuralPattern is a compiled structural search pattern. A structural
+// pattern is source code with holes:
+//
+//	:[name]    matches any text in which brackets ((), [] and {}) are
+//	           balanced. Strings and comments are matched as a whole, so
+//	           brackets in them are ignored.
+//	:[[name]]  matches an identifier (letters, digits and underscores).
+//
+// Holes with the same name must match the same text, except for holes named
+// "_", which match independently. Whitespace in the pattern matches any
+// whitespace, including none (except between two identifier characters).
+// Everything else in the pattern matches literally.
+//
+// For example, "fmt.Sprintf(:[args])" matches `fmt.Sprintf("%d", f(x))`
+// (with args bound to `"%d", f(x)`).
+type structuralPattern struct {
+	elems []structuralElem
+
+	// literal is the longest literal in the pattern. It appears in every
+	// match.
+	literal []byte
+}
+
+type structuralElemKind int
+
+const (
+	structuralLiteral structuralElemKind = iota
+	structuralSpace
+	structuralHole
+)
+
+type structuralElem struct {
+	kind  structuralElemKind
+	text  []byte // the literal text, or the hole name
+	ident bool   // the hole only matches an identifier
+}
+
+// compileStructural compiles a structural pattern (see structuralPattern).
+func compileStructural(pattern string) (*structuralPattern, error) {
+	p := &structuralPattern{}
+	var lit []byte
+	flushLiteral := func() {
+		if len(lit) > 0 {
+			p.elems = append(p.elems, structuralElem{kind: structuralLiteral, text: lit})
+			if len(lit) > len(p.literal) {
+				p.literal = lit
+			}
+			lit = nil
+		}
+	}
+
+	for i := 0; i < len(pattern); {
+		switch {
+		case isSpace(pattern[i]):
+			flushLiteral()
+			for i < len(pattern) && isSpace(pattern[i]) {
+				i++
+			}
+			if len(p.elems) > 0 && i < len(pattern) {
+				p.elems = append(p.elems, structuralElem{kind: structuralSpace})
+			}
+
+		case pattern[i] == ':' && i+1 < len(pattern) && pattern[i+1] == '[':
+			flushLiteral()
+			ident := i+2 < len(pattern) && pattern[i+2] == '['
+			open, close := ":[", "]"
+			if ident {
+				open, close = ":[[", "]]"
+			}
+			end := bytes.Index([]byte(pattern[i+len(open):]), []byte(close))
+			if end < 0 {
+				return nil, fmt.Errorf("structural pattern has an unterminated hole at offset %d (want %q...%q)", i, open, close)
+			}
+			name := pattern[i+len(open) : i+len(open)+end]
+			if !isHoleName(name) {
+				return nil, fmt.Errorf("structural pattern has an invalid hole name %q at offset %d (hole names may only contain letters, digits and underscores)", name, i)
+			}
+			p.elems = append(p.elems, structuralElem{kind: structuralHole, text: []byte(name), ident: ident})
+			i += len(open) + end + len(close)
+
+		default:
+			lit = append(lit, pattern[i])
+			i++
+		}
+	}
+	flushLiteral()
+
+	if len(p.elems) == 0 {
+		return nil, errors.New("structural pattern is empty")
+	}
+	return p, nil
+}
+
+func isHoleName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isIdentByte(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// isIdentByte reports whether c is part of an identifier. Non-ASCII bytes are
+// treated as identifier characters, so that identifiers with non-ASCII letters
+// are not split.
+func isIdentByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+// structuralMatcher matches a structuralPattern against a file. It is not
+// safe for concurrent use.
+type structuralMatcher struct {
+	p     *structuralPattern
+	buf   []byte
+	holes map[string][]byte // the text matched by the named holes bound so far
+	steps int
+}
+
+// errStructuralTooComplex is returned when matching exceeds maxStructuralSteps.
+var errStructuralTooComplex = errors.New("structural pattern too complex")
+
+// findAll returns the byte ranges of non-overlapping matches of p in buf, up
+// to limit matches. The error is errStructuralTooComplex if matching stopped
+// early.
+func (p *structuralPattern) findAll(buf []byte, limit int) (matches [][2]int, err error) {
+	m := &structuralMatcher{p: p, buf: buf, holes: map[string][]byte{}}
+	for pos := 0; pos < len(buf) && len(matches) < limit; {
+		// Cheaply skip positions where the pattern's leading literal does
+		// not match, so that they do not count towards maxStructuralSteps.
+		end := -1
+		if first := p.elems[0]; first.kind != structuralLiteral || bytes.HasPrefix(buf[pos:], first.text) {
+			var err error
+			if end, err = m.match(0, pos); err != nil {
+				return matches, err
+			}
+		}
+
+		switch {
+		case end > pos:
+			matches = append(matches, [2]int{pos, end})
+			pos = end
+		case m.skipStringOrComment(pos) > pos:
+			// Matches do not start inside strings or comments.
+			pos = m.skipStringOrComment(pos)
+		default:
+			pos++
+		}
+	}
+	return matches, nil
+}
+
+// match returns the end of the match of the pattern elements starting at
+// elems[i] at pos, or -1 if there is none.
+func (m *structuralMatcher) match(i, pos int) (int, error) {
+	m.steps++
+	if m.steps > maxStructuralSteps {
+		return -1, errStructuralTooComplex
+	}
+	if i == len(m.p.elems) {
+		return pos, nil
+	}
+
+	e := m.p.elems[i]
+	switch e.kind {
+	case structuralLiteral:
+		if !bytes.HasPrefix(m.buf[pos:], e.text) {
+			return -1, nil
+		}
+		return m.match(i+1, pos+len(e.text))
+
+	case structuralSpace:
+		end := pos
+		for end < len(m.buf) && isSpace(m.buf[end]) {
+			end++
+		}
+		if end == pos && pos > 0 && pos < len(m.buf) && isIdentByte(m.buf[pos-1]) && isIdentByte(m.buf[pos]) {
+			return -1, nil
+		}
+		return m.match(i+1, end)
+
+	case structuralHole:
+		if e.ident {
+			end := pos
+			for end < len(m.buf) && isIdentByte(m.buf[end]) {
+				end++
+			}
+			if end == pos {
+				return -1, nil
+			}
+			return m.matchHole(i, pos, end)
+		}
+
+		// Try the shortest match first, then extend it one (balanced) unit
+		// at a time.
+		for end := pos; ; {
+			if res, err := m.matchHole(i, pos, end); res >= 0 || err != nil {
+				return res, err
+			}
+			if end = m.skipUnit(end); end < 0 {
+				return -1, nil
+			}
+		}
+	}
+	panic(fmt.Sprintf("unknown structural pattern element kind %d", e.kind))
+}
+
+// matchHole matches the rest of the pattern after the hole elems[i] matched
+// buf[start:end].
+func (m *structuralMatcher) matchHole(i, start, end int) (int, error) {
+	name := string(m.p.elems[i].text)
+	text := m.buf[start:end]
+	if name != "_" {
+		if bound, ok := m.holes[name]; ok {
+			if !bytes.Equal(bound, text) {
+				return -1, nil
+			}
+			return m.match(i+1, end)
+		}
+		m.holes[name] = text
+		defer delete(m.holes, name)
+	}
+	return m.match(i+1, end)
+}
+
+// skipUnit returns the end of the unit starting at pos: a balanced bracketed
+// expression, a string, a comment or a single byte. It returns -1 if there is
+// no unit at pos, because pos is at the end of buf or at an unbalanced
+// closing bracket.
+func (m *structuralMatcher) skipUnit(pos int) int {
+	if pos >= len(m.buf) {
+		return -1
+	}
+	if next := m.skipStringOrComment(pos); next > pos {
+		return next
+	}
+
+	var close byte
+	switch m.buf[pos] {
+	case ')', ']', '}':
+		return -1
+	case '(':
+		close = ')'
+	case '[':
+		close = ']'
+	case '{':
+		close = '}'
+	default:
+		return pos + 1
+	}
+	for pos++; pos < len(m.buf); {
+		if m.buf[pos] == close {
+			return pos + 1
+		}
+		if pos = m.skipUnit(pos); pos < 0 {
+			return -1
+		}
+	}
+	return -1
+}
+
+// skipStringOrComment returns the end of the string or comment starting at
+// pos, or pos if there is none. Strings are delimited by ", ' or `, and
+// comments are C-style (// and /* */).
+func (m *structuralMatcher) skipStringOrComment(pos int) int {
+	buf := m.buf
+	switch c := buf[pos]; c {
+	case '"', '\'':
+		// Strings end at the closing quote, and may not span lines.
+		for i := pos + 1; i < len(buf) && buf[i] != '\n'; i++ {
+			switch buf[i] {
+			case '\\':
+				i++
+			case c:
+				return i + 1
+			}
+		}
+		return pos // not a string (e.g., an apostrophe in a comment)
+	case '`':
+		if i := bytes.IndexByte(buf[pos+1:], '`'); i >= 0 {
+			return pos + 1 + i + 1
+		}
+		return pos
+	case '/':
+		if pos+1 < len(buf) {
+			switch buf[pos+1] {
+			case '/':
+				if i := bytes.IndexByte(buf[pos:], '\n'); i >= 0 {
+					return pos + i
+				}
+				return len(buf)
+			case '*':
+				if i := bytes.Index(buf[pos+2:], []byte("*/")); i >= 0 {
+					return pos + 2 + i + 2
+				}
+				return len(buf)
+			}
+		}
+	}
+	return pos
+}
+
+// findStructural returns a LineMatch for each line that overlaps a match of
+// p in buf. Matches that span several lines are reported on each line.
+func findStructural(p *structuralPattern, buf []byte) (matches []protocol.LineMatch, limitHit bool) {
+	ranges, err := p.findAll(buf, maxLineMatches*maxOffsets)
+	limitHit = err != nil || len(ranges) == maxLineMatches*maxOffsets
+	if len(ranges) == 0 {
+		return nil, limitHit
+	}
+
+	// lineStarts[i] is the offset of the start of line i.
+	lineStarts := []int{0}
+	for i, c := range buf {
+		if c == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	lineOf := func(offset int) int {
+		return sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset }) - 1
+	}
+	lineEnd := func(line int) int {
+		if line+1 < len(lineStarts) {
+			return lineStarts[line+1] - 1 // exclude the newline
+		}
+		return len(buf)
+	}
+
+	for _, r := range ranges {
+		for line := lineOf(r[0]); line < len(lineStarts) && lineStarts[line] <= r[1]; line++ {
+			start, end := lineStarts[line], lineEnd(line)
+			if end > start && buf[end-1] == '\r' {
+				end--
+			}
+			if end-start > maxLineSize {
+				continue
+			}
+			matchStart, matchEnd := max(r[0], start), min(r[1], end)
+			if matchEnd <= matchStart && r[1] > r[0] {
+				continue // only the newline of this line is in the match
+			}
+			lineBuf := buf[start:end]
+			offsetAndLength := [2]int{
+				utf8.RuneCount(lineBuf[:matchStart-start]),
+				utf8.RuneCount(buf[matchStart:matchEnd]),
+			}
+
+			if n := len(matches); n > 0 && matches[n-1].LineNumber == line {
+				lm := &matches[n-1]
+				if len(lm.OffsetAndLengths) == maxOffsets {
+					lm.LimitHit = true
+				} else {
+					lm.OffsetAndLengths = append(lm.OffsetAndLengths, offsetAndLength)
+				}
+				continue
+			}
+			if len(matches) == maxLineMatches {
+				return matches, true
+			}
+			matches = append(matches, protocol.LineMatch{
+				Preview:          string(lineBuf),
+				LineNumber:       line,
+				OffsetAndLengths: [][2]int{offsetAndLength},
+			})
+		}
+	}
+	return matches, limitHit
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,138 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/searcher/protocol"
+)
+
+func TestStructuralPattern_findAll(t *testing.T) {
+	cases := []struct {
+		pattern string
+		input   string
+		want    []string
+	}{
+		{
+			pattern: "fmt.Sprintf(:[args])",
+			input:   `x := fmt.Sprintf("%d(", f(a, b)) + fmt.Sprintf()`,
+			want:    []string{`fmt.Sprintf("%d(", f(a, b))`, `fmt.Sprintf()`},
+		},
+		{
+			// Holes stop at unbalanced closing brackets.
+			pattern: "f(:[x])",
+			input:   "g(f(a)) f(b]",
+			want:    []string{"f(a)"},
+		},
+		{
+			// Brackets in comments are ignored.
+			pattern: "f(:[x])",
+			input:   "f(a /* ) */, b // )\n)",
+			want:    []string{"f(a /* ) */, b // )\n)"},
+		},
+		{
+			// Matches do not start in strings or comments.
+			pattern: "f(:[x])",
+			input:   `"f(a)" // f(b)` + "\n/* f(c) */ `f(d)` f(e)",
+			want:    []string{"f(e)"},
+		},
+		{
+			// Whitespace matches any whitespace.
+			pattern: "if :[c] {",
+			input:   "if  x == y\t{\nif(z){",
+			want:    []string{"if  x == y\t{", "if(z){"},
+		},
+		{
+			// Whitespace does not join identifiers.
+			pattern: "return :[[x]]",
+			input:   "returnx; return y;",
+			want:    []string{"return y"},
+		},
+		{
+			// Holes with the same name match the same text.
+			pattern: ":[[a]] = :[[a]]",
+			input:   "x = y; z = z;",
+			want:    []string{"z = z"},
+		},
+		{
+			// Anonymous holes match independently.
+			pattern: ":[[_]] = :[[_]]",
+			input:   "x = y;",
+			want:    []string{"x = y"},
+		},
+		{
+			pattern: "[:[x]]",
+			input:   "a[[1, 2], [3]]",
+			want:    []string{"[[1, 2], [3]]"},
+		},
+		{
+			pattern: `":[x]"`,
+			input:   `f("a", 'b', "c")`,
+			want:    []string{`"a"`, `"c"`},
+		},
+		{
+			pattern: "foo",
+			input:   "foofoo",
+			want:    []string{"foo", "foo"},
+		},
+	}
+	for _, c := range cases {
+		p, err := compileStructural(c.pattern)
+		if err != nil {
+			t.Fatalf("%q: %s", c.pattern, err)
+		}
+		ranges, err := p.findAll([]byte(c.input), 100)
+		if err != nil {
+			t.Fatalf("%q: %s", c.pattern, err)
+		}
+		var got []string
+		for _, r := range ranges {
+			got = append(got, c.input[r[0]:r[1]])
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q in %q: got %q, want %q", c.pattern, c.input, got, c.want)
+		}
+	}
+}
+
+func TestCompileStructural_errors(t *testing.T) {
+	for _, pattern := range []string{"", "  ", "f(:[x)", "f(:[[x])", "f(:[])", "f(:[a-b])"} {
+		if _, err := compileStructural(pattern); err == nil {
+			t.Errorf("%q: got nil error", pattern)
+		}
+	}
+}
+
+func TestStructuralPattern_tooComplex(t *testing.T) {
+	p, err := compileStructural(":[a]:[b]:[c]:[d]x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1000)
+	for i := range buf {
+		buf[i] = 'a'
+	}
+	if _, err := p.findAll(buf, 100); err != errStructuralTooComplex {
+		t.Errorf("got err %v, want %v", err, errStructuralTooComplex)
+	}
+}
+
+func TestFindStructural(t *testing.T) {
+	p, err := compileStructural("f(:[x])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, limitHit := findStructural(p, []byte("a := f(1, f(2))\nb := ƒ + f(\n\t3,\n) + f(4)\n"))
+	want := []protocol.LineMatch{
+		{Preview: "a := f(1, f(2))", LineNumber: 0, OffsetAndLengths: [][2]int{{5, 10}}},
+		{Preview: "b := ƒ + f(", LineNumber: 1, OffsetAndLengths: [][2]int{{9, 2}}},
+		{Preview: "\t3,", LineNumber: 2, OffsetAndLengths: [][2]int{{0, 3}}},
+		{Preview: ") + f(4)", LineNumber: 3, OffsetAndLengths: [][2]int{{0, 1}, {4, 4}}},
+	}
+	if limitHit {
+		t.Error("got limitHit")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
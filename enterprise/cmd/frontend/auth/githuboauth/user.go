@@ -10,6 +10,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/external/auth"
 	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	extsvcgithub "github.com/sourcegraph/sourcegraph/pkg/extsvc/github"
 	"golang.org/x/oauth2"
 )
 
@@ -19,8 +20,10 @@ func getOrCreateUser(ctx context.Context, p *provider, ghUser *github.User, toke
 		return nil, fmt.Sprintf("Error normalizing the username %q. See https://docs.sourcegraph.com/admin/auth/#username-normalization.", login), err
 	}
 
+	// The token is stored so that the GitHub authz provider can determine which repositories the
+	// user can access.
 	var data extsvc.ExternalAccountData
-	data.SetAccountData(ghUser)
+	extsvcgithub.SetExternalAccountData(&data, ghUser, token)
 	userID, safeErrMsg, err := auth.CreateOrUpdateUser(ctx, db.NewUser{
 		Username:        login,
 		Email:           deref(ghUser.Email),
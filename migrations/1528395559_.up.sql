@@ -0,0 +1,6 @@
+-- Repositories that were deleted on their code host are marked as deleted instead of being removed,
+-- so that they can be restored (with their discussions, saved searches, etc.) if they reappear.
+ALTER TABLE repo ADD COLUMN deleted_at timestamp with time zone;
+
+-- Renamed repositories are found by their external repository spec.
+CREATE INDEX repo_external_repo_idx ON repo(external_service_type, external_service_id, external_id);
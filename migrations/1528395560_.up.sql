@@ -0,0 +1,14 @@
+-- Explicit repository permissions, granted by site admins to users or organizations for the
+-- repositories whose names match a pattern.
+CREATE TABLE repo_permissions (
+    id serial PRIMARY KEY,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    org_id integer REFERENCES orgs(id) ON DELETE CASCADE,
+    repo_pattern text NOT NULL,
+    permission text NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT repo_permissions_has_one_subject CHECK ((user_id IS NULL) <> (org_id IS NULL)),
+    CONSTRAINT repo_permissions_repo_pattern_not_empty CHECK (repo_pattern <> '')
+);
+CREATE INDEX repo_permissions_user_id ON repo_permissions(user_id);
+CREATE INDEX repo_permissions_org_id ON repo_permissions(org_id);
@@ -0,0 +1,12 @@
+-- The repository permissions of each user from each authz provider, precomputed by the background
+-- permissions syncer. repo_ids holds the IDs of the repositories the user has the permission on
+-- (among those that the provider is the source of permissions for) as of updated_at.
+CREATE TABLE user_permissions (
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission text NOT NULL,
+    service_type text NOT NULL,
+    service_id text NOT NULL,
+    repo_ids integer[] NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    PRIMARY KEY (user_id, permission, service_type, service_id)
+);
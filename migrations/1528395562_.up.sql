@@ -0,0 +1,2 @@
+-- Access tokens that have an expiry date are invalid after that date (and never expire if it is null).
+ALTER TABLE access_tokens ADD COLUMN expires_at timestamp with time zone;
@@ -0,0 +1 @@
+ALTER TABLE user_permissions DROP COLUMN claimed_repo_ids;
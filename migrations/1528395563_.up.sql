@@ -0,0 +1,6 @@
+-- claimed_repo_ids holds the IDs of the repositories that the authz provider was the source of
+-- permissions for as of updated_at, so that the stored permissions can be used without asking the
+-- provider which repositories it is the source of permissions for. The existing permissions don't
+-- record that, so they are recomputed by the background permissions syncer.
+DELETE FROM user_permissions;
+ALTER TABLE user_permissions ADD COLUMN claimed_repo_ids integer[] NOT NULL;
@@ -25,9 +25,8 @@ type RepoCreateOrUpdateRequest struct {
 	// optional during the transition period.
 	ExternalRepo *ExternalRepoSpec
 
-	// RepoName is the repository's name.
-	//
-	// TODO(sqs): Add a way for callers to request that this repository be renamed.
+	// RepoName is the repository's name. If a stored repository with the same ExternalRepo has a
+	// different name (because the repository was renamed on its external service), it is renamed.
 	RepoName `json:"repo"`
 
 	// Enabled is whether the repository should be enabled when initially created.
@@ -52,6 +51,19 @@ type RepoUpdateIndexRequest struct {
 	Language string `json:"language"`
 }
 
+// RepoIndexBranchesRequest is a request for the branches of a repository that
+// should be indexed for search.
+type RepoIndexBranchesRequest struct {
+	RepoName `json:"repo"`
+}
+
+// RepoIndexBranch is a branch of a repository that should be indexed for
+// search. The default branch is named "HEAD".
+type RepoIndexBranch struct {
+	Name     string `json:"name"`
+	CommitID `json:"commit"`
+}
+
 type RepoUnindexedDependenciesRequest struct {
 	RepoID   `json:"repoID"`
 	Language string `json:"language"`
@@ -69,6 +81,18 @@ type ReposUpdateMetadataRequest struct {
 	Archived    bool   `json:"Archived"`
 }
 
+// ReposListByExternalServiceRequest lists the repositories of an external service, regardless of
+// whether they are enabled.
+type ReposListByExternalServiceRequest struct {
+	ServiceType string
+	ServiceID   string
+}
+
+// ReposMarkDeletedRequest is a request to mark a repository as deleted on its external service.
+type ReposMarkDeletedRequest struct {
+	RepoName `json:"repo"`
+}
+
 type ReposGetInventoryRequest struct {
 	Repo RepoID
 	CommitID
@@ -280,6 +280,23 @@ func (c *internalClient) ReposListEnabled(ctx context.Context) ([]RepoName, erro
 	return names, err
 }
 
+// ReposListByExternalService returns all repositories (enabled or not) whose external repository
+// spec has the given service type and ID.
+func (c *internalClient) ReposListByExternalService(ctx context.Context, serviceType, serviceID string) ([]*Repo, error) {
+	var repos []*Repo
+	err := c.postInternal(ctx, "repos/list-by-external-service", ReposListByExternalServiceRequest{
+		ServiceType: serviceType,
+		ServiceID:   serviceID,
+	}, &repos)
+	return repos, err
+}
+
+// ReposMarkDeleted marks the repository as deleted on its external service. It is no longer listed,
+// cloned or updated, but it is restored if it is created or updated again.
+func (c *internalClient) ReposMarkDeleted(ctx context.Context, repo RepoName) error {
+	return c.postInternal(ctx, "repos/mark-deleted", ReposMarkDeletedRequest{RepoName: repo}, nil)
+}
+
 func (c *internalClient) ConfigurationRawJSON(ctx context.Context) (string, error) {
 	var rawJSON string
 	err := c.postInternal(ctx, "configuration/raw-json", nil, &rawJSON)
@@ -303,6 +320,14 @@ func (c *internalClient) ReposUpdateIndex(ctx context.Context, repo RepoID, comm
 	}, nil)
 }
 
+// ReposIndexBranches returns the branches of repo that should be indexed for
+// search, resolved to the commits to index.
+func (c *internalClient) ReposIndexBranches(ctx context.Context, repo RepoName) ([]RepoIndexBranch, error) {
+	var branches []RepoIndexBranch
+	err := c.postInternal(ctx, "repos/index-branches", RepoIndexBranchesRequest{RepoName: repo}, &branches)
+	return branches, err
+}
+
 func (c *internalClient) ReposGetByName(ctx context.Context, repoName RepoName) (*Repo, error) {
 	var repo Repo
 	err := c.postInternal(ctx, "repos/"+string(repoName), nil, &repo)
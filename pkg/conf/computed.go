@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -50,6 +51,27 @@ func UpdateScheduler2Enabled() bool {
 	return p == "enabled"
 }
 
+// ExplicitPermissionsEnabled returns true if ExplicitPermissions experiment is enabled.
+func ExplicitPermissionsEnabled() bool {
+	ef := Get().ExperimentalFeatures
+	// default is disabled
+	return ef != nil && ef.ExplicitPermissions == "enabled"
+}
+
+// PermissionsBackgroundSyncEnabled returns true if PermissionsBackgroundSync experiment is enabled.
+func PermissionsBackgroundSyncEnabled() bool {
+	ef := Get().ExperimentalFeatures
+	// default is disabled
+	return ef != nil && ef.PermissionsBackgroundSync == "enabled"
+}
+
+// SearchDefinitionRankingEnabled returns true if SearchDefinitionRanking experiment is enabled.
+func SearchDefinitionRankingEnabled() bool {
+	ef := Get().ExperimentalFeatures
+	// default is disabled
+	return ef != nil && ef.SearchDefinitionRanking == "enabled"
+}
+
 type AccessTokAllow string
 
 const (
@@ -216,6 +238,30 @@ func SearchIndexEnabled() bool {
 	return DeployType() != DeployDocker
 }
 
+// SearchIndexBranches returns the branches of the named repository that
+// should be indexed for search in addition to the default branch (see the
+// "search.index.branches" site configuration property). Repository names are
+// compared case-insensitively.
+func SearchIndexBranches(repoName string) []string {
+	var branches []string
+	seen := map[string]bool{}
+	for name, names := range Get().SearchIndexBranches {
+		if !strings.EqualFold(name, repoName) {
+			continue
+		}
+		for _, branch := range names {
+			// The default branch is always indexed (as "HEAD").
+			if branch == "" || branch == "HEAD" || seen[branch] {
+				continue
+			}
+			seen[branch] = true
+			branches = append(branches, branch)
+		}
+	}
+	sort.Strings(branches)
+	return branches
+}
+
 // SrcGitServers represents the SRC_GIT_SERVERS environment variable.
 //
 // Non-frontend callers should go through api.InternalClient.GitServerAddrs() instead.
@@ -68,6 +68,27 @@ func TestComputed(t *testing.T) {
 	}
 }
 
+func TestSearchIndexBranches(t *testing.T) {
+	Mock(&schema.SiteConfiguration{
+		SearchIndexBranches: map[string][]string{
+			"github.com/foo/bar": {"release-2.0", "HEAD", "", "release-1.0", "release-2.0"},
+			"github.com/foo/baz": {"dev"},
+		},
+	})
+	defer Mock(nil)
+
+	tests := map[string][]string{
+		"github.com/foo/bar": {"release-1.0", "release-2.0"},
+		"github.com/Foo/Bar": {"release-1.0", "release-2.0"},
+		"github.com/foo/qux": nil,
+	}
+	for repoName, want := range tests {
+		if got := SearchIndexBranches(repoName); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, want %q", repoName, got, want)
+		}
+	}
+}
+
 func setenv(t *testing.T, keyval string) func() {
 	t.Helper()
 
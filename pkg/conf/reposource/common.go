@@ -37,10 +37,12 @@ func CloneURLToRepoName(cloneURL string) (repoName api.RepoName, err error) {
 
 	repoSources := make([]repoSource, 0, len(cfg.Github)+
 		len(cfg.Gitlab)+
+		len(cfg.Gitea)+
 		len(cfg.BitbucketServer)+
 		len(cfg.AwsCodeCommit)+
 		1+ /* for repos.list */
-		len(cfg.Gitolite))
+		len(cfg.Gitolite)+
+		len(cfg.Perforce))
 
 	for _, c := range cfg.Github {
 		repoSources = append(repoSources, GitHub{c})
@@ -48,6 +50,9 @@ func CloneURLToRepoName(cloneURL string) (repoName api.RepoName, err error) {
 	for _, c := range cfg.Gitlab {
 		repoSources = append(repoSources, GitLab{c})
 	}
+	for _, c := range cfg.Gitea {
+		repoSources = append(repoSources, Gitea{c})
+	}
 	for _, c := range cfg.BitbucketServer {
 		repoSources = append(repoSources, BitbucketServer{c})
 	}
@@ -58,6 +63,9 @@ func CloneURLToRepoName(cloneURL string) (repoName api.RepoName, err error) {
 	for _, c := range cfg.Gitolite {
 		repoSources = append(repoSources, Gitolite{c})
 	}
+	for _, c := range cfg.Perforce {
+		repoSources = append(repoSources, Perforce{c})
+	}
 	for _, ch := range repoSources {
 		repoName, err := ch.cloneURLToRepoName(cloneURL)
 		if err != nil {
@@ -86,6 +94,7 @@ func NormalizeBaseURL(baseURL *url.URL) *url.URL {
 // parseCloneURL parses a git clone URL into a URL struct. It supports the SCP-style git@host:path
 // syntax that is common among code hosts.
 func parseCloneURL(cloneURL string) (*url.URL, error) {
+	cloneURL = trimMercurialScheme(cloneURL)
 	if strings.HasPrefix(cloneURL, "https://") || strings.HasPrefix(cloneURL, "http://") || strings.HasPrefix(cloneURL, "ssh://") || strings.HasPrefix(cloneURL, "git://") || strings.HasPrefix(cloneURL, "rsync://") || strings.HasPrefix(cloneURL, "file://") {
 		return url.Parse(cloneURL)
 	}
@@ -100,6 +109,13 @@ func parseCloneURL(cloneURL string) (*url.URL, error) {
 	return u, nil
 }
 
+// trimMercurialScheme removes the "hg+" or "hg::" prefix from the clone URL
+// of a Mercurial repository (such as "hg+https://hg.example.com/repo"), so it
+// can be mapped to a repo name like a Git clone URL.
+func trimMercurialScheme(cloneURL string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(cloneURL, "hg+"), "hg::")
+}
+
 // parseURLs parses the clone URL and repository host base URL into structs. It also returns a
 // boolean indicating whether the hostnames of the URLs match.
 func parseURLs(cloneURL, baseURL string) (parsedCloneURL, parsedBaseURL *url.URL, equalHosts bool, err error) {
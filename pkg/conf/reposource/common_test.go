@@ -98,6 +98,21 @@ func TestParseCloneURL(t *testing.T) {
 				Host: "host.xz",
 				Path: "~user/path/to/repo.git/",
 			},
+		}, {
+			input: "hg+https://hg.example.com/foo",
+			output: &url.URL{
+				Scheme: "https",
+				Host:   "hg.example.com",
+				Path:   "/foo",
+			},
+		}, {
+			input: "hg::ssh://hg@hg.example.com/foo",
+			output: &url.URL{
+				Scheme: "ssh",
+				User:   url.User("hg"),
+				Host:   "hg.example.com",
+				Path:   "/foo",
+			},
 		}, {
 			input: "ssh://host.xz/~/path/to/repo.git",
 			output: &url.URL{
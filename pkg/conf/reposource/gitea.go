@@ -0,0 +1,43 @@
+package reposource
+
+import (
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type Gitea struct {
+	*schema.GiteaConnection
+}
+
+var _ repoSource = Gitea{}
+
+func (c Gitea) cloneURLToRepoName(cloneURL string) (repoName api.RepoName, err error) {
+	parsedCloneURL, baseURL, match, err := parseURLs(cloneURL, c.Url)
+	if err != nil {
+		return "", err
+	}
+	if !match {
+		return "", nil
+	}
+
+	path := parsedCloneURL.Path
+	if strings.HasPrefix(parsedCloneURL.Scheme, "http") {
+		// HTTP clone URLs include the path that Gitea is served at, if any.
+		path = strings.TrimPrefix(path, strings.TrimSuffix(baseURL.Path, "/"))
+	}
+	nameWithOwner := strings.TrimPrefix(strings.TrimSuffix(path, ".git"), "/")
+	return GiteaRepoName(c.RepositoryPathPattern, baseURL.Hostname(), nameWithOwner), nil
+}
+
+func GiteaRepoName(repositoryPathPattern, host, nameWithOwner string) api.RepoName {
+	if repositoryPathPattern == "" {
+		repositoryPathPattern = "{host}/{nameWithOwner}"
+	}
+
+	return api.RepoName(strings.NewReplacer(
+		"{host}", host,
+		"{nameWithOwner}", nameWithOwner,
+	).Replace(repositoryPathPattern))
+}
@@ -0,0 +1,69 @@
+package reposource
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+type Perforce struct {
+	*schema.PerforceConnection
+}
+
+var _ repoSource = Perforce{}
+
+func (c Perforce) cloneURLToRepoName(cloneURL string) (repoName api.RepoName, err error) {
+	if !strings.HasPrefix(cloneURL, "perforce://") && !strings.HasPrefix(cloneURL, "perforce+ssl://") {
+		return "", nil
+	}
+	parsedCloneURL, err := url.Parse(cloneURL)
+	if err != nil {
+		return "", err
+	}
+	if perforceHostPort(c.P4Port) != strings.ToLower(parsedCloneURL.Host) {
+		return "", nil
+	}
+	return PerforceRepoName(c.RepositoryPathPattern, c.P4Port, parsedCloneURL.Path), nil
+}
+
+// PerforceRepoName returns the repository name of the depot at depotPath
+// (e.g., "//depot/main/") on the Perforce server at p4port.
+func PerforceRepoName(repositoryPathPattern, p4port, depotPath string) api.RepoName {
+	if repositoryPathPattern == "" {
+		repositoryPathPattern = "{host}/{depot}"
+	}
+
+	host := perforceHostPort(p4port)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return api.RepoName(strings.NewReplacer(
+		"{host}", host,
+		"{depot}", strings.Trim(depotPath, "/"),
+	).Replace(repositoryPathPattern))
+}
+
+// PerforceCloneURL returns the URL that gitserver mirrors the depot at
+// depotPath on the Perforce server of conn from. The URL has the form
+// perforce://host:port//depot/path/, with the scheme perforce+ssl for servers
+// that use SSL. It doesn't contain credentials: gitserver looks them up in
+// the site configuration.
+func PerforceCloneURL(conn *schema.PerforceConnection, depotPath string) string {
+	u := url.URL{
+		Scheme: "perforce",
+		Host:   perforceHostPort(conn.P4Port),
+		Path:   "//" + strings.Trim(depotPath, "/") + "/",
+	}
+	if strings.HasPrefix(conn.P4Port, "ssl:") {
+		u.Scheme = "perforce+ssl"
+	}
+	return u.String()
+}
+
+// perforceHostPort returns the host:port part of a P4PORT value.
+func perforceHostPort(p4port string) string {
+	return strings.ToLower(strings.TrimPrefix(p4port, "ssl:"))
+}
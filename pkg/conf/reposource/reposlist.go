@@ -66,6 +66,7 @@ func normalizeCloneURL(cloneURL string) string {
 		u   *url.URL
 		err error
 	)
+	cloneURL = trimMercurialScheme(cloneURL)
 	if strings.HasPrefix(cloneURL, "https://") || strings.HasPrefix(cloneURL, "http://") || strings.HasPrefix(cloneURL, "ssh://") {
 		u, err = url.Parse(cloneURL)
 	} else { // SCP-like case
@@ -40,6 +40,18 @@ func TestReposList_cloneURLToRepoName(t *testing.T) {
 			{"git@github.com:asdf/mux.git", ""},
 			{"ssh://git@github.com:1234/asdf/mux.git", ""},
 		},
+	}, {
+		repos: []*schema.Repository{
+			{Path: "hg.example.com/foo", Url: "hg+https://hg.example.com/foo", Type: "hg"},
+		},
+		urls: []urlToRepoName{
+			{"hg+https://hg.example.com/foo", "hg.example.com/foo"},
+			{"hg::https://hg.example.com/foo", "hg.example.com/foo"},
+			{"https://hg.example.com/foo", "hg.example.com/foo"},
+			{"hg+ssh://hg@hg.example.com/foo", "hg.example.com/foo"},
+
+			{"hg+https://hg.example.com/bar", ""},
+		},
 	}}
 
 	for _, test := range tests {
@@ -61,6 +61,13 @@ type Client struct {
 	// version 5.4 and older). If both Token and Username/Password are specified, Token is used.
 	Username, Password string
 
+	// OAuth, if set, is the OAuth consumer of an application link that signs requests instead
+	// of Token and Username/Password. It is used to impersonate users (see Sudo).
+	OAuth *OAuth
+
+	// sudo is the username of the user that the client impersonates, if any.
+	sudo string
+
 	// HTTPClient is the client used to access Bitbucket Server. To enabled
 	// tracing, ensure the transport includes nethttp.Transport.
 	//
@@ -72,6 +79,17 @@ type Client struct {
 	RateLimit *rate.Limiter
 }
 
+// Sudo returns a copy of the client that makes requests as the Bitbucket Server user with the given
+// username. It requires OAuth, and the application link must allow user impersonation.
+func (c *Client) Sudo(username string) (*Client, error) {
+	if c.OAuth == nil {
+		return nil, errors.New("bitbucketserver: user impersonation requires OAuth")
+	}
+	sudo := *c
+	sudo.sudo = username
+	return &sudo, nil
+}
+
 func (c *Client) Repo(ctx context.Context, projectKey, repoSlug string) (*Repo, error) {
 	u := fmt.Sprintf("rest/api/1.0/projects/%s/repos/%s", projectKey, repoSlug)
 	req, err := http.NewRequest("GET", u, nil)
@@ -117,12 +135,47 @@ func (c *Client) RecentRepos(ctx context.Context, pageToken *PageToken) ([]*Repo
 	return resp.Values, resp.PageToken, nil
 }
 
+// Users lists the users whose username, name or email address match the filter.
+func (c *Client) Users(ctx context.Context, filter string, pageToken *PageToken) ([]*User, *PageToken, error) {
+	q := url.Values{"filter": []string{filter}}
+	if pageToken != nil {
+		if pageToken.NextPageStart != 0 {
+			q.Set("start", strconv.Itoa(pageToken.NextPageStart))
+		}
+		if pageToken.Limit != 0 {
+			q.Set("limit", strconv.Itoa(pageToken.Limit))
+		}
+	}
+	req, err := http.NewRequest("GET", "rest/api/1.0/users?"+q.Encode(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var resp struct {
+		*PageToken
+		Values []*User
+	}
+	err = c.do(ctx, req, &resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Values, resp.PageToken, nil
+}
+
 func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) error {
 	req.URL = c.URL.ResolveReference(req.URL)
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
-	// Authenticate request, preferring token.
-	if c.Token != "" {
+	// Authenticate request, preferring OAuth and then token.
+	if c.OAuth != nil {
+		if c.sudo != "" {
+			q := req.URL.Query()
+			q.Set("user_id", c.sudo)
+			req.URL.RawQuery = q.Encode()
+		}
+		if err := c.OAuth.sign(req); err != nil {
+			return err
+		}
+	} else if c.Token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.Token)
 	} else if c.Username != "" || c.Password != "" {
 		req.SetBasicAuth(c.Username, c.Password)
@@ -133,8 +186,10 @@ func (c *Client) do(ctx context.Context, req *http.Request, result interface{})
 		nethttp.ClientTrace(false))
 	defer ht.Finish()
 
-	if err := c.RateLimit.Wait(ctx); err != nil {
-		return err
+	if c.RateLimit != nil {
+		if err := c.RateLimit.Wait(ctx); err != nil {
+			return err
+		}
 	}
 	resp, err := ctxhttp.Do(ctx, c.HTTPClient, req)
 	if err != nil {
@@ -221,6 +276,16 @@ type Project struct {
 	} `json:"links"`
 }
 
+type User struct {
+	Name         string `json:"name"` // the username
+	Slug         string `json:"slug"`
+	ID           int    `json:"id"`
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress"`
+	Active       bool   `json:"active"`
+	Type         string `json:"type"`
+}
+
 type httpError struct {
 	StatusCode int
 	URL        *url.URL
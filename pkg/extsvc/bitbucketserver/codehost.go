@@ -1,5 +1,28 @@
 package bitbucketserver
 
+import (
+	"net/url"
+
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+)
+
 // ServiceType is the (api.ExternalRepoSpec).ServiceType value for Bitbucket Server projects. The
 // ServiceID value is the base URL to the Bitbucket Server instance.
 const ServiceType = "bitbucketServer"
+
+// CodeHost is the Bitbucket Server instance at a base URL.
+type CodeHost struct {
+	id string
+}
+
+func NewCodeHost(baseURL *url.URL) *CodeHost {
+	return &CodeHost{id: extsvc.NormalizeBaseURL(baseURL).String()}
+}
+
+func (h *CodeHost) ServiceID() string {
+	return h.id
+}
+
+func (h *CodeHost) ServiceType() string {
+	return ServiceType
+}
@@ -0,0 +1,153 @@
+package bitbucketserver
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OAuth is the OAuth consumer of an incoming Bitbucket Server application link. Requests are
+// signed with OAuth 1.0a (RSA-SHA1) without an access token ("2-legged OAuth"). If the application
+// link allows user impersonation, a client can act as any Bitbucket Server user (see
+// (*Client).Sudo).
+type OAuth struct {
+	// ConsumerKey is the consumer key of the application link.
+	ConsumerKey string
+
+	// SigningKey is the private key whose public key is configured in the application link.
+	SigningKey *rsa.PrivateKey
+}
+
+// ParseSigningKey parses a PEM-encoded RSA private key (in PKCS #1 or PKCS #8 form). The PEM data may
+// itself be base64-encoded, so that it fits on a single line in the site configuration.
+func ParseSigningKey(key string) (*rsa.PrivateKey, error) {
+	data := []byte(key)
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key)); err == nil {
+		data = decoded
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not a PEM-encoded private key")
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing signing key")
+	}
+	rsaKey, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an RSA private key")
+	}
+	return rsaKey, nil
+}
+
+// oauthNow and oauthNonce are replaced by tests.
+var (
+	oauthNow   = time.Now
+	oauthNonce = func() string {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			panic(err)
+		}
+		return hex.EncodeToString(b[:])
+	}
+)
+
+// sign sets the OAuth Authorization header of the request. The request's query parameters are
+// part of the signature, so they must not be changed afterwards.
+func (o *OAuth) sign(req *http.Request) error {
+	params := map[string]string{
+		"oauth_consumer_key":     o.ConsumerKey,
+		"oauth_nonce":            oauthNonce(),
+		"oauth_signature_method": "RSA-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(oauthNow().Unix(), 10),
+		"oauth_token":            "",
+		"oauth_version":          "1.0",
+	}
+
+	hashed := sha1.Sum([]byte(oauthSignatureBase(req.Method, req.URL, params)))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, o.SigningKey, crypto.SHA1, hashed[:])
+	if err != nil {
+		return err
+	}
+	params["oauth_signature"] = base64.StdEncoding.EncodeToString(sig)
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = oauthEscape(k) + `="` + oauthEscape(params[k]) + `"`
+	}
+	req.Header.Set("Authorization", "OAuth "+strings.Join(parts, ", "))
+	return nil
+}
+
+// oauthSignatureBase returns the signature base string of a request with the given OAuth protocol
+// parameters. See https://tools.ietf.org/html/rfc5849#section-3.4.1.
+func oauthSignatureBase(method string, u *url.URL, oauthParams map[string]string) string {
+	type param struct{ k, v string }
+	var params []param
+	for k, v := range oauthParams {
+		params = append(params, param{oauthEscape(k), oauthEscape(v)})
+	}
+	for k, vs := range u.Query() {
+		for _, v := range vs {
+			params = append(params, param{oauthEscape(k), oauthEscape(v)})
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].k != params[j].k {
+			return params[i].k < params[j].k
+		}
+		return params[i].v < params[j].v
+	})
+	pairs := make([]string, len(params))
+	for i, p := range params {
+		pairs[i] = p.k + "=" + p.v
+	}
+
+	scheme, host := strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+	if (scheme == "http" && strings.HasSuffix(host, ":80")) || (scheme == "https" && strings.HasSuffix(host, ":443")) {
+		host = host[:strings.LastIndex(host, ":")]
+	}
+	baseURL := scheme + "://" + host + u.EscapedPath()
+
+	return strings.ToUpper(method) + "&" + oauthEscape(baseURL) + "&" + oauthEscape(strings.Join(pairs, "&"))
+}
+
+// oauthEscape percent-encodes s as required by OAuth 1.0a: all bytes except unreserved characters
+// (ALPHA, DIGIT, "-", ".", "_" and "~") are encoded. See
+// https://tools.ietf.org/html/rfc5849#section-3.6.
+func oauthEscape(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package bitbucketserver
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOAuthSignatureBase(t *testing.T) {
+	u, err := url.Parse("HTTPS://Bitbucket.Example.com:443/rest/api/1.0/repos?user_id=alice%40example.com&limit=1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := oauthSignatureBase("get", u, map[string]string{
+		"oauth_consumer_key": "sourcegraph",
+		"oauth_nonce":        "n",
+		"oauth_timestamp":    "1",
+		"oauth_token":        "",
+	})
+	want := "GET&https%3A%2F%2Fbitbucket.example.com%2Frest%2Fapi%2F1.0%2Frepos&limit%3D1000%26oauth_consumer_key%3Dsourcegraph%26oauth_nonce%3Dn%26oauth_timestamp%3D1%26oauth_token%3D%26user_id%3Dalice%2540example.com"
+	if got != want {
+		t.Errorf("got signature base\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestClient_Sudo(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oauthNow = func() time.Time { return time.Unix(1000, 0) }
+	oauthNonce = func() string { return "nonce" }
+	defer func() { oauthNow, oauthNonce = time.Now, origOAuthNonce }()
+
+	var gotReq *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReq = r
+		w.Write([]byte(`{"isLastPage":true,"values":[{"slug":"r","project":{"key":"P"}}]}`))
+	}))
+	defer srv.Close()
+
+	c := &Client{URL: mustParseURL(t, srv.URL), Token: "ignored", HTTPClient: http.DefaultClient, OAuth: &OAuth{ConsumerKey: "sourcegraph", SigningKey: key}}
+	sudo, err := c.Sudo("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos, _, err := sudo.Repos(context.Background(), &PageToken{Limit: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].Slug != "r" {
+		t.Errorf("unexpected repos %+v", repos)
+	}
+
+	if got := gotReq.URL.Query().Get("user_id"); got != "alice" {
+		t.Errorf("got user_id %q, want alice", got)
+	}
+	auth := gotReq.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "OAuth ") {
+		t.Fatalf("got Authorization header %q, want OAuth", auth)
+	}
+	params := map[string]string{}
+	for _, p := range strings.Split(strings.TrimPrefix(auth, "OAuth "), ", ") {
+		kv := strings.SplitN(p, "=", 2)
+		v, err := url.PathUnescape(strings.Trim(kv[1], `"`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		params[kv[0]] = v
+	}
+	if params["oauth_consumer_key"] != "sourcegraph" || params["oauth_signature_method"] != "RSA-SHA1" || params["oauth_timestamp"] != "1000" {
+		t.Errorf("unexpected OAuth parameters %v", params)
+	}
+	sig, err := base64.StdEncoding.DecodeString(params["oauth_signature"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(params, "oauth_signature")
+	reqURL := *gotReq.URL
+	reqURL.Scheme, reqURL.Host = "http", gotReq.Host
+	hashed := sha1.Sum([]byte(oauthSignatureBase(gotReq.Method, &reqURL, params)))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA1, hashed[:], sig); err != nil {
+		t.Errorf("invalid signature: %s", err)
+	}
+
+	if _, err := (&Client{}).Sudo("alice"); err == nil {
+		t.Error("got no error for Sudo without OAuth")
+	}
+}
+
+var origOAuthNonce = oauthNonce
+
+func TestParseSigningKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	for _, s := range []string{pemKey, base64.StdEncoding.EncodeToString([]byte(pemKey))} {
+		parsed, err := ParseSigningKey(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.N.Cmp(key.N) != 0 {
+			t.Error("parsed key differs from the encoded key")
+		}
+	}
+	if _, err := ParseSigningKey("not a key"); err == nil {
+		t.Error("got no error for invalid key")
+	}
+}
+
+func mustParseURL(t *testing.T, u string) *url.URL {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
@@ -0,0 +1,194 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/metrics"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+var requestCounter = metrics.NewRequestCounter("gitea", "Total number of requests sent to the Gitea API.")
+
+// WithRequestCounter wraps the given transport with a request counter metric.
+func WithRequestCounter(transport http.RoundTripper) http.RoundTripper {
+	return requestCounter.Transport(transport, func(u *url.URL) string {
+		// The 4th component of the Path (/api/v1/XYZ) mostly maps to the type of API
+		// request we are making ("user", "repos" or "repositories").
+		var category string
+		if parts := strings.SplitN(u.Path, "/", 5); len(parts) >= 4 {
+			category = parts[3]
+		}
+		return category
+	})
+}
+
+// Client accesses a Gitea (or Gogs) instance via the REST API.
+type Client struct {
+	// URL is the base URL of Gitea, e.g. https://gitea.example.com/.
+	URL *url.URL
+
+	// Token is the access token used to authenticate requests, if set.
+	// https://gitea.example.com/user/settings/applications
+	Token string
+
+	// HTTPClient is the client used to access Gitea. To enable tracing, ensure
+	// the transport includes nethttp.Transport.
+	//
+	// To enable metrics, always wrap the Transport using WithRequestCounter.
+	HTTPClient *http.Client
+}
+
+// Repo is a Gitea repository.
+type Repo struct {
+	ID            int64  `json:"id"`
+	Owner         *User  `json:"owner"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"` // "owner/name"
+	Description   string `json:"description"`
+	Private       bool   `json:"private"`
+	Fork          bool   `json:"fork"`
+	Mirror        bool   `json:"mirror"`
+	Archived      bool   `json:"archived"`
+	HTMLURL       string `json:"html_url"`  // the web URL of this repository ("https://gitea.example.com/foo/bar")
+	SSHURL        string `json:"ssh_url"`   // SSH clone URL ("git@gitea.example.com:foo/bar.git")
+	CloneURL      string `json:"clone_url"` // HTTP clone URL
+	DefaultBranch string `json:"default_branch"`
+}
+
+// User is a Gitea user or organization.
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+// Repo gets the repository with the given owner and name.
+func (c *Client) Repo(ctx context.Context, owner, name string) (*Repo, error) {
+	u := fmt.Sprintf("api/v1/repos/%s/%s", url.PathEscape(owner), url.PathEscape(name))
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	var repo Repo
+	if err := c.do(ctx, req, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// RepoByID gets the repository with the given ID. Gogs does not support
+// looking up repositories by ID.
+func (c *Client) RepoByID(ctx context.Context, id int64) (*Repo, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("api/v1/repositories/%d", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	var repo Repo
+	if err := c.do(ctx, req, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// ListUserRepos lists all repositories that the authenticated user owns, or
+// has access to as a collaborator or organization member, fetching perPage
+// repositories per request.
+func (c *Client) ListUserRepos(ctx context.Context, perPage int) ([]*Repo, error) {
+	var (
+		all  []*Repo
+		seen = map[int64]bool{}
+	)
+	// Gitea caps the page size at its MAX_RESPONSE_ITEMS setting, so a short
+	// page is not necessarily the last one. Gogs ignores the page parameter
+	// and returns all repositories on every page, so we also stop at the first
+	// page that has no new repositories.
+	for page := 1; ; page++ {
+		repos, err := c.userRepos(ctx, page, perPage)
+		if err != nil {
+			return nil, err
+		}
+		var added bool
+		for _, r := range repos {
+			if !seen[r.ID] {
+				seen[r.ID] = true
+				all = append(all, r)
+				added = true
+			}
+		}
+		if !added {
+			return all, nil
+		}
+	}
+}
+
+// userRepos lists one page of the authenticated user's repositories. Pages are
+// numbered from 1.
+func (c *Client) userRepos(ctx context.Context, page, perPage int) ([]*Repo, error) {
+	q := url.Values{}
+	q.Set("page", strconv.Itoa(page))
+	q.Set("limit", strconv.Itoa(perPage))
+	req, err := http.NewRequest("GET", "api/v1/user/repos?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var repos []*Repo
+	if err := c.do(ctx, req, &repos); err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) error {
+	req.URL = c.URL.ResolveReference(req.URL)
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+
+	req, ht := nethttp.TraceRequest(opentracing.GlobalTracer(), req,
+		nethttp.OperationName("Gitea"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	resp, err := ctxhttp.Do(ctx, c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.WithStack(&httpError{URL: req.URL, StatusCode: resp.StatusCode})
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("unexpected %d response from Gitea API at %s", e.StatusCode, e.URL)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsNotFound reports whether err is a Gitea API error of HTTP 404.
+func IsNotFound(err error) bool {
+	e, ok := errors.Cause(err).(*httpError)
+	return ok && e.NotFound()
+}
@@ -0,0 +1,124 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// newTestServer returns a Gitea stand-in that serves the repositories with
+// the given IDs to requests authenticated with the token "t". If maxPerPage
+// is nonzero, it caps the page size like Gitea's MAX_RESPONSE_ITEMS; if it
+// is negative, it ignores pagination like Gogs.
+func newTestServer(t *testing.T, ids []int64, maxPerPage int) (*Client, func()) {
+	repo := func(id int64) *Repo {
+		name := "r" + strconv.FormatInt(id, 10)
+		return &Repo{
+			ID:       id,
+			Owner:    &User{ID: 1, Login: "o"},
+			Name:     name,
+			FullName: "o/" + name,
+			HTMLURL:  "https://gitea.example.com/o/" + name,
+			CloneURL: "https://gitea.example.com/o/" + name + ".git",
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/user/repos", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Authorization"), "token t"; got != want {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if maxPerPage > 0 && limit > maxPerPage {
+			limit = maxPerPage
+		}
+		start, end := (page-1)*limit, page*limit
+		if maxPerPage < 0 {
+			start, end = 0, len(ids)
+		}
+		repos := []*Repo{}
+		for i := start; i < end && i < len(ids); i++ {
+			repos = append(repos, repo(ids[i]))
+		}
+		json.NewEncoder(w).Encode(repos)
+	})
+	mux.HandleFunc("/api/v1/repos/o/r1", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(repo(1))
+	})
+	srv := httptest.NewServer(mux)
+
+	u, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{URL: u, Token: "t", HTTPClient: srv.Client()}, srv.Close
+}
+
+func repoIDs(repos []*Repo) []int64 {
+	ids := make([]int64, len(repos))
+	for i, r := range repos {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestClient_ListUserRepos(t *testing.T) {
+	ids := []int64{1, 2, 3, 4, 5}
+	tests := map[string]int{
+		"paginated":    0,
+		"capped limit": 1,
+		"gogs":         -1,
+	}
+	for name, maxPerPage := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, done := newTestServer(t, ids, maxPerPage)
+			defer done()
+
+			repos, err := c.ListUserRepos(context.Background(), 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := repoIDs(repos); !reflect.DeepEqual(got, ids) {
+				t.Errorf("got repos %v, want %v", got, ids)
+			}
+		})
+	}
+}
+
+func TestClient_Repo(t *testing.T) {
+	c, done := newTestServer(t, nil, 0)
+	defer done()
+
+	repo, err := c.Repo(context.Background(), "o", "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.ID != 1 || repo.FullName != "o/r1" || repo.Owner.Login != "o" {
+		t.Errorf("got unexpected repo %+v", repo)
+	}
+
+	_, err = c.Repo(context.Background(), "o", "missing")
+	if !IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+}
+
+func TestClient_unauthorized(t *testing.T) {
+	c, done := newTestServer(t, []int64{1}, 0)
+	defer done()
+	c.Token = "wrong"
+
+	_, err := c.ListUserRepos(context.Background(), 2)
+	if e, ok := errors.Cause(err).(*httpError); !ok || !e.Unauthorized() {
+		t.Errorf("got error %v, want unauthorized", err)
+	}
+}
@@ -0,0 +1,3 @@
+// Package gitea implements a Gitea API client. Gogs exposes a compatible API,
+// so the client works with Gogs instances as well.
+package gitea
@@ -207,14 +207,16 @@ func (c *Client) requestGraphQL(ctx context.Context, query string, vars map[stri
 	if err := c.do(ctx, req, &respBody); err != nil {
 		return err
 	}
-	if len(respBody.Errors) > 0 {
-		return respBody.Errors
-	}
+	// The data is unmarshaled even if there are errors, because GitHub returns partial results
+	// (e.g., null for each node that wasn't found) along with the errors.
 	if result != nil && respBody.Data != nil {
 		if err := unmarshal(respBody.Data, result); err != nil {
 			return err
 		}
 	}
+	if len(respBody.Errors) > 0 {
+		return respBody.Errors
+	}
 	return nil
 }
 
@@ -296,9 +298,9 @@ func IsRateLimitExceeded(err error) bool {
 // graphqlErrors describes the errors in a GraphQL response. It contains at least 1 element when returned by
 // requestGraphQL. See https://facebook.github.io/graphql/#sec-Errors.
 type graphqlErrors []struct {
-	Message   string   `json:"message"`
-	Type      string   `json:"type"`
-	Path      []string `json:"path"`
+	Message   string        `json:"message"`
+	Type      string        `json:"type"`
+	Path      []interface{} `json:"path"` // field names and list indexes
 	Locations []struct {
 		Line   int `json:"line"`
 		Column int `json:"column"`
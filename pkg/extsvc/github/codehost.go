@@ -19,3 +19,20 @@ func ExternalRepoSpec(repo *Repository, baseURL url.URL) *api.ExternalRepoSpec {
 		ServiceID:   extsvc.NormalizeBaseURL(&baseURL).String(),
 	}
 }
+
+// CodeHost is the GitHub instance (GitHub.com or GitHub Enterprise) at a base URL.
+type CodeHost struct {
+	id string
+}
+
+func NewCodeHost(baseURL *url.URL) *CodeHost {
+	return &CodeHost{id: extsvc.NormalizeBaseURL(baseURL).String()}
+}
+
+func (h *CodeHost) ServiceID() string {
+	return h.id
+}
+
+func (h *CodeHost) ServiceType() string {
+	return ServiceType
+}
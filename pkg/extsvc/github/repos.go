@@ -290,6 +290,62 @@ query Repository($id: ID!) {
 	return result.Node, nil
 }
 
+// GetRepositoriesByNodeIDs gets the repositories with the given GraphQL node IDs that are visible
+// to the client's token. Repositories that don't exist or that the token can't read are omitted
+// from the result (instead of causing an error), so that the result tells which of the
+// repositories are readable. The result is not cached.
+func (c *Client) GetRepositoriesByNodeIDs(ctx context.Context, ids []string) ([]*Repository, error) {
+	const batchSize = 100 // the maximum number of nodes per query allowed by GitHub
+	var repos []*Repository
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		ids = ids[len(batch):]
+
+		var result struct {
+			Nodes []*Repository `json:"nodes"`
+		}
+		err := c.requestGraphQL(ctx, `
+query Repositories($ids: [ID!]!) {
+	nodes(ids: $ids) {
+		... on Repository {
+			...RepositoryFields
+		}
+	}
+}`+c.repositoryFieldsGraphQLFragment(),
+			map[string]interface{}{"ids": batch},
+			&result,
+		)
+		if err != nil && !isOnlyNotFound(err) {
+			return nil, err
+		}
+		for _, repo := range result.Nodes {
+			// Nodes that aren't found are null, and nodes that aren't repositories are empty.
+			if repo != nil && repo.ID != "" {
+				repos = append(repos, repo)
+			}
+		}
+	}
+	return repos, nil
+}
+
+// isOnlyNotFound reports whether err is a GraphQL error response whose errors are all of type
+// NOT_FOUND.
+func isOnlyNotFound(err error) bool {
+	errs, ok := err.(graphqlErrors)
+	if !ok {
+		return false
+	}
+	for _, err := range errs {
+		if err.Type != "NOT_FOUND" {
+			return false
+		}
+	}
+	return true
+}
+
 func (c *Client) ListPublicRepositories(ctx context.Context, sinceRepoID int64) ([]*Repository, error) {
 	repos, err := c.getPublicRepositories(ctx, sinceRepoID)
 	if err != nil {
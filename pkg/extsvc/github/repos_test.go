@@ -233,6 +233,51 @@ func repoListsAreEqual(a []*Repository, b []*Repository) bool {
 	return true
 }
 
+// TestClient_GetRepositoriesByNodeIDs tests that GetRepositoriesByNodeIDs omits the repositories
+// that are not found instead of returning an error.
+func TestClient_GetRepositoriesByNodeIDs(t *testing.T) {
+	mock := mockHTTPResponseBody{
+		responseBody: `
+{
+	"data": {
+		"nodes": [
+			{
+				"id": "i",
+				"nameWithOwner": "o/n",
+				"isPrivate": true
+			},
+			null
+		]
+	},
+	"errors": [
+		{
+			"type": "NOT_FOUND",
+			"path": ["nodes", 1],
+			"message": "Could not resolve to a node with the global id of 'j'"
+		}
+	]
+}
+`}
+	c := newTestClient(t)
+	c.httpClient.Transport = &mock
+
+	repos, err := c.GetRepositoriesByNodeIDs(context.Background(), []string{"i", "j"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*Repository{{ID: "i", NameWithOwner: "o/n", IsPrivate: true}}; !reflect.DeepEqual(repos, want) {
+		t.Errorf("got repositories %+v, want %+v", repos, want)
+	}
+	if mock.count != 1 {
+		t.Errorf("mock.count == %d, want 1", mock.count)
+	}
+
+	mock.responseBody = `{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`
+	if _, err := c.GetRepositoriesByNodeIDs(context.Background(), []string{"i"}); !IsRateLimitExceeded(err) {
+		t.Errorf("got err == %v, want IsRateLimitExceeded(err) == true", err)
+	}
+}
+
 func TestClient_ListRepositoriesForSearch(t *testing.T) {
 	mock := mockHTTPResponseBody{
 		responseBody: `
@@ -0,0 +1,132 @@
+package gitserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs"
+)
+
+// BatchResult is the result of a command run by (*Client).Batch.
+type BatchResult struct {
+	Stdout []byte
+	Stderr []byte
+	Err    error // the error of the command, as returned by (*Cmd).DividedOutput
+}
+
+// Batch runs cmds, which must all be for the same repository, in order using
+// a single request to gitserver. It returns the result of each command, and
+// sets the ExitStatus of each command. The returned error is non-nil if the
+// batch as a whole failed (e.g., because the repository is not cloned).
+//
+// If gitserver does not support batches (e.g., during a rolling upgrade), the
+// commands are run one at a time.
+func (c *Client) Batch(ctx context.Context, cmds []*Cmd) (_ []BatchResult, errRes error) {
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	repoName := protocol.NormalizeRepo(cmds[0].Repo.Name)
+	for _, cmd := range cmds[1:] {
+		if protocol.NormalizeRepo(cmd.Repo.Name) != repoName {
+			return nil, fmt.Errorf("all commands in a batch must be for the same repository (got %q and %q)", repoName, cmd.Repo.Name)
+		}
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Client.Batch")
+	defer func() {
+		if errRes != nil {
+			ext.Error.Set(span, true)
+			span.SetTag("err", errRes.Error())
+		}
+		span.Finish()
+	}()
+	span.SetTag("repo", repoName)
+	span.SetTag("commands", len(cmds))
+
+	// Check that ctx is not expired.
+	if err := ctx.Err(); err != nil {
+		deadlineExceededCounter.Inc()
+		return nil, err
+	}
+
+	req := &protocol.BatchExecRequest{
+		Repo:     repoName,
+		URL:      cmds[0].Repo.URL,
+		Requests: make([]protocol.ExecRequest, len(cmds)),
+	}
+	for i, cmd := range cmds {
+		req.Requests[i] = protocol.ExecRequest{
+			EnsureRevision: cmd.EnsureRevision,
+			Args:           cmd.Args[1:],
+		}
+	}
+	resp, err := c.httpPost(ctx, repoName, "batch-exec", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return readBatchResults(resp.Body, cmds)
+
+	case http.StatusNotFound:
+		var payload protocol.NotFoundPayload
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			// The gitserver predates the batch-exec endpoint.
+			return runUnbatched(ctx, cmds), nil
+		}
+		return nil, &vcs.RepoNotExistError{Repo: repoName, CloneInProgress: payload.CloneInProgress, CloneProgress: payload.CloneProgress}
+
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+// readBatchResults reads the results of cmds from the body of a batch-exec
+// response. Each result is a protocol.BatchExecResult on its own line,
+// followed by the standard output of the command.
+func readBatchResults(body io.Reader, cmds []*Cmd) ([]BatchResult, error) {
+	r := bufio.NewReader(body)
+	results := make([]BatchResult, len(cmds))
+	for i, cmd := range cmds {
+		// JSON-encoded values never contain a raw newline, so the line is
+		// exactly the result.
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading result %d of batch", i)
+		}
+		var res protocol.BatchExecResult
+		if err := json.Unmarshal(line, &res); err != nil {
+			return nil, errors.Wrapf(err, "decoding result %d of batch", i)
+		}
+		stdout := make([]byte, res.StdoutLen)
+		if _, err := io.ReadFull(r, stdout); err != nil {
+			return nil, errors.Wrapf(err, "reading stdout of result %d of batch", i)
+		}
+
+		cmd.ExitStatus = res.ExitStatus
+		results[i] = BatchResult{Stdout: stdout, Stderr: []byte(res.Stderr)}
+		if res.Error != "" {
+			results[i].Err = errors.New(res.Error)
+		}
+	}
+	return results, nil
+}
+
+// runUnbatched runs each of cmds with its own request to gitserver.
+func runUnbatched(ctx context.Context, cmds []*Cmd) []BatchResult {
+	results := make([]BatchResult, len(cmds))
+	for i, cmd := range cmds {
+		results[i].Stdout, results[i].Stderr, results[i].Err = cmd.DividedOutput(ctx)
+	}
+	return results
+}
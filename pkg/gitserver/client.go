@@ -21,6 +21,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	multierror "github.com/hashicorp/go-multierror"
 	"github.com/neelance/parallel"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -56,6 +57,7 @@ var DefaultClient = &Client{
 	// which service is making the request (excluding requests proxied via the
 	// frontend internal API)
 	UserAgent: filepath.Base(os.Args[0]),
+	Replicas:  replicationFactor,
 }
 
 func init() {
@@ -123,14 +125,57 @@ type Client struct {
 	// UserAgent is a string identifing who the client is. It will be logged in
 	// the telemetry in gitserver.
 	UserAgent string
+
+	// Replicas is the number of gitservers that each repository is cloned
+	// on. Requests for a repository fail over to its replicas when its
+	// primary gitserver is unreachable. 0 and 1 both mean that repositories
+	// are not replicated.
+	Replicas int
 }
 
+// replicationFactor is the number of gitservers that each repository is
+// cloned on. It must be the same for all services.
+var replicationFactor, _ = strconv.Atoi(env.Get("SRC_GIT_SERVER_REPLICAS", "1", "number of gitservers that each repository is cloned on"))
+
 // addrForRepo returns the gitserver address to use for the given repo name.
 func (c *Client) addrForRepo(ctx context.Context, repo api.RepoName) string {
 	repo = protocol.NormalizeRepo(repo) // in case the caller didn't already normalize it
 	return c.addrForKey(ctx, string(repo))
 }
 
+// AddrsForRepo returns the addresses of the gitservers that the given repo
+// is cloned on. The first address is the repo's primary gitserver (the one
+// returned by addrForRepo), and the others are its replicas.
+func (c *Client) AddrsForRepo(ctx context.Context, repo api.RepoName) []string {
+	repo = protocol.NormalizeRepo(repo)
+	addrs := c.Addrs(ctx)
+	if len(addrs) == 0 {
+		panic("unexpected state: no gitserver addresses")
+	}
+	n := c.Replicas
+	if n < 1 {
+		n = 1
+	}
+	if n > len(addrs) {
+		n = len(addrs)
+	}
+
+	// The replicas are the gitservers after the primary in the address list,
+	// so that the primary is the same as without replication.
+	primary := c.addrForKey(ctx, string(repo))
+	var i int
+	for i = range addrs {
+		if addrs[i] == primary {
+			break
+		}
+	}
+	repoAddrs := make([]string, n)
+	for j := range repoAddrs {
+		repoAddrs[j] = addrs[(i+j)%len(addrs)]
+	}
+	return repoAddrs
+}
+
 // addrForKey returns the gitserver address to use for the given string key,
 // which is hashed for sharding purposes.
 func (c *Client) addrForKey(ctx context.Context, key string) string {
@@ -225,6 +270,10 @@ type Repo struct {
 	// this field is optional (it will use the last-used Git remote URL). If the repository is not
 	// cloned on the gitserver, the request will fail.
 	URL string
+
+	// CloneOptions, if set, specify how gitserver clones and fetches the repository. They are only
+	// sent with requests to update the repository.
+	CloneOptions *protocol.CloneOptions
 }
 
 // Command creates a new Cmd. Command name must be 'git',
@@ -333,6 +382,13 @@ func (c *Client) ListGitolite(ctx context.Context, gitoliteHost string) ([]strin
 	return doListOne(ctx, "?gitolite="+url.QueryEscape(gitoliteHost), c.addrForKey(ctx, gitoliteHost))
 }
 
+// ListPerforceDepots lists the paths of the depots on the Perforce server at
+// p4port, which must be configured in the site configuration.
+func (c *Client) ListPerforceDepots(ctx context.Context, p4port string) ([]string, error) {
+	// Like ListGitolite, only a single gitserver calls the Perforce server.
+	return doListOne(ctx, "?perforce="+url.QueryEscape(p4port), c.addrForKey(ctx, p4port))
+}
+
 // ListCloned lists all cloned repositories
 func (c *Client) ListCloned(ctx context.Context) ([]string, error) {
 	var (
@@ -393,9 +449,10 @@ func doListOne(ctx context.Context, urlSuffix string, addr string) ([]string, er
 // update won't happen.
 func (c *Client) RequestRepoUpdate(ctx context.Context, repo Repo, since time.Duration) (*protocol.RepoUpdateResponse, error) {
 	req := &protocol.RepoUpdateRequest{
-		Repo:  repo.Name,
-		URL:   repo.URL,
-		Since: since,
+		Repo:         repo.Name,
+		URL:          repo.URL,
+		Since:        since,
+		CloneOptions: repo.CloneOptions,
 	}
 	resp, err := c.httpPost(ctx, repo.Name, "repo-update", req)
 	if err != nil {
@@ -523,7 +580,24 @@ func (c *Client) Remove(ctx context.Context, repo api.RepoName) error {
 	req := &protocol.RepoDeleteRequest{
 		Repo: repo,
 	}
-	resp, err := c.httpPost(ctx, repo, "delete", req)
+	addrs := c.AddrsForRepo(ctx, repo)
+	if err := c.removeFrom(ctx, addrs[0], req); err != nil {
+		return err
+	}
+	// Remove the repository from its replicas too, so that they don't serve
+	// it if its primary gitserver becomes unavailable. This is best-effort,
+	// so that a replica that is down doesn't fail the removal.
+	for _, addr := range addrs[1:] {
+		if err := c.removeFrom(ctx, addr, req); err != nil {
+			log15.Warn("Failed to remove repository from replica", "repo", repo, "addr", addr, "error", err)
+		}
+	}
+	return nil
+}
+
+// removeFrom removes a repository from the gitserver at addr.
+func (c *Client) removeFrom(ctx context.Context, addr string, req *protocol.RepoDeleteRequest) error {
+	resp, err := c.httpPostAddr(ctx, addr, "delete", req)
 	if err != nil {
 		return err
 	}
@@ -536,7 +610,110 @@ func (c *Client) Remove(ctx context.Context, repo api.RepoName) error {
 	return nil
 }
 
+// MockRenameRepo mocks (*Client).RenameRepo for tests.
+var MockRenameRepo func(from, to api.RepoName) error
+
+// renameRepoAttempts is the number of times that RenameRepo sends its request to each gitserver.
+const renameRepoAttempts = 3
+
+// RenameRepo moves the clone of a repository that was renamed on its code host to its new name, so
+// that it doesn't have to be cloned again. Gitservers that don't also serve the new name (because
+// the new name is sharded to other gitservers) delete their clone instead.
+//
+// The requests are idempotent, so each is retried a few times, and a gitserver that fails doesn't
+// prevent the others from moving their clones. (Clones that are left under the old name are
+// removed by repo-updater's purge worker, since the old name is no longer a repository.)
+func (c *Client) RenameRepo(ctx context.Context, from, to api.RepoName) error {
+	if MockRenameRepo != nil {
+		return MockRenameRepo(from, to)
+	}
+
+	toAddrs := make(map[string]bool)
+	for _, addr := range c.AddrsForRepo(ctx, to) {
+		toAddrs[addr] = true
+	}
+	var errs *multierror.Error
+	for _, addr := range c.AddrsForRepo(ctx, from) {
+		var err error
+		for i := 0; i < renameRepoAttempts; i++ {
+			if i > 0 {
+				time.Sleep(time.Duration(i) * time.Second)
+			}
+			if toAddrs[addr] {
+				err = c.renameAt(ctx, addr, &protocol.RepoRenameRequest{From: from, To: to})
+			} else {
+				err = c.removeFrom(ctx, addr, &protocol.RepoDeleteRequest{Repo: from})
+			}
+			if err == nil || ctx.Err() != nil {
+				break
+			}
+		}
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// renameAt moves a repository clone on the gitserver at addr to its new name.
+func (c *Client) renameAt(ctx context.Context, addr string, req *protocol.RepoRenameRequest) error {
+	resp, err := c.httpPostAddr(ctx, addr, "rename", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return &url.Error{URL: resp.Request.URL.String(), Op: "RenameRepo", Err: fmt.Errorf("RenameRepo: http status %d: %s", resp.StatusCode, string(body))}
+	}
+	return nil
+}
+
+// httpPost sends a request for repo to its primary gitserver. Requests that
+// don't modify the repository fail over to its replicas if the primary
+// gitserver is unreachable.
 func (c *Client) httpPost(ctx context.Context, repo api.RepoName, method string, payload interface{}) (resp *http.Response, err error) {
+	addrs := c.AddrsForRepo(ctx, repo)
+	if !failoverMethods[method] {
+		addrs = addrs[:1]
+	}
+	for i, addr := range addrs {
+		resp, err = c.httpPostAddr(ctx, addr, method, payload)
+		if err == nil || ctx.Err() != nil {
+			return resp, err
+		}
+		if i+1 < len(addrs) {
+			log15.Warn("gitserver unreachable, failing over to replica", "repo", repo, "addr", addr, "replica", addrs[i+1], "error", err)
+			failoverCounter.Inc()
+		}
+	}
+	return resp, err
+}
+
+// failoverMethods are the methods that may be sent to a replica of a
+// repository.
+var failoverMethods = map[string]bool{
+	"exec":              true,
+	"batch-exec":        true,
+	"is-repo-cloneable": true,
+	"is-repo-cloned":    true,
+	"repo":              true,
+}
+
+var failoverCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "gitserver",
+	Name:      "client_failovers",
+	Help:      "Times that a request to gitserver was retried on a replica because the primary gitserver was unreachable",
+})
+
+func init() {
+	prometheus.MustRegister(failoverCounter)
+}
+
+// httpPostAddr sends a request to the gitserver at addr.
+func (c *Client) httpPostAddr(ctx context.Context, addr, method string, payload interface{}) (resp *http.Response, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "Client.httpPost")
 	defer func() {
 		if err != nil {
@@ -551,7 +728,6 @@ func (c *Client) httpPost(ctx context.Context, repo api.RepoName, method string,
 		return nil, err
 	}
 
-	addr := c.addrForRepo(ctx, repo)
 	req, err := http.NewRequest("POST", "http://"+addr+"/"+method, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, err
@@ -20,6 +20,35 @@ type ExecRequest struct {
 	Opt            *RemoteOpts `json:"opt"`
 }
 
+// BatchExecRequest is a request to execute several commands, in order, inside
+// the same git repository.
+//
+// The response body consists of one result for each command, in the same
+// order: a line with a JSON-encoded BatchExecResult, followed by the
+// command's standard output (BatchExecResult.StdoutLen bytes).
+type BatchExecRequest struct {
+	Repo api.RepoName `json:"repo"`
+
+	// URL is the repository's Git remote URL (see ExecRequest.URL).
+	URL string `json:"url,omitempty"`
+
+	// Requests are the commands to execute. Their Repo must be empty or equal
+	// to Repo, and their URL is ignored.
+	Requests []ExecRequest `json:"requests"`
+}
+
+// BatchExecResult is the result of executing one command of a
+// BatchExecRequest. Error, ExitStatus and Stderr correspond to the
+// X-Exec-Error, X-Exec-Exit-Status and X-Exec-Stderr trailers of the response
+// to an ExecRequest. A command whose standard output is too large for
+// gitserver to hold in memory fails with an Error.
+type BatchExecResult struct {
+	Error      string `json:"error,omitempty"`
+	ExitStatus int    `json:"exitStatus"`
+	Stderr     string `json:"stderr,omitempty"` // at most the first 1024 bytes of the standard error (as in X-Exec-Stderr)
+	StdoutLen  int    `json:"stdoutLen"`        // the length of the standard output that follows the result
+}
+
 // RemoteOpts configures interactions with a remote repository.
 type RemoteOpts struct {
 	SSH   *SSHConfig   `json:"ssh"`   // SSH configuration for communication with the remote
@@ -44,6 +73,27 @@ type RepoUpdateRequest struct {
 	Repo  api.RepoName  `json:"repo"`  // identifying URL for repo
 	URL   string        `json:"url"`   // repo's remote URL
 	Since time.Duration `json:"since"` // debounce interval for queries, used only with request-repo-update
+
+	// CloneOptions, if set, replace the options that the repo is cloned and
+	// fetched with.
+	CloneOptions *CloneOptions `json:"cloneOptions,omitempty"`
+}
+
+// CloneOptions specify how gitserver clones and fetches a repo. They are
+// useful for very large repos, which take too long to clone or use too much
+// disk when cloned in full.
+type CloneOptions struct {
+	// Filter is a partial clone filter, such as "blob:none". Objects left out
+	// by the filter are fetched from the remote when they are needed.
+	Filter string `json:"filter,omitempty"`
+
+	// Refs are patterns of the refs to fetch, such as "refs/heads/*". If
+	// empty, all branches, tags and pull request refs are fetched.
+	Refs []string `json:"refs,omitempty"`
+
+	// ExcludeRefs are patterns of refs not to fetch, such as
+	// "refs/heads/archive/*".
+	ExcludeRefs []string `json:"excludeRefs,omitempty"`
 }
 
 // RepoUpdateResponse returns meta information of the repo enqueued for
@@ -104,6 +154,15 @@ type RepoDeleteRequest struct {
 	Repo api.RepoName
 }
 
+// RepoRenameRequest is a request to move a repository clone on gitserver to a new name, because
+// the repository was renamed on its code host.
+type RepoRenameRequest struct {
+	// From is the repository's old name.
+	From api.RepoName
+	// To is the repository's new name.
+	To api.RepoName
+}
+
 // RepoInfoResponse is the response to a repository information request (RepoInfoRequest).
 type RepoInfoResponse struct {
 	URL             string     // this repository's Git remote URL
@@ -112,6 +171,7 @@ type RepoInfoResponse struct {
 	Cloned          bool       // whether the repository has been cloned successfully
 	LastFetched     *time.Time // when the last `git remote update` or `git fetch` occurred
 	LastChanged     *time.Time // timestamp of the most recent ref in the git repository
+	LastMaintained  *time.Time // when gitserver last ran maintenance (git gc etc.) on the repository
 
 	// CloneTime is the time the clone occurred. Note: Repositories may be
 	// recloned automatically, so this time is likely to move forward
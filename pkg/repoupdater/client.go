@@ -5,7 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"github.com/opentracing/opentracing-go"
@@ -29,6 +32,10 @@ var (
 	// ErrTemporarilyUnavailable is when the repository was reported as being temporarily
 	// unavailable.
 	ErrTemporarilyUnavailable = errors.New("repository temporarily unavailable")
+
+	// ErrSchedulerDisabled is when the repository update scheduler
+	// (experimentalFeatures.updateScheduler2) is not enabled.
+	ErrSchedulerDisabled = errors.New("repository update scheduler is not enabled")
 )
 
 // DefaultClient is the default Client. Unless overwritten, it is connected to the server specified by the
@@ -142,6 +149,90 @@ func (c *Client) EnqueueRepoUpdate(ctx context.Context, repo gitserver.Repo) err
 	return nil
 }
 
+// MockSchedulerStatus mocks (*Client).SchedulerStatus for tests.
+var MockSchedulerStatus func(ctx context.Context) (*protocol.SchedulerStatus, error)
+
+// SchedulerStatus returns the status of the repository update scheduler. It
+// returns ErrSchedulerDisabled if the update scheduler is not enabled.
+func (c *Client) SchedulerStatus(ctx context.Context) (*protocol.SchedulerStatus, error) {
+	if MockSchedulerStatus != nil {
+		return MockSchedulerStatus(ctx)
+	}
+
+	resp, err := c.httpPost(ctx, "scheduler-status", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrSchedulerDisabled
+	default:
+		return nil, fmt.Errorf("SchedulerStatus: http status %d", resp.StatusCode)
+	}
+
+	var status protocol.SchedulerStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// MockSchedulerBumpRepo mocks (*Client).SchedulerBumpRepo for tests.
+var MockSchedulerBumpRepo func(ctx context.Context, repo api.RepoName) error
+
+// SchedulerBumpRepo moves the named repository to the front of the update
+// queue of the repository update scheduler. It returns ErrSchedulerDisabled if
+// the update scheduler is not enabled.
+func (c *Client) SchedulerBumpRepo(ctx context.Context, repo api.RepoName) error {
+	if MockSchedulerBumpRepo != nil {
+		return MockSchedulerBumpRepo(ctx, repo)
+	}
+
+	resp, err := c.httpPost(ctx, "scheduler-bump-repo", &protocol.RepoUpdateRequest{Repo: repo})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return schedulerResponseError("SchedulerBumpRepo", resp)
+}
+
+// MockSchedulerPauseSource mocks (*Client).SchedulerPauseSource for tests.
+var MockSchedulerPauseSource func(ctx context.Context, source string, paused bool) error
+
+// SchedulerPauseSource pauses or resumes scheduled updates of the
+// repositories of the given update scheduler source. It returns
+// ErrSchedulerDisabled if the update scheduler is not enabled.
+func (c *Client) SchedulerPauseSource(ctx context.Context, source string, paused bool) error {
+	if MockSchedulerPauseSource != nil {
+		return MockSchedulerPauseSource(ctx, source, paused)
+	}
+
+	resp, err := c.httpPost(ctx, "scheduler-pause-source", &protocol.SchedulerPauseRequest{Source: source, Paused: paused})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return schedulerResponseError(fmt.Sprintf("SchedulerPauseSource(%q)", source), resp)
+}
+
+// schedulerResponseError returns the error of a response to a request to
+// the repository update scheduler, or nil if it succeeded.
+func schedulerResponseError(op string, resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrSchedulerDisabled
+	default:
+		// best-effort inclusion of body in error message
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("%s: http status %d: %s", op, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+}
+
 func (c *Client) httpPost(ctx context.Context, method string, payload interface{}) (resp *http.Response, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "Client.httpPost")
 	defer func() {
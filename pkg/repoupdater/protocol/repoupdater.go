@@ -3,6 +3,7 @@ package protocol
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 )
@@ -96,3 +97,43 @@ type RepoUpdateRequest struct {
 	// URL is the repository's Git remote URL (from which to clone or update).
 	URL string `json:"url"`
 }
+
+// SchedulerStatus is the status of repo-updater's repository update scheduler.
+type SchedulerStatus struct {
+	// Repos are the repositories in the schedule or in the update queue, ordered by their position
+	// in the update queue, followed by repositories that are updating and then the rest of the
+	// schedule in the order that repositories are due.
+	Repos []*ScheduledRepo
+
+	// Sources are the configuration sources (such as code host connections) of the scheduled
+	// repositories.
+	Sources []*SchedulerSource
+}
+
+// ScheduledRepo is the update schedule of a repository.
+type ScheduledRepo struct {
+	Name api.RepoName
+
+	Due           time.Time     // when the repository is next enqueued for an update (zero if it is not in the schedule)
+	Interval      time.Duration // how regularly the repository is updated
+	LastError     string        // the error of the last update, if any
+	QueuePosition int           // the position in the update queue, starting at 1 (0 if it is not queued)
+	Updating      bool          // whether the repository is being updated
+}
+
+// SchedulerSource is a source of the repositories in the update scheduler.
+type SchedulerSource struct {
+	Name   string // e.g. "github:https://github.com#1a2b3c4d"
+	Repos  int    // the number of repositories from the source
+	Paused bool   // whether scheduled updates of the source's repositories are paused
+
+	LastSync      time.Time // when the source's repositories were last synced from the code host (zero if never)
+	LastSyncError string    // the error that occurred while listing the source's repositories in the last sync, if any
+}
+
+// SchedulerPauseRequest is a request to pause or resume scheduled updates of the repositories of
+// an update scheduler source.
+type SchedulerPauseRequest struct {
+	Source string `json:"source"`
+	Paused bool   `json:"paused"`
+}
@@ -39,6 +39,11 @@ type Request struct {
 	// The deadline for the search request.
 	// It is parsed with time.Time.UnmarshalText.
 	Deadline string
+
+	// Stream, if true, requests a streaming response (see StreamEvent) that
+	// sends each file match as soon as it is found, instead of a single
+	// Response once the search is done.
+	Stream bool
 }
 
 // GitserverRepo returns the repository information necessary to perform gitserver requests.
@@ -61,6 +66,13 @@ type PatternInfo struct {
 	// when finding matches.
 	IsCaseSensitive bool
 
+	// IsStructuralPat if true will treat the Pattern as a structural
+	// pattern: source code with holes such as :[name] that match balanced
+	// text. IsRegExp, IsWordMatch and IsCaseSensitive are ignored for
+	// structural patterns, which are always case sensitive. eg
+	// "fmt.Sprintf(:[args])"
+	IsStructuralPat bool
+
 	// ExcludePattern is a pattern that may not match the returned files' paths.
 	// eg '**/node_modules'
 	ExcludePattern string
@@ -125,6 +137,35 @@ type Response struct {
 	DeadlineHit bool
 }
 
+// StreamContentType is the Content-Type of a streaming response (see
+// Request.Stream).
+const StreamContentType = "application/x-ndjson"
+
+// StreamEvent is an event of a streaming response. A streaming response is a
+// sequence of JSON-encoded StreamEvents, one per line. Every event except for
+// the last one has Match set. The last event has Trailer set; a response
+// without a trailer is incomplete (e.g., because searcher went away).
+//
+// Errors that occur before the first event is sent are reported with an HTTP
+// error status, like for non-streaming requests.
+type StreamEvent struct {
+	Match   *FileMatch     `json:",omitempty"`
+	Trailer *StreamTrailer `json:",omitempty"`
+}
+
+// StreamTrailer is the last event of a streaming response.
+type StreamTrailer struct {
+	// LimitHit is true if the matches sent may not include all FileMatches because a match limit was hit.
+	LimitHit bool
+
+	// DeadlineHit is true if the matches sent may not include all FileMatches because a deadline was hit.
+	DeadlineHit bool
+
+	// Error is the error that stopped the search after matches were sent, if
+	// any.
+	Error string `json:",omitempty"`
+}
+
 // FileMatch is the struct used by vscode to receive search results
 type FileMatch struct {
 	Path        string
@@ -0,0 +1,101 @@
+package git
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+)
+
+// Commands for the same repository that are run within batchWindow of each
+// other are sent to gitserver in a single batch of at most batchMaxCommands.
+// This helps callers that run many small commands concurrently, such as
+// resolving the last commit of each entry in a directory listing.
+const (
+	batchWindow      = 2 * time.Millisecond
+	batchMaxCommands = 50
+)
+
+var (
+	pendingBatchesMu sync.Mutex
+	pendingBatches   = map[api.RepoName]*cmdBatch{}
+)
+
+type cmdBatch struct {
+	cmds    []*gitserver.Cmd
+	results []gitserver.BatchResult
+	err     error
+	done    chan struct{}
+
+	// ctx is canceled once every caller waiting on the batch has given up.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiting int // guarded by pendingBatchesMu
+}
+
+// batchedDividedOutput is like cmd.DividedOutput, except that cmd may be sent
+// to gitserver in a batch with other commands for the same repository.
+func batchedDividedOutput(ctx context.Context, cmd *gitserver.Cmd) (stdout, stderr []byte, err error) {
+	pendingBatchesMu.Lock()
+	b := pendingBatches[cmd.Repo.Name]
+	if b == nil || len(b.cmds) >= batchMaxCommands {
+		b = &cmdBatch{done: make(chan struct{})}
+		b.ctx, b.cancel = context.WithCancel(context.Background())
+		pendingBatches[cmd.Repo.Name] = b
+		go b.run(cmd.Repo.Name)
+	}
+	i := len(b.cmds)
+	b.cmds = append(b.cmds, cmd)
+	b.waiting++
+	pendingBatchesMu.Unlock()
+
+	select {
+	case <-b.done:
+		if b.err != nil {
+			return nil, nil, b.err
+		}
+		res := b.results[i]
+		return res.Stdout, res.Stderr, res.Err
+	case <-ctx.Done():
+		pendingBatchesMu.Lock()
+		b.waiting--
+		if b.waiting == 0 {
+			b.cancel()
+		}
+		pendingBatchesMu.Unlock()
+		return nil, nil, ctx.Err()
+	}
+}
+
+// run waits for other commands to join the batch and then runs it. The batch
+// is shared by many callers, so it runs until the last of them gives up.
+func (b *cmdBatch) run(repo api.RepoName) {
+	defer close(b.done)
+	defer b.cancel()
+
+	select {
+	case <-time.After(batchWindow):
+	case <-b.ctx.Done():
+	}
+
+	pendingBatchesMu.Lock()
+	if pendingBatches[repo] == b {
+		delete(pendingBatches, repo)
+	}
+	pendingBatchesMu.Unlock()
+
+	if err := b.ctx.Err(); err != nil {
+		b.err = err
+		return
+	}
+
+	if len(b.cmds) == 1 {
+		var res gitserver.BatchResult
+		res.Stdout, res.Stderr, res.Err = b.cmds[0].DividedOutput(b.ctx)
+		b.results = []gitserver.BatchResult{res}
+		return
+	}
+	b.results, b.err = gitserver.DefaultClient.Batch(b.ctx, b.cmds)
+}
@@ -43,6 +43,11 @@ type CommitsOptions struct {
 	After  string // include only commits after this date
 
 	Path string // only commits modifying the given path are selected (optional)
+
+	// Batch allows the command to be sent to gitserver in a batch with other
+	// commands for the same repository that are run at about the same time.
+	// It is useful for callers that look up many commits concurrently.
+	Batch bool
 }
 
 // logEntryPattern is the regexp pattern that matches entries in the output of the `git shortlog
@@ -113,7 +118,12 @@ func commitLog(ctx context.Context, repo gitserver.Repo, opt CommitsOptions) ([]
 
 	cmd := gitserver.DefaultClient.Command("git", args...)
 	cmd.Repo = repo
-	data, stderr, err := cmd.DividedOutput(ctx)
+	var data, stderr []byte
+	if opt.Batch {
+		data, stderr, err = batchedDividedOutput(ctx, cmd)
+	} else {
+		data, stderr, err = cmd.DividedOutput(ctx)
+	}
 	if err != nil {
 		data = bytes.TrimSpace(data)
 		if isBadObjectErr(string(stderr), string(opt.Range)) {
@@ -205,6 +205,7 @@ func lsTreeUncached(ctx context.Context, repo gitserver.Repo, commit api.CommitI
 	prefixLen := strings.LastIndexByte(trimPath, '/') + 1
 	lines := strings.Split(string(out), "\x00")
 	fis := make([]os.FileInfo, len(lines)-1)
+	var gitmodules *config.Config // read when the first submodule is found
 	for i, line := range lines {
 		if i == len(lines)-1 {
 			// last entry is empty
@@ -259,20 +260,20 @@ func lsTreeUncached(ctx context.Context, repo gitserver.Repo, commit api.CommitI
 			}
 		case "commit":
 			mode = mode | ModeSubmodule
-			cmd := gitserver.DefaultClient.Command("git", "show", fmt.Sprintf("%s:.gitmodules", commit))
-			cmd.Repo = repo
-			var submodule Submodule
-			if out, err := cmd.Output(ctx); err == nil {
-
-				var cfg config.Config
-				err := config.NewDecoder(bytes.NewBuffer(out)).Decode(&cfg)
-				if err != nil {
-					return nil, fmt.Errorf("error parsing .gitmodules: %s", err)
+			if gitmodules == nil {
+				gitmodules = new(config.Config)
+				cmd := gitserver.DefaultClient.Command("git", "show", fmt.Sprintf("%s:.gitmodules", commit))
+				cmd.Repo = repo
+				if out, err := cmd.Output(ctx); err == nil {
+					err := config.NewDecoder(bytes.NewBuffer(out)).Decode(gitmodules)
+					if err != nil {
+						return nil, fmt.Errorf("error parsing .gitmodules: %s", err)
+					}
 				}
-
-				submodule.Path = cfg.Section("submodule").Subsection(name).Option("path")
-				submodule.URL = cfg.Section("submodule").Subsection(name).Option("url")
 			}
+			var submodule Submodule
+			submodule.Path = gitmodules.Section("submodule").Subsection(name).Option("path")
+			submodule.URL = gitmodules.Section("submodule").Subsection(name).Option("url")
 			submodule.CommitID = api.CommitID(oid)
 			sys = submodule
 		case "tree":
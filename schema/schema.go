@@ -10,11 +10,12 @@ import (
 )
 
 type AWSCodeCommitConnection struct {
-	AccessKeyID                 string `json:"accessKeyID"`
-	InitialRepositoryEnablement bool   `json:"initialRepositoryEnablement,omitempty"`
-	Region                      string `json:"region"`
-	RepositoryPathPattern       string `json:"repositoryPathPattern,omitempty"`
-	SecretAccessKey             string `json:"secretAccessKey"`
+	AccessKeyID                 string                `json:"accessKeyID"`
+	Exclude                     []*ExcludedRepository `json:"exclude,omitempty"`
+	InitialRepositoryEnablement bool                  `json:"initialRepositoryEnablement,omitempty"`
+	Region                      string                `json:"region"`
+	RepositoryPathPattern       string                `json:"repositoryPathPattern,omitempty"`
+	SecretAccessKey             string                `json:"secretAccessKey"`
 }
 type Action struct {
 	ActionItem       *ActionItem   `json:"actionItem,omitempty"`
@@ -102,16 +103,44 @@ type Authorization struct {
 	AuthnProvider AuthnProvider `json:"authnProvider"`
 	Ttl           string        `json:"ttl,omitempty"`
 }
+
+// BitbucketServerAuthorization description: If non-null, enables Bitbucket Server permission checks: users can only access the repositories on this Bitbucket Server instance that they can read on Bitbucket Server. Sourcegraph impersonates each user (through an incoming application link with OAuth) to list the repositories they can read. Users who can't be mapped to a Bitbucket Server user can only access public repositories.
+type BitbucketServerAuthorization struct {
+	IdentityProvider BitbucketServerIdentityProvider `json:"identityProvider"`
+	Oauth            BitbucketServerOAuth            `json:"oauth"`
+	Ttl              string                          `json:"ttl,omitempty"`
+}
 type BitbucketServerConnection struct {
-	Certificate                 string `json:"certificate,omitempty"`
-	ExcludePersonalRepositories bool   `json:"excludePersonalRepositories,omitempty"`
-	GitURLType                  string `json:"gitURLType,omitempty"`
-	InitialRepositoryEnablement bool   `json:"initialRepositoryEnablement,omitempty"`
-	Password                    string `json:"password,omitempty"`
-	RepositoryPathPattern       string `json:"repositoryPathPattern,omitempty"`
-	Token                       string `json:"token,omitempty"`
-	Url                         string `json:"url"`
-	Username                    string `json:"username,omitempty"`
+	Authorization               *BitbucketServerAuthorization `json:"authorization,omitempty"`
+	Certificate                 string                        `json:"certificate,omitempty"`
+	Exclude                     []*ExcludedRepository         `json:"exclude,omitempty"`
+	ExcludePersonalRepositories bool                          `json:"excludePersonalRepositories,omitempty"`
+	GitCloneOptions             []*GitCloneOptions            `json:"gitCloneOptions,omitempty"`
+	GitURLType                  string                        `json:"gitURLType,omitempty"`
+	InitialRepositoryEnablement bool                          `json:"initialRepositoryEnablement,omitempty"`
+	Password                    string                        `json:"password,omitempty"`
+	RepositoryPathPattern       string                        `json:"repositoryPathPattern,omitempty"`
+	Token                       string                        `json:"token,omitempty"`
+	Url                         string                        `json:"url"`
+	Username                    string                        `json:"username,omitempty"`
+	WebhookSecret               string                        `json:"webhookSecret,omitempty"`
+}
+
+// BitbucketServerIdentityProvider description: How Sourcegraph users are mapped to Bitbucket Server users.
+//
+// If "username", a Sourcegraph user is the Bitbucket Server user with the same username. Only use this if Sourcegraph usernames are set by a single sign-on provider that is shared with Bitbucket Server, because users could otherwise choose the username of another Bitbucket Server user. It is refused if the builtin authentication provider allows signup.
+//
+// If "externalAccount", a Sourcegraph user is the Bitbucket Server user whose username is the ID of the user's external account from the authentication provider (in `auth.providers`) with the given `authnConfigID` and `authnType`, such as the NameID of a SAML provider.
+type BitbucketServerIdentityProvider struct {
+	AuthnConfigID string `json:"authnConfigID,omitempty"`
+	AuthnType     string `json:"authnType,omitempty"`
+	Type          string `json:"type"`
+}
+
+// BitbucketServerOAuth description: The OAuth consumer of an incoming application link in Bitbucket Server, with user impersonation allowed. Create the application link in Bitbucket Server's administration (Application Links) with the URL of Sourcegraph, and set the consumer key and the public key of `signingKey` for its incoming authentication.
+type BitbucketServerOAuth struct {
+	ConsumerKey string `json:"consumerKey"`
+	SigningKey  string `json:"signingKey"`
 }
 
 // BuiltinAuthProvider description: Configures the builtin username-password authentication provider.
@@ -139,13 +168,24 @@ type Discussions struct {
 	AbuseProtection bool     `json:"abuseProtection,omitempty"`
 }
 
+// ExcludedRepository description: A rule that excludes repositories of a code host connection. Names are the repository names on the code host ("owner/name" on GitHub and Gitea, "mygroup/myproject" on GitLab, "PROJECTKEY/repo-slug" on Bitbucket Server, the repository name on AWS CodeCommit, the name without the prefix on Gitolite, and the depot path without the leading "//" and the trailing "/" on Perforce), not the Sourcegraph repository names. A repository matches the rule if it matches all of the rule's properties.
+type ExcludedRepository struct {
+	Archived bool   `json:"archived,omitempty"`
+	Forks    bool   `json:"forks,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+}
+
 // ExperimentalFeatures description: Experimental features to enable or disable. Features that are now enabled by default are marked as deprecated.
 type ExperimentalFeatures struct {
-	CanonicalURLRedirect string `json:"canonicalURLRedirect,omitempty"`
-	Discussions          string `json:"discussions,omitempty"`
-	GithubAuth           bool   `json:"githubAuth,omitempty"`
-	JumpToDefOSSIndex    string `json:"jumpToDefOSSIndex,omitempty"`
-	UpdateScheduler2     string `json:"updateScheduler2,omitempty"`
+	CanonicalURLRedirect      string `json:"canonicalURLRedirect,omitempty"`
+	Discussions               string `json:"discussions,omitempty"`
+	ExplicitPermissions       string `json:"explicitPermissions,omitempty"`
+	GithubAuth                bool   `json:"githubAuth,omitempty"`
+	JumpToDefOSSIndex         string `json:"jumpToDefOSSIndex,omitempty"`
+	PermissionsBackgroundSync string `json:"permissionsBackgroundSync,omitempty"`
+	SearchDefinitionRanking   string `json:"searchDefinitionRanking,omitempty"`
+	UpdateScheduler2          string `json:"updateScheduler2,omitempty"`
 }
 
 // ExtensionRepository description: The location of the version control repository for this extension.
@@ -160,6 +200,12 @@ type Extensions struct {
 	Disabled              *bool       `json:"disabled,omitempty"`
 	RemoteRegistry        interface{} `json:"remoteRegistry,omitempty"`
 }
+type GitCloneOptions struct {
+	ExcludeRefs []string `json:"excludeRefs,omitempty"`
+	Filter      string   `json:"filter,omitempty"`
+	Refs        []string `json:"refs,omitempty"`
+	Repos       string   `json:"repos"`
+}
 
 // GitHubAuthProvider description: Configures the GitHub (or GitHub Enterprise) OAuth authentication provider for SSO. In addition to specifying this configuration object, you must also create a OAuth App on your GitHub instance: https://developer.github.com/apps/building-oauth-apps/creating-an-oauth-app/. When a user signs into Sourcegraph or links their GitHub account to their existing Sourcegraph account, GitHub will prompt the user for the repo scope.
 type GitHubAuthProvider struct {
@@ -169,31 +215,54 @@ type GitHubAuthProvider struct {
 	Type         string `json:"type"`
 	Url          string `json:"url,omitempty"`
 }
+
+// GitHubAuthorization description: If non-null, enables GitHub permission checks: users can only access the repositories on this GitHub instance that they can read on GitHub. Users are identified by the GitHub account they signed in with, so this requires a GitHub authentication provider (in `auth.providers`) with the same `url`. Users who didn't sign in with GitHub can only access public repositories.
+type GitHubAuthorization struct {
+	Ttl string `json:"ttl,omitempty"`
+}
 type GitHubConnection struct {
-	Certificate                 string   `json:"certificate,omitempty"`
-	GitURLType                  string   `json:"gitURLType,omitempty"`
-	InitialRepositoryEnablement bool     `json:"initialRepositoryEnablement,omitempty"`
-	Repos                       []string `json:"repos,omitempty"`
-	RepositoryPathPattern       string   `json:"repositoryPathPattern,omitempty"`
-	RepositoryQuery             []string `json:"repositoryQuery,omitempty"`
-	Token                       string   `json:"token"`
-	Url                         string   `json:"url"`
+	Authorization               *GitHubAuthorization  `json:"authorization,omitempty"`
+	Certificate                 string                `json:"certificate,omitempty"`
+	Exclude                     []*ExcludedRepository `json:"exclude,omitempty"`
+	GitCloneOptions             []*GitCloneOptions    `json:"gitCloneOptions,omitempty"`
+	GitURLType                  string                `json:"gitURLType,omitempty"`
+	InitialRepositoryEnablement bool                  `json:"initialRepositoryEnablement,omitempty"`
+	Repos                       []string              `json:"repos,omitempty"`
+	RepositoryPathPattern       string                `json:"repositoryPathPattern,omitempty"`
+	RepositoryQuery             []string              `json:"repositoryQuery,omitempty"`
+	Token                       string                `json:"token"`
+	Url                         string                `json:"url"`
+	WebhookSecret               string                `json:"webhookSecret,omitempty"`
 }
 type GitLabConnection struct {
-	Authorization               *Authorization `json:"authorization,omitempty"`
-	Certificate                 string         `json:"certificate,omitempty"`
-	GitURLType                  string         `json:"gitURLType,omitempty"`
-	InitialRepositoryEnablement bool           `json:"initialRepositoryEnablement,omitempty"`
-	ProjectQuery                []string       `json:"projectQuery,omitempty"`
-	RepositoryPathPattern       string         `json:"repositoryPathPattern,omitempty"`
-	Token                       string         `json:"token"`
-	Url                         string         `json:"url"`
+	Authorization               *Authorization        `json:"authorization,omitempty"`
+	Certificate                 string                `json:"certificate,omitempty"`
+	Exclude                     []*ExcludedRepository `json:"exclude,omitempty"`
+	GitCloneOptions             []*GitCloneOptions    `json:"gitCloneOptions,omitempty"`
+	GitURLType                  string                `json:"gitURLType,omitempty"`
+	InitialRepositoryEnablement bool                  `json:"initialRepositoryEnablement,omitempty"`
+	ProjectQuery                []string              `json:"projectQuery,omitempty"`
+	RepositoryPathPattern       string                `json:"repositoryPathPattern,omitempty"`
+	Token                       string                `json:"token"`
+	Url                         string                `json:"url"`
+	WebhookSecret               string                `json:"webhookSecret,omitempty"`
+}
+type GiteaConnection struct {
+	Certificate                 string                `json:"certificate,omitempty"`
+	Exclude                     []*ExcludedRepository `json:"exclude,omitempty"`
+	GitCloneOptions             []*GitCloneOptions    `json:"gitCloneOptions,omitempty"`
+	GitURLType                  string                `json:"gitURLType,omitempty"`
+	InitialRepositoryEnablement bool                  `json:"initialRepositoryEnablement,omitempty"`
+	RepositoryPathPattern       string                `json:"repositoryPathPattern,omitempty"`
+	Token                       string                `json:"token"`
+	Url                         string                `json:"url"`
 }
 type GitoliteConnection struct {
-	Blacklist                  string `json:"blacklist,omitempty"`
-	Host                       string `json:"host"`
-	PhabricatorMetadataCommand string `json:"phabricatorMetadataCommand,omitempty"`
-	Prefix                     string `json:"prefix"`
+	Blacklist                  string                `json:"blacklist,omitempty"`
+	Exclude                    []*ExcludedRepository `json:"exclude,omitempty"`
+	Host                       string                `json:"host"`
+	PhabricatorMetadataCommand string                `json:"phabricatorMetadataCommand,omitempty"`
+	Prefix                     string                `json:"prefix"`
 }
 
 // HTTPHeaderAuthProvider description: Configures the HTTP header authentication provider (which authenticates users by consulting an HTTP request header set by an authentication proxy such as https://github.com/bitly/oauth2_proxy).
@@ -263,6 +332,14 @@ type OpenIDConnectAuthProvider struct {
 type ParentSourcegraph struct {
 	Url string `json:"url,omitempty"`
 }
+type PerforceConnection struct {
+	Depots                []string              `json:"depots,omitempty"`
+	Exclude               []*ExcludedRepository `json:"exclude,omitempty"`
+	P4Passwd              string                `json:"p4.passwd"`
+	P4Port                string                `json:"p4.port"`
+	P4User                string                `json:"p4.user"`
+	RepositoryPathPattern string                `json:"repositoryPathPattern,omitempty"`
+}
 type Phabricator struct {
 	Repos []*Repos `json:"repos,omitempty"`
 	Token string   `json:"token,omitempty"`
@@ -365,6 +442,7 @@ type SiteConfiguration struct {
 	ExternalURL                       string                       `json:"externalURL,omitempty"`
 	GitCloneURLToRepositoryName       []*CloneURLToRepositoryName  `json:"git.cloneURLToRepositoryName,omitempty"`
 	GitMaxConcurrentClones            int                          `json:"gitMaxConcurrentClones,omitempty"`
+	Gitea                             []*GiteaConnection           `json:"gitea,omitempty"`
 	Github                            []*GitHubConnection          `json:"github,omitempty"`
 	GithubClientID                    string                       `json:"githubClientID,omitempty"`
 	GithubClientSecret                string                       `json:"githubClientSecret,omitempty"`
@@ -384,6 +462,7 @@ type SiteConfiguration struct {
 	MaxReposToSearch                  int                          `json:"maxReposToSearch,omitempty"`
 	NoGoGetDomains                    string                       `json:"noGoGetDomains,omitempty"`
 	ParentSourcegraph                 *ParentSourcegraph           `json:"parentSourcegraph,omitempty"`
+	Perforce                          []*PerforceConnection        `json:"perforce,omitempty"`
 	Phabricator                       []*Phabricator               `json:"phabricator,omitempty"`
 	PrivateArtifactRepoID             string                       `json:"privateArtifactRepoID,omitempty"`
 	PrivateArtifactRepoPassword       string                       `json:"privateArtifactRepoPassword,omitempty"`
@@ -392,6 +471,7 @@ type SiteConfiguration struct {
 	RepoListUpdateInterval            int                          `json:"repoListUpdateInterval,omitempty"`
 	ReposList                         []*Repository                `json:"repos.list,omitempty"`
 	ReviewBoard                       []*ReviewBoard               `json:"reviewBoard,omitempty"`
+	SearchIndexBranches               map[string][]string          `json:"search.index.branches,omitempty"`
 	SearchIndexEnabled                *bool                        `json:"search.index.enabled,omitempty"`
 	TlsLetsencrypt                    string                       `json:"tls.letsencrypt,omitempty"`
 	TlsCert                           string                       `json:"tlsCert,omitempty"`
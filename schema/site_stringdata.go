@@ -61,6 +61,18 @@ const SiteSchemaJSON = `{
       "type": "boolean",
       "!go": { "pointer": true }
     },
+    "search.index.branches": {
+      "description":
+        "A map from repository name to a list of branches to index for search, in addition to the default branch. Searches of an indexed branch (such as ` + "`" + `repo:^github\\.com/myorg/myrepo$@release-2.0` + "`" + `) use indexed search, which is much faster than searching an unindexed branch. Changes take effect the next time the repository is indexed.",
+      "type": "object",
+      "additionalProperties": {
+        "type": "array",
+        "items": {
+          "type": "string"
+        }
+      },
+      "examples": [{ "github.com/myorg/myrepo": ["release-2.0", "release-2.1"] }]
+    },
     "experimentalFeatures": {
       "description":
         "Experimental features to enable or disable. Features that are now enabled by default are marked as deprecated.",
@@ -93,6 +105,27 @@ const SiteSchemaJSON = `{
           "enum": ["enabled", "disabled"],
           "default": "disabled"
         },
+        "explicitPermissions": {
+          "description":
+            "Enables repository permissions that site admins grant to users and organizations directly on Sourcegraph (with the addRepositoryPermission GraphQL mutation). Repositories whose names match the pattern of any such permission are only accessible to the users they are granted to.",
+          "type": "string",
+          "enum": ["enabled", "disabled"],
+          "default": "disabled"
+        },
+        "permissionsBackgroundSync": {
+          "description":
+            "Enables syncing repository permissions from code hosts in the background. Each user's permissions are stored in the database and refreshed periodically and when the user signs in, instead of being fetched from the code hosts when the user accesses repositories.",
+          "type": "string",
+          "enum": ["enabled", "disabled"],
+          "default": "disabled"
+        },
+        "searchDefinitionRanking": {
+          "description":
+            "Enables ranking search results in files that define a symbol matching the search pattern higher. This looks up the symbols in the repositories with the most matches, which makes searches slower (by up to 250ms).",
+          "type": "string",
+          "enum": ["enabled", "disabled"],
+          "default": "disabled"
+        },
         "githubAuth": {
           "description":
             "Enables GitHub instances as a sign-in mechanism. Note: after setting this to true, it is still necessary to add the GitHub instance to the ` + "`" + `auth.providers` + "`" + ` field.",
@@ -218,6 +251,13 @@ const SiteSchemaJSON = `{
         "$ref": "#/definitions/GitLabConnection"
       }
     },
+    "gitea": {
+      "description": "JSON array of configuration for Gitea (and Gogs) hosts.",
+      "type": "array",
+      "items": {
+        "$ref": "#/definitions/GiteaConnection"
+      }
+    },
     "awsCodeCommit": {
       "description": "JSON array of configuration for AWS CodeCommit endpoints.",
       "type": "array",
@@ -239,6 +279,14 @@ const SiteSchemaJSON = `{
         "$ref": "#/definitions/GitoliteConnection"
       }
     },
+    "perforce": {
+      "description":
+        "JSON array of configuration for Perforce servers. Perforce depots are converted to Git repositories on gitserver (using git p4) and kept up to date, so they can be searched and browsed like any other repository. Requires the p4 CLI and git p4 to be installed on gitserver.",
+      "type": "array",
+      "items": {
+        "$ref": "#/definitions/PerforceConnection"
+      }
+    },
     "gitMaxConcurrentClones": {
       "description": "Maximum number of git clone processes that will be run concurrently to update repositories.",
       "type": "integer",
@@ -625,6 +673,13 @@ const SiteSchemaJSON = `{
           "items": { "type": "string" },
           "default": ["public", "affiliated"]
         },
+        "exclude": {
+          "description":
+            "An array of rules for repositories on this GitHub instance to exclude from Sourcegraph, such as forks, archived or generated repositories. A repository is excluded if it matches any rule. Excluded repositories are neither synced nor found when navigating to them, as if they did not exist on the code host.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/ExcludedRepository" },
+          "examples": [[{ "forks": true }, { "name": "myorg/generated-code" }, { "pattern": "^myorg/archive-" }]]
+        },
         "repositoryPathPattern": {
           "description":
             "The pattern used to generate the corresponding Sourcegraph repository name for a GitHub or GitHub Enterprise repository. In the pattern, the variable \"{host}\" is replaced with the GitHub host (such as github.example.com), and \"{nameWithOwner}\" is replaced with the GitHub repository's \"owner/path\" (such as \"myorg/myrepo\").\n\nFor example, if your GitHub Enterprise URL is https://github.example.com and your Sourcegraph URL is https://src.example.com, then a repositoryPathPattern of \"{host}/{nameWithOwner}\" would mean that a GitHub repository at https://github.example.com/myorg/myrepo is available on Sourcegraph at https://src.example.com/github.example.com/myorg/myrepo.\n\nIt is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.",
@@ -635,6 +690,35 @@ const SiteSchemaJSON = `{
           "description":
             "Defines whether repositories from this GitHub instance should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable GitHub repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately (subject to rate limiting by GitHub); site admins can still disable them explicitly, and they'll remain disabled.",
           "type": "boolean"
+        },
+        "gitCloneOptions": {
+          "description":
+            "Options for how Sourcegraph clones and fetches repositories from this GitHub instance, for repositories that are too large to clone in full. The first item whose \"repos\" pattern matches a repository's name applies.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/GitCloneOptions" }
+        },
+        "webhookSecret": {
+          "description":
+            "The secret of the webhooks that push events from this GitHub instance to Sourcegraph, so that pushed repositories are updated immediately. Add a webhook for the \"push\" event to your repositories or organizations with the payload URL https://[your-sourcegraph-hostname]/.api/webhooks/github, content type application/json and this secret.",
+          "type": "string",
+          "minLength": 1
+        },
+        "authorization": {
+          "$ref": "#/definitions/GitHubAuthorization"
+        }
+      }
+    },
+    "GitHubAuthorization": {
+      "description":
+        "If non-null, enables GitHub permission checks: users can only access the repositories on this GitHub instance that they can read on GitHub. Users are identified by the GitHub account they signed in with, so this requires a GitHub authentication provider (in ` + "`" + `auth.providers` + "`" + `) with the same ` + "`" + `url` + "`" + `. Users who didn't sign in with GitHub can only access public repositories.",
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "ttl": {
+          "description":
+            "The TTL of how long to cache permissions data. This is 3 hours by default.\n\nDecreasing the TTL will increase the load on the code host API. The permissions of up to 100 repositories are fetched per API request.\n\nIf set to zero, Sourcegraph will fetch a user's permissions on every request (NOT recommended).",
+          "type": "string",
+          "default": "3h"
         }
       }
     },
@@ -683,6 +767,13 @@ const SiteSchemaJSON = `{
             "type": "string"
           }
         },
+        "exclude": {
+          "description":
+            "An array of rules for repositories on this GitLab instance to exclude from Sourcegraph, such as forks, archived or generated repositories. A repository is excluded if it matches any rule. Excluded repositories are neither synced nor found when navigating to them, as if they did not exist on the code host.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/ExcludedRepository" },
+          "examples": [[{ "archived": true }, { "name": "mygroup/generated-code" }]]
+        },
         "repositoryPathPattern": {
           "description":
             "The pattern used to generate a the corresponding Sourcegraph repository name for a GitLab project. In the pattern, the variable \"{host}\" is replaced with the GitLab URL's host (such as gitlab.example.com), and \"{pathWithNamespace}\" is replaced with the GitLab project's \"namespace/path\" (such as \"myteam/myproject\").\n\nFor example, if your GitLab is https://gitlab.example.com and your Sourcegraph is https://src.example.com, then a repositoryPathPattern of \"{host}/{pathWithNamespace}\" would mean that a GitLab project at https://gitlab.example.com/myteam/myproject is available on Sourcegraph at https://src.example.com/gitlab.example.com/myteam/myproject.\n\nIt is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.",
@@ -694,6 +785,18 @@ const SiteSchemaJSON = `{
             "Defines whether repositories from this GitLab instance should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable GitLab repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately (subject to rate limiting by GitLab); site admins can still disable them explicitly, and they'll remain disabled.",
           "type": "boolean"
         },
+        "gitCloneOptions": {
+          "description":
+            "Options for how Sourcegraph clones and fetches repositories from this GitLab instance, for repositories that are too large to clone in full. The first item whose \"repos\" pattern matches a repository's name applies.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/GitCloneOptions" }
+        },
+        "webhookSecret": {
+          "description":
+            "The secret token of the webhooks that push events from this GitLab instance to Sourcegraph, so that pushed repositories are updated immediately. Add a webhook with \"Push events\" and \"Tag push events\" to your projects or groups (or a system hook) with the URL https://[your-sourcegraph-hostname]/.api/webhooks/gitlab and this secret token.",
+          "type": "string",
+          "minLength": 1
+        },
         "authorization": {
           "description":
             "If non-null, enables GitLab permission checks. This requires that the value of ` + "`" + `token` + "`" + ` be an access token with \"sudo\" and \"api\" scopes.",
@@ -732,6 +835,127 @@ const SiteSchemaJSON = `{
         }
       }
     },
+    "GiteaConnection": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["url", "token"],
+      "properties": {
+        "url": {
+          "description": "URL of a Gitea (or Gogs) instance, such as https://gitea.example.com.",
+          "type": "string",
+          "pattern": "^https?://",
+          "not": {
+            "type": "string",
+            "pattern": "example\\.com"
+          },
+          "format": "uri",
+          "examples": ["https://gitea.example.com", "https://try.gitea.io"]
+        },
+        "token": {
+          "description":
+            "A Gitea access token. Sourcegraph mirrors all repositories that the token's user owns, or has access to as a collaborator or organization member. Create one at https://[your-gitea-hostname]/user/settings/applications.",
+          "type": "string",
+          "pattern": "^[^<>]+$"
+        },
+        "gitURLType": {
+          "description":
+            "The type of Git URLs to use for cloning and fetching Git repositories on this Gitea instance.\n\nIf \"http\", Sourcegraph will access Gitea repositories using Git URLs of the form http(s)://gitea.example.com/myteam/myrepo.git (using https: if the Gitea instance uses HTTPS).\n\nIf \"ssh\", Sourcegraph will access Gitea repositories using Git URLs of the form git@gitea.example.com:myteam/myrepo.git. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/add_from_git_repository#repositories-that-need-http-s-or-ssh-authentication.",
+          "type": "string",
+          "enum": ["http", "ssh"],
+          "default": "http"
+        },
+        "certificate": {
+          "description": "TLS certificate of a Gitea instance.",
+          "type": "string",
+          "pattern": "^-----BEGIN CERTIFICATE-----\n"
+        },
+        "exclude": {
+          "description":
+            "An array of rules for repositories on this Gitea instance to exclude from Sourcegraph, such as forks, archived or generated repositories. A repository is excluded if it matches any rule. Excluded repositories are neither synced nor found when navigating to them, as if they did not exist on the code host.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/ExcludedRepository" },
+          "examples": [[{ "forks": true }, { "name": "myorg/generated-code" }]]
+        },
+        "repositoryPathPattern": {
+          "description":
+            "The pattern used to generate the corresponding Sourcegraph repository name for a Gitea repository. In the pattern, the variable \"{host}\" is replaced with the Gitea URL's host (such as gitea.example.com), and \"{nameWithOwner}\" is replaced with the Gitea repository's \"owner/name\" (such as \"myteam/myrepo\").\n\nFor example, if your Gitea is https://gitea.example.com and your Sourcegraph is https://src.example.com, then a repositoryPathPattern of \"{host}/{nameWithOwner}\" would mean that a Gitea repository at https://gitea.example.com/myteam/myrepo is available on Sourcegraph at https://src.example.com/gitea.example.com/myteam/myrepo.\n\nIt is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.",
+          "type": "string",
+          "default": "{host}/{nameWithOwner}"
+        },
+        "initialRepositoryEnablement": {
+          "description":
+            "Defines whether repositories from this Gitea instance should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable Gitea repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately; site admins can still disable them explicitly, and they'll remain disabled.",
+          "type": "boolean"
+        },
+        "gitCloneOptions": {
+          "description":
+            "Options for how Sourcegraph clones and fetches repositories from this Gitea instance, for repositories that are too large to clone in full. The first item whose \"repos\" pattern matches a repository's name applies.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/GitCloneOptions" }
+        }
+      }
+    },
+    "GitCloneOptions": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["repos"],
+      "properties": {
+        "repos": {
+          "description": "A regular expression matching the names of the repositories that these options apply to.",
+          "type": "string",
+          "format": "regex",
+          "examples": ["^github\\.com/myorg/monorepo$"]
+        },
+        "filter": {
+          "description":
+            "A partial clone filter. Objects left out by the filter (such as file contents, with \"blob:none\") are fetched from the code host when they are first needed. The code host must support partial clones.",
+          "type": "string",
+          "pattern": "^(blob:none|blob:limit=[0-9]+[kmg]?|tree:[0-9]+)$",
+          "examples": ["blob:none", "blob:limit=1m"]
+        },
+        "refs": {
+          "description":
+            "Patterns of the refs to fetch. By default, all branches (refs/heads/*), tags (refs/tags/*) and pull request refs (refs/pull/*) are fetched. Commits that are not on a fetched ref are fetched when they are first needed.",
+          "type": "array",
+          "items": { "type": "string", "pattern": "^refs/[^:^ ]*$" },
+          "examples": [["refs/heads/*", "refs/tags/*"]]
+        },
+        "excludeRefs": {
+          "description": "Patterns of refs not to fetch, such as \"refs/heads/archive/*\". Requires Git 2.29 or newer on gitserver.",
+          "type": "array",
+          "items": { "type": "string", "pattern": "^refs/[^:^ ]*$" }
+        }
+      }
+    },
+    "ExcludedRepository": {
+      "description":
+        "A rule that excludes repositories of a code host connection. Names are the repository names on the code host (\"owner/name\" on GitHub and Gitea, \"mygroup/myproject\" on GitLab, \"PROJECTKEY/repo-slug\" on Bitbucket Server, the repository name on AWS CodeCommit, the name without the prefix on Gitolite, and the depot path without the leading \"//\" and the trailing \"/\" on Perforce), not the Sourcegraph repository names. A repository matches the rule if it matches all of the rule's properties.",
+      "type": "object",
+      "additionalProperties": false,
+      "minProperties": 1,
+      "properties": {
+        "name": {
+          "description": "The name of the repository on the code host, such as \"myorg/myrepo\". Compared case-insensitively.",
+          "type": "string",
+          "minLength": 1
+        },
+        "pattern": {
+          "description": "A regular expression matching the names of the repositories on the code host. Matched case-insensitively.",
+          "type": "string",
+          "format": "regex",
+          "minLength": 1,
+          "examples": ["^myorg/archive-", "-generated$"]
+        },
+        "forks": {
+          "description": "If true, only forks of other repositories match. Forks are not known for AWS CodeCommit, Gitolite and Perforce repositories.",
+          "type": "boolean"
+        },
+        "archived": {
+          "description": "If true, only archived repositories match. Archived repositories are not known for Bitbucket Server, AWS CodeCommit, Gitolite and Perforce repositories.",
+          "type": "boolean"
+        }
+      }
+    },
     "BitbucketServerConnection": {
       "type": "object",
       "additionalProperties": false,
@@ -787,10 +1011,93 @@ const SiteSchemaJSON = `{
             "Whether or not personal repositories should be excluded or not. When true, Sourcegraph will ignore personal repositories it may have access to. See https://docs.sourcegraph.com/integration/bitbucket_server#excluding-personal-repositories for more information. Default: false.",
           "type": "boolean"
         },
+        "exclude": {
+          "description":
+            "An array of rules for repositories on this Bitbucket Server instance to exclude from Sourcegraph, such as forks, archived or generated repositories. A repository is excluded if it matches any rule. Excluded repositories are neither synced nor found when navigating to them, as if they did not exist on the code host.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/ExcludedRepository" },
+          "examples": [[{ "forks": true }, { "name": "PRJ/generated-code" }]]
+        },
         "initialRepositoryEnablement": {
           "description":
             "Defines whether repositories from this Bitbucket Server instance should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable Bitbucket Server repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately (subject to rate limiting by Bitbucket Server); site admins can still disable them explicitly, and they'll remain disabled.",
           "type": "boolean"
+        },
+        "gitCloneOptions": {
+          "description":
+            "Options for how Sourcegraph clones and fetches repositories from this Bitbucket Server instance, for repositories that are too large to clone in full. The first item whose \"repos\" pattern matches a repository's name applies.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/GitCloneOptions" }
+        },
+        "webhookSecret": {
+          "description":
+            "The secret of the webhooks that push events from this Bitbucket Server instance to Sourcegraph, so that pushed repositories are updated immediately. Add a webhook for the \"Repository: Push\" event to your repositories or projects with the URL https://[your-sourcegraph-hostname]/.api/webhooks/bitbucket-server and this secret.",
+          "type": "string",
+          "minLength": 1
+        },
+        "authorization": {
+          "$ref": "#/definitions/BitbucketServerAuthorization"
+        }
+      }
+    },
+    "BitbucketServerAuthorization": {
+      "description":
+        "If non-null, enables Bitbucket Server permission checks: users can only access the repositories on this Bitbucket Server instance that they can read on Bitbucket Server. Sourcegraph impersonates each user (through an incoming application link with OAuth) to list the repositories they can read. Users who can't be mapped to a Bitbucket Server user can only access public repositories.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["identityProvider", "oauth"],
+      "properties": {
+        "identityProvider": {
+          "$ref": "#/definitions/BitbucketServerIdentityProvider"
+        },
+        "oauth": {
+          "$ref": "#/definitions/BitbucketServerOAuth"
+        },
+        "ttl": {
+          "description":
+            "The TTL of how long to cache permissions data. This is 3 hours by default.\n\nDecreasing the TTL will increase the load on the code host API. If you have X repos on your instance, it will take ~X/1000 API requests to fetch the complete list for 1 user.  If you have Y users, you will incur X*Y/1000 API requests per cache refresh period.\n\nIf set to zero, Sourcegraph will sync a user's entire accessible repository list on every request (NOT recommended).",
+          "type": "string",
+          "default": "3h"
+        }
+      }
+    },
+    "BitbucketServerIdentityProvider": {
+      "description":
+        "How Sourcegraph users are mapped to Bitbucket Server users.\n\nIf \"username\", a Sourcegraph user is the Bitbucket Server user with the same username. Only use this if Sourcegraph usernames are set by a single sign-on provider that is shared with Bitbucket Server, because users could otherwise choose the username of another Bitbucket Server user. It is refused if the builtin authentication provider allows signup.\n\nIf \"externalAccount\", a Sourcegraph user is the Bitbucket Server user whose username is the ID of the user's external account from the authentication provider (in ` + "`" + `auth.providers` + "`" + `) with the given ` + "`" + `authnConfigID` + "`" + ` and ` + "`" + `authnType` + "`" + `, such as the NameID of a SAML provider.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["type"],
+      "properties": {
+        "type": {
+          "type": "string",
+          "enum": ["username", "externalAccount"]
+        },
+        "authnConfigID": {
+          "description": "The value of the ` + "`" + `configID` + "`" + ` field of the authentication provider whose external accounts identify users (if ` + "`" + `type` + "`" + ` is \"externalAccount\").",
+          "type": "string"
+        },
+        "authnType": {
+          "description": "The ` + "`" + `type` + "`" + ` field of the authentication provider whose external accounts identify users (if ` + "`" + `type` + "`" + ` is \"externalAccount\").",
+          "type": "string"
+        }
+      }
+    },
+    "BitbucketServerOAuth": {
+      "description":
+        "The OAuth consumer of an incoming application link in Bitbucket Server, with user impersonation allowed. Create the application link in Bitbucket Server's administration (Application Links) with the URL of Sourcegraph, and set the consumer key and the public key of ` + "`" + `signingKey` + "`" + ` for its incoming authentication.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["consumerKey", "signingKey"],
+      "properties": {
+        "consumerKey": {
+          "description": "The consumer key of the application link's incoming authentication.",
+          "type": "string",
+          "minLength": 1
+        },
+        "signingKey": {
+          "description": "The PEM-encoded RSA private key (optionally base64-encoded, so that it fits on one line) whose public key is configured in the application link's incoming authentication.",
+          "type": "string",
+          "minLength": 1
         }
       }
     },
@@ -837,6 +1144,13 @@ const SiteSchemaJSON = `{
           "type": "string",
           "default": "{name}"
         },
+        "exclude": {
+          "description":
+            "An array of rules for repositories on this AWS CodeCommit account to exclude from Sourcegraph, such as forks, archived or generated repositories. A repository is excluded if it matches any rule. Excluded repositories are neither synced nor found when navigating to them, as if they did not exist on the code host.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/ExcludedRepository" },
+          "examples": [[{ "pattern": "^tmp-" }]]
+        },
         "initialRepositoryEnablement": {
           "description":
             "Defines whether repositories from AWS CodeCommit should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable AWS CodeCommit repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately (subject to rate limiting by AWS); site admins can still disable them explicitly, and they'll remain disabled.",
@@ -863,6 +1177,13 @@ const SiteSchemaJSON = `{
             "Regular expression to filter repositories from auto-discovery, so they will not get cloned automatically.",
           "type": "string"
         },
+        "exclude": {
+          "description":
+            "An array of rules for repositories on this Gitolite host to exclude from Sourcegraph, such as forks, archived or generated repositories. A repository is excluded if it matches any rule. Excluded repositories are neither synced nor found when navigating to them, as if they did not exist on the code host.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/ExcludedRepository" },
+          "examples": [[{ "pattern": "^archive/" }]]
+        },
         "phabricatorMetadataCommand": {
           "description":
             "Bash command that prints out the Phabricator callsign for a Gitolite repository. This will be run with environment variable $REPO set to the name of the repository and used to obtain the Phabricator metadata for a Gitolite repository. (Note: this requires ` + "`" + `bash` + "`" + ` to be installed.)",
@@ -870,6 +1191,47 @@ const SiteSchemaJSON = `{
         }
       }
     },
+    "PerforceConnection": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["p4.port", "p4.user", "p4.passwd"],
+      "properties": {
+        "p4.port": {
+          "description": "The address of the Perforce server, in the format of the P4PORT environment variable of the p4 CLI.",
+          "type": "string",
+          "pattern": "^(ssl:)?[^:/@ ]+:[0-9]+$",
+          "examples": ["perforce.example.com:1666", "ssl:perforce.example.com:1666"]
+        },
+        "p4.user": {
+          "description": "The user to authenticate to the Perforce server as. The user needs read access to the depots that are mirrored.",
+          "type": "string"
+        },
+        "p4.passwd": {
+          "description": "The password or ticket of the Perforce user.",
+          "type": "string"
+        },
+        "depots": {
+          "description":
+            "Depot paths to mirror, each as its own repository. If empty, all local and stream depots on the Perforce server are mirrored.",
+          "type": "array",
+          "items": { "type": "string", "pattern": "^//[^ @#]+$" },
+          "examples": [["//depot/main/", "//streams/release/"]]
+        },
+        "exclude": {
+          "description":
+            "An array of rules for depots on this Perforce server to exclude from Sourcegraph. A depot is excluded if it matches any rule. Excluded depots are neither synced nor found when navigating to them.",
+          "type": "array",
+          "items": { "$ref": "#/definitions/ExcludedRepository" },
+          "examples": [[{ "pattern": "^depot/archive/" }]]
+        },
+        "repositoryPathPattern": {
+          "description":
+            "The pattern used to generate the corresponding Sourcegraph repository name for a Perforce depot path. In the pattern, the variable \"{host}\" is replaced with the Perforce server's host name, and \"{depot}\" is replaced with the depot path without the leading \"//\" and the trailing \"/\".\n\nFor example, if your Perforce server is perforce.example.com:1666 and your depot path is //depot/main/, then a repositoryPathPattern of \"{host}/{depot}\" would mean that the depot is available on Sourcegraph as perforce.example.com/depot/main.\n\nIt is important that the Sourcegraph repository name generated with this pattern be unique to this Perforce server. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.",
+          "type": "string",
+          "default": "{host}/{depot}"
+        }
+      }
+    },
     "CloneURLToRepositoryName": {
       "description":
         "Describes a mapping from clone URL to repository name. The ` + "`" + `from` + "`" + ` field contains a regular expression with named capturing groups. The ` + "`" + `to` + "`" + ` field contains a template string that references capturing group names. For instance, if ` + "`" + `from` + "`" + ` is \"^../(?P<name>\\w+)$\" and ` + "`" + `to` + "`" + ` is \"github.com/user/{name}\", the clone URL \"../myRepository\" would be mapped to the repository name \"github.com/user/myRepository\".",
@@ -895,13 +1257,15 @@ const SiteSchemaJSON = `{
       "required": ["url", "path"],
       "properties": {
         "type": {
-          "description": "Type of the version control system for this repository, such as \"git\"",
+          "description":
+            "Type of the version control system for this repository, such as \"git\". Mercurial (\"hg\") repositories are converted to Git on gitserver, which requires hg and git-remote-hg to be installed on gitserver.",
           "type": "string",
-          "enum": ["git"],
+          "enum": ["git", "hg"],
           "default": "git"
         },
         "url": {
-          "description": "Clone URL for the repository, such as git@example.com:my/repo.git",
+          "description":
+            "Clone URL for the repository, such as git@example.com:my/repo.git. Clone URLs that start with \"hg+\" (such as hg+https://hg.example.com/my/repo) are Mercurial repositories, regardless of the type.",
           "type": "string"
         },
         "path": {
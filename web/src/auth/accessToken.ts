@@ -4,4 +4,7 @@
 export enum AccessTokenScopes {
     UserAll = 'user:all',
     SiteAdminSudo = 'site-admin:sudo',
+    SearchRead = 'search:read',
+    RepoRead = 'repo:read',
+    SettingsRead = 'settings:read',
 }
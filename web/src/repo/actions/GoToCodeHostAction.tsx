@@ -145,6 +145,8 @@ function serviceTypeDisplayNameAndIcon(
             return { displayName: 'GitLab' }
         case 'bitbucketserver':
             return { displayName: 'Bitbucket Server' }
+        case 'gitea':
+            return { displayName: 'Gitea' }
         case 'phabricator':
             return { displayName: 'Phabricator', icon: PhabricatorIcon }
         case 'awscodecommit':
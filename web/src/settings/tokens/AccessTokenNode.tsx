@@ -18,6 +18,7 @@ export const accessTokenFragment = gql`
         note
         createdAt
         lastUsedAt
+        expiresAt
         subject {
             username
         }
@@ -142,6 +143,13 @@ export class AccessTokenNode extends React.PureComponent<AccessTokenNodeProps, A
                                     </Link>
                                 </>
                             )}
+                            {this.props.node.expiresAt && (
+                                <>
+                                    ,{' '}
+                                    {new Date(this.props.node.expiresAt) < new Date() ? 'expired' : 'expires'}{' '}
+                                    <Timestamp date={this.props.node.expiresAt} />
+                                </>
+                            )}
                         </small>
                     </div>
                     <div>
@@ -39,6 +39,13 @@ function createAccessToken(user: GQL.ID, scopes: string[], note: string): Observ
     )
 }
 
+/** The read-only access token scopes, documented at the GraphQL Mutation.createAccessToken. */
+const READ_ONLY_SCOPES: { scope: AccessTokenScopes; description: string }[] = [
+    { scope: AccessTokenScopes.SearchRead, description: 'Read access to search results and saved searches' },
+    { scope: AccessTokenScopes.RepoRead, description: 'Read access to repositories and their contents' },
+    { scope: AccessTokenScopes.SettingsRead, description: 'Read access to settings' },
+]
+
 interface Props extends UserAreaRouteContext, RouteComponentProps<{}> {
     /** Called when a new access token is created and should be temporarily displayed to the user. */
     onDidCreateAccessToken: (result: GQL.ICreateAccessTokenResult) => void
@@ -132,20 +139,14 @@ export class UserAccountCreateAccessTokenPage extends React.PureComponent<Props,
                         <label className="mb-1" htmlFor="user-settings-create-access-token-page__note">
                             Token scope
                         </label>
-                        <div>
-                            <small className="form-help text-muted">
-                                Tokens with limited user scopes are not yet supported.
-                            </small>
-                        </div>
                         <div className="form-check">
                             <input
                                 className="form-check-input"
                                 type="checkbox"
                                 id="user-settings-create-access-token-page__scope-user:all"
-                                checked={true}
+                                checked={this.state.scopes.includes(AccessTokenScopes.UserAll)}
                                 value={AccessTokenScopes.UserAll}
                                 onChange={this.onScopesChange}
-                                disabled={true}
                             />
                             <label
                                 className="form-check-label"
@@ -155,6 +156,24 @@ export class UserAccountCreateAccessTokenPage extends React.PureComponent<Props,
                                 to the user account
                             </label>
                         </div>
+                        {READ_ONLY_SCOPES.map(({ scope, description }) => (
+                            <div className="form-check" key={scope}>
+                                <input
+                                    className="form-check-input"
+                                    type="checkbox"
+                                    id={`user-settings-create-access-token-page__scope-${scope}`}
+                                    checked={this.state.scopes.includes(scope)}
+                                    value={scope}
+                                    onChange={this.onScopesChange}
+                                />
+                                <label
+                                    className="form-check-label"
+                                    htmlFor={`user-settings-create-access-token-page__scope-${scope}`}
+                                >
+                                    <strong>{scope}</strong> — {description}
+                                </label>
+                            </div>
+                        ))}
                         {this.props.user.siteAdmin && (
                             <div className="form-check">
                                 <input
@@ -174,6 +193,10 @@ export class UserAccountCreateAccessTokenPage extends React.PureComponent<Props,
                                 </label>
                             </div>
                         )}
+                        <small className="form-help text-muted">
+                            Tokens without the <code>{AccessTokenScopes.UserAll}</code> scope can only read the
+                            resources covered by their read-only scopes.
+                        </small>
                     </div>
                     <button
                         type="submit"